@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"ai-devs3/internal/config"
+	"ai-devs3/internal/storage/cache"
+
+	"github.com/spf13/cobra"
+)
+
+// newCacheCommand creates the cache command group
+func newCacheCommand(cfg *config.Config) *cobra.Command {
+	cacheCmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Inspect and clean the task and LLM response caches",
+		Long: `Inspect and clean the caches under the data directory.
+
+Tasks cache OCR text, transcripts and image descriptions in --data-dir (or
+$CACHE_DIR), keyed by task ID, e.g. s02e04_audio_<file>_transcript. LLM
+responses are cached in <data-dir>/llm under keys starting with llm. Commands
+taking [task] accept any key prefix.
+
+Entries expire after $CACHE_TTL (default: never), and once a cache holds more
+than $CACHE_MAX_SIZE_MB (default: 1024) the least recently used entries are
+evicted.`,
+	}
+
+	cacheCmd.AddCommand(&cobra.Command{
+		Use:   "ls [task]",
+		Short: "List cached entries with their size, age and expiry",
+		Example: `  ai-devs3 cache ls
+  ai-devs3 cache ls s02e04
+  ai-devs3 cache ls llm_chat`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			caches, err := openCaches(cfg)
+			if err != nil {
+				return err
+			}
+
+			now := time.Now()
+			var count int
+			var size int64
+			tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "Key\tSize\tCreated\tUsed\tExpires\t")
+			for _, fileCache := range caches {
+				entries, err := fileCache.List(cmd.Context(), prefixArg(args))
+				if err != nil {
+					return err
+				}
+				for _, entry := range entries {
+					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t\n", entry.Key, formatSize(entry.Size),
+						formatTime(entry.CreatedAt), formatTime(entry.UsedAt), formatExpiry(entry, now))
+					count++
+					size += entry.Size
+				}
+			}
+			tw.Flush()
+
+			fmt.Printf("\n%d entries, %s\n", count, formatSize(size))
+			return nil
+		},
+	})
+
+	cacheCmd.AddCommand(&cobra.Command{
+		Use:   "show <key>",
+		Short: "Print the cached value of a key",
+		Long: `Print the cached value of a key as listed by 'ai-devs3 cache ls', e.g. to check
+what a transcript or an LLM response contained.`,
+		Example: `  ai-devs3 cache show s02e05_audio_audio_01_transcript`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			caches, err := openCaches(cfg)
+			if err != nil {
+				return err
+			}
+
+			for _, fileCache := range caches {
+				data, ok, err := fileCache.Peek(cmd.Context(), args[0])
+				if err != nil {
+					return err
+				}
+				if ok {
+					fmt.Println(string(data))
+					return nil
+				}
+			}
+			return fmt.Errorf("no cached entry %q, see 'ai-devs3 cache ls'", args[0])
+		},
+	})
+
+	purgeCmd := &cobra.Command{
+		Use:   "purge [task]",
+		Short: "Delete cached entries",
+		Long: `Delete the cached entries of a task, or every entry when no task is given.
+With --expired only entries past their TTL are deleted.`,
+		Example: `  ai-devs3 cache purge s02e05
+  ai-devs3 cache purge llm
+  ai-devs3 cache purge --expired`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			expiredOnly, _ := cmd.Flags().GetBool("expired")
+
+			caches, err := openCaches(cfg)
+			if err != nil {
+				return err
+			}
+
+			now := time.Now()
+			var count int
+			var size int64
+			for _, fileCache := range caches {
+				entries, err := fileCache.List(cmd.Context(), prefixArg(args))
+				if err != nil {
+					return err
+				}
+				for _, entry := range entries {
+					if expiredOnly && !entry.Expired(now) {
+						continue
+					}
+					if err := fileCache.Delete(cmd.Context(), entry.Key); err != nil {
+						return err
+					}
+					count++
+					size += entry.Size
+				}
+			}
+
+			fmt.Printf("Deleted %d entries, %s\n", count, formatSize(size))
+			return nil
+		},
+	}
+	purgeCmd.Flags().Bool("expired", false, "only delete entries past their TTL")
+	cacheCmd.AddCommand(purgeCmd)
+
+	return cacheCmd
+}
+
+// openCaches opens the task cache and the LLM response cache of the data
+// directory, skipping those that were never created
+func openCaches(cfg *config.Config) ([]*cache.FileCache, error) {
+	var caches []*cache.FileCache
+	for _, cacheCfg := range []config.CacheConfig{cfg.Cache, responseCacheConfig(cfg)} {
+		if _, err := os.Stat(cacheCfg.BaseDir); os.IsNotExist(err) {
+			continue
+		}
+		fileCache, err := cache.NewFileCache(cacheCfg)
+		if err != nil {
+			return nil, err
+		}
+		caches = append(caches, fileCache)
+	}
+	return caches, nil
+}
+
+// prefixArg returns the optional key prefix argument
+func prefixArg(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return args[0]
+}
+
+// formatSize renders a byte count with a binary unit
+func formatSize(size int64) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(size)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", size)
+	}
+}
+
+// formatTime renders a timestamp in local time to the minute
+func formatTime(t time.Time) string {
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+// formatExpiry describes when an entry expires
+func formatExpiry(entry cache.Entry, now time.Time) string {
+	switch {
+	case entry.ExpiresAt.IsZero():
+		return "never"
+	case entry.Expired(now):
+		return "expired"
+	default:
+		return formatTime(entry.ExpiresAt)
+	}
+}
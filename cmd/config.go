@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"ai-devs3/internal/config"
+	"ai-devs3/internal/tasks"
+	pkgerrors "ai-devs3/pkg/errors"
+
+	"github.com/spf13/cobra"
+)
+
+// newConfigCommand creates the config command group
+func newConfigCommand(cfg *config.Config) *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the configuration",
+	}
+
+	configCmd.AddCommand(&cobra.Command{
+		Use:   "check [task]",
+		Short: "Report which settings a task (or every task) is missing",
+		Long: `Check the configuration against the requirements of a task.
+
+Settings come from the config file (--config, $AI_DEVS3_CONFIG or
+./ai-devs3.{yaml,yml,toml}) overridden by environment variables. With a task
+ID the command lists every requirement and exits with an error when one is
+missing; without arguments it prints a one-line summary per task.`,
+		Example: `  ai-devs3 config check
+  ai-devs3 config check s03e02
+  ai-devs3 config check s03e05 --config ai-devs3.yaml`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			printConfigSource(cfg)
+
+			if len(args) == 0 {
+				printConfigSummary(cfg)
+				return nil
+			}
+
+			task, ok := tasks.Lookup(args[0])
+			if !ok {
+				return fmt.Errorf("unknown task %q, see 'ai-devs3 list'", args[0])
+			}
+			return checkTask(cfg, task)
+		},
+	})
+
+	return configCmd
+}
+
+// printConfigSource prints where the configuration was read from
+func printConfigSource(cfg *config.Config) {
+	if cfg.File != "" {
+		fmt.Printf("Config file: %s (environment variables take precedence)\n", cfg.File)
+	} else {
+		fmt.Println("Config file: none (environment variables only)")
+	}
+	fmt.Println()
+}
+
+// printConfigSummary prints one line per task with its missing settings
+func printConfigSummary(cfg *config.Config) {
+	for _, task := range tasks.All() {
+		var missing []string
+		for _, req := range task.Requires {
+			if err := tasks.Check(cfg, req); err != nil {
+				missing = append(missing, missingField(err))
+			}
+		}
+
+		status := "ok"
+		if len(missing) > 0 {
+			status = "missing " + strings.Join(missing, ", ")
+		}
+		fmt.Printf("  %-12s %s\n", task.ID, status)
+	}
+}
+
+// checkTask prints the status of every requirement of task
+func checkTask(cfg *config.Config, task tasks.Task) error {
+	fmt.Printf("%s - %s\n", task.ID, task.Title)
+	if len(task.Requires) == 0 {
+		fmt.Println("  no requirements")
+		return nil
+	}
+
+	missing := 0
+	for _, req := range task.Requires {
+		if err := tasks.Check(cfg, req); err != nil {
+			missing++
+			fmt.Printf("  [missing] %-8s %v\n", req, err)
+			continue
+		}
+		fmt.Printf("  [ok]      %s\n", req)
+	}
+
+	if missing > 0 {
+		return fmt.Errorf("%s is missing %d requirement(s)", task.ID, missing)
+	}
+	return nil
+}
+
+// missingField names the setting behind a failed requirement check
+func missingField(err error) string {
+	var configErr pkgerrors.ConfigError
+	if errors.As(err, &configErr) {
+		return configErr.Field
+	}
+	return err.Error()
+}
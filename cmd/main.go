@@ -1,28 +1,23 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"ai-devs3/internal/budget"
 	"ai-devs3/internal/config"
-	s01e01 "ai-devs3/internal/tasks/s01/e01"
-	s01e02 "ai-devs3/internal/tasks/s01/e02"
-	s01e03 "ai-devs3/internal/tasks/s01/e03"
-	s01e05 "ai-devs3/internal/tasks/s01/e05"
-	s02e01 "ai-devs3/internal/tasks/s02/e01"
-	s02e02 "ai-devs3/internal/tasks/s02/e02"
-	s02e03 "ai-devs3/internal/tasks/s02/e03"
-	s02e04 "ai-devs3/internal/tasks/s02/e04"
-	s02e05 "ai-devs3/internal/tasks/s02/e05"
-	s03e01 "ai-devs3/internal/tasks/s03/e01"
-	s03e02 "ai-devs3/internal/tasks/s03/e02"
-	s03e03 "ai-devs3/internal/tasks/s03/e03"
-	s03e04 "ai-devs3/internal/tasks/s03/e04"
-	s03e05 "ai-devs3/internal/tasks/s03/e05"
-	s04e01 "ai-devs3/internal/tasks/s04/e01"
-	s04e02 "ai-devs3/internal/tasks/s04/e02"
-	"ai-devs3/internal/tasks/utils/ocr"
-	"ai-devs3/internal/tasks/utils/video"
+	"ai-devs3/internal/http/cassette"
+	"ai-devs3/internal/logging"
+	"ai-devs3/internal/prompts"
+	"ai-devs3/internal/tasks"
+	_ "ai-devs3/internal/tasks/all"
+	"ai-devs3/internal/tracing"
+	"ai-devs3/internal/usage"
 
 	"github.com/spf13/cobra"
 )
@@ -41,75 +36,160 @@ of AI-DEVS challenges, including:
 - API integration and automation
 
 Each task is organized by season and episode (e.g., s01e01, s01e02, etc.)`,
-	Example: `  # Run specific tasks
-  ai-devs3 s01e01  # Robot authentication
-  ai-devs3 s01e02  # RoboISO verification
-  ai-devs3 s01e03  # JSON data processing
-  ai-devs3 s01e05  # Text censoring
-  ai-devs3 s02e01  # Audio transcription and analysis
-  ai-devs3 s02e02  # Map analysis
-  ai-devs3 s02e03  # Robot image generation
-  ai-devs3 s02e04  # File categorization
-  ai-devs3 s02e05  # Arxiv document analysis
-  ai-devs3 s03e01  # Security reports processing
-  ai-devs3 s03e04  # Barbara search task
-  ai-devs3 s04e01  # Image restoration and description
-  ai-devs3 s04e02  # Text classification research
-
-  # Utility commands
-  ai-devs3 ocr [image_url]                      # OCR text extraction
+	Example: `  # Record a live run, then replay it without network access
+  ai-devs3 s02e05 --record testdata/s02e05
+  ai-devs3 s02e05 --replay testdata/s02e05
+
+  # Stop a run once it has spent 10 cents or 200k tokens
+  ai-devs3 s02e04 --max-cost 0.10 --max-tokens 200000
+
+  # Continue a run that was interrupted or hit its budget
+  ai-devs3 s03e04 --resume
+
+  # Write debug logs as JSON lines to a file
+  ai-devs3 s03e03 --log-level debug --log-format json 2>run.log
+
+  # Trace a run and show where it spent its time
+  ai-devs3 s02e05 --trace-file s02e05.trace.json
+  ai-devs3 trace show s02e05.trace.json
+
+  # List the cached results of a task, then drop them
+  ai-devs3 cache ls s02e04
+  ai-devs3 cache purge s02e04
+
+  # Check which settings a task is missing
+  ai-devs3 config check s03e02
 
   # Get help for a specific task
   ai-devs3 s01e01 --help`,
 }
 
+// Global flags, applied to the config before any task runs
+var (
+	configFile string
+	recordDir  string
+	replayDir  string
+	baseURL    string
+	dataDir    string
+	lessonsDir string
+	promptsDir string
+	inputFlags map[string]string
+
+	maxCost     float64
+	maxTokens   int64
+	maxRequests int
+
+	noCache      bool
+	refreshCache bool
+
+	resume bool
+
+	logLevel  string
+	logFormat string
+
+	traceFile     string
+	traceEndpoint string
+)
+
+// runUsage records LLM usage of the current run; tasks without their own
+// statistics get the report printed once the command finishes
+var runUsage *usage.Recorder
+
+// runBudget enforces the --max-* limits of the current run; nil when unlimited
+var runBudget *budget.Budget
+
+// stopTracing flushes the spans of the current run; nil until tracing is set up
+var stopTracing func(context.Context) error
+
 func main() {
-	if err := rootCmd.Execute(); err != nil {
+	err := rootCmd.Execute()
+	if !runUsage.Printed() {
+		runUsage.Print(os.Stdout)
+	}
+	if stopTracing != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if traceErr := stopTracing(ctx); traceErr != nil {
+			fmt.Fprintf(os.Stderr, "Failed to export traces: %v\n", traceErr)
+		}
+		cancel()
+	}
+	if budgetErr := runBudget.Err(); budgetErr != nil {
+		fmt.Fprintf(os.Stderr, "Run aborted: %v\n", budgetErr)
+		os.Exit(1)
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
 
 func init() {
-	// Load configuration
-	cfg, err := config.Load()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Configuration error: %v\n", err)
-		fmt.Fprintf(os.Stderr, "Please check your environment variables:\n")
-		fmt.Fprintf(os.Stderr, "  AI_DEVS_API_KEY: required\n")
-		fmt.Fprintf(os.Stderr, "  OPENAI_API_KEY: required\n")
-		fmt.Fprintf(os.Stderr, "  OLLAMA_BASE_URL: optional (default: http://localhost:11434)\n")
-		fmt.Fprintf(os.Stderr, "  OLLAMA_MODEL: optional (default: llama3.2)\n")
-		os.Exit(1)
-	}
+	// Configuration is loaded lazily once flags are parsed; commands keep this
+	// pointer and read it when they run
+	cfg := &config.Config{}
+
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "config file (YAML or TOML); defaults to $AI_DEVS3_CONFIG or ./ai-devs3.{yaml,yml,toml}")
+	rootCmd.PersistentFlags().StringVar(&recordDir, "record", "", "record all HTTP interactions into this directory")
+	rootCmd.PersistentFlags().StringVar(&replayDir, "replay", "", "replay HTTP interactions from this directory instead of the network")
+	rootCmd.MarkFlagsMutuallyExclusive("record", "replay")
+	rootCmd.PersistentFlags().StringVar(&baseURL, "base-url", "", "Centrala base URL, e.g. a local mock server (overrides AI_DEVS_BASE_URL)")
+	rootCmd.PersistentFlags().StringVar(&dataDir, "data-dir", "", "directory for task data and caches (overrides CACHE_DIR)")
+	rootCmd.PersistentFlags().StringVar(&lessonsDir, "lessons-dir", "", "course materials directory (overrides LESSONS_DIR)")
+	rootCmd.PersistentFlags().StringVar(&promptsDir, "prompts-dir", "", "directory overriding the embedded prompt templates (overrides PROMPTS_DIR)")
+	rootCmd.PersistentFlags().StringToStringVar(&inputFlags, "input", nil, "override a named task input with a URL or path, e.g. --input arxiv.txt=http://localhost:8080/arxiv.txt")
+	rootCmd.PersistentFlags().Float64Var(&maxCost, "max-cost", 0, "abort the run once the estimated LLM cost reaches this many USD (0 = no limit)")
+	rootCmd.PersistentFlags().Int64Var(&maxTokens, "max-tokens", 0, "abort the run once LLM prompt and completion tokens reach this total (0 = no limit)")
+	rootCmd.PersistentFlags().IntVar(&maxRequests, "max-requests", 0, "abort the run before sending more than this many HTTP and LLM requests (0 = no limit)")
+	rootCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "do not read or store cached LLM responses")
+	rootCmd.PersistentFlags().BoolVar(&refreshCache, "refresh-cache", false, "ignore cached LLM responses and store the fresh ones")
+	rootCmd.MarkFlagsMutuallyExclusive("no-cache", "refresh-cache")
+	rootCmd.PersistentFlags().BoolVar(&resume, "resume", false, "continue an interrupted run from its last checkpoint")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "", "minimum log level: debug, info, warn or error (overrides LOG_LEVEL)")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "", "log output format: text or json (overrides LOG_FORMAT)")
+	rootCmd.PersistentFlags().StringVar(&traceFile, "trace-file", "", "write the spans of the run as JSON lines to this file (overrides TRACE_FILE)")
+	rootCmd.PersistentFlags().StringVar(&traceEndpoint, "trace-endpoint", "", "export spans to this OTLP/HTTP collector, e.g. http://localhost:4318 (overrides OTEL_EXPORTER_OTLP_ENDPOINT)")
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		loaded, err := config.Load(configFile)
+		if err != nil {
+			return err
+		}
+		*cfg = *loaded
 
-	// Add Season 1 tasks
-	rootCmd.AddCommand(s01e01.NewCommand(cfg))
-	rootCmd.AddCommand(s01e02.NewCommand(cfg))
-	rootCmd.AddCommand(s01e03.NewCommand(cfg))
-	rootCmd.AddCommand(s01e05.NewCommand(cfg))
+		runID := logging.NewRunID()
+		if err := setupLogging(cmd, cfg, runID); err != nil {
+			return err
+		}
+		if err := setupTracing(cmd, cfg, runID); err != nil {
+			return err
+		}
 
-	// Add Season 2 tasks
-	rootCmd.AddCommand(s02e01.NewCommand(cfg))
-	rootCmd.AddCommand(s02e02.NewCommand(cfg))
-	rootCmd.AddCommand(s02e03.NewCommand(cfg))
-	rootCmd.AddCommand(s02e04.NewCommand(cfg))
-	rootCmd.AddCommand(s02e05.NewCommand(cfg))
+		runUsage = usage.NewRecorder()
+		cfg.OpenAI.Usage = runUsage
 
-	// Add Season 3 tasks
-	rootCmd.AddCommand(s03e01.NewCommand(cfg))
-	rootCmd.AddCommand(s03e02.NewCommand(cfg))
-	rootCmd.AddCommand(s03e03.NewCommand(cfg))
-	rootCmd.AddCommand(s03e04.NewCommand(cfg))
-	rootCmd.AddCommand(s03e05.NewCommand(cfg))
+		// The budget cancels the command context, so tasks stop at their next
+		// request or context check once a limit is hit
+		runBudget = budget.New(budget.Limits{MaxCost: maxCost, MaxTokens: maxTokens, MaxRequests: maxRequests})
+		cfg.HTTP.Budget = runBudget
+		cfg.OpenAI.Budget = runBudget
+		cmd.SetContext(runBudget.Bind(cmd.Context()))
 
-	// Add Season 4 tasks
-	rootCmd.AddCommand(s04e01.NewCommand(cfg))
-	rootCmd.AddCommand(s04e02.NewCommand(cfg))
+		applyInputFlags(cfg)
+		if err := setupPrompts(cfg); err != nil {
+			return err
+		}
+		if err := setupCassette(cfg); err != nil {
+			return err
+		}
+		setupResponseCache(cfg)
+		setupCheckpoints(cfg)
+		return nil
+	}
 
-	// Add utility commands
-	rootCmd.AddCommand(ocr.NewCommand(cfg))
-	rootCmd.AddCommand(video.NewCommand(cfg))
+	// Add every registered task and utility
+	for _, task := range tasks.All() {
+		rootCmd.AddCommand(task.Command(cfg))
+	}
+	rootCmd.Example = taskExamples() + "\n\n" + rootCmd.Example
 
 	// Add version command
 	rootCmd.AddCommand(&cobra.Command{
@@ -125,36 +205,215 @@ func init() {
 		Use:   "list",
 		Short: "List all available tasks",
 		Run: func(cmd *cobra.Command, args []string) {
-			fmt.Println("Available tasks:")
-			fmt.Println()
-			fmt.Println("Season 1:")
-			fmt.Println("  s01e01  - Robot Authentication")
-			fmt.Println("  s01e02  - RoboISO Verification")
-			fmt.Println("  s01e03  - JSON Data Processing")
-			fmt.Println("  s01e05  - Text Censoring")
-			fmt.Println()
-			fmt.Println("Season 2:")
-			fmt.Println("  s02e01  - Audio Transcription and Analysis")
-			fmt.Println("  s02e02  - Map Analysis")
-			fmt.Println("  s02e03  - Robot Image Generation")
-			fmt.Println("  s02e04  - File Categorization")
-			fmt.Println("  s02e05  - Arxiv Document Analysis")
-			fmt.Println()
-			fmt.Println("Season 3:")
-			fmt.Println("  s03e01  - Security Reports Processing")
-			fmt.Println("  s03e02  - Weapon Reports Vector Search")
-			fmt.Println("  s03e03  - Database Query Task")
-			fmt.Println("  s03e04  - Barbara Search Task (loop)")
-			fmt.Println("  s03e05  - Connections Task (Neo4j Graph)")
-			fmt.Println()
-			fmt.Println("Season 4:")
-			fmt.Println("  s04e01  - Image Restoration and Description")
-			fmt.Println("  s04e02  - Text Classification Research")
-			fmt.Println()
-			fmt.Println("Utilities:")
-			fmt.Println("  ocr      - OCR Text Extraction")
-			fmt.Println()
-			fmt.Println("Use 'ai-devs3 <task> --help' for more information about a specific task.")
+			printTaskList()
 		},
 	})
+
+	// Add config command to inspect the loaded configuration
+	rootCmd.AddCommand(newConfigCommand(cfg))
+
+	// Add prompts command to inspect the prompt library
+	rootCmd.AddCommand(newPromptsCommand())
+
+	// Add trace command to inspect recorded traces
+	rootCmd.AddCommand(newTraceCommand())
+
+	// Add cache command to inspect and clean the caches
+	rootCmd.AddCommand(newCacheCommand(cfg))
+}
+
+// printTaskList prints registered tasks grouped by season, utilities last
+func printTaskList() {
+	fmt.Println("Available tasks:")
+
+	heading := ""
+	for _, task := range tasks.All() {
+		if group := taskGroup(task); group != heading {
+			heading = group
+			fmt.Println()
+			fmt.Printf("%s:\n", heading)
+		}
+
+		line := fmt.Sprintf("  %-12s - %s", task.ID, task.Title)
+		if len(task.Requires) > 0 {
+			line += fmt.Sprintf(" [%s]", task.RequirementList())
+		}
+		fmt.Println(line)
+	}
+
+	fmt.Println()
+	fmt.Println("Use 'ai-devs3 <task> --help' for more information about a specific task.")
+}
+
+// taskExamples builds the task part of the root command examples
+func taskExamples() string {
+	var b strings.Builder
+	all := tasks.All()
+	for i, task := range all {
+		if i == 0 || task.Utility() != all[i-1].Utility() {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			if task.Utility() {
+				b.WriteString("  # Utility commands\n")
+			} else {
+				b.WriteString("  # Run specific tasks\n")
+			}
+		}
+		fmt.Fprintf(&b, "  ai-devs3 %-12s # %s\n", task.ID, task.Title)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// taskGroup names the list section a task belongs to
+func taskGroup(task tasks.Task) string {
+	if task.Utility() {
+		return "Utilities"
+	}
+	return fmt.Sprintf("Season %d", task.Season)
+}
+
+// applyInputFlags overrides where tasks read their inputs from
+func applyInputFlags(cfg *config.Config) {
+	if baseURL != "" {
+		cfg.AIDevs.BaseURL = baseURL
+	}
+	if dataDir != "" {
+		cfg.Cache.BaseDir = dataDir
+	}
+	if lessonsDir != "" {
+		cfg.Inputs.LessonsDir = lessonsDir
+	}
+	for name, value := range inputFlags {
+		cfg.Inputs.Overrides[name] = value
+	}
+}
+
+// setupLogging installs the run logger as the default logger, which also
+// routes the standard log package through it, and hands it to the command
+func setupLogging(cmd *cobra.Command, cfg *config.Config, runID string) error {
+	if logLevel != "" {
+		cfg.Log.Level = logLevel
+	}
+	if logFormat != "" {
+		cfg.Log.Format = logFormat
+	}
+
+	logger, err := logging.New(os.Stderr, logging.Options{
+		Level:   cfg.Log.Level,
+		Format:  cfg.Log.Format,
+		Secrets: cfg.Secrets(),
+	})
+	if err != nil {
+		return err
+	}
+
+	logger = logger.With("run_id", runID)
+	if task, ok := tasks.Lookup(cmd.Name()); ok {
+		logger = logger.With("task", task.ID)
+	}
+
+	slog.SetDefault(logger)
+	cmd.SetContext(logging.WithLogger(cmd.Context(), logger))
+	return nil
+}
+
+// setupTracing installs the exporters selected by --trace-file and
+// --trace-endpoint; spans carry the same run ID as the logs. Only task runs are
+// traced, so that e.g. 'trace show' never overwrites the file it reads.
+func setupTracing(cmd *cobra.Command, cfg *config.Config, runID string) error {
+	if _, ok := tasks.Lookup(cmd.Name()); !ok {
+		return nil
+	}
+
+	if traceFile != "" {
+		cfg.Trace.File = traceFile
+	}
+	if traceEndpoint != "" {
+		cfg.Trace.Endpoint = traceEndpoint
+	}
+
+	stop, err := tracing.Setup(cmd.Context(), tracing.Options{
+		File:     cfg.Trace.File,
+		Endpoint: cfg.Trace.Endpoint,
+		RunID:    runID,
+		Secrets:  cfg.Secrets(),
+	})
+	if err != nil {
+		return err
+	}
+	stopTracing = stop
+	return nil
+}
+
+// setupPrompts loads the prompt library, applying the override directory if any
+func setupPrompts(cfg *config.Config) error {
+	if promptsDir != "" {
+		cfg.Prompts.Dir = promptsDir
+	}
+
+	lib, err := prompts.Load(cfg.Prompts.Dir)
+	if err != nil {
+		return err
+	}
+	prompts.SetDefault(lib)
+	return nil
+}
+
+// setupResponseCache enables the LLM response cache under the data directory.
+// Recording bypasses cached responses so that every call ends up on the cassette.
+func setupResponseCache(cfg *config.Config) {
+	if noCache {
+		return
+	}
+
+	cfg.OpenAI.ResponseCache = config.ResponseCacheConfig{
+		Cache:   responseCacheConfig(cfg),
+		Refresh: refreshCache || cfg.Cassette.Mode == cassette.ModeRecord,
+	}
+}
+
+// responseCacheConfig places the LLM response cache under the data directory,
+// with the same TTL and size limit as the task caches
+func responseCacheConfig(cfg *config.Config) config.CacheConfig {
+	cacheCfg := cfg.Cache
+	cacheCfg.BaseDir = filepath.Join(cfg.Cache.BaseDir, "llm")
+	return cacheCfg
+}
+
+// setupCheckpoints keeps task checkpoints under the data directory
+func setupCheckpoints(cfg *config.Config) {
+	cfg.Checkpoints = config.CheckpointConfig{
+		Dir:    filepath.Join(cfg.Cache.BaseDir, "checkpoints"),
+		Resume: resume,
+	}
+}
+
+// setupCassette installs the record/replay transport selected by the global flags
+// into every HTTP-based client configuration
+func setupCassette(cfg *config.Config) error {
+	switch {
+	case recordDir != "":
+		cfg.Cassette = config.CassetteConfig{Mode: cassette.ModeRecord, Dir: recordDir}
+	case replayDir != "":
+		cfg.Cassette = config.CassetteConfig{Mode: cassette.ModeReplay, Dir: replayDir}
+	default:
+		return nil
+	}
+
+	transport, err := cassette.New(cfg.Cassette, cfg.AIDevs.APIKey, cfg.OpenAI.APIKey)
+	if err != nil {
+		return err
+	}
+
+	cfg.HTTP.Transport = transport
+	cfg.OpenAI.Transport = transport
+	cfg.Ollama.Transport = transport
+
+	// Recorded responses come from disk, so there is nothing to throttle
+	if transport.Replaying() {
+		cfg.HTTP.RateLimit = 0
+	}
+
+	return nil
 }
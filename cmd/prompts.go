@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+
+	"ai-devs3/internal/prompts"
+
+	"github.com/spf13/cobra"
+)
+
+// newPromptsCommand creates the prompts command group
+func newPromptsCommand() *cobra.Command {
+	promptsCmd := &cobra.Command{
+		Use:   "prompts",
+		Short: "Inspect the prompt library",
+	}
+
+	promptsCmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List every prompt with its version and origin",
+		Long: `List the prompts used by the tasks.
+
+Prompts are embedded in the binary and can be overridden by template files in
+the directory given by --prompts-dir or $PROMPTS_DIR, laid out as
+<area>/<name>.tmpl. The version is a hash of the template source and is logged
+the first time a run uses the prompt.`,
+		Example: `  ai-devs3 prompts list
+  ai-devs3 prompts list --prompts-dir my-prompts`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			for _, prompt := range prompts.Default().All() {
+				fmt.Printf("  %-28s %s  %s\n", prompt.Name, prompt.Version, prompt.Origin)
+			}
+		},
+	})
+
+	promptsCmd.AddCommand(&cobra.Command{
+		Use:   "show <name>",
+		Short: "Print the template source of a prompt",
+		Long: `Print the template source of a prompt, e.g. to copy it into an override
+directory as a starting point.`,
+		Example: `  ai-devs3 prompts show ocr/extract_text
+  mkdir -p my-prompts/ocr && ai-devs3 prompts show ocr/extract_text > my-prompts/ocr/extract_text.tmpl`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			prompt, ok := prompts.Default().Get(args[0])
+			if !ok {
+				return fmt.Errorf("unknown prompt %q, see 'ai-devs3 prompts list'", args[0])
+			}
+			fmt.Println(prompt.Source)
+			return nil
+		},
+	})
+
+	return promptsCmd
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"ai-devs3/internal/tracing"
+
+	"github.com/spf13/cobra"
+)
+
+// newTraceCommand creates the trace command group
+func newTraceCommand() *cobra.Command {
+	traceCmd := &cobra.Command{
+		Use:   "trace",
+		Short: "Inspect traces of past runs",
+	}
+
+	traceCmd.AddCommand(&cobra.Command{
+		Use:   "show <file>",
+		Short: "Print the span waterfall of a trace file",
+		Long: `Print the spans of a run recorded with --trace-file or $TRACE_FILE.
+
+Every task run is traced as a span holding its steps, which in turn hold the
+HTTP, LLM, Qdrant and Neo4j calls made during the step. Spans are printed as a
+tree with their offset from the start of the run and their duration, followed
+by the total time spent per span name.`,
+		Example: `  ai-devs3 s02e05 --trace-file s02e05.trace.json
+  ai-devs3 trace show s02e05.trace.json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			spans, err := tracing.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+			if len(spans) == 0 {
+				return fmt.Errorf("no spans in %s", args[0])
+			}
+			tracing.PrintWaterfall(os.Stdout, spans)
+			return nil
+		},
+	})
+
+	return traceCmd
+}
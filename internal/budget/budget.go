@@ -0,0 +1,132 @@
+// Package budget enforces per-run limits on LLM cost, tokens and request
+// count, cancelling the run once any of them is exceeded.
+package budget
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"ai-devs3/internal/usage"
+)
+
+// Limits caps what a single run may spend; zero disables a limit
+type Limits struct {
+	MaxCost     float64 // estimated USD across all LLM calls
+	MaxTokens   int64   // prompt plus completion tokens
+	MaxRequests int     // outgoing HTTP and OpenAI requests
+}
+
+// ExceededError reports which limit stopped the run
+type ExceededError struct {
+	Limit string // flag that set the limit, e.g. "--max-cost"
+	Used  float64
+	Max   float64
+}
+
+func (e ExceededError) Error() string {
+	return fmt.Sprintf("budget exceeded: %s %g reached (used %g)", e.Limit, e.Max, e.Used)
+}
+
+// Budget tracks spending against Limits. It is shared by every client of a
+// run; a nil *Budget imposes no limits.
+type Budget struct {
+	limits Limits
+
+	mu       sync.Mutex
+	cost     float64
+	tokens   int64
+	requests int
+	err      error
+	cancel   context.CancelCauseFunc
+}
+
+// New creates a budget for limits, or returns nil when no limit is set
+func New(limits Limits) *Budget {
+	if limits.MaxCost <= 0 && limits.MaxTokens <= 0 && limits.MaxRequests <= 0 {
+		return nil
+	}
+	return &Budget{limits: limits}
+}
+
+// Bind returns a context that is cancelled, with the ExceededError as its
+// cause, as soon as the budget is exceeded
+func (b *Budget) Bind(ctx context.Context) context.Context {
+	if b == nil {
+		return ctx
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+
+	b.mu.Lock()
+	b.cancel = cancel
+	b.mu.Unlock()
+
+	return ctx
+}
+
+// Allow counts an outgoing request and reports an error when the run must
+// not make it, either because the request limit is reached or because the
+// budget was already exceeded
+func (b *Budget) Allow() error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.err != nil {
+		return b.err
+	}
+
+	if b.limits.MaxRequests > 0 && b.requests >= b.limits.MaxRequests {
+		b.exceed(ExceededError{Limit: "--max-requests", Used: float64(b.requests), Max: float64(b.limits.MaxRequests)})
+		return b.err
+	}
+
+	b.requests++
+	return nil
+}
+
+// Charge adds the tokens and estimated cost of a completed LLM call
+func (b *Budget) Charge(e usage.Entry) {
+	if b == nil {
+		return
+	}
+
+	cost, _ := usage.Cost(e)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.cost += cost
+	b.tokens += e.PromptTokens + e.CompletionTokens
+
+	switch {
+	case b.err != nil:
+	case b.limits.MaxCost > 0 && b.cost >= b.limits.MaxCost:
+		b.exceed(ExceededError{Limit: "--max-cost", Used: b.cost, Max: b.limits.MaxCost})
+	case b.limits.MaxTokens > 0 && b.tokens >= b.limits.MaxTokens:
+		b.exceed(ExceededError{Limit: "--max-tokens", Used: float64(b.tokens), Max: float64(b.limits.MaxTokens)})
+	}
+}
+
+// Err returns the ExceededError once the budget is exceeded, nil before
+func (b *Budget) Err() error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.err
+}
+
+// exceed records err and cancels the bound context; b.mu must be held
+func (b *Budget) exceed(err ExceededError) {
+	b.err = err
+	if b.cancel != nil {
+		b.cancel(err)
+	}
+}
@@ -0,0 +1,103 @@
+package budget
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"ai-devs3/internal/usage"
+)
+
+func TestChargeOverLimitCancelsRun(t *testing.T) {
+	tests := []struct {
+		name      string
+		limits    Limits
+		entry     usage.Entry
+		wantLimit string
+	}{
+		{"cost", Limits{MaxCost: 1}, usage.Entry{Model: "gpt-4o", PromptTokens: 400_000}, "--max-cost"},
+		{"tokens", Limits{MaxTokens: 1000}, usage.Entry{Model: "llama3.2", PromptTokens: 900, CompletionTokens: 100}, "--max-tokens"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New(tt.limits)
+			ctx := b.Bind(context.Background())
+
+			// Half the entry stays within the limit
+			half := tt.entry
+			half.PromptTokens /= 2
+			half.CompletionTokens /= 2
+			b.Charge(half)
+			if err := b.Allow(); err != nil {
+				t.Fatalf("Allow() within the limit = %v", err)
+			}
+			if ctx.Err() != nil {
+				t.Fatalf("context cancelled within the limit: %v", context.Cause(ctx))
+			}
+
+			b.Charge(half)
+
+			var exceeded ExceededError
+			if !errors.As(context.Cause(ctx), &exceeded) || exceeded.Limit != tt.wantLimit {
+				t.Fatalf("context cause = %v, want an ExceededError for %s", context.Cause(ctx), tt.wantLimit)
+			}
+			if ctx.Err() != context.Canceled {
+				t.Errorf("ctx.Err() = %v, want context.Canceled", ctx.Err())
+			}
+			for i := range 2 {
+				if err := b.Allow(); !errors.As(err, &exceeded) || exceeded.Limit != tt.wantLimit {
+					t.Errorf("Allow() #%d after exceeding = %v, want the %s error", i+1, err, tt.wantLimit)
+				}
+			}
+			if err := b.Err(); err == nil {
+				t.Error("Err() = nil after exceeding")
+			}
+		})
+	}
+}
+
+func TestAllowRequestLimit(t *testing.T) {
+	b := New(Limits{MaxRequests: 2})
+	ctx := b.Bind(context.Background())
+
+	for i := range 2 {
+		if err := b.Allow(); err != nil {
+			t.Fatalf("Allow() #%d = %v, want nil", i+1, err)
+		}
+	}
+
+	err := b.Allow()
+	var exceeded ExceededError
+	if !errors.As(err, &exceeded) || exceeded.Limit != "--max-requests" || exceeded.Used != 2 {
+		t.Fatalf("third Allow() = %v, want --max-requests exceeded after 2", err)
+	}
+	if !errors.Is(context.Cause(ctx), err) {
+		t.Errorf("context cause = %v, want %v", context.Cause(ctx), err)
+	}
+
+	// A charge after the run stopped must not replace the first error
+	b.Charge(usage.Entry{Model: "gpt-4o", PromptTokens: 1})
+	if got := b.Allow(); got != err {
+		t.Errorf("Allow() after a later charge = %v, want %v", got, err)
+	}
+}
+
+func TestNilBudget(t *testing.T) {
+	b := New(Limits{})
+	if b != nil {
+		t.Fatalf("New() with no limits = %+v, want nil", b)
+	}
+
+	ctx := context.Background()
+	if got := b.Bind(ctx); got != ctx {
+		t.Error("Bind() on a nil budget wrapped the context")
+	}
+	b.Charge(usage.Entry{Model: "gpt-4o", PromptTokens: 1_000_000})
+	if err := b.Allow(); err != nil {
+		t.Errorf("Allow() = %v, want nil", err)
+	}
+	if err := b.Err(); err != nil {
+		t.Errorf("Err() = %v, want nil", err)
+	}
+}
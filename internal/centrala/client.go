@@ -0,0 +1,143 @@
+package centrala
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/url"
+	"strings"
+
+	"ai-devs3/internal/config"
+	"ai-devs3/internal/http"
+	"ai-devs3/pkg/errors"
+)
+
+// Client is a typed client for the AI-DEVS Centrala API
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+	overrides  map[string]string
+}
+
+// NewClient creates a Centrala client using the configured base URL and API key
+func NewClient(httpClient *http.Client, cfg config.AIDevsConfig) *Client {
+	return &Client{
+		httpClient: httpClient,
+		baseURL:    strings.TrimRight(cfg.BaseURL, "/"),
+		apiKey:     cfg.APIKey,
+	}
+}
+
+// BaseURL returns the Centrala base URL without a trailing slash
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// URL joins path onto the base URL
+func (c *Client) URL(path string) string {
+	return c.baseURL + "/" + strings.TrimLeft(path, "/")
+}
+
+// WithInputs returns a copy of the client that downloads the task data files
+// named in overrides (the --input flag) from the given URLs instead
+func (c *Client) WithInputs(overrides map[string]string) *Client {
+	clone := *c
+	clone.overrides = maps.Clone(overrides)
+	return &clone
+}
+
+// TaskDataURL returns the URL of a per-key task file under /data/<apikey>/
+func (c *Client) TaskDataURL(filename string) string {
+	if override, ok := c.overrides[filename]; ok {
+		return override
+	}
+	return c.URL(fmt.Sprintf("data/%s/%s", url.PathEscape(c.apiKey), filename))
+}
+
+// FetchTaskData downloads a per-key task file from /data/<apikey>/<filename>
+func (c *Client) FetchTaskData(ctx context.Context, filename string) (string, error) {
+	content, err := c.httpClient.FetchData(ctx, c.TaskDataURL(filename))
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch task data %s: %w", filename, err)
+	}
+
+	return content, nil
+}
+
+// Report submits an answer for task to /report. A non-zero response code is
+// returned as an Error together with the parsed response.
+func (c *Client) Report(ctx context.Context, task string, answer any) (*Response, error) {
+	request := ReportRequest{
+		Task:   task,
+		APIKey: c.apiKey,
+		Answer: answer,
+	}
+
+	return c.postEnvelope(ctx, "report", task, request)
+}
+
+// DBQuery runs a SQL query through the /apidb endpoint and returns the rows
+func (c *Client) DBQuery(ctx context.Context, query string) ([]map[string]any, error) {
+	request := DatabaseRequest{
+		Task:   "database",
+		APIKey: c.apiKey,
+		Query:  query,
+	}
+
+	statusCode, body, err := c.httpClient.PostJSONRaw(ctx, c.URL("apidb"), request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute database query: %w", err)
+	}
+
+	var response DatabaseResponse
+	if err := json.Unmarshal([]byte(body), &response); err != nil {
+		if statusCode >= 400 {
+			return nil, errors.NewAPIError("Centrala", statusCode, "HTTP error: "+body, nil)
+		}
+		return nil, fmt.Errorf("failed to parse database response: %w", err)
+	}
+
+	if response.Error != "" && response.Error != "OK" {
+		return nil, Error{Task: "database", Message: response.Error}
+	}
+
+	return response.Reply, nil
+}
+
+// People asks the /people endpoint about a person; the message lists cities
+func (c *Client) People(ctx context.Context, name string) (*Response, error) {
+	return c.postEnvelope(ctx, "people", "people", QueryRequest{APIKey: c.apiKey, Query: name})
+}
+
+// Places asks the /places endpoint about a city; the message lists people
+func (c *Client) Places(ctx context.Context, city string) (*Response, error) {
+	return c.postEnvelope(ctx, "places", "places", QueryRequest{APIKey: c.apiKey, Query: city})
+}
+
+// postEnvelope posts payload to path and decodes the {code,message} answer.
+// Centrala reports rejected answers with HTTP 4xx and the envelope in the body,
+// so the status code only matters when the body cannot be parsed.
+func (c *Client) postEnvelope(ctx context.Context, path, task string, payload any) (*Response, error) {
+	statusCode, body, err := c.httpClient.PostJSONRaw(ctx, c.URL(path), payload)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &Response{Raw: body}
+	if err := json.Unmarshal([]byte(body), response); err != nil {
+		if statusCode >= 400 {
+			return nil, errors.NewAPIError("Centrala", statusCode, "HTTP error: "+body, nil)
+		}
+		// Some endpoints answer with plain text; keep it as the message
+		response.Message = body
+		return response, nil
+	}
+
+	if response.Code != 0 {
+		return response, Error{Task: task, Code: response.Code, Message: response.Message}
+	}
+
+	return response, nil
+}
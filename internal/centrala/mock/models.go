@@ -0,0 +1,69 @@
+package mock
+
+// Response codes returned in the {code,message} envelope, mirroring Centrala
+// where a zero code means success and negative codes describe the failure
+const (
+	CodeOK             = 0
+	CodeWrongAnswer    = -1
+	CodeInvalidPayload = -2
+	CodeUnauthorized   = -3
+	CodeUnknownTask    = -4
+	CodeNoData         = -200
+)
+
+// Envelope is the standard Centrala {code,message} answer
+type Envelope struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// ReportRequest is the payload accepted by /report
+type ReportRequest struct {
+	Task   string `json:"task"`
+	APIKey string `json:"apikey"`
+	Answer any    `json:"answer"`
+}
+
+// QueryRequest is the payload accepted by /people and /places
+type QueryRequest struct {
+	APIKey string `json:"apikey"`
+	Query  string `json:"query"`
+}
+
+// DatabaseRequest is the payload accepted by /apidb
+type DatabaseRequest struct {
+	Task   string `json:"task"`
+	APIKey string `json:"apikey"`
+	Query  string `json:"query"`
+}
+
+// DatabaseResponse is the answer returned by /apidb
+type DatabaseResponse struct {
+	Reply []map[string]any `json:"reply"`
+	Error string           `json:"error"`
+}
+
+// VerifyMessage is the RoboISO message exchanged with /verify
+type VerifyMessage struct {
+	MsgID int    `json:"msgID"`
+	Text  string `json:"text"`
+}
+
+// ReportFixture describes how /report answers for one task (report/<task>.json)
+type ReportFixture struct {
+	Expected  any               `json:"expected,omitempty"`  // correct answer; any well-formed answer passes when absent
+	Responses map[string]string `json:"responses,omitempty"` // canned messages for conversational string answers
+	Flag      string            `json:"flag,omitempty"`      // flag name returned on success
+}
+
+// VerifyFixture holds the RoboISO questions and their expected answers (verify.json)
+type VerifyFixture struct {
+	Questions []VerifyQuestion `json:"questions"`
+	Flag      string           `json:"flag,omitempty"`
+}
+
+// VerifyQuestion is a single RoboISO question with the answer that passes it
+type VerifyQuestion struct {
+	Text   string `json:"text"`
+	Answer string `json:"answer"`
+}
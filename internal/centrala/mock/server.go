@@ -0,0 +1,313 @@
+// Package mock implements a local stand-in for Centrala that serves the
+// endpoints our tasks depend on from fixture files, so tasks can run offline.
+package mock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// maxBodySize limits request bodies accepted by the mock server
+const maxBodySize = 10 << 20
+
+// Server serves Centrala endpoints from a fixtures directory laid out as:
+//
+//	report/<task>.json      ReportFixture for /report
+//	apidb.json              query -> reply rows for /apidb
+//	people.json             query -> message for /people
+//	places.json             query -> message for /places
+//	verify.json             VerifyFixture for /verify
+//	data/<file>             files served under /data/<apikey>/<file>
+//	dane/...                static files served under /dane/
+type Server struct {
+	fixturesDir string
+	apiKey      string
+	mux         *http.ServeMux
+
+	mu         sync.Mutex
+	nextMsgID  int
+	verifyMsgs map[int]VerifyQuestion
+}
+
+// NewServer creates a mock server reading fixtures from fixturesDir. When
+// apiKey is empty any non-empty key is accepted.
+func NewServer(fixturesDir, apiKey string) *Server {
+	s := &Server{
+		fixturesDir: fixturesDir,
+		apiKey:      apiKey,
+		mux:         http.NewServeMux(),
+		nextMsgID:   1,
+		verifyMsgs:  make(map[int]VerifyQuestion),
+	}
+
+	s.mux.HandleFunc("POST /report", s.handleReport)
+	s.mux.HandleFunc("POST /apidb", s.handleDatabase)
+	s.mux.HandleFunc("POST /people", s.handleQuery("people.json"))
+	s.mux.HandleFunc("POST /places", s.handleQuery("places.json"))
+	s.mux.HandleFunc("POST /verify", s.handleVerify)
+	s.mux.HandleFunc("GET /data/{key}/{file}", s.handleData)
+	s.mux.Handle("GET /dane/", http.StripPrefix("/dane/", http.FileServer(http.Dir(filepath.Join(fixturesDir, "dane")))))
+
+	return s
+}
+
+// ServeHTTP implements http.Handler and logs every request
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	slog.Info("Mock request", "method", r.Method, "path", r.URL.Path)
+	s.mux.ServeHTTP(w, r)
+}
+
+// handleReport validates a task answer and compares it with the fixture
+func (s *Server) handleReport(w http.ResponseWriter, r *http.Request) {
+	var req ReportRequest
+	if !s.decode(w, r, &req) || !s.authorize(w, req.APIKey) {
+		return
+	}
+
+	if req.Task == "" {
+		writeEnvelope(w, http.StatusBadRequest, CodeInvalidPayload, "task is required")
+		return
+	}
+
+	known, err := validateAnswer(req.Task, req.Answer)
+	if !known {
+		writeEnvelope(w, http.StatusBadRequest, CodeUnknownTask, fmt.Sprintf("unknown task %q", req.Task))
+		return
+	}
+	if err != nil {
+		writeEnvelope(w, http.StatusBadRequest, CodeInvalidPayload, fmt.Sprintf("invalid answer for %s: %v", req.Task, err))
+		return
+	}
+
+	var fixture ReportFixture
+	found, err := s.loadFixture(filepath.Join("report", req.Task+".json"), &fixture)
+	if err != nil {
+		writeEnvelope(w, http.StatusInternalServerError, CodeInvalidPayload, err.Error())
+		return
+	}
+
+	// Conversational tasks (e.g. loop) answer free-form strings with canned hints
+	if answer, ok := req.Answer.(string); ok {
+		if message, ok := lookupFold(fixture.Responses, answer); ok {
+			writeEnvelope(w, http.StatusOK, CodeOK, message)
+			return
+		}
+	}
+
+	if found && fixture.Expected != nil && !answersMatch(fixture.Expected, req.Answer) {
+		writeEnvelope(w, http.StatusBadRequest, CodeWrongAnswer, "Wrong answer")
+		return
+	}
+
+	writeEnvelope(w, http.StatusOK, CodeOK, flagMessage(fixture.Flag, req.Task))
+}
+
+// handleDatabase answers /apidb queries from apidb.json
+func (s *Server) handleDatabase(w http.ResponseWriter, r *http.Request) {
+	var req DatabaseRequest
+	if !s.decode(w, r, &req) || !s.authorize(w, req.APIKey) {
+		return
+	}
+
+	if req.Task != "database" {
+		writeEnvelope(w, http.StatusBadRequest, CodeUnknownTask, fmt.Sprintf("unknown task %q", req.Task))
+		return
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		writeEnvelope(w, http.StatusBadRequest, CodeInvalidPayload, "query is required")
+		return
+	}
+
+	replies := make(map[string][]map[string]any)
+	if _, err := s.loadFixture("apidb.json", &replies); err != nil {
+		writeEnvelope(w, http.StatusInternalServerError, CodeInvalidPayload, err.Error())
+		return
+	}
+
+	reply := []map[string]any{}
+	for query, rows := range replies {
+		if normalizeQuery(query) == normalizeQuery(req.Query) {
+			reply = rows
+			break
+		}
+	}
+
+	writeJSON(w, http.StatusOK, DatabaseResponse{Reply: reply, Error: "OK"})
+}
+
+// handleQuery answers /people and /places lookups from the given fixture
+func (s *Server) handleQuery(fixture string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req QueryRequest
+		if !s.decode(w, r, &req) || !s.authorize(w, req.APIKey) {
+			return
+		}
+
+		if strings.TrimSpace(req.Query) == "" {
+			writeEnvelope(w, http.StatusBadRequest, CodeInvalidPayload, "query is required")
+			return
+		}
+
+		answers := make(map[string]string)
+		if _, err := s.loadFixture(fixture, &answers); err != nil {
+			writeEnvelope(w, http.StatusInternalServerError, CodeInvalidPayload, err.Error())
+			return
+		}
+
+		message, ok := lookupFold(answers, strings.TrimSpace(req.Query))
+		if !ok {
+			writeEnvelope(w, http.StatusOK, CodeNoData, "[**RESTRICTED DATA**]")
+			return
+		}
+		writeEnvelope(w, http.StatusOK, CodeOK, message)
+	}
+}
+
+// handleVerify implements the RoboISO exchange: READY returns a question,
+// answering it with the same msgID returns the flag
+func (s *Server) handleVerify(w http.ResponseWriter, r *http.Request) {
+	var msg VerifyMessage
+	if !s.decode(w, r, &msg) {
+		return
+	}
+
+	var fixture VerifyFixture
+	if _, err := s.loadFixture("verify.json", &fixture); err != nil {
+		writeEnvelope(w, http.StatusInternalServerError, CodeInvalidPayload, err.Error())
+		return
+	}
+	if len(fixture.Questions) == 0 {
+		writeEnvelope(w, http.StatusInternalServerError, CodeInvalidPayload, "verify.json has no questions")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if strings.EqualFold(strings.TrimSpace(msg.Text), "READY") {
+		id := s.nextMsgID
+		s.nextMsgID++
+		question := fixture.Questions[(id-1)%len(fixture.Questions)]
+		s.verifyMsgs[id] = question
+		writeJSON(w, http.StatusOK, VerifyMessage{MsgID: id, Text: question.Text})
+		return
+	}
+
+	question, ok := s.verifyMsgs[msg.MsgID]
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, VerifyMessage{MsgID: 0, Text: "ALARM! UNKNOWN CONVERSATION"})
+		return
+	}
+	delete(s.verifyMsgs, msg.MsgID)
+
+	if !strings.EqualFold(strings.TrimSpace(msg.Text), strings.TrimSpace(question.Answer)) {
+		writeJSON(w, http.StatusBadRequest, VerifyMessage{MsgID: 0, Text: "ALARM! INTRUDER DETECTED"})
+		return
+	}
+	writeJSON(w, http.StatusOK, VerifyMessage{MsgID: msg.MsgID, Text: flagMessage(fixture.Flag, "verify")})
+}
+
+// handleData serves per-key datasets from the data/ fixtures directory
+func (s *Server) handleData(w http.ResponseWriter, r *http.Request) {
+	if !s.authorize(w, r.PathValue("key")) {
+		return
+	}
+
+	file := r.PathValue("file")
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".json", ".txt":
+	default:
+		writeEnvelope(w, http.StatusNotFound, CodeNoData, fmt.Sprintf("unsupported data file %q", file))
+		return
+	}
+
+	path := filepath.Join(s.fixturesDir, "data", filepath.Base(file))
+	if _, err := os.Stat(path); err != nil {
+		writeEnvelope(w, http.StatusNotFound, CodeNoData, fmt.Sprintf("data file %q not found", file))
+		return
+	}
+	http.ServeFile(w, r, path)
+}
+
+// decode reads a JSON request body into v, answering 400 on failure
+func (s *Server) decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize))
+	if err != nil {
+		writeEnvelope(w, http.StatusBadRequest, CodeInvalidPayload, "failed to read request body")
+		return false
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		writeEnvelope(w, http.StatusBadRequest, CodeInvalidPayload, fmt.Sprintf("invalid JSON payload: %v", err))
+		return false
+	}
+	return true
+}
+
+// authorize checks the API key, answering 401 when it is missing or wrong
+func (s *Server) authorize(w http.ResponseWriter, apiKey string) bool {
+	if apiKey == "" || (s.apiKey != "" && apiKey != s.apiKey) {
+		writeEnvelope(w, http.StatusUnauthorized, CodeUnauthorized, "invalid or missing apikey")
+		return false
+	}
+	return true
+}
+
+// loadFixture decodes the named fixture into v, reporting whether it exists
+func (s *Server) loadFixture(name string, v any) (bool, error) {
+	data, err := os.ReadFile(filepath.Join(s.fixturesDir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read fixture %s: %w", name, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("failed to parse fixture %s: %w", name, err)
+	}
+	return true, nil
+}
+
+// lookupFold finds key in m ignoring case
+func lookupFold(m map[string]string, key string) (string, bool) {
+	for k, v := range m {
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// normalizeQuery collapses whitespace and case so equivalent SQL matches
+func normalizeQuery(query string) string {
+	return strings.ToLower(strings.Join(strings.Fields(strings.TrimSuffix(strings.TrimSpace(query), ";")), " "))
+}
+
+// flagMessage formats a success message carrying a flag
+func flagMessage(flag, fallback string) string {
+	if flag == "" {
+		flag = strings.ToUpper(fallback)
+	}
+	return fmt.Sprintf("{{FLG:%s}}", flag)
+}
+
+// writeEnvelope writes a {code,message} answer
+func writeEnvelope(w http.ResponseWriter, status, code int, message string) {
+	writeJSON(w, status, Envelope{Code: code, Message: message})
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Warn("Failed to write response", "error", err)
+	}
+}
@@ -0,0 +1,202 @@
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// answerValidator checks that a decoded JSON answer has the shape a task expects
+type answerValidator func(answer any) error
+
+// answerShapes lists the answer shape of every task submitted through /report
+var answerShapes = map[string]answerValidator{
+	"JSON":        objectWithKeys("apikey", "test-data"),
+	"CENZURA":     nonEmptyString,
+	"mp3":         nonEmptyString,
+	"robotid":     absoluteURL,
+	"kategorie":   objectOfStringArrays("people", "hardware"),
+	"arxiv":       stringMap,
+	"dokumenty":   stringMap,
+	"wektory":     nonEmptyString,
+	"database":    integerArray,
+	"loop":        nonEmptyString,
+	"connections": nonEmptyString,
+	"photos":      nonEmptyString,
+	"research":    stringArray,
+}
+
+// validateAnswer checks answer against the shape registered for task
+func validateAnswer(task string, answer any) (known bool, err error) {
+	validator, ok := answerShapes[task]
+	if !ok {
+		return false, nil
+	}
+	return true, validator(answer)
+}
+
+// nonEmptyString requires a string with visible content
+func nonEmptyString(answer any) error {
+	s, ok := answer.(string)
+	if !ok {
+		return fmt.Errorf("answer must be a string, got %s", jsonType(answer))
+	}
+	if strings.TrimSpace(s) == "" {
+		return fmt.Errorf("answer must not be empty")
+	}
+	return nil
+}
+
+// absoluteURL requires a string holding an absolute http(s) URL
+func absoluteURL(answer any) error {
+	if err := nonEmptyString(answer); err != nil {
+		return err
+	}
+	u, err := url.Parse(answer.(string))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("answer must be an absolute http(s) URL")
+	}
+	return nil
+}
+
+// stringMap requires a non-empty object whose values are all strings
+func stringMap(answer any) error {
+	m, ok := answer.(map[string]any)
+	if !ok {
+		return fmt.Errorf("answer must be an object, got %s", jsonType(answer))
+	}
+	if len(m) == 0 {
+		return fmt.Errorf("answer must not be empty")
+	}
+	for key, value := range m {
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("answer[%q] must be a string, got %s", key, jsonType(value))
+		}
+	}
+	return nil
+}
+
+// stringArray requires an array of strings
+func stringArray(answer any) error {
+	items, ok := answer.([]any)
+	if !ok {
+		return fmt.Errorf("answer must be an array, got %s", jsonType(answer))
+	}
+	for i, item := range items {
+		if _, ok := item.(string); !ok {
+			return fmt.Errorf("answer[%d] must be a string, got %s", i, jsonType(item))
+		}
+	}
+	return nil
+}
+
+// integerArray requires an array of whole numbers
+func integerArray(answer any) error {
+	items, ok := answer.([]any)
+	if !ok {
+		return fmt.Errorf("answer must be an array, got %s", jsonType(answer))
+	}
+	for i, item := range items {
+		n, ok := item.(float64)
+		if !ok || n != math.Trunc(n) {
+			return fmt.Errorf("answer[%d] must be an integer, got %v", i, item)
+		}
+	}
+	return nil
+}
+
+// objectWithKeys requires an object containing at least the given keys
+func objectWithKeys(keys ...string) answerValidator {
+	return func(answer any) error {
+		m, ok := answer.(map[string]any)
+		if !ok {
+			return fmt.Errorf("answer must be an object, got %s", jsonType(answer))
+		}
+		for _, key := range keys {
+			if _, ok := m[key]; !ok {
+				return fmt.Errorf("answer is missing %q", key)
+			}
+		}
+		return nil
+	}
+}
+
+// objectOfStringArrays requires an object whose given keys hold string arrays
+func objectOfStringArrays(keys ...string) answerValidator {
+	return func(answer any) error {
+		if err := objectWithKeys(keys...)(answer); err != nil {
+			return err
+		}
+		m := answer.(map[string]any)
+		for _, key := range keys {
+			if err := stringArray(m[key]); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+		}
+		return nil
+	}
+}
+
+// answersMatch compares a submitted answer with the expected one. Strings are
+// compared case-insensitively, arrays ignore order and objects compare per key.
+func answersMatch(expected, actual any) bool {
+	switch exp := expected.(type) {
+	case string:
+		act, ok := actual.(string)
+		return ok && strings.EqualFold(strings.TrimSpace(exp), strings.TrimSpace(act))
+	case []any:
+		act, ok := actual.([]any)
+		if !ok || len(act) != len(exp) {
+			return false
+		}
+		return reflect.DeepEqual(sortedJSON(exp), sortedJSON(act))
+	case map[string]any:
+		act, ok := actual.(map[string]any)
+		if !ok || len(act) != len(exp) {
+			return false
+		}
+		for key, value := range exp {
+			if !answersMatch(value, act[key]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(expected, actual)
+	}
+}
+
+// sortedJSON returns the JSON encodings of items in sorted order
+func sortedJSON(items []any) []string {
+	encoded := make([]string, len(items))
+	for i, item := range items {
+		data, _ := json.Marshal(item)
+		encoded[i] = strings.ToLower(string(data))
+	}
+	sort.Strings(encoded)
+	return encoded
+}
+
+// jsonType names the JSON type of a decoded value for error messages
+func jsonType(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
@@ -0,0 +1,83 @@
+package centrala
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// flagPattern matches flags in the {{FLG:NAME}} format
+var flagPattern = regexp.MustCompile(`\{\{FLG:([^}]+)\}\}`)
+
+// ReportRequest is the payload accepted by /report
+type ReportRequest struct {
+	Task   string `json:"task"`
+	APIKey string `json:"apikey"`
+	Answer any    `json:"answer"`
+}
+
+// DatabaseRequest is the payload accepted by /apidb
+type DatabaseRequest struct {
+	Task   string `json:"task"`
+	APIKey string `json:"apikey"`
+	Query  string `json:"query"`
+}
+
+// DatabaseResponse is the answer returned by /apidb
+type DatabaseResponse struct {
+	Reply []map[string]any `json:"reply"`
+	Error string           `json:"error,omitempty"`
+}
+
+// QueryRequest is the payload accepted by /people and /places
+type QueryRequest struct {
+	APIKey string `json:"apikey"`
+	Query  string `json:"query"`
+}
+
+// Response is the standard {code,message} envelope returned by Centrala
+type Response struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Raw     string `json:"-"` // unparsed body, useful for logging
+}
+
+// Flags returns every {{FLG:...}} flag found in the response
+func (r *Response) Flags() []string {
+	return ExtractFlags(r.Raw + " " + r.Message)
+}
+
+// Flag returns the first flag found in the response, or an empty string
+func (r *Response) Flag() string {
+	flags := r.Flags()
+	if len(flags) == 0 {
+		return ""
+	}
+	return flags[0]
+}
+
+// Error is returned when Centrala answers with a non-zero code
+type Error struct {
+	Task    string
+	Code    int
+	Message string
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("centrala rejected %s (code %d): %s", e.Task, e.Code, e.Message)
+}
+
+// ExtractFlags returns the names of all {{FLG:NAME}} flags in text, without duplicates
+func ExtractFlags(text string) []string {
+	var flags []string
+	seen := make(map[string]struct{})
+
+	for _, match := range flagPattern.FindAllStringSubmatch(text, -1) {
+		if _, ok := seen[match[1]]; ok {
+			continue
+		}
+		seen[match[1]] = struct{}{}
+		flags = append(flags, match[1])
+	}
+
+	return flags
+}
@@ -0,0 +1,127 @@
+// Package checkpoint persists the progress of long task runs so that an
+// interrupted run can continue where it stopped. Each task keeps a single
+// JSON snapshot that it overwrites after every step and clears once done.
+package checkpoint
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"ai-devs3/internal/config"
+	"ai-devs3/internal/logging"
+	"ai-devs3/pkg/errors"
+)
+
+// snapshot is the file format of a checkpoint
+type snapshot struct {
+	Task    string          `json:"task"`
+	SavedAt time.Time       `json:"saved_at"`
+	State   json.RawMessage `json:"state"`
+}
+
+// Store reads and writes task checkpoints. A nil *Store keeps no checkpoints,
+// so tasks can use it unconditionally.
+type Store struct {
+	dir    string
+	resume bool
+}
+
+// New creates a checkpoint store, or returns nil when cfg.Dir is empty
+func New(cfg config.CheckpointConfig) *Store {
+	if cfg.Dir == "" {
+		return nil
+	}
+	return &Store{dir: cfg.Dir, resume: cfg.Resume}
+}
+
+// Load restores the checkpoint of task into state when the run was started
+// with --resume, and reports whether it did. Without --resume an existing
+// checkpoint is only mentioned; the run starts over and replaces it.
+func (s *Store) Load(ctx context.Context, task string, state any) (bool, error) {
+	if s == nil {
+		return false, nil
+	}
+
+	data, err := os.ReadFile(s.path(task))
+	if err != nil {
+		if os.IsNotExist(err) {
+			if s.resume {
+				logging.FromContext(ctx).Info("No checkpoint to resume, starting from scratch", "checkpoint", task)
+			}
+			return false, nil
+		}
+		return false, errors.NewProcessingError("checkpoint", task, "failed to read checkpoint", err)
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return false, errors.NewProcessingError("checkpoint", task, "corrupt checkpoint "+s.path(task), err)
+	}
+
+	if !s.resume {
+		logging.FromContext(ctx).Info("Found a checkpoint; pass --resume to continue from it", "checkpoint", task, "saved_at", snap.SavedAt)
+		return false, nil
+	}
+
+	if err := json.Unmarshal(snap.State, state); err != nil {
+		return false, errors.NewProcessingError("checkpoint", task, "checkpoint does not match the task state", err)
+	}
+
+	logging.FromContext(ctx).Info("Resuming from checkpoint", "checkpoint", task, "saved_at", snap.SavedAt)
+	return true, nil
+}
+
+// Save replaces the checkpoint of task with state
+func (s *Store) Save(task string, state any) error {
+	if s == nil {
+		return nil
+	}
+
+	raw, err := json.Marshal(state)
+	if err != nil {
+		return errors.NewProcessingError("checkpoint", task, "failed to encode state", err)
+	}
+
+	data, err := json.MarshalIndent(snapshot{Task: task, SavedAt: time.Now(), State: raw}, "", "  ")
+	if err != nil {
+		return errors.NewProcessingError("checkpoint", task, "failed to encode checkpoint", err)
+	}
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create checkpoint directory: %w", err)
+	}
+
+	// Write to a temporary file first so an interrupted save keeps the previous checkpoint
+	path := s.path(task)
+	tempFile := path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := os.Rename(tempFile, path); err != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("failed to rename checkpoint: %w", err)
+	}
+
+	return nil
+}
+
+// Clear removes the checkpoint of a task that finished
+func (s *Store) Clear(task string) error {
+	if s == nil {
+		return nil
+	}
+
+	if err := os.Remove(s.path(task)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove checkpoint: %w", err)
+	}
+	return nil
+}
+
+// path returns the checkpoint file of a task
+func (s *Store) path(task string) string {
+	return filepath.Join(s.dir, task+".json")
+}
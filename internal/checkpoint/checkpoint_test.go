@@ -0,0 +1,123 @@
+package checkpoint
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"ai-devs3/internal/config"
+)
+
+// progress stands in for a task's checkpointed state
+type progress struct {
+	Step  string         `json:"step"`
+	Done  []string       `json:"done"`
+	Count map[string]int `json:"count"`
+}
+
+func TestSaveLoadClear(t *testing.T) {
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "checkpoints") // created by the first Save
+	store := New(config.CheckpointConfig{Dir: dir, Resume: true})
+
+	saved := progress{Step: "analyze_photos", Done: []string{"IMG_1.PNG"}, Count: map[string]int{"REPAIR": 2}}
+	if err := store.Save("s04e01", saved); err != nil {
+		t.Fatalf("Save() = %v", err)
+	}
+
+	// Save replaces the file through a rename, leaving no temporary file
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "s04e01.json" {
+		t.Errorf("checkpoint directory holds %v, want only s04e01.json", entries)
+	}
+
+	var loaded progress
+	ok, err := store.Load(ctx, "s04e01", &loaded)
+	if err != nil || !ok {
+		t.Fatalf("Load() = %v, %v; want true, nil", ok, err)
+	}
+	if !reflect.DeepEqual(loaded, saved) {
+		t.Errorf("Load() restored %+v, want %+v", loaded, saved)
+	}
+
+	// A later save overwrites the earlier one
+	saved.Step = "generate_rysopis"
+	if err := store.Save("s04e01", saved); err != nil {
+		t.Fatalf("second Save() = %v", err)
+	}
+	loaded = progress{}
+	if _, err := store.Load(ctx, "s04e01", &loaded); err != nil || loaded.Step != "generate_rysopis" {
+		t.Errorf("Load() after overwrite = %+v, %v; want step generate_rysopis", loaded, err)
+	}
+
+	if err := store.Clear("s04e01"); err != nil {
+		t.Fatalf("Clear() = %v", err)
+	}
+	if ok, err := store.Load(ctx, "s04e01", &loaded); err != nil || ok {
+		t.Errorf("Load() after Clear() = %v, %v; want false, nil", ok, err)
+	}
+	if err := store.Clear("s04e01"); err != nil {
+		t.Errorf("Clear() of a missing checkpoint = %v, want nil", err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		resume  bool
+		content string // checkpoint file content; empty means no file
+		wantOK  bool
+		wantErr bool
+	}{
+		{"missing file", true, "", false, false},
+		{"without --resume", false, `{"task": "s04e01", "state": {"step": "x"}}`, false, false},
+		{"resume", true, `{"task": "s04e01", "state": {"step": "x"}}`, true, false},
+		{"corrupt file", true, `{"task": `, false, true},
+		{"state of another shape", true, `{"task": "s04e01", "state": {"step": 42}}`, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.content != "" {
+				if err := os.WriteFile(filepath.Join(dir, "s04e01.json"), []byte(tt.content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var state progress
+			ok, err := New(config.CheckpointConfig{Dir: dir, Resume: tt.resume}).Load(ctx, "s04e01", &state)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if ok != tt.wantOK {
+				t.Errorf("Load() = %v, want %v", ok, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestNilStore(t *testing.T) {
+	store := New(config.CheckpointConfig{Resume: true})
+	if store != nil {
+		t.Fatalf("New() without a directory = %+v, want nil", store)
+	}
+
+	if err := store.Save("s04e01", progress{Step: "x"}); err != nil {
+		t.Errorf("Save() = %v, want nil", err)
+	}
+	var state progress
+	if ok, err := store.Load(context.Background(), "s04e01", &state); ok || err != nil {
+		t.Errorf("Load() = %v, %v; want false, nil", ok, err)
+	}
+	if err := store.Clear("s04e01"); err != nil {
+		t.Errorf("Clear() = %v, want nil", err)
+	}
+}
@@ -1,21 +1,50 @@
 package config
 
 import (
+	"net/http"
 	"os"
+	"strconv"
 	"time"
 
-	pkgerrors "ai-devs3/pkg/errors"
+	"ai-devs3/internal/budget"
+	"ai-devs3/internal/usage"
 )
 
 // Config holds all configuration for the application
 type Config struct {
-	AIDevs AIDevsConfig
-	OpenAI OpenAIConfig
-	Ollama OllamaConfig
-	HTTP   HTTPConfig
-	Cache  CacheConfig
-	Qdrant QdrantConfig
-	Neo4j  Neo4jConfig
+	AIDevs  AIDevsConfig
+	LLM     LLMConfig
+	OpenAI  OpenAIConfig
+	Ollama  OllamaConfig
+	HTTP    HTTPConfig
+	Cache   CacheConfig
+	Qdrant  QdrantConfig
+	Neo4j   Neo4jConfig
+	Inputs  InputsConfig
+	Prompts PromptsConfig
+	Log     LogConfig
+	Trace   TraceConfig
+
+	// Cassette is set from the global --record/--replay flags rather than the environment
+	Cassette CassetteConfig
+
+	// Checkpoints is set up from the data directory and the global --resume flag
+	Checkpoints CheckpointConfig
+
+	// File is the config file the settings were read from, empty when none was used
+	File string
+}
+
+// InputsConfig holds where tasks find their local and remote inputs
+type InputsConfig struct {
+	LessonsDir string            // local course materials (audio, factory files)
+	Overrides  map[string]string // input name (e.g. "arxiv.txt") -> URL or path
+}
+
+// CassetteConfig selects HTTP record/replay for offline, deterministic runs
+type CassetteConfig struct {
+	Mode string // "", "record" or "replay"
+	Dir  string
 }
 
 // AIDevsConfig holds AI-DEVS specific configuration
@@ -24,29 +53,79 @@ type AIDevsConfig struct {
 	BaseURL string
 }
 
+// LLMConfig selects the provider backing provider-agnostic LLM calls
+type LLMConfig struct {
+	Provider string // "openai" or "ollama"
+}
+
 // OpenAIConfig holds OpenAI API configuration
 type OpenAIConfig struct {
-	APIKey      string
-	Model       string
-	Temperature float64
+	APIKey         string
+	BaseURL        string // optional API endpoint, e.g. a proxy or a compatible server
+	Model          string
+	EmbeddingModel string
+	Temperature    float64
+	Transport      http.RoundTripper // optional override, e.g. record/replay
+	Usage          *usage.Recorder   // optional token usage and cost accounting
+	Budget         *budget.Budget    // optional run limits, see the --max-* flags
+	ResponseCache  ResponseCacheConfig
+}
+
+// ResponseCacheConfig controls the content-addressed cache of LLM responses
+type ResponseCacheConfig struct {
+	Cache   CacheConfig // directory and limits of cached responses; an empty BaseDir disables the cache
+	Refresh bool        // ignore cached responses but store the fresh ones
 }
 
 // HTTPConfig holds HTTP client configuration
 type HTTPConfig struct {
-	Timeout time.Duration
-	Retries int
+	Timeout      time.Duration
+	Retries      int               // extra attempts after the first one
+	RetryWaitMin time.Duration     // base delay for exponential backoff
+	RetryWaitMax time.Duration     // upper bound for a single wait, including Retry-After
+	RateLimit    float64           // requests per second per host, 0 disables limiting
+	RateBurst    int               // requests allowed back to back before the limit applies
+	Transport    http.RoundTripper // optional override, e.g. record/replay
+	Budget       *budget.Budget    // optional run limits, see the --max-* flags
 }
 
 // OllamaConfig holds Ollama local LLM configuration
 type OllamaConfig struct {
-	BaseURL     string
-	Model       string
-	Temperature float64
+	BaseURL        string
+	Model          string
+	EmbeddingModel string
+	Temperature    float64
+	Transport      http.RoundTripper // optional override, e.g. record/replay
+}
+
+// CheckpointConfig controls where tasks snapshot their progress
+type CheckpointConfig struct {
+	Dir    string // directory holding one checkpoint per task; empty disables checkpoints
+	Resume bool   // continue from an existing checkpoint instead of starting over
+}
+
+// LogConfig holds logging configuration
+type LogConfig struct {
+	Level  string // debug, info, warn or error
+	Format string // text or json
+}
+
+// TraceConfig selects where the spans of a run are exported
+type TraceConfig struct {
+	File     string // JSON file receiving every finished span
+	Endpoint string // OTLP/HTTP collector URL, e.g. http://localhost:4318
+}
+
+// PromptsConfig holds prompt library configuration
+type PromptsConfig struct {
+	Dir string // directory overriding the embedded prompt templates; empty uses them as is
 }
 
 // CacheConfig holds cache configuration
 type CacheConfig struct {
 	BaseDir string
+	TTL     time.Duration // how long entries stay valid; 0 keeps them until evicted
+	MaxSize int64         // bytes per cache directory before least recently used entries are evicted; 0 is unbounded
 }
 
 // QdrantConfig holds Qdrant vector database configuration
@@ -64,66 +143,134 @@ type Neo4jConfig struct {
 	Password string
 }
 
-// Load creates a new Config instance from environment variables
-func Load() (*Config, error) {
+// Load creates a new Config instance from the config file at path (or the
+// default file, see findConfigFile) overridden by environment variables.
+// Nothing is required here; each task checks the settings it needs before it
+// runs, so commands such as version or ocr work with a partial setup.
+func Load(path string) (*Config, error) {
+	env := source{}
+	path = findConfigFile(path)
+	if path != "" {
+		values, err := readConfigFile(path)
+		if err != nil {
+			return nil, err
+		}
+		env.file = values
+	}
+
 	config := &Config{
 		AIDevs: AIDevsConfig{
-			APIKey:  getEnv("AI_DEVS_API_KEY", ""),
-			BaseURL: getEnv("AI_DEVS_BASE_URL", "https://c3ntrala.ag3nts.org"),
+			APIKey:  env.get("AI_DEVS_API_KEY", ""),
+			BaseURL: env.get("AI_DEVS_BASE_URL", "https://c3ntrala.ag3nts.org"),
+		},
+		LLM: LLMConfig{
+			Provider: env.get("LLM_PROVIDER", "openai"),
 		},
 		OpenAI: OpenAIConfig{
-			APIKey:      getEnv("OPENAI_API_KEY", ""),
-			Model:       getEnv("OPENAI_MODEL", "gpt-4o-mini"),
-			Temperature: 0.3,
+			APIKey:         env.get("OPENAI_API_KEY", ""),
+			BaseURL:        env.get("OPENAI_BASE_URL", ""),
+			Model:          env.get("OPENAI_MODEL", "gpt-4o-mini"),
+			EmbeddingModel: env.get("OPENAI_EMBEDDING_MODEL", "text-embedding-3-large"),
+			Temperature:    0.3,
 		},
 		Ollama: OllamaConfig{
-			BaseURL:     getEnv("OLLAMA_BASE_URL", "http://localhost:11434"),
-			Model:       getEnv("OLLAMA_MODEL", "llama3.2:3b"),
-			Temperature: 0.5,
+			BaseURL:        env.get("OLLAMA_BASE_URL", "http://localhost:11434"),
+			Model:          env.get("OLLAMA_MODEL", "llama3.2:3b"),
+			EmbeddingModel: env.get("OLLAMA_EMBEDDING_MODEL", "nomic-embed-text"),
+			Temperature:    0.5,
 		},
 		HTTP: HTTPConfig{
-			Timeout: 30 * time.Second,
-			Retries: 3,
+			Timeout:      30 * time.Second,
+			Retries:      env.getInt("HTTP_RETRIES", 3),
+			RetryWaitMin: 500 * time.Millisecond,
+			RetryWaitMax: 30 * time.Second,
+			RateLimit:    env.getFloat("HTTP_RATE_LIMIT", 5),
+			RateBurst:    env.getInt("HTTP_RATE_BURST", 1),
 		},
 		Cache: CacheConfig{
-			BaseDir: getEnv("CACHE_DIR", "data"),
+			BaseDir: env.get("CACHE_DIR", "data"),
+			TTL:     env.getDuration("CACHE_TTL", 0),
+			MaxSize: int64(env.getInt("CACHE_MAX_SIZE_MB", 1024)) << 20,
+		},
+		Inputs: InputsConfig{
+			LessonsDir: env.get("LESSONS_DIR", ""),
+			Overrides:  make(map[string]string),
+		},
+		Prompts: PromptsConfig{
+			Dir: env.get("PROMPTS_DIR", ""),
+		},
+		Log: LogConfig{
+			Level:  env.get("LOG_LEVEL", "info"),
+			Format: env.get("LOG_FORMAT", "text"),
+		},
+		Trace: TraceConfig{
+			File:     env.get("TRACE_FILE", ""),
+			Endpoint: env.get("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
 		},
 		Qdrant: QdrantConfig{
-			Host:   getEnv("QDRANT_HOST", "localhost"),
+			Host:   env.get("QDRANT_HOST", "localhost"),
 			Port:   6334, // grpc port
-			APIKey: getEnv("QDRANT_API_KEY", ""),
+			APIKey: env.get("QDRANT_API_KEY", ""),
 			UseTLS: true,
 		},
 		Neo4j: Neo4jConfig{
-			URI:      getEnv("NEO4J_URI", "bolt://localhost:7687"),
-			Username: getEnv("NEO4J_USER", "neo4j"),
-			Password: getEnv("NEO4J_PASSWORD", ""),
+			URI:      env.get("NEO4J_URI", "bolt://localhost:7687"),
+			Username: env.get("NEO4J_USER", "neo4j"),
+			Password: env.get("NEO4J_PASSWORD", ""),
 		},
+		File: path,
 	}
 
-	// Validate required fields
-	if config.AIDevs.APIKey == "" {
-		return nil, pkgerrors.NewConfigError("AI_DEVS_API_KEY", "environment variable is required", nil)
-	}
+	return config, nil
+}
 
-	if config.OpenAI.APIKey == "" {
-		return nil, pkgerrors.NewConfigError("OPENAI_API_KEY", "environment variable is required", nil)
-	}
+// Secrets returns the configured credentials, which must never appear in logs
+func (c *Config) Secrets() []string {
+	return []string{c.AIDevs.APIKey, c.OpenAI.APIKey, c.Qdrant.APIKey, c.Neo4j.Password}
+}
+
+// source resolves settings from the environment, falling back to config file values
+type source struct {
+	file map[string]string
+}
 
-	if config.Qdrant.APIKey == "" {
-		return nil, pkgerrors.NewConfigError("QDRANT_API_KEY", "environment variable is required", nil)
+// get gets a setting with a default value
+func (s source) get(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
 	}
+	if value := s.file[key]; value != "" {
+		return value
+	}
+	return defaultValue
+}
 
-	// Note: Neo4j password is validated when creating the Neo4j client, not here
-	// This allows for optional Neo4j usage depending on the task
+// getInt gets an integer setting with a default value
+func (s source) getInt(key string, defaultValue int) int {
+	if value := s.get(key, ""); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
 
-	return config, nil
+// getDuration gets a duration setting such as "72h" with a default value
+func (s source) getDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := s.get(key, ""); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
 }
 
-// getEnv gets an environment variable with a default value
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// getFloat gets a floating point setting with a default value
+func (s source) getFloat(key string, defaultValue float64) float64 {
+	if value := s.get(key, ""); value != "" {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+			return parsed
+		}
 	}
 	return defaultValue
 }
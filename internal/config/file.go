@@ -0,0 +1,179 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	pkgerrors "ai-devs3/pkg/errors"
+)
+
+// ConfigFileEnv names the environment variable pointing at a config file
+const ConfigFileEnv = "AI_DEVS3_CONFIG"
+
+// defaultConfigFiles are looked up in the working directory when no file is given
+var defaultConfigFiles = []string{"ai-devs3.yaml", "ai-devs3.yml", "ai-devs3.toml"}
+
+// fileKeys maps config file keys (section.key) to the environment variables
+// they stand in for; the environment always wins over the file
+var fileKeys = map[string]string{
+	"ai_devs.api_key":        "AI_DEVS_API_KEY",
+	"ai_devs.base_url":       "AI_DEVS_BASE_URL",
+	"llm.provider":           "LLM_PROVIDER",
+	"openai.api_key":         "OPENAI_API_KEY",
+	"openai.base_url":        "OPENAI_BASE_URL",
+	"openai.model":           "OPENAI_MODEL",
+	"openai.embedding_model": "OPENAI_EMBEDDING_MODEL",
+	"ollama.base_url":        "OLLAMA_BASE_URL",
+	"ollama.model":           "OLLAMA_MODEL",
+	"ollama.embedding_model": "OLLAMA_EMBEDDING_MODEL",
+	"http.retries":           "HTTP_RETRIES",
+	"http.rate_limit":        "HTTP_RATE_LIMIT",
+	"http.rate_burst":        "HTTP_RATE_BURST",
+	"cache.dir":              "CACHE_DIR",
+	"cache.ttl":              "CACHE_TTL",
+	"cache.max_size_mb":      "CACHE_MAX_SIZE_MB",
+	"inputs.lessons_dir":     "LESSONS_DIR",
+	"prompts.dir":            "PROMPTS_DIR",
+	"log.level":              "LOG_LEVEL",
+	"log.format":             "LOG_FORMAT",
+	"trace.file":             "TRACE_FILE",
+	"trace.endpoint":         "OTEL_EXPORTER_OTLP_ENDPOINT",
+	"qdrant.host":            "QDRANT_HOST",
+	"qdrant.api_key":         "QDRANT_API_KEY",
+	"neo4j.uri":              "NEO4J_URI",
+	"neo4j.user":             "NEO4J_USER",
+	"neo4j.password":         "NEO4J_PASSWORD",
+}
+
+// findConfigFile returns path, the file named by AI_DEVS3_CONFIG, or the first
+// default config file present in the working directory
+func findConfigFile(path string) string {
+	if path != "" {
+		return path
+	}
+	if path := os.Getenv(ConfigFileEnv); path != "" {
+		return path
+	}
+	for _, name := range defaultConfigFiles {
+		if _, err := os.Stat(name); err == nil {
+			return name
+		}
+	}
+	return ""
+}
+
+// readConfigFile parses a YAML or TOML config file into environment variable
+// names and values. Only the flat "section: key: value" subset is supported.
+func readConfigFile(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, pkgerrors.NewConfigError("config file", "cannot open "+path, err)
+	}
+	defer file.Close()
+
+	toml := strings.EqualFold(filepath.Ext(path), ".toml")
+
+	values := make(map[string]string)
+	section := ""
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		raw := scanner.Text()
+		line := strings.TrimSpace(stripComment(raw))
+		if line == "" {
+			continue
+		}
+
+		var key, value string
+		switch {
+		case toml && strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		case toml:
+			k, v, ok := strings.Cut(line, "=")
+			if !ok {
+				return nil, parseError(path, lineNo, "expected key = value")
+			}
+			key, value = strings.TrimSpace(k), strings.TrimSpace(v)
+		default:
+			k, v, ok := strings.Cut(line, ":")
+			if !ok {
+				return nil, parseError(path, lineNo, "expected key: value")
+			}
+			key, value = strings.TrimSpace(k), strings.TrimSpace(v)
+
+			// An unindented key without a value opens a section
+			if value == "" && raw == strings.TrimLeft(raw, " \t") {
+				section = key
+				continue
+			}
+		}
+
+		name := key
+		if section != "" {
+			name = section + "." + key
+		}
+		envName, ok := fileKeys[strings.ToLower(name)]
+		if !ok {
+			return nil, parseError(path, lineNo, fmt.Sprintf("unknown setting %q", name))
+		}
+
+		value, err := unquote(value)
+		if err != nil {
+			return nil, parseError(path, lineNo, err.Error())
+		}
+		values[envName] = value
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, pkgerrors.NewConfigError("config file", "cannot read "+path, err)
+	}
+
+	return values, nil
+}
+
+// stripComment removes a trailing # comment that is not inside quotes
+func stripComment(line string) string {
+	quote := rune(0)
+	for i, r := range line {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote == 0 && (r == '"' || r == '\''):
+			quote = r
+		case quote == 0 && r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// unquote strips matching single or double quotes from a value
+func unquote(value string) (string, error) {
+	if len(value) < 2 {
+		return value, nil
+	}
+	switch value[0] {
+	case '"':
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return "", errors.New("invalid quoted string")
+		}
+		return unquoted, nil
+	case '\'':
+		if value[len(value)-1] != '\'' {
+			return "", errors.New("unterminated quoted string")
+		}
+		return value[1 : len(value)-1], nil
+	}
+	return value, nil
+}
+
+// parseError reports a malformed config file line
+func parseError(path string, line int, message string) error {
+	return pkgerrors.NewConfigError("config file", fmt.Sprintf("%s:%d: %s", path, line, message), nil)
+}
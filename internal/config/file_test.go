@@ -0,0 +1,139 @@
+package config
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadConfigFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name: "yaml sections",
+			file: "ai-devs3.yaml",
+			content: `# AI Devs 3
+ai_devs:
+  api_key: secret
+  base_url: "http://localhost:8080"
+
+openai:
+  model: 'gpt-4o'
+  base_url: http://proxy.local/v1 # trailing comment
+`,
+			want: map[string]string{
+				"AI_DEVS_API_KEY":  "secret",
+				"AI_DEVS_BASE_URL": "http://localhost:8080",
+				"OPENAI_MODEL":     "gpt-4o",
+				"OPENAI_BASE_URL":  "http://proxy.local/v1",
+			},
+		},
+		{
+			name: "toml sections",
+			file: "ai-devs3.toml",
+			content: `[llm]
+provider = "ollama"
+
+[http]
+retries = 5
+rate_limit = 2.5
+`,
+			want: map[string]string{
+				"LLM_PROVIDER":    "ollama",
+				"HTTP_RETRIES":    "5",
+				"HTTP_RATE_LIMIT": "2.5",
+			},
+		},
+		{
+			name:    "keys are case insensitive",
+			file:    "ai-devs3.yml",
+			content: "LOG:\n  Level: debug\n",
+			want:    map[string]string{"LOG_LEVEL": "debug"},
+		},
+		{
+			name:    "hash inside quotes is kept",
+			file:    "ai-devs3.yaml",
+			content: "neo4j:\n  password: \"p#ss\" # comment\n",
+			want:    map[string]string{"NEO4J_PASSWORD": "p#ss"},
+		},
+		{
+			name:    "unknown setting",
+			file:    "ai-devs3.yaml",
+			content: "openai:\n  organization: acme\n",
+			wantErr: `:2: unknown setting "openai.organization"`,
+		},
+		{
+			name:    "yaml line without colon",
+			file:    "ai-devs3.yaml",
+			content: "openai:\n  model gpt-4o\n",
+			wantErr: ":2: expected key: value",
+		},
+		{
+			name:    "toml line without equals",
+			file:    "ai-devs3.toml",
+			content: "[openai]\nmodel: gpt-4o\n",
+			wantErr: ":2: expected key = value",
+		},
+		{
+			name:    "unterminated quote",
+			file:    "ai-devs3.yaml",
+			content: "openai:\n  model: 'gpt-4o\n",
+			wantErr: ":2: unterminated quoted string",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := readConfigFile(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("readConfigFile() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readConfigFile() error = %v", err)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("readConfigFile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadEnvironmentOverridesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ai-devs3.yaml")
+	content := "openai:\n  model: gpt-4o\n  base_url: http://proxy.local/v1\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("OPENAI_MODEL", "gpt-4.1")
+	t.Setenv("OPENAI_BASE_URL", "")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.OpenAI.Model != "gpt-4.1" {
+		t.Errorf("OpenAI.Model = %q, want the environment's gpt-4.1", cfg.OpenAI.Model)
+	}
+	if cfg.OpenAI.BaseURL != "http://proxy.local/v1" {
+		t.Errorf("OpenAI.BaseURL = %q, want the file's http://proxy.local/v1", cfg.OpenAI.BaseURL)
+	}
+	if cfg.File != path {
+		t.Errorf("File = %q, want %q", cfg.File, path)
+	}
+}
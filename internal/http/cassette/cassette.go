@@ -0,0 +1,299 @@
+// Package cassette provides a record/replay http.RoundTripper so task runs
+// can be captured once against live services and replayed offline.
+package cassette
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"ai-devs3/internal/config"
+	"ai-devs3/pkg/errors"
+)
+
+// Supported values for config.CassetteConfig.Mode
+const (
+	ModeOff    = ""
+	ModeRecord = "record"
+	ModeReplay = "replay"
+)
+
+// redactedValue replaces secrets in stored requests and responses
+const redactedValue = "<redacted>"
+
+// credentialHeaders are response headers dropped from recordings, since they
+// carry session credentials rather than anything a replay needs
+var credentialHeaders = []string{"Authorization", "Set-Cookie", "Proxy-Authorization"}
+
+// Interaction is a single recorded request/response pair as stored on disk
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest holds the parts of a request used to match it on replay
+type RecordedRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   string `json:"body,omitempty"`
+}
+
+// RecordedResponse holds everything needed to rebuild a response
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       []byte      `json:"body"` // base64 in JSON, so binary payloads survive
+}
+
+// Transport records or replays HTTP interactions in a directory. Identical
+// requests are numbered in the order they are made, so polling loops and
+// repeated LLM prompts replay their responses in the original sequence.
+type Transport struct {
+	mode    string
+	dir     string
+	next    http.RoundTripper
+	secrets []string
+
+	mu       sync.Mutex
+	counters map[string]int
+}
+
+// New returns a Transport for cfg, or nil when record/replay is off.
+// Any non-empty secrets (API keys) are redacted from stored requests and
+// responses.
+func New(cfg config.CassetteConfig, secrets ...string) (*Transport, error) {
+	switch cfg.Mode {
+	case ModeOff:
+		return nil, nil
+	case ModeRecord, ModeReplay:
+	default:
+		return nil, errors.NewConfigError("cassette", fmt.Sprintf("unknown mode %q", cfg.Mode), nil)
+	}
+
+	if cfg.Dir == "" {
+		return nil, errors.NewConfigError("cassette", "directory is required", nil)
+	}
+
+	if cfg.Mode == ModeRecord {
+		if err := os.MkdirAll(cfg.Dir, 0755); err != nil {
+			return nil, errors.NewConfigError("cassette", "failed to create directory", err)
+		}
+	} else if _, err := os.Stat(cfg.Dir); err != nil {
+		return nil, errors.NewConfigError("cassette", "replay directory not found", err)
+	}
+
+	var nonEmpty []string
+	for _, secret := range secrets {
+		if secret != "" {
+			nonEmpty = append(nonEmpty, secret)
+		}
+	}
+
+	return &Transport{
+		mode:     cfg.Mode,
+		dir:      cfg.Dir,
+		next:     http.DefaultTransport,
+		secrets:  nonEmpty,
+		counters: make(map[string]int),
+	}, nil
+}
+
+// Replaying reports whether the transport serves responses from disk only
+func (t *Transport) Replaying() bool {
+	return t != nil && t.mode == ModeReplay
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorded, err := t.fingerprint(req)
+	if err != nil {
+		return nil, err
+	}
+
+	path := t.nextPath(recorded)
+
+	if t.mode == ModeReplay {
+		return t.replay(req, recorded, path)
+	}
+	return t.record(req, recorded, path)
+}
+
+// record performs the real request and stores the interaction
+func (t *Transport) record(req *http.Request, recorded RecordedRequest, path string) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	// The caller gets the real response; only the stored copy is redacted
+	live := RecordedResponse{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header.Clone(),
+		Body:       body,
+	}
+	interaction := Interaction{
+		Request:  recorded,
+		Response: t.redactResponse(live),
+	}
+
+	data, err := json.MarshalIndent(interaction, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal interaction: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write cassette %s: %w", path, err)
+	}
+
+	return live.toResponse(req), nil
+}
+
+// replay serves a recorded interaction without touching the network
+func (t *Transport) replay(req *http.Request, recorded RecordedRequest, path string) (*http.Response, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		// More calls than were recorded: keep serving the last known answer
+		if first := t.pathFor(recorded, 0); path != first {
+			path = t.lastRecorded(recorded)
+			data, err = os.ReadFile(path)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("no recorded interaction for %s %s: %w", recorded.Method, recorded.URL, err)
+	}
+
+	var interaction Interaction
+	if err := json.Unmarshal(data, &interaction); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+	}
+
+	return interaction.Response.toResponse(req), nil
+}
+
+// fingerprint extracts the redacted, normalized request used for matching,
+// restoring the request body so it can still be sent
+func (t *Transport) fingerprint(req *http.Request) (RecordedRequest, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return RecordedRequest{}, fmt.Errorf("failed to read request body: %w", err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	// Multipart boundaries are random per request and would defeat matching
+	if _, params, err := mime.ParseMediaType(req.Header.Get("Content-Type")); err == nil && params["boundary"] != "" {
+		body = bytes.ReplaceAll(body, []byte(params["boundary"]), []byte("boundary"))
+	}
+
+	return RecordedRequest{
+		Method: req.Method,
+		URL:    t.redact(req.URL.String()),
+		Body:   t.redact(string(body)),
+	}, nil
+}
+
+// nextPath returns the file for the next occurrence of this request
+func (t *Transport) nextPath(recorded RecordedRequest) string {
+	key := recorded.key()
+
+	t.mu.Lock()
+	seq := t.counters[key]
+	t.counters[key] = seq + 1
+	t.mu.Unlock()
+
+	return t.pathFor(recorded, seq)
+}
+
+// lastRecorded finds the highest numbered recording of this request
+func (t *Transport) lastRecorded(recorded RecordedRequest) string {
+	last := t.pathFor(recorded, 0)
+	for seq := 1; ; seq++ {
+		path := t.pathFor(recorded, seq)
+		if _, err := os.Stat(path); err != nil {
+			return last
+		}
+		last = path
+	}
+}
+
+// pathFor builds the cassette file name for the seq-th occurrence of a request
+func (t *Transport) pathFor(recorded RecordedRequest, seq int) string {
+	return filepath.Join(t.dir, fmt.Sprintf("%s-%03d.json", recorded.key(), seq))
+}
+
+// redact removes configured secrets from s
+func (t *Transport) redact(s string) string {
+	for _, secret := range t.secrets {
+		s = strings.ReplaceAll(s, secret, redactedValue)
+	}
+	return s
+}
+
+// redactResponse returns a copy of r without credential headers and with
+// configured secrets removed from the remaining headers and the body
+func (t *Transport) redactResponse(r RecordedResponse) RecordedResponse {
+	header := r.Header.Clone()
+	for _, name := range credentialHeaders {
+		header.Del(name)
+	}
+	for name, values := range header {
+		for i, value := range values {
+			header[name][i] = t.redact(value)
+		}
+	}
+
+	body := r.Body
+	for _, secret := range t.secrets {
+		body = bytes.ReplaceAll(body, []byte(secret), []byte(redactedValue))
+	}
+
+	return RecordedResponse{StatusCode: r.StatusCode, Header: header, Body: body}
+}
+
+// key is a stable hash of the request identity
+func (r RecordedRequest) key() string {
+	sum := sha256.Sum256([]byte(r.Method + " " + r.URL + "\n" + r.Body))
+	return hex.EncodeToString(sum[:8])
+}
+
+// toResponse rebuilds an *http.Response for req
+func (r RecordedResponse) toResponse(req *http.Request) *http.Response {
+	header := r.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	// The body is stored decoded, so these no longer describe it
+	header.Del("Content-Encoding")
+	header.Del("Content-Length")
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,243 @@
+package cassette
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"ai-devs3/internal/config"
+)
+
+// testAPIKey stands in for a key that must never reach the cassette
+const testAPIKey = "secret-key"
+
+// newTransport opens a transport in mode on dir
+func newTransport(t *testing.T, mode, dir string) *Transport {
+	t.Helper()
+	transport, err := New(config.CassetteConfig{Mode: mode, Dir: dir}, testAPIKey, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return transport
+}
+
+// roundTrip sends a request through transport and returns the status and body
+func roundTrip(t *testing.T, transport http.RoundTripper, method, url, body string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, url, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(data)
+}
+
+func TestNew(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		cfg     config.CassetteConfig
+		wantNil bool
+		wantErr bool
+	}{
+		{"off", config.CassetteConfig{}, true, false},
+		{"record", config.CassetteConfig{Mode: ModeRecord, Dir: filepath.Join(dir, "new")}, false, false},
+		{"replay", config.CassetteConfig{Mode: ModeReplay, Dir: dir}, false, false},
+		{"unknown mode", config.CassetteConfig{Mode: "rewind", Dir: dir}, true, true},
+		{"missing directory", config.CassetteConfig{Mode: ModeRecord}, true, true},
+		{"replay directory not found", config.CassetteConfig{Mode: ModeReplay, Dir: filepath.Join(dir, "missing")}, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport, err := New(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (transport == nil) != tt.wantNil {
+				t.Errorf("New() = %v, want nil %v", transport, tt.wantNil)
+			}
+		})
+	}
+}
+
+func TestRecordThenReplay(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, "%s %s #%d", r.Method, body, n)
+	}))
+
+	dir := t.TempDir()
+	url := server.URL + "/data/" + testAPIKey + "/file.txt"
+
+	recorder := newTransport(t, ModeRecord, dir)
+	var recorded []string
+	for _, body := range []string{"a", "a", "b"} {
+		status, got := roundTrip(t, recorder, http.MethodPost, url, body)
+		if status != http.StatusCreated {
+			t.Fatalf("recorded status = %d, want %d", status, http.StatusCreated)
+		}
+		recorded = append(recorded, got)
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("recorded %d files, want 3", len(files))
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(data), testAPIKey) {
+			t.Errorf("%s contains the API key", file.Name())
+		}
+	}
+
+	// Replay must not touch the network
+	server.Close()
+
+	replayer := newTransport(t, ModeReplay, dir)
+	for i, body := range []string{"a", "a", "b"} {
+		status, got := roundTrip(t, replayer, http.MethodPost, url, body)
+		if status != http.StatusCreated {
+			t.Errorf("replayed status = %d, want %d", status, http.StatusCreated)
+		}
+		if got != recorded[i] {
+			t.Errorf("replay %d = %q, want %q", i, got, recorded[i])
+		}
+	}
+
+	// More identical calls than were recorded keep getting the last answer
+	if _, got := roundTrip(t, replayer, http.MethodPost, url, "a"); got != recorded[1] {
+		t.Errorf("extra replay = %q, want %q", got, recorded[1])
+	}
+}
+
+func TestRecordRedactsResponses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "session=abc123")
+		w.Header().Set("X-Echo", "key="+testAPIKey)
+		fmt.Fprintf(w, `{"error": "invalid key %s"}`, testAPIKey)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	recorder := newTransport(t, ModeRecord, dir)
+
+	// The live caller still sees the real response
+	if _, got := roundTrip(t, recorder, http.MethodGet, server.URL, ""); !strings.Contains(got, testAPIKey) {
+		t.Errorf("live body = %q, want it unredacted", got)
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("recorded %d files, want 1", len(files))
+	}
+	data, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var interaction Interaction
+	if err := json.Unmarshal(data, &interaction); err != nil {
+		t.Fatal(err)
+	}
+
+	stored := interaction.Response
+	if want := `{"error": "invalid key ` + redactedValue + `"}`; string(stored.Body) != want {
+		t.Errorf("stored body = %q, want %q", stored.Body, want)
+	}
+	if got := stored.Header.Get("X-Echo"); got != "key="+redactedValue {
+		t.Errorf("stored X-Echo = %q, want the key redacted", got)
+	}
+	if got := stored.Header.Get("Set-Cookie"); got != "" {
+		t.Errorf("stored Set-Cookie = %q, want it dropped", got)
+	}
+}
+
+func TestReplayMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	roundTrip(t, newTransport(t, ModeRecord, dir), http.MethodPost, server.URL+"/report", `{"answer":1}`)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+	}{
+		{"other body", http.MethodPost, "/report", `{"answer":2}`},
+		{"other path", http.MethodPost, "/verify", `{"answer":1}`},
+		{"other method", http.MethodGet, "/report", ""},
+	}
+
+	replayer := newTransport(t, ModeReplay, dir)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, server.URL+tt.path, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := replayer.RoundTrip(req); err == nil || !strings.Contains(err.Error(), "no recorded interaction") {
+				t.Errorf("RoundTrip() error = %v, want no recorded interaction", err)
+			}
+		})
+	}
+}
+
+func TestFingerprintIgnoresMultipartBoundary(t *testing.T) {
+	transport := newTransport(t, ModeReplay, t.TempDir())
+
+	var keys []string
+	for _, boundary := range []string{"first-boundary", "second-boundary"} {
+		body := "--" + boundary + "\r\nContent-Disposition: form-data; name=\"file\"\r\n\r\ndata\r\n--" + boundary + "--\r\n"
+		req, err := http.NewRequest(http.MethodPost, "http://example.com/upload", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "multipart/form-data; boundary="+boundary)
+
+		recorded, err := transport.fingerprint(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The body must still be readable for the real request
+		if sent, _ := io.ReadAll(req.Body); string(sent) != body {
+			t.Errorf("request body after fingerprint = %q, want %q", sent, body)
+		}
+		keys = append(keys, recorded.key())
+	}
+
+	if keys[0] != keys[1] {
+		t.Errorf("keys differ by boundary: %v", keys)
+	}
+}
@@ -10,46 +10,39 @@ import (
 	"net/url"
 	"strings"
 
+	"ai-devs3/internal/budget"
 	"ai-devs3/internal/config"
 	"ai-devs3/pkg/errors"
 )
 
 // Client wraps http.Client with configuration and error handling
 type Client struct {
-	client *http.Client
-	config config.HTTPConfig
+	client   *http.Client
+	config   config.HTTPConfig
+	limiter  *hostLimiter
+	inflight *coalescer
+	budget   *budget.Budget
 }
 
 // NewClient creates a new HTTP client with the given configuration
 func NewClient(cfg config.HTTPConfig) *Client {
 	return &Client{
 		client: &http.Client{
-			Timeout: cfg.Timeout,
+			Timeout:   cfg.Timeout,
+			Transport: cfg.Transport,
 		},
-		config: cfg,
+		config:   cfg,
+		limiter:  newHostLimiter(cfg.RateLimit, cfg.RateBurst),
+		inflight: newCoalescer(),
+		budget:   cfg.Budget,
 	}
 }
 
 // FetchPage retrieves the content of a web page
 func (c *Client) FetchPage(ctx context.Context, url string) (string, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	body, err := c.get(ctx, url, "fetch page")
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
-
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return "", errors.NewAPIError("HTTP", 0, "failed to fetch page", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		return "", errors.NewAPIError("HTTP", resp.StatusCode, "HTTP error", nil)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response body: %w", err)
+		return "", err
 	}
 
 	return string(body), nil
@@ -57,24 +50,9 @@ func (c *Client) FetchPage(ctx context.Context, url string) (string, error) {
 
 // FetchJSONData downloads and unmarshals JSON from the given URL
 func (c *Client) FetchJSONData(ctx context.Context, url string) (map[string]any, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return nil, errors.NewAPIError("HTTP", 0, "failed to fetch JSON", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		return nil, errors.NewAPIError("HTTP", resp.StatusCode, "HTTP error", nil)
-	}
-
-	body, err := io.ReadAll(resp.Body)
+	body, err := c.get(ctx, url, "fetch JSON")
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, err
 	}
 
 	var data map[string]any
@@ -87,24 +65,9 @@ func (c *Client) FetchJSONData(ctx context.Context, url string) (map[string]any,
 
 // FetchData retrieves raw data from a URL
 func (c *Client) FetchData(ctx context.Context, url string) (string, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	body, err := c.get(ctx, url, "fetch data")
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
-
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return "", errors.NewAPIError("HTTP", 0, "failed to fetch data", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		return "", errors.NewAPIError("HTTP", resp.StatusCode, "HTTP error", nil)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response body: %w", err)
+		return "", err
 	}
 
 	return string(body), nil
@@ -112,61 +75,55 @@ func (c *Client) FetchData(ctx context.Context, url string) (string, error) {
 
 // FetchBinaryData retrieves binary data from a URL
 func (c *Client) FetchBinaryData(ctx context.Context, url string) ([]byte, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	body, err := c.get(ctx, url, "fetch binary data")
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
 
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return nil, errors.NewAPIError("HTTP", 0, "failed to fetch binary data", err)
-	}
-	defer resp.Body.Close()
+	// The buffer may be shared with coalesced callers, so hand out a private copy
+	return bytes.Clone(body), nil
+}
 
-	if resp.StatusCode >= 400 {
-		return nil, errors.NewAPIError("HTTP", resp.StatusCode, "HTTP error", nil)
+// PostJSON sends a JSON payload to the specified URL
+func (c *Client) PostJSON(ctx context.Context, url string, payload any) (string, error) {
+	statusCode, body, err := c.PostJSONRaw(ctx, url, payload)
+	if err != nil {
+		return "", err
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+	if statusCode >= 400 {
+		return "", errors.NewAPIError("HTTP", statusCode, "HTTP error: "+body, nil)
 	}
 
 	return body, nil
 }
 
-// PostJSON sends a JSON payload to the specified URL
-func (c *Client) PostJSON(ctx context.Context, url string, payload any) (string, error) {
-	var jsonData []byte
-	var err error
-
-	jsonData, err = json.Marshal(payload)
+// PostJSONRaw sends a JSON payload and returns the status code and body without
+// treating 4xx/5xx as errors, for APIs that describe failures in the body
+func (c *Client) PostJSONRaw(ctx context.Context, url string, payload any) (int, string, error) {
+	jsonData, err := json.Marshal(payload)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal JSON: %w", err)
+		return 0, "", fmt.Errorf("failed to marshal JSON: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return 0, "", fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
-		return "", errors.NewAPIError("HTTP", 0, "failed to post JSON", err)
+		return 0, "", errors.NewAPIError("HTTP", 0, "failed to post JSON", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response body: %w", err)
+		return 0, "", fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	if resp.StatusCode >= 400 {
-		return "", errors.NewAPIError("HTTP", resp.StatusCode, "HTTP error: "+string(body), nil)
-	}
-
-	return string(body), nil
+	return resp.StatusCode, string(body), nil
 }
 
 // PostForm sends form data to the specified URL
@@ -182,7 +139,7 @@ func (c *Client) PostForm(ctx context.Context, reqURL string, data map[string]st
 	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	resp, err := c.client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return "", errors.NewAPIError("HTTP", 0, "failed to post form", err)
 	}
@@ -199,37 +156,3 @@ func (c *Client) PostForm(ctx context.Context, reqURL string, data map[string]st
 
 	return string(body), nil
 }
-
-// BuildAIDevsResponse creates a response for AI-DEVS API
-func (c *Client) BuildAIDevsResponse(task string, apiKey string, answer any) map[string]any {
-	return map[string]any{
-		"task":   task,
-		"apikey": apiKey,
-		"answer": answer,
-	}
-}
-
-// AIDevsAPIResponse represents the standard AI-DEVS API response format
-type AIDevsAPIResponse struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
-}
-
-// PostReport sends a report to the AI-DEVS central endpoint
-func (c *Client) PostReport(ctx context.Context, baseURL string, response map[string]any) (string, error) {
-	reportURL := fmt.Sprintf("%s/report", baseURL)
-
-	res, err := c.PostJSON(ctx, reportURL, response)
-	if err != nil {
-		return "", err
-	}
-
-	// Parse the JSON response to extract the message
-	var apiResponse AIDevsAPIResponse
-	message := res // Default to raw response if parsing fails
-	if err := json.Unmarshal([]byte(res), &apiResponse); err == nil {
-		message = apiResponse.Message
-	}
-
-	return message, nil
-}
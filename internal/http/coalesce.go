@@ -0,0 +1,105 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	pkgerrors "ai-devs3/pkg/errors"
+)
+
+// inflightCall is a GET whose result is shared by every concurrent caller
+type inflightCall struct {
+	done chan struct{}
+	body []byte
+	err  error
+}
+
+// coalescer merges identical in-flight GET requests into a single round trip
+type coalescer struct {
+	mu    sync.Mutex
+	calls map[string]*inflightCall
+}
+
+// newCoalescer creates an empty coalescer
+func newCoalescer() *coalescer {
+	return &coalescer{
+		calls: make(map[string]*inflightCall),
+	}
+}
+
+// Do runs fn once per key at a time; callers arriving while it runs wait for
+// and receive the same result. A waiter whose own ctx ends stops waiting
+// without affecting the shared call. A call that failed because its caller's
+// context ended says nothing about the waiters, so those still waiting run
+// fn again.
+func (g *coalescer) Do(ctx context.Context, key string, fn func() ([]byte, error)) ([]byte, error) {
+	for {
+		g.mu.Lock()
+		call, ok := g.calls[key]
+		if !ok {
+			break
+		}
+		g.mu.Unlock()
+
+		select {
+		case <-call.done:
+			if isContextError(call.err) && ctx.Err() == nil {
+				continue
+			}
+			return call.body, call.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	call := &inflightCall{done: make(chan struct{})}
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	call.body, call.err = fn()
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+	close(call.done)
+
+	return call.body, call.err
+}
+
+// isContextError reports whether err comes from a canceled or expired context
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// get downloads the body of url, sharing the round trip with any identical
+// GET already in flight. The leading caller's ctx governs the request; when
+// it ends the request, the remaining callers send their own.
+func (c *Client) get(ctx context.Context, url, operation string) ([]byte, error) {
+	return c.inflight.Do(ctx, url, func() ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		resp, err := c.do(req)
+		if err != nil {
+			return nil, pkgerrors.NewAPIError("HTTP", 0, "failed to "+operation, err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode >= 400 {
+			return nil, pkgerrors.NewAPIError("HTTP", resp.StatusCode, "HTTP error", nil)
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response body: %w", err)
+		}
+
+		return body, nil
+	})
+}
@@ -0,0 +1,102 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"ai-devs3/internal/config"
+)
+
+func TestGetCoalescesIdenticalRequests(t *testing.T) {
+	var requests atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		<-release
+		io.WriteString(w, "shared")
+	}))
+	defer server.Close()
+
+	client := NewClient(config.HTTPConfig{})
+
+	const callers = 4
+	results := make(chan string, callers)
+	for range callers {
+		go func() {
+			body, err := client.FetchData(context.Background(), server.URL)
+			if err != nil {
+				t.Errorf("FetchData() error = %v", err)
+			}
+			results <- body
+		}()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	for range callers {
+		if body := <-results; body != "shared" {
+			t.Errorf("FetchData() = %q, want %q", body, "shared")
+		}
+	}
+
+	if got := requests.Load(); got != 1 {
+		t.Errorf("server got %d requests, want 1", got)
+	}
+}
+
+func TestGetWaiterRetriesAfterLeaderCanceled(t *testing.T) {
+	var requests atomic.Int32
+	leaderArrived := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			// Hold the leader's request until its caller gives up
+			close(leaderArrived)
+			<-r.Context().Done()
+			return
+		}
+		io.WriteString(w, "waiter")
+	}))
+	defer server.Close()
+
+	client := NewClient(config.HTTPConfig{})
+
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := client.FetchData(leaderCtx, server.URL)
+		leaderErr <- err
+	}()
+	<-leaderArrived
+
+	type result struct {
+		body string
+		err  error
+	}
+	waiter := make(chan result, 1)
+	go func() {
+		body, err := client.FetchData(context.Background(), server.URL)
+		waiter <- result{body, err}
+	}()
+
+	// Let the waiter block on the leader's call before it ends
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	if err := <-leaderErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("leader error = %v, want %v", err, context.Canceled)
+	}
+	got := <-waiter
+	if got.err != nil || got.body != "waiter" {
+		t.Errorf("waiter FetchData() = %q, %v, want %q", got.body, got.err, "waiter")
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("server got %d requests, want 2", n)
+	}
+}
@@ -0,0 +1,80 @@
+package http
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// hostLimiter keeps one token bucket per host so a slow endpoint on one
+// server never throttles requests to another
+type hostLimiter struct {
+	rate  float64 // tokens added per second
+	burst int     // bucket capacity
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+// tokenBucket is a classic token bucket refilled lazily on each reservation
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// newHostLimiter creates a limiter; a non-positive rate disables limiting
+func newHostLimiter(rate float64, burst int) *hostLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &hostLimiter{
+		rate:    rate,
+		burst:   burst,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// Wait blocks until a token for host is available or ctx is cancelled
+func (l *hostLimiter) Wait(ctx context.Context, host string) error {
+	if l == nil {
+		return nil
+	}
+
+	wait := l.reserve(host)
+	if wait <= 0 {
+		return nil
+	}
+
+	return sleepContext(ctx, wait)
+}
+
+// reserve takes a token from the host's bucket, possibly going into debt,
+// and returns how long the caller has to wait for that token to exist
+func (l *hostLimiter) reserve(host string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	bucket, ok := l.buckets[host]
+	if !ok {
+		bucket = &tokenBucket{tokens: float64(l.burst), last: now}
+		l.buckets[host] = bucket
+	}
+
+	bucket.tokens += now.Sub(bucket.last).Seconds() * l.rate
+	if bucket.tokens > float64(l.burst) {
+		bucket.tokens = float64(l.burst)
+	}
+	bucket.last = now
+
+	bucket.tokens--
+	if bucket.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-bucket.tokens / l.rate * float64(time.Second))
+}
@@ -0,0 +1,72 @@
+package http
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestHostLimiterThrottlesPerHost(t *testing.T) {
+	// One request a second with a burst of two
+	l := newHostLimiter(1, 2)
+
+	for i := range 2 {
+		if wait := l.reserve("centrala"); wait != 0 {
+			t.Fatalf("reserve #%d within the burst = %v, want 0", i+1, wait)
+		}
+	}
+
+	// Bucket empty: the next two tokens are a second apart
+	if wait := l.reserve("centrala"); wait < 900*time.Millisecond || wait > time.Second {
+		t.Errorf("reserve after the burst = %v, want about 1s", wait)
+	}
+	if wait := l.reserve("centrala"); wait < 1900*time.Millisecond || wait > 2*time.Second {
+		t.Errorf("second reserve after the burst = %v, want about 2s", wait)
+	}
+
+	// Another host has its own full bucket
+	if wait := l.reserve("openai"); wait != 0 {
+		t.Errorf("reserve on another host = %v, want 0", wait)
+	}
+
+	// Wait gives up on the throttled host when the context ends, while the
+	// other host goes straight through
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx, "centrala"); err == nil {
+		t.Error("Wait on the throttled host = nil, want the context error")
+	}
+	start := time.Now()
+	if err := l.Wait(context.Background(), "openai"); err != nil {
+		t.Errorf("Wait on another host = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("Wait on another host took %v, want no wait", elapsed)
+	}
+}
+
+func TestHostLimiterRefills(t *testing.T) {
+	l := newHostLimiter(50, 1)
+
+	l.reserve("centrala")
+	time.Sleep(40 * time.Millisecond) // two tokens' worth, capped at the burst of one
+
+	if wait := l.reserve("centrala"); wait != 0 {
+		t.Errorf("reserve after refilling = %v, want 0", wait)
+	}
+	if wait := l.reserve("centrala"); wait <= 0 {
+		t.Errorf("reserve beyond the burst = %v, want a wait", wait)
+	}
+}
+
+func TestHostLimiterDisabled(t *testing.T) {
+	l := newHostLimiter(0, 5)
+	if l != nil {
+		t.Fatalf("newHostLimiter(0) = %+v, want nil", l)
+	}
+	for range 10 {
+		if err := l.Wait(context.Background(), "centrala"); err != nil {
+			t.Fatalf("Wait on a disabled limiter = %v", err)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package http
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ai-devs3/internal/logging"
+	"ai-devs3/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// do executes the request as one span covering every attempt, so retries and
+// rate limit waits show up in the time the request took
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	ctx, span := tracing.Start(req.Context(), "HTTP "+req.Method,
+		attribute.String("http.request.method", req.Method),
+		attribute.String("url.full", tracing.Redact(req.URL.Redacted())),
+		attribute.String("server.address", req.URL.Host))
+
+	resp, err := c.doWithRetry(req.WithContext(ctx))
+	if resp != nil {
+		span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+		if resp.StatusCode >= 400 {
+			span.SetStatus(codes.Error, resp.Status)
+		}
+	}
+	tracing.End(span, err)
+	return resp, err
+}
+
+// doWithRetry executes the request, retrying transient failures according to the
+// client configuration. Every attempt counts against the run budget and waits
+// for the per-host rate limit.
+// Idempotent requests are retried on network errors, 429 and any 5xx; other
+// methods only on 429/502/503/504, where the server has almost certainly not
+// processed the request. The last response is returned as-is so callers can
+// build their usual error from it.
+func (c *Client) doWithRetry(req *http.Request) (*http.Response, error) {
+	maxAttempts := c.config.Retries + 1
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 {
+			// Each attempt needs a fresh copy of the request body
+			if req.Body != nil && req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				req.Body = body
+			}
+		}
+
+		if err := c.budget.Allow(); err != nil {
+			return nil, err
+		}
+
+		if err := c.limiter.Wait(req.Context(), req.URL.Host); err != nil {
+			return nil, err
+		}
+
+		resp, err := c.client.Do(req)
+
+		if attempt >= maxAttempts || !c.shouldRetry(req, resp, err) {
+			return resp, err
+		}
+
+		wait := c.backoff(attempt, resp)
+		logger := logging.FromContext(req.Context()).With(
+			"method", req.Method, "url", req.URL.Redacted(), "wait", wait, "attempt", attempt+1, "max_attempts", maxAttempts)
+		trace.SpanFromContext(req.Context()).AddEvent("retry", trace.WithAttributes(
+			attribute.Int("attempt", attempt+1), attribute.String("wait", wait.String())))
+		if resp != nil {
+			logger.Warn("HTTP request returned a retryable status", "status", resp.StatusCode)
+			drainBody(resp)
+		} else {
+			logger.Warn("HTTP request failed, retrying", "error", err)
+		}
+
+		if err := sleepContext(req.Context(), wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// shouldRetry decides whether a failed attempt is worth repeating
+func (c *Client) shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	// Never retry once the caller has given up
+	if req.Context().Err() != nil {
+		return false
+	}
+
+	// A consumed body that cannot be recreated cannot be resent
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+
+	if err != nil {
+		return isIdempotent(req.Method)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	return resp.StatusCode >= 500 && isIdempotent(req.Method)
+}
+
+// backoff returns how long to wait before the next attempt: the server's
+// Retry-After when present, otherwise exponential backoff with jitter
+func (c *Client) backoff(attempt int, resp *http.Response) time.Duration {
+	maxWait := c.config.RetryWaitMax
+	if maxWait <= 0 {
+		maxWait = 30 * time.Second
+	}
+
+	if resp != nil {
+		if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			return min(wait, maxWait)
+		}
+	}
+
+	base := c.config.RetryWaitMin
+	if base <= 0 {
+		base = 500 * time.Millisecond
+	}
+
+	wait := base << (attempt - 1)
+	if wait <= 0 || wait > maxWait {
+		wait = maxWait
+	}
+
+	// Equal jitter: half fixed, half random, so concurrent clients spread out
+	half := wait / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// parseRetryAfter understands both delay-seconds and HTTP-date forms
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+
+	return 0, false
+}
+
+// isIdempotent reports whether repeating a request with this method is safe
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// sleepContext waits for d or until ctx is cancelled
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// drainBody discards and closes a response body so the connection can be reused
+func drainBody(resp *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	resp.Body.Close()
+}
@@ -0,0 +1,160 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"ai-devs3/internal/config"
+)
+
+func TestDoWithRetry(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		statuses     []int // answered in turn, the last one repeatedly
+		retryAfter   string
+		wantStatus   int
+		wantAttempts int32
+	}{
+		{"GET succeeds at once", http.MethodGet, []int{200}, "", 200, 1},
+		{"GET retried after 503", http.MethodGet, []int{503, 200}, "", 200, 2},
+		{"GET retried after 500", http.MethodGet, []int{500, 200}, "", 200, 2},
+		{"GET gives up after all retries", http.MethodGet, []int{500}, "", 500, 3},
+		{"GET not retried after 404", http.MethodGet, []int{404, 200}, "", 404, 1},
+		{"GET honours Retry-After", http.MethodGet, []int{429, 200}, "0", 200, 2},
+		{"POST retried after 503", http.MethodPost, []int{503, 200}, "", 200, 2},
+		{"POST retried after 429", http.MethodPost, []int{429, 200}, "", 200, 2},
+		{"POST not retried after 500", http.MethodPost, []int{500, 200}, "", 500, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(attempts.Add(1))
+				status := tt.statuses[min(n, len(tt.statuses))-1]
+				if r.Method == http.MethodPost {
+					// Every attempt must resend the whole body
+					if body, err := io.ReadAll(r.Body); err != nil || string(body) != "payload" {
+						status = http.StatusBadRequest
+					}
+				}
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(status)
+			}))
+			defer server.Close()
+
+			client := NewClient(config.HTTPConfig{
+				Retries:      2,
+				RetryWaitMin: time.Millisecond,
+				RetryWaitMax: 5 * time.Millisecond,
+			})
+
+			req, err := http.NewRequestWithContext(context.Background(), tt.method, server.URL, strings.NewReader("payload"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := client.do(req)
+			if err != nil {
+				t.Fatalf("do() error = %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if got := attempts.Load(); got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestDoWithRetryStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		cancel()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewClient(config.HTTPConfig{Retries: 3, RetryWaitMin: time.Millisecond})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := client.do(req); err == nil {
+		resp.Body.Close()
+	}
+	if got := attempts.Load(); got != 1 {
+		t.Errorf("attempts = %d, want 1 after the caller gave up", got)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"", 0, false},
+		{"0", 0, true},
+		{"120", 2 * time.Minute, true},
+		{"-1", 0, false},
+		{"soon", 0, false},
+		{"Mon, 02 Jan 2006 15:04:05 GMT", 0, true}, // a date in the past waits no longer
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.value)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("parseRetryAfter(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	client := NewClient(config.HTTPConfig{
+		RetryWaitMin: 100 * time.Millisecond,
+		RetryWaitMax: time.Second,
+	})
+
+	tests := []struct {
+		name       string
+		attempt    int
+		retryAfter string
+		min, max   time.Duration
+	}{
+		{"first retry", 1, "", 50 * time.Millisecond, 100 * time.Millisecond},
+		{"doubles", 3, "", 200 * time.Millisecond, 400 * time.Millisecond},
+		{"capped", 10, "", 500 * time.Millisecond, time.Second},
+		{"Retry-After", 1, "0", 0, 0},
+		{"Retry-After capped", 1, "60", time.Second, time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{Header: make(http.Header)}
+			if tt.retryAfter != "" {
+				resp.Header.Set("Retry-After", tt.retryAfter)
+			}
+			got := client.backoff(tt.attempt, resp)
+			if got < tt.min || got > tt.max {
+				t.Errorf("backoff(%d) = %v, want between %v and %v", tt.attempt, got, tt.min, tt.max)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package image
+
+import (
+	"image"
+	"math"
+	"slices"
+)
+
+// AdjustBrightness shifts every channel by delta, from -1 (black) to 1 (white)
+func AdjustBrightness(img image.Image, delta float64) *image.RGBA {
+	return applyCurve(img, func(v float64) float64 { return v + delta })
+}
+
+// AdjustContrast scales the distance of every channel from mid-grey by
+// factor; above 1 increases contrast, between 0 and 1 flattens it
+func AdjustContrast(img image.Image, factor float64) *image.RGBA {
+	return applyCurve(img, func(v float64) float64 { return (v-0.5)*factor + 0.5 })
+}
+
+// AdjustGamma applies a gamma curve; below 1 lifts shadows and mid-tones,
+// above 1 deepens them while keeping black and white in place
+func AdjustGamma(img image.Image, gamma float64) *image.RGBA {
+	return applyCurve(img, func(v float64) float64 { return math.Pow(v, gamma) })
+}
+
+// Equalize spreads the luminance histogram over the full range, which
+// recovers detail in washed-out or murky photos. The same mapping is applied
+// to every channel so that colours keep their hue.
+func Equalize(img image.Image) *image.RGBA {
+	src := copyRegion(img, img.Bounds())
+
+	var histogram [256]int
+	forEachPixel(src, func(r, g, b uint8) { histogram[luminance(r, g, b)]++ })
+
+	total := src.Bounds().Dx() * src.Bounds().Dy()
+	var lut [256]uint8
+	var cumulative, first int
+	for v, count := range histogram {
+		if first == 0 && count > 0 {
+			first = count
+		}
+		cumulative += count
+		if total > first {
+			lut[v] = uint8(math.Round(float64(cumulative-first) / float64(total-first) * 255))
+		} else {
+			lut[v] = uint8(v) // a single tone has nothing to spread
+		}
+	}
+
+	return applyLUT(src, lut)
+}
+
+// Denoise replaces every channel with the median of its neighbourhood within
+// radius pixels, removing speckles and scan-line glitches while keeping edges
+func Denoise(img image.Image, radius int) *image.RGBA {
+	src := copyRegion(img, img.Bounds())
+	if radius <= 0 {
+		return src
+	}
+
+	width, height := src.Bounds().Dx(), src.Bounds().Dy()
+	dst := image.NewRGBA(src.Bounds())
+	window := make([]uint8, 0, (2*radius+1)*(2*radius+1))
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			j := dst.PixOffset(x, y)
+			for c := 0; c < 3; c++ {
+				window = window[:0]
+				for wy := max(y-radius, 0); wy <= min(y+radius, height-1); wy++ {
+					for wx := max(x-radius, 0); wx <= min(x+radius, width-1); wx++ {
+						window = append(window, src.Pix[src.PixOffset(wx, wy)+c])
+					}
+				}
+				slices.Sort(window)
+				dst.Pix[j+c] = window[len(window)/2]
+			}
+			dst.Pix[j+3] = src.Pix[j+3]
+		}
+	}
+
+	return dst
+}
+
+// applyCurve maps every colour channel through curve, which works on values
+// from 0 to 1 and is clamped to that range
+func applyCurve(img image.Image, curve func(float64) float64) *image.RGBA {
+	var lut [256]uint8
+	for v := range lut {
+		lut[v] = uint8(math.Round(clamp(curve(float64(v)/255)) * 255))
+	}
+	return applyLUT(copyRegion(img, img.Bounds()), lut)
+}
+
+// applyLUT maps the colour channels of img in place and returns it. Pixels
+// are treated as opaque; photos sent to vision models carry no transparency.
+func applyLUT(img *image.RGBA, lut [256]uint8) *image.RGBA {
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i] = lut[img.Pix[i]]
+		img.Pix[i+1] = lut[img.Pix[i+1]]
+		img.Pix[i+2] = lut[img.Pix[i+2]]
+	}
+	return img
+}
+
+// forEachPixel calls fn with the colour of every pixel of img
+func forEachPixel(img *image.RGBA, fn func(r, g, b uint8)) {
+	for i := 0; i < len(img.Pix); i += 4 {
+		fn(img.Pix[i], img.Pix[i+1], img.Pix[i+2])
+	}
+}
+
+// luminance returns the Rec. 601 luma of a colour
+func luminance(r, g, b uint8) uint8 {
+	return uint8((299*int(r) + 587*int(g) + 114*int(b) + 500) / 1000)
+}
+
+// clamp limits v to the range from 0 to 1
+func clamp(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
@@ -0,0 +1,187 @@
+package image
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestCurvesAreMonotonic(t *testing.T) {
+	tests := []struct {
+		name                 string
+		apply                func(image.Image) *image.RGBA
+		wantBlack, wantWhite uint8
+	}{
+		{"brighten", func(img image.Image) *image.RGBA { return AdjustBrightness(img, 0.2) }, 51, 255},
+		{"darken", func(img image.Image) *image.RGBA { return AdjustBrightness(img, -0.2) }, 0, 204},
+		{"more contrast", func(img image.Image) *image.RGBA { return AdjustContrast(img, 1.5) }, 0, 255},
+		{"less contrast", func(img image.Image) *image.RGBA { return AdjustContrast(img, 0.5) }, 64, 191},
+		{"lift shadows", func(img image.Image) *image.RGBA { return AdjustGamma(img, 0.6) }, 0, 255},
+		{"deepen shadows", func(img image.Image) *image.RGBA { return AdjustGamma(img, 1.8) }, 0, 255},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lut := greyLUT(tt.apply(greyRamp()))
+			assertMonotonic(t, lut)
+			if lut[0] != tt.wantBlack || lut[255] != tt.wantWhite {
+				t.Errorf("black, white = %d, %d, want %d, %d", lut[0], lut[255], tt.wantBlack, tt.wantWhite)
+			}
+		})
+	}
+}
+
+func TestEqualize(t *testing.T) {
+	t.Run("LUT is monotonic", func(t *testing.T) {
+		// Uneven counts per level, so equalizing moves every level differently
+		img := image.NewRGBA(image.Rect(0, 0, 256, 4))
+		for x := 0; x < 256; x++ {
+			for y := 0; y < 4; y++ {
+				v := uint8(x)
+				if y > x%4 {
+					v = uint8(x / 2)
+				}
+				img.Set(x, y, color.RGBA{v, v, v, 255})
+			}
+		}
+
+		out := Equalize(img)
+		lut := make(map[uint8]uint8)
+		for i := 0; i < len(img.Pix); i += 4 {
+			lut[img.Pix[i]] = out.Pix[i]
+		}
+		prev := -1
+		for v := 0; v < 256; v++ {
+			mapped, ok := lut[uint8(v)]
+			if !ok {
+				continue
+			}
+			if int(mapped) < prev {
+				t.Fatalf("level %d maps to %d, below the %d of a darker level", v, mapped, prev)
+			}
+			prev = int(mapped)
+		}
+	})
+
+	t.Run("stretches a murky photo", func(t *testing.T) {
+		// Levels 100 to 139 only
+		img := image.NewRGBA(image.Rect(0, 0, 40, 1))
+		for x := 0; x < 40; x++ {
+			img.Set(x, 0, color.RGBA{uint8(100 + x), uint8(100 + x), uint8(100 + x), 255})
+		}
+
+		out := Equalize(img)
+		if lo, hi := out.Pix[0], out.Pix[len(out.Pix)-4]; lo != 0 || hi != 255 {
+			t.Errorf("range after equalizing = %d..%d, want 0..255", lo, hi)
+		}
+		assertMonotonic(t, greyLUT(out))
+	})
+
+	t.Run("keeps a single tone", func(t *testing.T) {
+		img := uniform(8, 8, 77)
+		out := Equalize(img)
+		for i := 0; i < len(out.Pix); i += 4 {
+			if out.Pix[i] != 77 {
+				t.Fatalf("pixel = %d, want 77", out.Pix[i])
+			}
+		}
+	})
+
+	t.Run("keeps the alpha channel and the source", func(t *testing.T) {
+		img := greyRamp()
+		before := bytes.Clone(img.Pix)
+		out := Equalize(img)
+		for i := 3; i < len(out.Pix); i += 4 {
+			if out.Pix[i] != 255 {
+				t.Fatalf("alpha = %d, want 255", out.Pix[i])
+			}
+		}
+		if !bytes.Equal(before, img.Pix) {
+			t.Error("Equalize modified its input")
+		}
+	})
+}
+
+func TestDenoise(t *testing.T) {
+	speckled := uniform(9, 9, 128)
+	for _, p := range []image.Point{{2, 2}, {6, 3}, {4, 7}} {
+		speckled.Set(p.X, p.Y, color.RGBA{255, 0, 255, 255})
+	}
+
+	// Left half black, right half white
+	edge := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			v := uint8(0)
+			if x >= 4 {
+				v = 255
+			}
+			edge.Set(x, y, color.RGBA{v, v, v, 255})
+		}
+	}
+
+	tests := []struct {
+		name   string
+		img    *image.RGBA
+		radius int
+		want   func(x, y int) uint8
+	}{
+		{"removes speckles", speckled, 1, func(x, y int) uint8 { return 128 }},
+		{"keeps edges", edge, 1, func(x, y int) uint8 { return uint8(min(x/4, 1) * 255) }},
+		{"radius 0 keeps the image", speckled, 0, func(x, y int) uint8 {
+			return speckled.Pix[speckled.PixOffset(x, y)]
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := Denoise(tt.img, tt.radius)
+			bounds := out.Bounds()
+			for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+				for x := bounds.Min.X; x < bounds.Max.X; x++ {
+					if got, want := out.Pix[out.PixOffset(x, y)], tt.want(x, y); got != want {
+						t.Fatalf("red at (%d,%d) = %d, want %d", x, y, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+// greyRamp is a 256x1 image holding every grey level once, in order
+func greyRamp() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 256, 1))
+	for x := 0; x < 256; x++ {
+		img.Set(x, 0, color.RGBA{uint8(x), uint8(x), uint8(x), 255})
+	}
+	return img
+}
+
+// greyLUT reads the red channel of a processed ramp row by row
+func greyLUT(img *image.RGBA) []uint8 {
+	lut := make([]uint8, 0, len(img.Pix)/4)
+	for i := 0; i < len(img.Pix); i += 4 {
+		lut = append(lut, img.Pix[i])
+	}
+	return lut
+}
+
+// assertMonotonic fails unless lut never decreases
+func assertMonotonic(t *testing.T, lut []uint8) {
+	t.Helper()
+	for v := 1; v < len(lut); v++ {
+		if lut[v] < lut[v-1] {
+			t.Fatalf("level %d maps to %d, below level %d at %d", v, lut[v], v-1, lut[v-1])
+		}
+	}
+}
+
+// uniform is a width x height image of a single grey level
+func uniform(width, height int, v uint8) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = v, v, v, 255
+	}
+	return img
+}
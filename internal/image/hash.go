@@ -0,0 +1,240 @@
+package image
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"math"
+	"math/bits"
+	"slices"
+	"strconv"
+
+	"ai-devs3/pkg/errors"
+)
+
+// DuplicateDistance is the largest perceptual hash distance between two
+// images considered the same photo, e.g. before and after a restoration step
+const DuplicateDistance = 10
+
+// Hash is a 64-bit perceptual hash; similar images have hashes that differ in
+// few bits
+type Hash uint64
+
+// Distance returns the number of bits in which h and other differ
+func (h Hash) Distance(other Hash) int {
+	return bits.OnesCount64(uint64(h ^ other))
+}
+
+// String returns the hash as 16 hex digits
+func (h Hash) String() string {
+	return fmt.Sprintf("%016x", uint64(h))
+}
+
+// ParseHash parses a hash formatted by Hash.String
+func ParseHash(s string) (Hash, error) {
+	v, err := strconv.ParseUint(s, 16, 64)
+	if err != nil {
+		return 0, errors.NewProcessingError("image", "parse_hash", "invalid image hash", err)
+	}
+	return Hash(v), nil
+}
+
+// MarshalText stores the hash as hex digits, which JSON keeps exact
+func (h Hash) MarshalText() ([]byte, error) {
+	return []byte(h.String()), nil
+}
+
+// UnmarshalText parses a hash stored by MarshalText
+func (h *Hash) UnmarshalText(text []byte) error {
+	parsed, err := ParseHash(string(text))
+	if err != nil {
+		return err
+	}
+	*h = parsed
+	return nil
+}
+
+// Hashes holds the perceptual hashes of an image
+type Hashes struct {
+	Average    Hash `json:"average"`    // fast, but thrown off by exposure changes
+	Difference Hash `json:"difference"` // follows gradients, robust to exposure
+	Perceptual Hash `json:"perceptual"` // follows low frequencies, robust to noise and small edits
+}
+
+// ComputeHashes computes the average, difference and perceptual hashes of img
+func ComputeHashes(img image.Image) Hashes {
+	return Hashes{
+		Average:    AverageHash(img),
+		Difference: DifferenceHash(img),
+		Perceptual: PerceptualHash(img),
+	}
+}
+
+// AverageHash sets a bit for every cell of an 8x8 thumbnail brighter than the
+// thumbnail's mean
+func AverageHash(img image.Image) Hash {
+	gray := grayThumbnail(img, 8, 8)
+
+	var mean float64
+	for _, v := range gray {
+		mean += v
+	}
+	mean /= float64(len(gray))
+
+	var hash Hash
+	for i, v := range gray {
+		if v > mean {
+			hash |= 1 << i
+		}
+	}
+	return hash
+}
+
+// DifferenceHash sets a bit for every cell of a 9x8 thumbnail brighter than
+// its right neighbour
+func DifferenceHash(img image.Image) Hash {
+	gray := grayThumbnail(img, 9, 8)
+
+	var hash Hash
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if gray[y*9+x] > gray[y*9+x+1] {
+				hash |= 1 << (y*8 + x)
+			}
+		}
+	}
+	return hash
+}
+
+// PerceptualHash sets a bit for every one of the 8x8 lowest frequencies of a
+// 32x32 thumbnail's discrete cosine transform above their median
+func PerceptualHash(img image.Image) Hash {
+	const size, low = 32, 8
+	gray := grayThumbnail(img, size, size)
+
+	// cosines[k][n] is the DCT-II basis function k at sample n
+	var cosines [low][size]float64
+	for k := range cosines {
+		for n := range cosines[k] {
+			cosines[k][n] = math.Cos(math.Pi / size * (float64(n) + 0.5) * float64(k))
+		}
+	}
+
+	// Transform the rows, then the columns, keeping only the low frequencies
+	var rows [size][low]float64
+	for y := 0; y < size; y++ {
+		for k := 0; k < low; k++ {
+			for x := 0; x < size; x++ {
+				rows[y][k] += gray[y*size+x] * cosines[k][x]
+			}
+		}
+	}
+	coefficients := make([]float64, 0, low*low)
+	for ky := 0; ky < low; ky++ {
+		for kx := 0; kx < low; kx++ {
+			var sum float64
+			for y := 0; y < size; y++ {
+				sum += rows[y][kx] * cosines[ky][y]
+			}
+			coefficients = append(coefficients, sum)
+		}
+	}
+
+	// The first coefficient is the mean brightness and would skew the median
+	sorted := slices.Clone(coefficients[1:])
+	slices.Sort(sorted)
+	median := (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+
+	var hash Hash
+	for i, v := range coefficients {
+		if v > median {
+			hash |= 1 << i
+		}
+	}
+	return hash
+}
+
+// Cluster groups hashes that lie within maxDistance of each other, directly
+// or through other members of the group. Groups hold indexes into hashes in
+// ascending order and are ordered by their first index; an image without
+// near-duplicates forms a group of its own.
+func Cluster(hashes []Hash, maxDistance int) [][]int {
+	parent := make([]int, len(hashes))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for i := range hashes {
+		for j := i + 1; j < len(hashes); j++ {
+			if hashes[i].Distance(hashes[j]) > maxDistance {
+				continue
+			}
+			// Root every group at its lowest index
+			ri, rj := find(i), find(j)
+			parent[max(ri, rj)] = min(ri, rj)
+		}
+	}
+
+	var clusters [][]int
+	position := make(map[int]int)
+	for i := range hashes {
+		root := find(i)
+		p, ok := position[root]
+		if !ok {
+			p = len(clusters)
+			position[root] = p
+			clusters = append(clusters, nil)
+		}
+		clusters[p] = append(clusters[p], i)
+	}
+	return clusters
+}
+
+// Hash decodes image data and computes its perceptual hashes
+func (p *Processor) Hash(data []byte) (Hashes, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Hashes{}, errors.NewProcessingError("image", "hash_image", "failed to decode image", err)
+	}
+	return ComputeHashes(img), nil
+}
+
+// grayThumbnail shrinks img to width x height luminance values in row order,
+// averaging the source pixels each cell covers. Images smaller than the
+// thumbnail are stretched instead.
+func grayThumbnail(img image.Image, width, height int) []float64 {
+	src := copyRegion(img, img.Bounds())
+	srcWidth, srcHeight := src.Bounds().Dx(), src.Bounds().Dy()
+
+	gray := make([]float64, width*height)
+	if srcWidth == 0 || srcHeight == 0 {
+		return gray
+	}
+
+	for y := 0; y < height; y++ {
+		y0 := y * srcHeight / height
+		y1 := max((y+1)*srcHeight/height, y0+1)
+
+		for x := 0; x < width; x++ {
+			x0 := x * srcWidth / width
+			x1 := max((x+1)*srcWidth/width, x0+1)
+
+			var sum int
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					i := src.PixOffset(sx, sy)
+					sum += int(luminance(src.Pix[i], src.Pix[i+1], src.Pix[i+2]))
+				}
+			}
+			gray[y*width+x] = float64(sum) / float64((x1-x0)*(y1-y0))
+		}
+	}
+	return gray
+}
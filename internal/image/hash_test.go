@@ -0,0 +1,145 @@
+package image
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"slices"
+	"testing"
+
+	"golang.org/x/image/bmp"
+)
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b Hash
+		want int
+	}{
+		{0, 0, 0},
+		{0, 1, 1},
+		{0xff, 0x0f, 4},
+		{0, ^Hash(0), 64},
+	}
+
+	for _, tt := range tests {
+		if got := tt.a.Distance(tt.b); got != tt.want {
+			t.Errorf("%v.Distance(%v) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestParseHash(t *testing.T) {
+	for _, h := range []Hash{0, 1, 0x8000000000000000, ^Hash(0)} {
+		got, err := ParseHash(h.String())
+		if err != nil || got != h {
+			t.Errorf("ParseHash(%q) = %v, %v, want %v", h.String(), got, err, h)
+		}
+	}
+
+	if _, err := ParseHash("not a hash"); err == nil {
+		t.Error("ParseHash() accepted an invalid hash")
+	}
+}
+
+func TestCluster(t *testing.T) {
+	tests := []struct {
+		name        string
+		hashes      []Hash
+		maxDistance int
+		want        [][]int
+	}{
+		{"empty", nil, 10, nil},
+		{"all distinct", []Hash{0, 0xff, 0xff00}, 2, [][]int{{0}, {1}, {2}}},
+		{"pair", []Hash{0, 0xff00, 0x1}, 2, [][]int{{0, 2}, {1}}},
+		// 0 and 0xf are too far apart, but both are close to 0x3
+		{"chained", []Hash{0, 0xf, 0x3}, 2, [][]int{{0, 1, 2}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Cluster(tt.hashes, tt.maxDistance)
+			if !slices.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("Cluster() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHashMatchesAcrossFormats(t *testing.T) {
+	photo := gradient(64, 48)
+	brighter := gradient(64, 48)
+	for i := range brighter.Pix {
+		if i%4 != 3 {
+			brighter.Pix[i] = uint8(min(int(brighter.Pix[i])+20, 255))
+		}
+	}
+	flipped := gradient(64, 48)
+	for y := 0; y < 48; y++ {
+		for x := 0; x < 64; x++ {
+			flipped.Set(x, y, photo.At(63-x, y))
+		}
+	}
+
+	p := NewProcessor()
+	original, err := p.Hash(encodeImage(t, photo, png.Encode))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		data      []byte
+		duplicate bool
+	}{
+		{"same image as BMP", encodeImage(t, photo, bmp.Encode), true},
+		{"brighter", encodeImage(t, brighter, png.Encode), true},
+		{"mirrored", encodeImage(t, flipped, png.Encode), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hashes, err := p.Hash(tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			distance := original.Perceptual.Distance(hashes.Perceptual)
+			if got := distance <= DuplicateDistance; got != tt.duplicate {
+				t.Errorf("perceptual distance %d, duplicate = %v, want %v", distance, got, tt.duplicate)
+			}
+		})
+	}
+}
+
+func TestHashRejectsUndecodableData(t *testing.T) {
+	if _, err := NewProcessor().Hash([]byte("not an image")); err == nil {
+		t.Error("Hash() accepted data that is no image")
+	}
+}
+
+// gradient draws an image getting brighter to the right, with a dark block
+// in its top left corner
+func gradient(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := uint8(x * 255 / width)
+			if x < width/3 && y < height/3 {
+				v = 10
+			}
+			img.Set(x, y, color.RGBA{v, v, v, 255})
+		}
+	}
+	return img
+}
+
+// encodeImage encodes img with the given encoder
+func encodeImage(t *testing.T, img image.Image, encoder func(io.Writer, image.Image) error) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := encoder(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
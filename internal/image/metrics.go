@@ -0,0 +1,151 @@
+package image
+
+import (
+	"bytes"
+	"image"
+	"math"
+
+	"ai-devs3/pkg/errors"
+)
+
+// Thresholds beyond which Metrics.Correction asks for a repair or an
+// exposure change
+const (
+	noisyThreshold    = 0.03 // estimated noise deviation, ~8 of 255 levels
+	darkThreshold     = 0.30 // mean luminance of an underexposed photo
+	brightThreshold   = 0.70 // mean luminance of an overexposed photo
+	clippingThreshold = 0.20 // share of crushed or blown pixels
+)
+
+// clipLevel is how close to black or white a pixel must be to count as clipped
+const clipLevel = 5
+
+// Metrics are objective measures of the quality of a photo
+type Metrics struct {
+	MeanLuminance  float64 // from 0 (black) to 1 (white)
+	DarkClipping   float64 // share of pixels crushed to black
+	BrightClipping float64 // share of pixels blown to white
+	Noise          float64 // estimated standard deviation of noise, from 0 to 1
+}
+
+// Correction is the enhancement a photo needs most
+type Correction string
+
+// Corrections suggested by Metrics.Correction and applied by Enhance
+const (
+	CorrectionNone     Correction = "none"
+	CorrectionDenoise  Correction = "denoise"
+	CorrectionBrighten Correction = "brighten"
+	CorrectionDarken   Correction = "darken"
+)
+
+// Measure computes the quality metrics of img
+func Measure(img image.Image) Metrics {
+	src := copyRegion(img, img.Bounds())
+	width, height := src.Bounds().Dx(), src.Bounds().Dy()
+	if width == 0 || height == 0 {
+		return Metrics{}
+	}
+
+	luma := make([]uint8, 0, width*height)
+	forEachPixel(src, func(r, g, b uint8) { luma = append(luma, luminance(r, g, b)) })
+
+	var sum, dark, bright int
+	for _, v := range luma {
+		sum += int(v)
+		if v <= clipLevel {
+			dark++
+		} else if v >= 255-clipLevel {
+			bright++
+		}
+	}
+
+	pixels := float64(len(luma))
+	return Metrics{
+		MeanLuminance:  float64(sum) / pixels / 255,
+		DarkClipping:   float64(dark) / pixels,
+		BrightClipping: float64(bright) / pixels,
+		Noise:          estimateNoise(luma, width, height) / 255,
+	}
+}
+
+// Correction suggests the enhancement that fixes the worst defect: noise and
+// glitches first, as they also distort the exposure metrics, then exposure
+func (m Metrics) Correction() Correction {
+	switch {
+	case m.Noise > noisyThreshold:
+		return CorrectionDenoise
+	case m.MeanLuminance < darkThreshold || m.DarkClipping > clippingThreshold:
+		return CorrectionBrighten
+	case m.MeanLuminance > brightThreshold || m.BrightClipping > clippingThreshold:
+		return CorrectionDarken
+	default:
+		return CorrectionNone
+	}
+}
+
+// Enhance applies a correction to img
+func Enhance(img image.Image, correction Correction) *image.RGBA {
+	switch correction {
+	case CorrectionDenoise:
+		return Denoise(img, 1)
+	case CorrectionBrighten:
+		return AdjustGamma(img, 0.6)
+	case CorrectionDarken:
+		return AdjustGamma(img, 1.8)
+	default:
+		return copyRegion(img, img.Bounds())
+	}
+}
+
+// Measure decodes image data and computes its quality metrics
+func (p *Processor) Measure(data []byte) (Metrics, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Metrics{}, errors.NewProcessingError("image", "measure_image", "failed to decode image", err)
+	}
+	return Measure(img), nil
+}
+
+// Enhance applies a correction to image data and encodes the result in the
+// format of the source, JPEG staying JPEG and anything else becoming PNG
+func (p *Processor) Enhance(data []byte, correction Correction) ([]byte, error) {
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.NewProcessingError("image", "enhance_image", "failed to decode image", err)
+	}
+
+	target := FormatPNG
+	if format == string(FormatJPEG) {
+		target = FormatJPEG
+	}
+
+	encoded, err := encode(Enhance(img, correction), target, 0)
+	if err != nil {
+		return nil, errors.NewProcessingError("image", "enhance_image", "failed to encode image", err)
+	}
+	return encoded, nil
+}
+
+// estimateNoise estimates the standard deviation of additive noise in a
+// luminance plane with Immerkær's method: a Laplacian-difference kernel
+// cancels image structure, leaving mostly noise
+func estimateNoise(luma []uint8, width, height int) float64 {
+	if width < 3 || height < 3 {
+		return 0
+	}
+
+	at := func(x, y int) int { return int(luma[y*width+x]) }
+
+	var sum float64
+	for y := 1; y < height-1; y++ {
+		for x := 1; x < width-1; x++ {
+			v := at(x-1, y-1) - 2*at(x, y-1) + at(x+1, y-1) -
+				2*at(x-1, y) + 4*at(x, y) - 2*at(x+1, y) +
+				at(x-1, y+1) - 2*at(x, y+1) + at(x+1, y+1)
+			sum += math.Abs(float64(v))
+		}
+	}
+
+	return sum * math.Sqrt(math.Pi/2) / (6 * float64(width-2) * float64(height-2))
+}
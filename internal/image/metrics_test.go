@@ -0,0 +1,185 @@
+package image
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestCorrection(t *testing.T) {
+	// A well exposed, clean photo; each case changes one metric
+	good := Metrics{MeanLuminance: 0.5, Noise: 0.01}
+
+	tests := []struct {
+		name    string
+		metrics func(m Metrics) Metrics
+		want    Correction
+	}{
+		{"good photo", func(m Metrics) Metrics { return m }, CorrectionNone},
+		{"noise at threshold", func(m Metrics) Metrics { m.Noise = noisyThreshold; return m }, CorrectionNone},
+		{"noise above threshold", func(m Metrics) Metrics { m.Noise = noisyThreshold + 0.001; return m }, CorrectionDenoise},
+		{"noise wins over darkness", func(m Metrics) Metrics { m.Noise = 0.1; m.MeanLuminance = 0.1; return m }, CorrectionDenoise},
+		{"mean at dark threshold", func(m Metrics) Metrics { m.MeanLuminance = darkThreshold; return m }, CorrectionNone},
+		{"mean below dark threshold", func(m Metrics) Metrics { m.MeanLuminance = darkThreshold - 0.01; return m }, CorrectionBrighten},
+		{"crushed shadows", func(m Metrics) Metrics { m.DarkClipping = clippingThreshold + 0.01; return m }, CorrectionBrighten},
+		{"darkness wins over blown highlights", func(m Metrics) Metrics {
+			m.MeanLuminance = 0.2
+			m.BrightClipping = 0.5
+			return m
+		}, CorrectionBrighten},
+		{"mean at bright threshold", func(m Metrics) Metrics { m.MeanLuminance = brightThreshold; return m }, CorrectionNone},
+		{"mean above bright threshold", func(m Metrics) Metrics { m.MeanLuminance = brightThreshold + 0.01; return m }, CorrectionDarken},
+		{"blown highlights", func(m Metrics) Metrics { m.BrightClipping = clippingThreshold + 0.01; return m }, CorrectionDarken},
+		{"clipping at threshold", func(m Metrics) Metrics {
+			m.DarkClipping = clippingThreshold
+			m.BrightClipping = clippingThreshold
+			return m
+		}, CorrectionNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tt.metrics(good)
+			if got := m.Correction(); got != tt.want {
+				t.Errorf("%+v.Correction() = %s, want %s", m, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMeasure(t *testing.T) {
+	tests := []struct {
+		name           string
+		img            image.Image
+		wantMean       float64
+		wantDark       float64
+		wantBright     float64
+		wantCorrection Correction
+	}{
+		{"mid grey", uniform(16, 16, 128), 128.0 / 255, 0, 0, CorrectionNone},
+		{"black", uniform(16, 16, 0), 0, 1, 0, CorrectionBrighten},
+		{"white", uniform(16, 16, 255), 1, 0, 1, CorrectionDarken},
+		{"dark grey", uniform(16, 16, 51), 0.2, 0, 0, CorrectionBrighten},
+		{"light grey", uniform(16, 16, 204), 0.8, 0, 0, CorrectionDarken},
+		{"half black, half mid grey", halves(16, 16, 0, 160), 80.0 / 255, 0.5, 0, CorrectionBrighten},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := Measure(tt.img)
+			if math.Abs(m.MeanLuminance-tt.wantMean) > 1e-9 {
+				t.Errorf("MeanLuminance = %v, want %v", m.MeanLuminance, tt.wantMean)
+			}
+			if m.DarkClipping != tt.wantDark || m.BrightClipping != tt.wantBright {
+				t.Errorf("clipping = %v dark, %v bright, want %v, %v", m.DarkClipping, m.BrightClipping, tt.wantDark, tt.wantBright)
+			}
+			if got := m.Correction(); got != tt.wantCorrection {
+				t.Errorf("Correction() = %s, want %s", got, tt.wantCorrection)
+			}
+		})
+	}
+}
+
+func TestEstimateNoise(t *testing.T) {
+	const size = 64
+
+	tests := []struct {
+		name      string
+		img       *image.RGBA
+		wantNoise float64 // in levels of 255
+		tolerance float64
+	}{
+		{"flat", uniform(size, size, 128), 0, 0},
+		// The kernel cancels linear ramps, so smooth shading is no noise
+		{"smooth gradient", ramp(size, size), 0, 0},
+		{"light noise", withNoise(uniform(size, size, 128), 3), 3, 0.6},
+		{"heavy noise", withNoise(uniform(size, size, 128), 15), 15, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Measure(tt.img).Noise * 255
+			if math.Abs(got-tt.wantNoise) > tt.tolerance {
+				t.Errorf("noise = %.2f levels, want %.2f ± %.2f", got, tt.wantNoise, tt.tolerance)
+			}
+		})
+	}
+
+	// Noise is measured on a plane at least 3 pixels wide and high
+	if got := Measure(uniform(2, 2, 0)).Noise; got != 0 {
+		t.Errorf("noise of a 2x2 image = %v, want 0", got)
+	}
+}
+
+func TestEnhanceFixesWhatCorrectionFinds(t *testing.T) {
+	tests := []struct {
+		name string
+		img  *image.RGBA
+		want Correction
+	}{
+		{"noisy", withNoise(uniform(64, 64, 128), 20), CorrectionDenoise},
+		{"underexposed", uniform(32, 32, 40), CorrectionBrighten},
+		{"overexposed", uniform(32, 32, 215), CorrectionDarken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := Measure(tt.img)
+			if got := before.Correction(); got != tt.want {
+				t.Fatalf("Correction() = %s, want %s", got, tt.want)
+			}
+
+			after := Measure(Enhance(tt.img, tt.want))
+			switch tt.want {
+			case CorrectionDenoise:
+				if after.Noise >= before.Noise/2 {
+					t.Errorf("noise %v -> %v, want it at least halved", before.Noise, after.Noise)
+				}
+			case CorrectionBrighten:
+				if after.MeanLuminance <= before.MeanLuminance {
+					t.Errorf("mean %v -> %v, want it brighter", before.MeanLuminance, after.MeanLuminance)
+				}
+			case CorrectionDarken:
+				if after.MeanLuminance >= before.MeanLuminance {
+					t.Errorf("mean %v -> %v, want it darker", before.MeanLuminance, after.MeanLuminance)
+				}
+			}
+		})
+	}
+}
+
+// halves is an image whose left half has level left and right half level right
+func halves(width, height int, left, right uint8) *image.RGBA {
+	img := uniform(width, height, right)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width/2; x++ {
+			img.Set(x, y, color.RGBA{left, left, left, 255})
+		}
+	}
+	return img
+}
+
+// ramp is a grey image brightening linearly to the right and downwards
+func ramp(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := uint8(x + y)
+			img.Set(x, y, color.RGBA{v, v, v, 255})
+		}
+	}
+	return img
+}
+
+// withNoise adds Gaussian noise of the given deviation in levels to every
+// channel of img, from a fixed seed so the result is reproducible
+func withNoise(img *image.RGBA, deviation float64) *image.RGBA {
+	rng := rand.New(rand.NewPCG(1, 2))
+	for i := 0; i < len(img.Pix); i += 4 {
+		v := uint8(math.Round(math.Max(0, math.Min(255, float64(img.Pix[i])+rng.NormFloat64()*deviation))))
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2] = v, v, v
+	}
+	return img
+}
@@ -7,65 +7,118 @@ import (
 	"math"
 	"os"
 
+	_ "image/gif"  // Register GIF decoder
 	_ "image/jpeg" // Register JPEG decoder
 	_ "image/png"  // Register PNG decoder
 
-	"ai-devs3/internal/config"
 	"ai-devs3/pkg/errors"
+
+	_ "golang.org/x/image/bmp"  // Register BMP decoder
+	_ "golang.org/x/image/webp" // Register WebP decoder
 )
 
+// Format is the encoding of a processed image
+type Format string
+
+// Supported output formats
+const (
+	FormatPNG  Format = "png"
+	FormatJPEG Format = "jpeg"
+)
+
+// defaultJPEGQuality is used when Options.Quality is not set
+const defaultJPEGQuality = 85
+
 // Processor handles image processing operations
-type Processor struct {
-	config config.Config
+type Processor struct{}
+
+// Options control how an image is prepared for a vision request
+type Options struct {
+	MaxDimension int             // longest side of the result in pixels; 0 keeps the size
+	Crop         image.Rectangle // region of the source to keep; empty keeps the whole image
+	Format       Format          // output encoding; empty keeps JPEG as JPEG and sends anything else as PNG
+	Quality      int             // JPEG quality from 1 to 100; 0 uses 85
+	Detail       string          // vision detail the token estimate is for: "low" or "high" (default)
 }
 
 // ProcessingResult represents processed image metadata
 type ProcessingResult struct {
+	Data       []byte // encoded image as sent to the model
 	Base64Data string
+	MIMEType   string // image/png or image/jpeg
 	Width      int
 	Height     int
 	TokenCost  int
 }
 
 // NewProcessor creates a new image processor
-func NewProcessor(cfg config.Config) *Processor {
-	return &Processor{
-		config: cfg,
-	}
+func NewProcessor() *Processor {
+	return &Processor{}
 }
 
-// ProcessImage processes raw image data for AI vision analysis
+// ProcessImage processes raw image data for AI vision analysis, downscaling it
+// so that its longest side is at most maxDimension
 func (p *Processor) ProcessImage(path string, maxDimension int) (*ProcessingResult, error) {
 	imageBytes, err := os.ReadFile(path)
 	if err != nil {
 		return nil, errors.NewProcessingError("image", "process_image", "failed to read image file", err)
 	}
 
-	// Decode image to get dimensions
-	img, _, err := image.Decode(bytes.NewReader(imageBytes))
+	return p.Process(imageBytes, Options{MaxDimension: maxDimension})
+}
+
+// Process crops, downscales and re-encodes image data as described by opts.
+// The original bytes are kept when none of that changes the image.
+func (p *Processor) Process(data []byte, opts Options) (*ProcessingResult, error) {
+	img, format, err := image.Decode(bytes.NewReader(data))
 	if err != nil {
 		return nil, errors.NewProcessingError("image", "process_image", "failed to decode image", err)
 	}
 
-	bounds := img.Bounds()
-	originalWidth := bounds.Dx()
-	originalHeight := bounds.Dy()
+	return p.process(data, img, format, opts)
+}
 
-	// Calculate new dimensions while maintaining aspect ratio
-	newWidth, newHeight := p.calculateResizedDimensions(originalWidth, originalHeight, maxDimension)
+// process prepares a decoded image; original holds its encoded source
+func (p *Processor) process(original []byte, img image.Image, sourceFormat string, opts Options) (*ProcessingResult, error) {
+	region := img.Bounds()
+	if !opts.Crop.Empty() {
+		region = opts.Crop.Intersect(region)
+		if region.Empty() {
+			return nil, errors.NewProcessingError("image", "process_image", "crop region lies outside the image", nil)
+		}
+	}
+
+	width, height := region.Dx(), region.Dy()
+	if opts.MaxDimension > 0 {
+		width, height = p.calculateResizedDimensions(width, height, opts.MaxDimension)
+	}
 
-	// For now, we'll use the original image data and convert to base64
-	// In a production environment, you might want to add actual resizing logic
-	base64Data := base64.StdEncoding.EncodeToString(imageBytes)
+	format := opts.Format
+	if format == "" {
+		format = FormatPNG
+		if sourceFormat == string(FormatJPEG) {
+			format = FormatJPEG
+		}
+	}
 
-	// Calculate estimated token cost for OpenAI Vision
-	tokenCost := p.calculateImageTokens(newWidth, newHeight, "high")
+	encoded := original
+	unchanged := region == img.Bounds() && width == region.Dx() && height == region.Dy() &&
+		string(format) == sourceFormat && opts.Quality == 0
+	if !unchanged {
+		var err error
+		encoded, err = encode(resize(copyRegion(img, region), width, height), format, opts.Quality)
+		if err != nil {
+			return nil, errors.NewProcessingError("image", "process_image", "failed to encode image", err)
+		}
+	}
 
 	return &ProcessingResult{
-		Base64Data: base64Data,
-		Width:      newWidth,
-		Height:     newHeight,
-		TokenCost:  tokenCost,
+		Data:       encoded,
+		Base64Data: base64.StdEncoding.EncodeToString(encoded),
+		MIMEType:   "image/" + string(format),
+		Width:      width,
+		Height:     height,
+		TokenCost:  p.calculateImageTokens(width, height, opts.Detail),
 	}, nil
 }
 
@@ -79,16 +132,17 @@ func (p *Processor) calculateResizedDimensions(width, height, maxDimension int)
 
 	if width > height {
 		newWidth := maxDimension
-		newHeight := int(float64(maxDimension) / aspectRatio)
+		newHeight := max(int(float64(maxDimension)/aspectRatio), 1)
 		return newWidth, newHeight
 	} else {
 		newHeight := maxDimension
-		newWidth := int(float64(maxDimension) * aspectRatio)
+		newWidth := max(int(float64(maxDimension)*aspectRatio), 1)
 		return newWidth, newHeight
 	}
 }
 
-// calculateImageTokens estimates OpenAI Vision API token usage
+// calculateImageTokens estimates OpenAI Vision API token usage of an image
+// sent with the given width, height and detail
 func (p *Processor) calculateImageTokens(width, height int, detail string) int {
 	if detail == "low" {
 		return 85
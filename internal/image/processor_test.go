@@ -0,0 +1,60 @@
+package image
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"testing"
+
+	"golang.org/x/image/bmp"
+)
+
+// webpPixel is a 1x1 lossless WebP; x/image can decode WebP but not encode it
+const webpPixel = "UklGRhoAAABXRUJQVlA4TA0AAAAvAAAAEAcQERGIiP4HAA=="
+
+func TestProcessConvertsToPNGOrJPEG(t *testing.T) {
+	webp, err := base64.StdEncoding.DecodeString(webpPixel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := gradient(8, 8)
+	jpegData := encodeImage(t, img, func(w io.Writer, m image.Image) error { return jpeg.Encode(w, m, nil) })
+	gifData := encodeImage(t, img, func(w io.Writer, m image.Image) error { return gif.Encode(w, m, nil) })
+
+	tests := []struct {
+		name         string
+		data         []byte
+		wantMIMEType string
+		wantOriginal bool
+	}{
+		{"PNG kept", encodeImage(t, img, png.Encode), "image/png", true},
+		{"JPEG kept", jpegData, "image/jpeg", true},
+		{"GIF converted", gifData, "image/png", false},
+		{"BMP converted", encodeImage(t, img, bmp.Encode), "image/png", false},
+		{"WebP converted", webp, "image/png", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewProcessor().Process(tt.data, Options{})
+			if err != nil {
+				t.Fatalf("Process() error = %v", err)
+			}
+			if result.MIMEType != tt.wantMIMEType {
+				t.Errorf("MIMEType = %q, want %q", result.MIMEType, tt.wantMIMEType)
+			}
+			if original := bytes.Equal(result.Data, tt.data); original != tt.wantOriginal {
+				t.Errorf("kept original bytes = %v, want %v", original, tt.wantOriginal)
+			}
+			if tt.wantMIMEType == "image/png" {
+				if _, err := png.Decode(bytes.NewReader(result.Data)); err != nil {
+					t.Errorf("result is no PNG: %v", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package image
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+)
+
+// copyRegion copies region of img into a new RGBA image whose bounds start
+// at the origin
+func copyRegion(img image.Image, region image.Rectangle) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, region.Dx(), region.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, region.Min, draw.Src)
+	return dst
+}
+
+// resize scales src down to width x height by averaging the source pixels
+// each target pixel covers, which keeps thin lines and small print legible.
+// Images are never enlarged.
+func resize(src *image.RGBA, width, height int) *image.RGBA {
+	srcWidth, srcHeight := src.Bounds().Dx(), src.Bounds().Dy()
+	if width >= srcWidth && height >= srcHeight {
+		return src
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := y * srcHeight / height
+		y1 := max((y+1)*srcHeight/height, y0+1)
+
+		for x := 0; x < width; x++ {
+			x0 := x * srcWidth / width
+			x1 := max((x+1)*srcWidth/width, x0+1)
+
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				i := src.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					sum[0] += int(src.Pix[i])
+					sum[1] += int(src.Pix[i+1])
+					sum[2] += int(src.Pix[i+2])
+					sum[3] += int(src.Pix[i+3])
+					i += 4
+				}
+			}
+
+			n := (x1 - x0) * (y1 - y0)
+			j := dst.PixOffset(x, y)
+			for c := range sum {
+				dst.Pix[j+c] = uint8(sum[c] / n)
+			}
+		}
+	}
+
+	return dst
+}
+
+// encode writes img in format; quality applies to JPEG only
+func encode(img image.Image, format Format, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+
+	switch format {
+	case FormatPNG:
+		encoder := png.Encoder{CompressionLevel: png.BestCompression}
+		if err := encoder.Encode(&buf, img); err != nil {
+			return nil, err
+		}
+	case FormatJPEG:
+		if quality <= 0 {
+			quality = defaultJPEGQuality
+		}
+		// JPEG has no alpha channel; transparent areas become white rather than black
+		flat := image.NewRGBA(img.Bounds())
+		draw.Draw(flat, flat.Bounds(), image.White, image.Point{}, draw.Src)
+		draw.Draw(flat, flat.Bounds(), img, img.Bounds().Min, draw.Over)
+		if err := jpeg.Encode(&buf, flat, &jpeg.Options{Quality: min(quality, 100)}); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported image format %q", format)
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,126 @@
+package image
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func TestProcessKeepsAspectRatio(t *testing.T) {
+	tests := []struct {
+		name                  string
+		width, height         int
+		maxDimension          int
+		wantWidth, wantHeight int
+	}{
+		{"landscape", 1000, 500, 200, 200, 100},
+		{"portrait", 300, 900, 300, 100, 300},
+		{"square", 800, 800, 256, 256, 256},
+		{"already small enough", 120, 80, 200, 120, 80},
+		{"thin strip keeps a pixel", 2000, 3, 1000, 1000, 1},
+		{"no limit", 640, 480, 0, 640, 480},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := encodeImage(t, gradient(tt.width, tt.height), png.Encode)
+
+			result, err := NewProcessor().Process(data, Options{MaxDimension: tt.maxDimension})
+			if err != nil {
+				t.Fatalf("Process() error = %v", err)
+			}
+			if result.Width != tt.wantWidth || result.Height != tt.wantHeight {
+				t.Errorf("Process() = %dx%d, want %dx%d", result.Width, result.Height, tt.wantWidth, tt.wantHeight)
+			}
+
+			img, err := png.Decode(bytes.NewReader(result.Data))
+			if err != nil {
+				t.Fatalf("result is no PNG: %v", err)
+			}
+			if img.Bounds().Dx() != tt.wantWidth || img.Bounds().Dy() != tt.wantHeight {
+				t.Errorf("encoded image is %v, want %dx%d", img.Bounds(), tt.wantWidth, tt.wantHeight)
+			}
+		})
+	}
+}
+
+func TestResizeAveragesPixels(t *testing.T) {
+	// Alternating black and white columns average to mid grey
+	src := image.NewRGBA(image.Rect(0, 0, 8, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 8; x++ {
+			v := uint8(255 * (x % 2))
+			src.Set(x, y, color.RGBA{v, v, v, 255})
+		}
+	}
+
+	dst := resize(src, 4, 2)
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 4; x++ {
+			if got := dst.RGBAAt(x, y); got != (color.RGBA{127, 127, 127, 255}) {
+				t.Fatalf("pixel (%d, %d) = %v, want mid grey", x, y, got)
+			}
+		}
+	}
+
+	if got := resize(src, 16, 8); got != src {
+		t.Error("resize() enlarged the image, want the source back")
+	}
+}
+
+func TestEncodeJPEGFlattensOntoWhite(t *testing.T) {
+	// Left half transparent, right half opaque black
+	src := image.NewNRGBA(image.Rect(0, 0, 16, 16))
+	for y := 0; y < 16; y++ {
+		for x := 8; x < 16; x++ {
+			src.Set(x, y, color.NRGBA{0, 0, 0, 255})
+		}
+	}
+	data := encodeImage(t, src, png.Encode)
+
+	result, err := NewProcessor().Process(data, Options{Format: FormatJPEG})
+	if err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if result.MIMEType != "image/jpeg" {
+		t.Fatalf("MIMEType = %q, want image/jpeg", result.MIMEType)
+	}
+
+	img, err := jpeg.Decode(bytes.NewReader(result.Data))
+	if err != nil {
+		t.Fatalf("result is no JPEG: %v", err)
+	}
+	for _, tt := range []struct {
+		x      int
+		want   uint8
+		reason string
+	}{
+		{2, 255, "transparent area"},
+		{13, 0, "opaque black area"},
+	} {
+		if got := grey(img.At(tt.x, 8)); absDiff(got, tt.want) > 8 {
+			t.Errorf("%s at x=%d = %d, want about %d", tt.reason, tt.x, got, tt.want)
+		}
+	}
+}
+
+// grey returns the 8-bit luminance of c
+func grey(c color.Color) uint8 {
+	return color.GrayModel.Convert(c).(color.Gray).Y
+}
+
+// absDiff returns |a - b|
+func absDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+// sameColor reports whether a and b are the same colour
+func sameColor(a, b color.Color) bool {
+	return color.RGBAModel.Convert(a) == color.RGBAModel.Convert(b)
+}
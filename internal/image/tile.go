@@ -0,0 +1,83 @@
+package image
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+
+	"ai-devs3/pkg/errors"
+)
+
+// Tiling for reading small print with a high-detail vision model, which views
+// an image at most 768 pixels on its short side: tiles of TextTileSize are
+// read at no less than half their resolution, and the overlap keeps a line of
+// text whole in at least one of two neighbouring tiles
+const (
+	TextTileSize    = 1536
+	TextTileOverlap = 128
+)
+
+// Tile is one part of an image split with Tile
+type Tile struct {
+	Bounds image.Rectangle // region of the source image covered by the tile
+	*ProcessingResult
+}
+
+// Tile splits a large image into tiles of at most size x size source pixels
+// that overlap by overlap pixels, so that text crossing a seam is whole in at
+// least one tile. Every tile is processed with opts, except for opts.Crop,
+// which restricts tiling to a region of the image. Sending the tiles with high
+// detail lets a vision model read print that would be lost when the whole
+// image is downscaled.
+func (p *Processor) Tile(data []byte, size, overlap int, opts Options) ([]Tile, error) {
+	if size <= 0 || overlap < 0 || overlap >= size {
+		return nil, errors.NewProcessingError("image", "tile_image",
+			fmt.Sprintf("invalid tile size %d with overlap %d", size, overlap), nil)
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.NewProcessingError("image", "tile_image", "failed to decode image", err)
+	}
+
+	region := img.Bounds()
+	if !opts.Crop.Empty() {
+		region = opts.Crop.Intersect(region)
+		if region.Empty() {
+			return nil, errors.NewProcessingError("image", "tile_image", "crop region lies outside the image", nil)
+		}
+	}
+
+	var tiles []Tile
+	for _, y := range tileStarts(region.Dy(), size, overlap) {
+		for _, x := range tileStarts(region.Dx(), size, overlap) {
+			bounds := image.Rect(x, y, x+size, y+size).Add(region.Min).Intersect(region)
+
+			tileOpts := opts
+			tileOpts.Crop = bounds
+			result, err := p.process(data, img, format, tileOpts)
+			if err != nil {
+				return nil, err
+			}
+			tiles = append(tiles, Tile{Bounds: bounds, ProcessingResult: result})
+		}
+	}
+
+	return tiles, nil
+}
+
+// tileStarts returns the offsets of the tiles along a side of length pixels,
+// spread evenly so that neighbours overlap by at least overlap pixels and the
+// last tile ends at the edge
+func tileStarts(length, size, overlap int) []int {
+	if length <= size {
+		return []int{0}
+	}
+
+	n := (length - overlap + size - overlap - 1) / (size - overlap)
+	starts := make([]int, n)
+	for i := range starts {
+		starts[i] = i * (length - size) / (n - 1)
+	}
+	return starts
+}
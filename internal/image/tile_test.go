@@ -0,0 +1,136 @@
+package image
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"reflect"
+	"testing"
+)
+
+func TestTileStarts(t *testing.T) {
+	tests := []struct {
+		name                  string
+		length, size, overlap int
+		want                  []int
+	}{
+		{"fits in one tile", 500, 500, 50, []int{0}},
+		{"shorter than a tile", 100, 500, 50, []int{0}},
+		{"just over one tile", 501, 500, 50, []int{0, 1}},
+		{"two tiles with exact overlap", 950, 500, 50, []int{0, 450}},
+		{"three tiles spread evenly", 1000, 400, 50, []int{0, 300, 600}},
+		{"no overlap", 1200, 400, 0, []int{0, 400, 800}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tileStarts(tt.length, tt.size, tt.overlap)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("tileStarts(%d, %d, %d) = %v, want %v", tt.length, tt.size, tt.overlap, got, tt.want)
+			}
+		})
+	}
+
+	// Whatever the sizes, tiles cover the side and overlap by at least overlap
+	for length := 1; length <= 3000; length += 37 {
+		starts := tileStarts(length, 512, 64)
+		if starts[0] != 0 || starts[len(starts)-1]+min(512, length) != length {
+			t.Fatalf("tiles of a side of %d start at %v, want them to cover it", length, starts)
+		}
+		for i := 1; i < len(starts); i++ {
+			if overlap := starts[i-1] + 512 - starts[i]; overlap < 64 {
+				t.Fatalf("tiles of a side of %d start at %v, tiles %d and %d overlap by %d", length, starts, i-1, i, overlap)
+			}
+		}
+	}
+}
+
+func TestTile(t *testing.T) {
+	src := gradient(1000, 600)
+	data := encodeImage(t, src, png.Encode)
+
+	tiles, err := NewProcessor().Tile(data, 400, 50, Options{})
+	if err != nil {
+		t.Fatalf("Tile() error = %v", err)
+	}
+
+	// Three columns by two rows, row by row from the top left
+	want := []image.Rectangle{
+		image.Rect(0, 0, 400, 400), image.Rect(300, 0, 700, 400), image.Rect(600, 0, 1000, 400),
+		image.Rect(0, 200, 400, 600), image.Rect(300, 200, 700, 600), image.Rect(600, 200, 1000, 600),
+	}
+	if len(tiles) != len(want) {
+		t.Fatalf("Tile() returned %d tiles, want %d", len(tiles), len(want))
+	}
+	for i, tile := range tiles {
+		if tile.Bounds != want[i] {
+			t.Errorf("tile %d bounds = %v, want %v", i, tile.Bounds, want[i])
+		}
+
+		// Each tile holds the pixels of its region of the source
+		img, err := png.Decode(bytes.NewReader(tile.Data))
+		if err != nil {
+			t.Fatalf("tile %d is no PNG: %v", i, err)
+		}
+		if img.Bounds().Dx() != tile.Bounds.Dx() || img.Bounds().Dy() != tile.Bounds.Dy() {
+			t.Errorf("tile %d is %v, want the size of %v", i, img.Bounds(), tile.Bounds)
+		}
+		for _, p := range []image.Point{{0, 0}, {399, 399}, {150, 250}} {
+			got := img.At(p.X, p.Y)
+			if wantColor := src.At(tile.Bounds.Min.X+p.X, tile.Bounds.Min.Y+p.Y); !sameColor(got, wantColor) {
+				t.Errorf("tile %d pixel %v = %v, want %v", i, p, got, wantColor)
+			}
+		}
+	}
+}
+
+func TestTileSmallImageIsOneTile(t *testing.T) {
+	data := encodeImage(t, gradient(300, 200), png.Encode)
+
+	tiles, err := NewProcessor().Tile(data, TextTileSize, TextTileOverlap, Options{})
+	if err != nil {
+		t.Fatalf("Tile() error = %v", err)
+	}
+	if len(tiles) != 1 || tiles[0].Bounds != image.Rect(0, 0, 300, 200) {
+		t.Fatalf("Tile() = %d tiles, want one covering the image", len(tiles))
+	}
+	if !bytes.Equal(tiles[0].Data, data) {
+		t.Error("the single tile re-encoded the image, want the original bytes")
+	}
+}
+
+func TestTileOptions(t *testing.T) {
+	data := encodeImage(t, gradient(1000, 600), png.Encode)
+
+	tests := []struct {
+		name      string
+		size      int
+		overlap   int
+		opts      Options
+		wantTiles int
+		wantErr   bool
+	}{
+		{"crop limits tiling to a region", 400, 50, Options{Crop: image.Rect(0, 0, 400, 300)}, 1, false},
+		{"max dimension shrinks every tile", 500, 0, Options{MaxDimension: 100}, 4, false},
+		{"overlap as large as the tile", 400, 400, Options{}, 0, true},
+		{"zero size", 0, 0, Options{}, 0, true},
+		{"crop outside the image", 400, 50, Options{Crop: image.Rect(2000, 2000, 2100, 2100)}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tiles, err := NewProcessor().Tile(data, tt.size, tt.overlap, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Tile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(tiles) != tt.wantTiles {
+				t.Fatalf("Tile() returned %d tiles, want %d", len(tiles), tt.wantTiles)
+			}
+			for i, tile := range tiles {
+				if tt.opts.MaxDimension > 0 && max(tile.Width, tile.Height) > tt.opts.MaxDimension {
+					t.Errorf("tile %d is %dx%d, want at most %d", i, tile.Width, tile.Height, tt.opts.MaxDimension)
+				}
+			}
+		})
+	}
+}
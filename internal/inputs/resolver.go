@@ -0,0 +1,87 @@
+// Package inputs resolves where a task reads its inputs from: the Centrala
+// base URL, remote datasets, the local data directory and course materials.
+package inputs
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"ai-devs3/internal/config"
+	"ai-devs3/pkg/errors"
+)
+
+// Resolver resolves input locations for a single task. Every named input can
+// be redirected with config.InputsConfig.Overrides (the --input flag), which
+// is how tasks are pointed at a mock server or a different checkout.
+type Resolver struct {
+	task       string
+	baseURL    string
+	dataDir    string
+	lessonsDir string
+	overrides  map[string]string
+}
+
+// NewResolver creates a resolver for task using the given configuration
+func NewResolver(cfg *config.Config, task string) *Resolver {
+	return &Resolver{
+		task:       task,
+		baseURL:    strings.TrimRight(cfg.AIDevs.BaseURL, "/"),
+		dataDir:    cfg.Cache.BaseDir,
+		lessonsDir: cfg.Inputs.LessonsDir,
+		overrides:  cfg.Inputs.Overrides,
+	}
+}
+
+// BaseURL returns the Centrala base URL without a trailing slash
+func (r *Resolver) BaseURL() string {
+	return r.baseURL
+}
+
+// URL joins path onto the Centrala base URL
+func (r *Resolver) URL(path string) string {
+	return r.baseURL + "/" + strings.TrimLeft(path, "/")
+}
+
+// RemoteURL returns the override for name, or defaultPath on the Centrala base URL
+func (r *Resolver) RemoteURL(name, defaultPath string) string {
+	if override, ok := r.overrides[name]; ok {
+		return override
+	}
+	return r.URL(defaultPath)
+}
+
+// ExternalURL returns the override for name, or defaultURL for inputs hosted
+// outside Centrala
+func (r *Resolver) ExternalURL(name, defaultURL string) string {
+	if override, ok := r.overrides[name]; ok {
+		return override
+	}
+	return defaultURL
+}
+
+// DataDir returns the task's local data directory (<CACHE_DIR>/<task>)
+func (r *Resolver) DataDir() string {
+	return filepath.Join(r.dataDir, r.task)
+}
+
+// DataFile returns the override for name, or name inside the task data directory
+func (r *Resolver) DataFile(name string) string {
+	if override, ok := r.overrides[name]; ok {
+		return override
+	}
+	return filepath.Join(r.DataDir(), name)
+}
+
+// LessonsPath returns the override for name, or elem joined onto the course
+// materials directory, which must be set with LESSONS_DIR or --lessons-dir
+func (r *Resolver) LessonsPath(name string, elem ...string) (string, error) {
+	if override, ok := r.overrides[name]; ok {
+		return override, nil
+	}
+	if r.lessonsDir == "" {
+		return "", errors.NewConfigError("LESSONS_DIR",
+			fmt.Sprintf("course materials directory is not set; set LESSONS_DIR, pass --lessons-dir or --input %s=<path>", name), nil)
+	}
+	return filepath.Join(append([]string{r.lessonsDir}, elem...)...), nil
+}
@@ -0,0 +1,222 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"ai-devs3/internal/config"
+	"ai-devs3/internal/llm/ollama"
+	"ai-devs3/internal/llm/openai"
+	"ai-devs3/internal/llm/schema"
+	"ai-devs3/pkg/errors"
+)
+
+// Supported provider names for config.LLMConfig.Provider
+const (
+	ProviderOpenAI = "openai"
+	ProviderOllama = "ollama"
+)
+
+// ChatModel is the capability every provider implements: a single-turn chat
+// with a system and a user prompt returning the raw assistant content
+type ChatModel interface {
+	Chat(ctx context.Context, systemPrompt, userPrompt string) (string, error)
+}
+
+// JSONChatModel is implemented by providers that can constrain output to valid JSON
+type JSONChatModel interface {
+	ChatModel
+	ChatJSON(ctx context.Context, systemPrompt, userPrompt string) (string, error)
+}
+
+// StructuredChatModel is implemented by providers that can constrain output
+// to a JSON Schema. ChatSchema answers with JSON matching the schema derived
+// from out's type, re-asking through schema.Ask until it validates, and
+// decodes it into out.
+type StructuredChatModel interface {
+	ChatModel
+	ChatSchema(ctx context.Context, systemPrompt, userPrompt string, out any) error
+}
+
+// VisionModel is implemented by providers that accept image input
+type VisionModel interface {
+	ChatModel
+	Vision(ctx context.Context, systemPrompt, userPrompt string, images [][]byte) (string, error)
+}
+
+// StructuredVisionModel is implemented by providers that can constrain the
+// answer about images to a JSON Schema, as StructuredChatModel does for text
+type StructuredVisionModel interface {
+	VisionModel
+	VisionSchema(ctx context.Context, systemPrompt, userPrompt string, images [][]byte, out any) error
+}
+
+// EmbeddingModel is implemented by providers that can generate text embeddings
+type EmbeddingModel interface {
+	Embed(ctx context.Context, text string) ([]float64, error)
+}
+
+// TranscriptionModel is implemented by providers that can transcribe audio
+type TranscriptionModel interface {
+	Transcribe(ctx context.Context, audio io.Reader, filename string) (string, error)
+}
+
+// ImageGenerationModel is implemented by providers that can draw an image
+// from a prompt; GenerateImage returns the URL of the image
+type ImageGenerationModel interface {
+	GenerateImage(ctx context.Context, prompt string) (string, error)
+}
+
+// Compile-time checks that both clients satisfy the interfaces they claim
+var (
+	_ JSONChatModel         = (*openai.Client)(nil)
+	_ StructuredChatModel   = (*openai.Client)(nil)
+	_ StructuredVisionModel = (*openai.Client)(nil)
+	_ EmbeddingModel        = (*openai.Client)(nil)
+	_ TranscriptionModel    = (*openai.Client)(nil)
+	_ ImageGenerationModel  = (*openai.Client)(nil)
+	_ JSONChatModel         = (*ollama.Client)(nil)
+	_ StructuredChatModel   = (*ollama.Client)(nil)
+	_ StructuredVisionModel = (*ollama.Client)(nil)
+	_ EmbeddingModel        = (*ollama.Client)(nil)
+)
+
+// New creates the ChatModel selected by cfg.LLM.Provider
+func New(cfg *config.Config) (ChatModel, error) {
+	switch strings.ToLower(cfg.LLM.Provider) {
+	case "", ProviderOpenAI:
+		return openai.NewClient(cfg.OpenAI), nil
+	case ProviderOllama:
+		return ollama.NewClient(cfg.Ollama), nil
+	default:
+		return nil, errors.NewConfigError("LLM_PROVIDER",
+			fmt.Sprintf("unsupported provider %q (expected %q or %q)", cfg.LLM.Provider, ProviderOpenAI, ProviderOllama), nil)
+	}
+}
+
+// As returns model as capability T, e.g. VisionModel, or a config error when
+// the configured provider does not offer it
+func As[T any](model ChatModel) (T, error) {
+	capable, ok := model.(T)
+	if !ok {
+		return capable, errors.NewConfigError("LLM_PROVIDER",
+			fmt.Sprintf("%T does not support %s", model, reflect.TypeFor[T]()), nil)
+	}
+	return capable, nil
+}
+
+// Tuning adjusts a model for one job; zero fields keep the configured values.
+// Model names are provider specific, so OpenAIModel only applies to OpenAI,
+// while a local provider keeps serving the model it was configured with.
+type Tuning struct {
+	OpenAIModel string // e.g. gpt-4.1 for demanding vision, or a fine-tune
+	Temperature float64
+	TopP        float64
+	MaxTokens   int
+	HighDetail  bool // let vision models read images at full resolution
+}
+
+// Tune returns a copy of model whose calls use t
+func Tune[M ChatModel](model M, t Tuning) M {
+	switch m := any(model).(type) {
+	case *openai.Client:
+		settings := openai.Settings{
+			Model:       t.OpenAIModel,
+			Temperature: t.Temperature,
+			TopP:        t.TopP,
+			MaxTokens:   t.MaxTokens,
+		}
+		if t.HighDetail {
+			settings.Detail = openai.DetailHigh
+		}
+		return any(m.WithSettings(settings)).(M)
+	case *ollama.Client:
+		options := make(map[string]any)
+		if t.Temperature > 0 {
+			options["temperature"] = t.Temperature
+		}
+		if t.TopP > 0 {
+			options["top_p"] = t.TopP
+		}
+		if t.MaxTokens > 0 {
+			options["num_predict"] = t.MaxTokens
+		}
+		return any(m.WithOptions(options)).(M)
+	default:
+		return model
+	}
+}
+
+// ChatJSON asks for a JSON answer, falling back to plain chat when the model
+// has no dedicated JSON mode
+func ChatJSON(ctx context.Context, model ChatModel, systemPrompt, userPrompt string) (string, error) {
+	if jm, ok := model.(JSONChatModel); ok {
+		return jm.ChatJSON(ctx, systemPrompt, userPrompt)
+	}
+	return model.Chat(ctx, systemPrompt, userPrompt)
+}
+
+// ChatStructured asks for an answer matching the JSON Schema derived from T and
+// returns it decoded. Providers without schema support get the schema in the
+// system prompt and JSON mode; as their chat has a single turn, an answer
+// that fails validation is re-asked with the error appended to the user prompt.
+func ChatStructured[T any](ctx context.Context, model ChatModel, systemPrompt, userPrompt string) (*T, error) {
+	var result T
+
+	if sm, ok := model.(StructuredChatModel); ok {
+		if err := sm.ChatSchema(ctx, systemPrompt, userPrompt, &result); err != nil {
+			return nil, err
+		}
+		return &result, nil
+	}
+
+	err := askInPrompt(&result, systemPrompt, userPrompt, func(systemPrompt, userPrompt string) (string, error) {
+		return ChatJSON(ctx, model, systemPrompt, userPrompt)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// VisionStructured is ChatStructured for a prompt about images
+func VisionStructured[T any](ctx context.Context, model VisionModel, systemPrompt, userPrompt string, images [][]byte) (*T, error) {
+	var result T
+
+	if sm, ok := model.(StructuredVisionModel); ok {
+		if err := sm.VisionSchema(ctx, systemPrompt, userPrompt, images, &result); err != nil {
+			return nil, err
+		}
+		return &result, nil
+	}
+
+	err := askInPrompt(&result, systemPrompt, userPrompt, func(systemPrompt, userPrompt string) (string, error) {
+		return model.Vision(ctx, systemPrompt, userPrompt, images)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// askInPrompt runs schema.Ask for out through a single-turn send, putting the
+// schema in the system prompt and the last rejected answer with its
+// correction in the user prompt
+func askInPrompt[T any](out *T, systemPrompt, userPrompt string, send func(systemPrompt, userPrompt string) (string, error)) error {
+	return schema.Ask(out, func(s schema.Schema, retries []schema.Retry) (string, error) {
+		definition, err := json.Marshal(s)
+		if err != nil {
+			return "", errors.NewProcessingError("schema", schema.Name(reflect.TypeFor[T]()), "cannot encode JSON Schema", err)
+		}
+		prompt := userPrompt
+		if len(retries) > 0 {
+			last := retries[len(retries)-1]
+			prompt = fmt.Sprintf("%s\n\nYour previous answer was:\n%s\n\n%s", userPrompt, last.Answer, last.Correction)
+		}
+		return send(systemPrompt+"\n\nRespond with a JSON object matching this JSON Schema:\n"+string(definition), prompt)
+	})
+}
@@ -1,28 +1,41 @@
 package ollama
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
+	"maps"
 	"net/http"
+	"slices"
 	"time"
 
 	"ai-devs3/internal/config"
+	"ai-devs3/internal/llm/schema"
+	"ai-devs3/internal/tracing"
 	"ai-devs3/pkg/errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Client wraps Ollama client with configuration and error handling
 type Client struct {
-	client  *http.Client
-	baseURL string
-	config  config.OllamaConfig
+	client       *http.Client
+	streamClient *http.Client // no overall timeout, as a stream lasts as long as the generation
+	baseURL      string
+	config       config.OllamaConfig
+	options      map[string]any // model options sent with every chat, e.g. top_p
 }
 
 // ChatMessage represents a single message in the chat
 type ChatMessage struct {
-	Role    string `json:"role"` // "system", "user", or "assistant"
-	Content string `json:"content"`
+	Role    string   `json:"role"` // "system", "user", or "assistant"
+	Content string   `json:"content"`
+	Images  []string `json:"images,omitempty"` // base64-encoded images for multimodal models
 }
 
 // ChatRequest represents the chat request structure for Ollama API
@@ -30,105 +43,271 @@ type ChatRequest struct {
 	Model    string         `json:"model"`
 	Messages []ChatMessage  `json:"messages"`
 	Stream   bool           `json:"stream"`
+	Format   any            `json:"format,omitempty"` // "json" or a JSON Schema object
 	Options  map[string]any `json:"options"`
 }
 
 // ChatResponse represents the response structure from Ollama chat API
 type ChatResponse struct {
-	Message ChatMessage `json:"message"`
-	Done    bool        `json:"done"`
+	Message         ChatMessage `json:"message"`
+	Done            bool        `json:"done"`
+	PromptEvalCount int         `json:"prompt_eval_count,omitempty"`
+	EvalCount       int         `json:"eval_count,omitempty"`
+}
+
+// EmbedRequest represents the request structure for Ollama embed API
+type EmbedRequest struct {
+	Model string `json:"model"`
+	Input string `json:"input"`
+}
+
+// EmbedResponse represents the response structure from Ollama embed API
+type EmbedResponse struct {
+	Model      string      `json:"model"`
+	Embeddings [][]float64 `json:"embeddings"`
 }
 
 // NewClient creates a new Ollama client with the given configuration
 func NewClient(cfg config.OllamaConfig) *Client {
 	return &Client{
 		client: &http.Client{
-			Timeout: 60 * time.Second,
+			Timeout:   60 * time.Second,
+			Transport: cfg.Transport,
+		},
+		streamClient: &http.Client{
+			Transport: cfg.Transport,
 		},
 		baseURL: cfg.BaseURL,
 		config:  cfg,
 	}
 }
 
-// CensorText sends text to Ollama for censoring according to specific rules
-func (c *Client) CensorText(ctx context.Context, text string) (string, error) {
-	systemPrompt := `You are a text replacement tool. Your ONLY task is to find and replace specific phrases with "CENZURA" while keeping ALL other text EXACTLY unchanged.
+// WithOptions returns a copy of the client that sends options with every
+// chat, so callers going through llm.ChatModel can still tune sampling
+func (c *Client) WithOptions(options map[string]any) *Client {
+	clone := *c
+	clone.options = maps.Clone(options)
+	return &clone
+}
 
-	REPLACEMENT RULES:
-		1. Name + surname together → "CENZURA" (e.g., "Jan Nowak" → "CENZURA")
-		2. Age numbers → "CENZURA" (e.g., "32" → "CENZURA")
-		3. City names → "CENZURA" (e.g., "Katowice" → "CENZURA")
-		4. Street addresses: Keep "ul." or "ulicy" or "przy ul." but replace everything after with "CENZURA"
-		   - "ul. Różanej 12" → "ul. CENZURA"
-		   - "przy ul. Różanej 12" → "przy ul. CENZURA"
-		   - "ulicy Pięknej 5" → "ulicy CENZURA"
+// Chat sends a system and user prompt to the configured model and returns the raw content
+func (c *Client) Chat(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	return c.ChatMessages(ctx, c.promptMessages(systemPrompt, userPrompt, nil), nil, nil)
+}
 
-	CRITICAL REQUIREMENTS:
-		- Do NOT change, rephrase, or improve ANY other text
-		- Do NOT remove prepositions like "przy" or "ul."
-		- Keep EXACT original wording except for the specified replacements
-		- Maintain ALL punctuation, spacing, and capitalization
+// ChatJSON is like Chat but sets Ollama's format field so the model emits valid JSON
+func (c *Client) ChatJSON(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	return c.ChatMessages(ctx, c.promptMessages(systemPrompt, userPrompt, nil), "json", nil)
+}
 
-	Examples:
-		Input: "Podejrzany: Jan Kowalski. Mieszka w Warszawie przy ul. Długiej 5. Ma 25 lat."
-		Output: "Podejrzany: CENZURA. Mieszka w CENZURA przy ul. CENZURA. Ma CENZURA lat."
+// ChatSchema passes the JSON Schema derived from out's type as Ollama's
+// format and decodes the answer into out. An answer that fails validation is
+// sent back together with the error; see llm.ChatStructured.
+func (c *Client) ChatSchema(ctx context.Context, systemPrompt, userPrompt string, out any) error {
+	return c.askSchema(ctx, c.promptMessages(systemPrompt, userPrompt, nil), out)
+}
 
-		Input: "Adam Nowak zamieszkały w Krakowie, ulicy Królewskiej 10, wiek 45 lat."
-		Output: "CENZURA zamieszkały w CENZURA, ulicy CENZURA, wiek CENZURA lat."
+// Vision sends a prompt together with images to a multimodal model (e.g. llava, llama3.2-vision)
+func (c *Client) Vision(ctx context.Context, systemPrompt, userPrompt string, images [][]byte) (string, error) {
+	return c.ChatMessages(ctx, c.promptMessages(systemPrompt, userPrompt, images), nil, nil)
+}
 
-		Input: Dane podejrzanego: Jakub Woźniak. Adres: Rzeszów, ul. Miła 4. Wiek: 33 lata.
-		Input: Dane podejrzanego: CENZURA. Adres: Cenzura, ul. CENZURA. Wiek: CENZURA lat.
+// VisionSchema is like Vision but answers with JSON matching the schema
+// derived from out's type and decodes it into out; see llm.VisionStructured
+func (c *Client) VisionSchema(ctx context.Context, systemPrompt, userPrompt string, images [][]byte, out any) error {
+	return c.askSchema(ctx, c.promptMessages(systemPrompt, userPrompt, images), out)
+}
 
-		Now perform ONLY the specified replacements on the following text:`
+// askSchema sends prompt with out's JSON Schema as the format until the
+// answer validates, appending every rejected answer and its correction
+func (c *Client) askSchema(ctx context.Context, prompt []ChatMessage, out any) error {
+	return schema.Ask(out, func(s schema.Schema, retries []schema.Retry) (string, error) {
+		messages := slices.Clone(prompt)
+		for _, retry := range retries {
+			messages = append(messages,
+				ChatMessage{Role: "assistant", Content: retry.Answer},
+				ChatMessage{Role: "user", Content: retry.Correction},
+			)
+		}
+		return c.ChatMessages(ctx, messages, s, nil)
+	})
+}
 
-	userPrompt := fmt.Sprintf("%s", text)
+// ChatMessages performs a non-streaming chat with full control over messages, format and options.
+// Options are merged over the configured temperature and the client options.
+func (c *Client) ChatMessages(ctx context.Context, messages []ChatMessage, format any, options map[string]any) (_ string, err error) {
+	request := c.buildChatRequest(messages, format, options, false)
 
-	request := ChatRequest{
-		Model: c.config.Model,
-		Messages: []ChatMessage{
-			{
-				Role:    "system",
-				Content: systemPrompt,
-			},
-			{
-				Role:    "user",
-				Content: userPrompt,
-			},
-		},
-		Stream: false,
-		Options: map[string]any{
-			"temperature": c.config.Temperature, //0.0
-			"top_p":       0.1,
-			"num_predict": 200,
-		},
+	ctx, span := startSpan(ctx, "chat", request.Model)
+	defer func() { tracing.End(span, err) }()
+
+	resp, err := c.postJSON(ctx, c.client, "/api/chat", request)
+	if err != nil {
+		return "", err
 	}
+	defer resp.Body.Close()
 
-	jsonData, err := json.Marshal(request)
+	var response ChatResponse
+	err = json.NewDecoder(resp.Body).Decode(&response)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return "", fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/chat", bytes.NewBuffer(jsonData))
+	setTokens(span, response)
+	return response.Message.Content, nil
+}
+
+// ChatStream performs a streaming chat, calling onChunk for every partial content
+// as it arrives, and returns the complete content once the model is done. The
+// stream has no overall timeout; bound it with a ctx deadline.
+func (c *Client) ChatStream(ctx context.Context, messages []ChatMessage, options map[string]any, onChunk func(string)) (_ string, err error) {
+	request := c.buildChatRequest(messages, nil, options, true)
+
+	ctx, span := startSpan(ctx, "chat", request.Model)
+	defer func() { tracing.End(span, err) }()
+
+	resp, err := c.postJSON(ctx, c.streamClient, "/api/chat", request)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return "", err
 	}
-	req.Header.Set("Content-Type", "application/json")
+	defer resp.Body.Close()
+
+	var content bytes.Buffer
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		var chunk ChatResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return content.String(), fmt.Errorf("failed to decode stream chunk: %w", err)
+		}
+
+		content.WriteString(chunk.Message.Content)
+		if onChunk != nil && chunk.Message.Content != "" {
+			onChunk(chunk.Message.Content)
+		}
+
+		if chunk.Done {
+			setTokens(span, chunk)
+			break
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return content.String(), fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	return content.String(), nil
+}
 
-	resp, err := c.client.Do(req)
+// Embed generates an embedding for text using the configured embedding model
+func (c *Client) Embed(ctx context.Context, text string) (_ []float64, err error) {
+	request := EmbedRequest{
+		Model: c.config.EmbeddingModel,
+		Input: text,
+	}
+
+	ctx, span := startSpan(ctx, "embeddings", request.Model)
+	defer func() { tracing.End(span, err) }()
+
+	resp, err := c.postJSON(ctx, c.client, "/api/embed", request)
 	if err != nil {
-		return "", errors.NewAPIError("Ollama", 0, "failed to call Ollama API", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return "", errors.NewAPIError("Ollama", resp.StatusCode, "Ollama API error", nil)
+	var response EmbedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode embed response: %w", err)
 	}
 
-	var response ChatResponse
-	err = json.NewDecoder(resp.Body).Decode(&response)
+	if len(response.Embeddings) == 0 {
+		return nil, errors.NewAPIError("Ollama", 0, "no embedding data received", nil)
+	}
+
+	return response.Embeddings[0], nil
+}
+
+// startSpan starts the span of an Ollama call
+func startSpan(ctx context.Context, operation, model string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "ollama "+operation,
+		attribute.String("gen_ai.system", "ollama"),
+		attribute.String("gen_ai.operation.name", operation),
+		attribute.String("gen_ai.request.model", model))
+}
+
+// setTokens records the token counts of the final chat response on span
+func setTokens(span trace.Span, response ChatResponse) {
+	span.SetAttributes(
+		attribute.Int("gen_ai.usage.input_tokens", response.PromptEvalCount),
+		attribute.Int("gen_ai.usage.output_tokens", response.EvalCount))
+}
+
+// promptMessages builds the system + user message pair, attaching images to the user message
+func (c *Client) promptMessages(systemPrompt, userPrompt string, images [][]byte) []ChatMessage {
+	userMessage := ChatMessage{
+		Role:    "user",
+		Content: userPrompt,
+	}
+	for _, imageData := range images {
+		userMessage.Images = append(userMessage.Images, base64.StdEncoding.EncodeToString(imageData))
+	}
+
+	return []ChatMessage{
+		{
+			Role:    "system",
+			Content: systemPrompt,
+		},
+		userMessage,
+	}
+}
+
+// buildChatRequest assembles a chat request with the configured temperature
+// and client options overridden by the given ones
+func (c *Client) buildChatRequest(messages []ChatMessage, format any, options map[string]any, stream bool) ChatRequest {
+	merged := map[string]any{
+		"temperature": c.config.Temperature,
+	}
+	maps.Copy(merged, c.options)
+	maps.Copy(merged, options)
+
+	return ChatRequest{
+		Model:    c.config.Model,
+		Messages: messages,
+		Stream:   stream,
+		Format:   format,
+		Options:  merged,
+	}
+}
+
+// postJSON posts a JSON payload to an Ollama endpoint with client and returns the successful response
+func (c *Client) postJSON(ctx context.Context, client *http.Client, path string, payload any) (*http.Response, error) {
+	jsonData, err := json.Marshal(payload)
 	if err != nil {
-		return "", fmt.Errorf("failed to decode response: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	return response.Message.Content, nil
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+path, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.NewAPIError("Ollama", 0, "failed to call Ollama API", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, errors.NewAPIError("Ollama", resp.StatusCode, "Ollama API error: "+string(body), nil)
+	}
+
+	return resp, nil
 }
@@ -0,0 +1,77 @@
+package openai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+
+	"ai-devs3/internal/config"
+	"ai-devs3/internal/logging"
+	"ai-devs3/internal/storage/cache"
+)
+
+// responseCache stores raw API responses under a hash of the request, so a
+// re-run does not pay again for calls whose model and input are unchanged.
+// A nil *responseCache never hits and never stores.
+type responseCache struct {
+	store   cache.Cache
+	refresh bool
+}
+
+// newResponseCache opens the cache described by cfg, or returns nil when it
+// is disabled or cannot be created
+func newResponseCache(cfg config.ResponseCacheConfig) *responseCache {
+	if cfg.Cache.BaseDir == "" {
+		return nil
+	}
+
+	store, err := cache.NewFileCache(cfg.Cache)
+	if err != nil {
+		slog.Warn("LLM response cache disabled", "error", err)
+		return nil
+	}
+
+	return &responseCache{store: store, refresh: cfg.Refresh}
+}
+
+// requestKey derives the cache key of a call from its kind and every request
+// field that influences the response: model, prompts and inputs
+func requestKey(kind string, request any) (string, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(data)
+	return cache.CacheKey("llm", kind, hex.EncodeToString(hash[:])), nil
+}
+
+// get loads the cached response for key into v and reports whether it did
+func (r *responseCache) get(ctx context.Context, key string, v any) bool {
+	if r == nil || r.refresh || key == "" {
+		return false
+	}
+
+	data, ok, err := r.store.Get(ctx, key)
+	if err != nil || !ok {
+		return false
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		logging.FromContext(ctx).Warn("Ignoring unreadable cached LLM response", "key", key, "error", err)
+		return false
+	}
+
+	return true
+}
+
+// set stores the raw JSON of a response under key
+func (r *responseCache) set(ctx context.Context, key, raw string) {
+	if r == nil || key == "" || raw == "" {
+		return
+	}
+
+	if err := r.store.Set(ctx, key, []byte(raw)); err != nil {
+		logging.FromContext(ctx).Warn("Failed to cache LLM response", "key", key, "error", err)
+	}
+}
@@ -0,0 +1,77 @@
+package openai
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+
+	"ai-devs3/internal/image"
+	"ai-devs3/pkg/errors"
+
+	"github.com/openai/openai-go"
+)
+
+// ImageDetail is the resolution at which a vision model looks at an image:
+// low costs a flat 85 tokens, high reads it in 512px tiles
+type ImageDetail string
+
+// Detail levels accepted by the API
+const (
+	DetailAuto ImageDetail = "auto"
+	DetailLow  ImageDetail = "low"
+	DetailHigh ImageDetail = "high"
+)
+
+// Limits of images sent inline
+const (
+	maxImageBytes     = 20 << 20 // largest image the API accepts
+	maxImageDimension = 2048     // the API shrinks larger images to fit, so they are shrunk before upload
+)
+
+// ImagePart builds the content part of an image sent inline. Its MIME type is
+// sniffed from the data: PNG and JPEG are sent as they are unless they need
+// shrinking, and GIF, WebP and BMP are converted to PNG.
+func (c *Client) ImagePart(data []byte, detail ImageDetail) (openai.ChatCompletionContentPartUnionParam, error) {
+	url, err := c.imageDataURL(data)
+	if err != nil {
+		return openai.ChatCompletionContentPartUnionParam{}, err
+	}
+	return ImageURLPart(url, detail), nil
+}
+
+// ImageURLPart builds the content part of an image the API downloads itself
+func ImageURLPart(url string, detail ImageDetail) openai.ChatCompletionContentPartUnionParam {
+	return openai.ImageContentPart(openai.ChatCompletionContentPartImageImageURLParam{
+		URL:    url,
+		Detail: string(detail),
+	})
+}
+
+// imageDataURL encodes image data as a data URL of a type the API reads
+func (c *Client) imageDataURL(data []byte) (string, error) {
+	mimeType := http.DetectContentType(data)
+
+	switch mimeType {
+	case "image/png", "image/jpeg", "image/gif", "image/webp", "image/bmp":
+	default:
+		return "", errors.NewProcessingError("image", "image_part",
+			fmt.Sprintf("unsupported image type %s", mimeType), nil)
+	}
+
+	// Keeps PNG and JPEG bytes as they are when they need no shrinking
+	result, err := c.images.Process(data, image.Options{MaxDimension: maxImageDimension})
+	if err != nil {
+		return "", err
+	}
+	if len(result.Data) > maxImageBytes {
+		return "", errors.NewProcessingError("image", "image_part",
+			fmt.Sprintf("image of %d bytes exceeds the %d byte limit", len(result.Data), maxImageBytes), nil)
+	}
+
+	return dataURL(result.MIMEType, result.Data), nil
+}
+
+// dataURL embeds data of the given MIME type in a URL
+func dataURL(mimeType string, data []byte) string {
+	return fmt.Sprintf("data:%s;base64,%s", mimeType, base64.StdEncoding.EncodeToString(data))
+}
@@ -0,0 +1,116 @@
+package openai
+
+import (
+	"cmp"
+	"context"
+	"io"
+
+	"ai-devs3/pkg/errors"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/shared"
+)
+
+// Chat sends a system and user prompt to the configured model and returns the raw content
+func (c *Client) Chat(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	chatCompletion, err := c.complete(ctx, c.params(
+		openai.SystemMessage(systemPrompt),
+		openai.UserMessage(userPrompt),
+	))
+	if err != nil {
+		return "", errors.NewAPIError("OpenAI", 0, "failed to get answer", err)
+	}
+
+	return chatCompletion.Choices[0].Message.Content, nil
+}
+
+// ChatJSON is like Chat but enables JSON mode so the response is always a valid JSON object
+func (c *Client) ChatJSON(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	params := c.params(
+		openai.SystemMessage(systemPrompt),
+		openai.UserMessage(userPrompt),
+	)
+	params.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{
+		OfJSONObject: &shared.ResponseFormatJSONObjectParam{},
+	}
+
+	chatCompletion, err := c.complete(ctx, params)
+	if err != nil {
+		return "", errors.NewAPIError("OpenAI", 0, "failed to get JSON answer", err)
+	}
+
+	return chatCompletion.Choices[0].Message.Content, nil
+}
+
+// Vision sends a prompt together with one or more images to the configured model
+func (c *Client) Vision(ctx context.Context, systemPrompt, userPrompt string, images [][]byte) (string, error) {
+	params, err := c.visionParams(systemPrompt, userPrompt, images)
+	if err != nil {
+		return "", err
+	}
+
+	chatCompletion, err := c.complete(ctx, params)
+	if err != nil {
+		return "", errors.NewAPIError("OpenAI Vision", 0, "failed to analyze images", err)
+	}
+
+	return chatCompletion.Choices[0].Message.Content, nil
+}
+
+// VisionSchema is like Vision but answers with JSON matching the schema
+// derived from out's type and decodes it into out; see llm.VisionStructured
+func (c *Client) VisionSchema(ctx context.Context, systemPrompt, userPrompt string, images [][]byte, out any) error {
+	params, err := c.visionParams(systemPrompt, userPrompt, images)
+	if err != nil {
+		return err
+	}
+
+	if err := c.completeStructured(ctx, params, out); err != nil {
+		return errors.NewAPIError("OpenAI Vision", 0, "failed to analyze images", err)
+	}
+
+	return nil
+}
+
+// visionParams builds a request with the images followed by the prompt, at
+// the detail chosen in the client settings
+func (c *Client) visionParams(systemPrompt, userPrompt string, images [][]byte) (openai.ChatCompletionNewParams, error) {
+	detail := cmp.Or(c.settings.Detail, DetailAuto)
+	request := c.NewRequest().System(systemPrompt)
+	for _, imageData := range images {
+		request.Image(imageData, detail)
+	}
+	return request.Text(userPrompt).build()
+}
+
+// Embed generates an embedding for text using the configured embedding model
+func (c *Client) Embed(ctx context.Context, text string) ([]float64, error) {
+	embedding, err := c.embed(ctx, openai.EmbeddingNewParams{
+		Input: openai.EmbeddingNewParamsInputUnion{
+			OfString: openai.String(text),
+		},
+		Model: openai.EmbeddingModel(c.config.EmbeddingModel),
+	})
+	if err != nil {
+		return nil, errors.NewAPIError("OpenAI Embeddings", 0, "failed to create embedding", err)
+	}
+
+	if len(embedding.Data) == 0 {
+		return nil, errors.NewAPIError("OpenAI Embeddings", 0, "no embedding data received", nil)
+	}
+
+	return embedding.Data[0].Embedding, nil
+}
+
+// Transcribe transcribes audio from any reader using Whisper
+func (c *Client) Transcribe(ctx context.Context, audio io.Reader, filename string) (string, error) {
+	transcription, err := c.transcribe(ctx, openai.AudioTranscriptionNewParams{
+		File:  openai.File(audio, filename, "application/octet-stream"),
+		Model: openai.AudioModelWhisper1,
+	})
+	if err != nil {
+		return "", errors.NewAPIError("OpenAI Whisper", 0, "failed to transcribe audio", err)
+	}
+
+	return transcription.Text, nil
+}
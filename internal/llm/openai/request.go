@@ -0,0 +1,154 @@
+package openai
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"ai-devs3/internal/usage"
+	"ai-devs3/pkg/errors"
+
+	"github.com/openai/openai-go"
+)
+
+// AudioFormat is the encoding of an audio part
+type AudioFormat string
+
+// Audio formats accepted by audio-capable models such as gpt-4o-audio-preview
+const (
+	AudioWAV AudioFormat = "wav"
+	AudioMP3 AudioFormat = "mp3"
+)
+
+// Request builds a chat completion mixing text, images and audio in one user
+// message, for calls that do not deserve a dedicated Client method:
+//
+//	resp, err := client.NewRequest().
+//		System(systemPrompt).
+//		Text("What does this sign say?").
+//		Image(photo, openai.DetailHigh).
+//		MaxTokens(256).
+//		Send(ctx)
+//
+// Parts keep the order in which they are added. The first part that cannot
+// be built fails Send.
+type Request struct {
+	client *Client
+	params openai.ChatCompletionNewParams
+	system string
+	parts  []openai.ChatCompletionContentPartUnionParam
+	err    error
+}
+
+// Response is the answer to a Request
+type Response struct {
+	Text  string
+	Usage usage.Entry // model and tokens of the completion
+}
+
+// NewRequest starts a request to the client's model with its sampling settings
+func (c *Client) NewRequest() *Request {
+	return &Request{
+		client: c,
+		params: c.params(),
+	}
+}
+
+// System sets the system prompt
+func (r *Request) System(prompt string) *Request {
+	r.system = prompt
+	return r
+}
+
+// Text adds a text part
+func (r *Request) Text(text string) *Request {
+	r.parts = append(r.parts, openai.TextContentPart(text))
+	return r
+}
+
+// Image adds an image sent inline; see Client.ImagePart for the formats
+func (r *Request) Image(data []byte, detail ImageDetail) *Request {
+	if r.err != nil {
+		return r
+	}
+	part, err := r.client.ImagePart(data, detail)
+	if err != nil {
+		r.err = fmt.Errorf("part %d: %w", len(r.parts)+1, err)
+		return r
+	}
+	r.parts = append(r.parts, part)
+	return r
+}
+
+// ImageURL adds an image the API downloads itself
+func (r *Request) ImageURL(url string, detail ImageDetail) *Request {
+	r.parts = append(r.parts, ImageURLPart(url, detail))
+	return r
+}
+
+// Audio adds an audio part; only audio-capable models accept it
+func (r *Request) Audio(data []byte, format AudioFormat) *Request {
+	r.parts = append(r.parts, openai.InputAudioContentPart(openai.ChatCompletionContentPartInputAudioInputAudioParam{
+		Data:   base64.StdEncoding.EncodeToString(data),
+		Format: string(format),
+	}))
+	return r
+}
+
+// Model overrides the configured model
+func (r *Request) Model(model string) *Request {
+	r.params.Model = openai.ChatModel(model)
+	return r
+}
+
+// Temperature overrides the configured temperature
+func (r *Request) Temperature(temperature float64) *Request {
+	r.params.Temperature = openai.Float(temperature)
+	return r
+}
+
+// MaxTokens caps the length of the answer
+func (r *Request) MaxTokens(maxTokens int) *Request {
+	r.params.MaxTokens = openai.Int(int64(maxTokens))
+	return r
+}
+
+// Send sends the request and returns the answer with its token usage
+func (r *Request) Send(ctx context.Context) (*Response, error) {
+	params, err := r.build()
+	if err != nil {
+		return nil, err
+	}
+
+	completion, err := r.client.complete(ctx, params)
+	if err != nil {
+		return nil, errors.NewAPIError("OpenAI", 0, "failed to complete request", err)
+	}
+
+	return &Response{
+		Text: completion.Choices[0].Message.Content,
+		Usage: usage.Entry{
+			Model:            modelName(completion.Model, string(params.Model)),
+			PromptTokens:     completion.Usage.PromptTokens,
+			CompletionTokens: completion.Usage.CompletionTokens,
+		},
+	}, nil
+}
+
+// build returns the parameters of the request, or the error of the first
+// part that could not be built
+func (r *Request) build() (openai.ChatCompletionNewParams, error) {
+	if r.err != nil {
+		return openai.ChatCompletionNewParams{}, r.err
+	}
+	if len(r.parts) == 0 {
+		return openai.ChatCompletionNewParams{}, errors.NewAPIError("OpenAI", 0, "request has no content", nil)
+	}
+
+	params := r.params
+	if r.system != "" {
+		params.Messages = append(params.Messages, openai.SystemMessage(r.system))
+	}
+	params.Messages = append(params.Messages, openai.UserMessage(r.parts))
+	return params, nil
+}
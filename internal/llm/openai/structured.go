@@ -0,0 +1,65 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+
+	"ai-devs3/internal/llm/schema"
+	"ai-devs3/pkg/errors"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/shared"
+)
+
+// ChatSchema answers a system and user prompt with JSON matching the schema
+// derived from out's type and decodes it into out; see llm.ChatStructured
+func (c *Client) ChatSchema(ctx context.Context, systemPrompt, userPrompt string, out any) error {
+	return c.completeStructured(ctx, c.params(
+		openai.SystemMessage(systemPrompt),
+		openai.UserMessage(userPrompt),
+	), out)
+}
+
+// completeStructured sends params using the API's strict structured outputs
+// with the schema derived from out's type and decodes the answer into out.
+// An answer that fails validation is sent back together with the error.
+func (c *Client) completeStructured(ctx context.Context, params openai.ChatCompletionNewParams, out any) error {
+	name := schema.Name(reflect.TypeOf(out))
+	return schema.Ask(out, func(s schema.Schema, retries []schema.Retry) (string, error) {
+		attempt := params
+		attempt.ResponseFormat = schemaFormat(name, s)
+		attempt.Messages = slices.Clone(params.Messages)
+		for _, retry := range retries {
+			attempt.Messages = append(attempt.Messages,
+				openai.AssistantMessage(retry.Answer),
+				openai.UserMessage(retry.Correction),
+			)
+		}
+
+		chatCompletion, err := c.complete(ctx, attempt)
+		if err != nil {
+			return "", errors.NewAPIError("OpenAI", 0, "failed to get "+name, err)
+		}
+
+		message := chatCompletion.Choices[0].Message
+		if message.Refusal != "" {
+			return "", errors.NewAPIError("OpenAI", 0, fmt.Sprintf("model refused to answer %s: %s", name, message.Refusal), nil)
+		}
+		return message.Content, nil
+	})
+}
+
+// schemaFormat builds a strict JSON Schema response format
+func schemaFormat(name string, s schema.Schema) openai.ChatCompletionNewParamsResponseFormatUnion {
+	return openai.ChatCompletionNewParamsResponseFormatUnion{
+		OfJSONSchema: &shared.ResponseFormatJSONSchemaParam{
+			JSONSchema: shared.ResponseFormatJSONSchemaJSONSchemaParam{
+				Name:   name,
+				Schema: s,
+				Strict: openai.Bool(true),
+			},
+		},
+	}
+}
@@ -0,0 +1,226 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"path/filepath"
+	"strconv"
+
+	"ai-devs3/internal/tracing"
+	"ai-devs3/internal/usage"
+	"ai-devs3/pkg/errors"
+
+	"github.com/openai/openai-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// complete creates a chat completion and records its token usage. Like the
+// other helpers here it traces the call as a span, answers from the response
+// cache when it can, and refuses to call the API once the run budget is spent.
+// A request that cannot be hashed is simply not cached.
+func (c *Client) complete(ctx context.Context, params openai.ChatCompletionNewParams) (_ *openai.ChatCompletion, err error) {
+	ctx, span := startSpan(ctx, "chat", string(params.Model))
+	defer func() { tracing.End(span, err) }()
+
+	key, _ := requestKey("chat", params)
+	var cached openai.ChatCompletion
+	if c.cache.get(ctx, key, &cached) && len(cached.Choices) > 0 {
+		span.SetAttributes(cacheHit)
+		return &cached, nil
+	}
+
+	if err := c.budget.Allow(); err != nil {
+		return nil, err
+	}
+
+	completion, err := c.client.Chat.Completions.New(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	c.record(ctx, usage.Entry{
+		Model:            modelName(completion.Model, string(params.Model)),
+		PromptTokens:     completion.Usage.PromptTokens,
+		CompletionTokens: completion.Usage.CompletionTokens,
+	})
+
+	if len(completion.Choices) == 0 {
+		return nil, errors.NewAPIError("OpenAI", 0, "no choices returned", nil)
+	}
+
+	c.cache.set(ctx, key, completion.RawJSON())
+	return completion, nil
+}
+
+// embed creates embeddings and records their token usage
+func (c *Client) embed(ctx context.Context, params openai.EmbeddingNewParams) (_ *openai.CreateEmbeddingResponse, err error) {
+	ctx, span := startSpan(ctx, "embeddings", string(params.Model))
+	defer func() { tracing.End(span, err) }()
+
+	key, _ := requestKey("embedding", params)
+	var cached openai.CreateEmbeddingResponse
+	if c.cache.get(ctx, key, &cached) && len(cached.Data) > 0 {
+		span.SetAttributes(cacheHit)
+		return &cached, nil
+	}
+
+	if err := c.budget.Allow(); err != nil {
+		return nil, err
+	}
+
+	embedding, err := c.client.Embeddings.New(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	c.record(ctx, usage.Entry{
+		Model:        modelName(embedding.Model, string(params.Model)),
+		PromptTokens: embedding.Usage.PromptTokens,
+	})
+
+	c.cache.set(ctx, key, embedding.RawJSON())
+	return embedding, nil
+}
+
+// transcribe transcribes audio and records its duration. The verbose_json
+// format is requested because it is the only one reporting the duration.
+func (c *Client) transcribe(ctx context.Context, params openai.AudioTranscriptionNewParams) (_ *openai.Transcription, err error) {
+	ctx, span := startSpan(ctx, "transcription", string(params.Model))
+	defer func() { tracing.End(span, err) }()
+
+	params.ResponseFormat = openai.AudioResponseFormatVerboseJSON
+
+	var key string
+	if c.cache != nil {
+		audio, err := bufferAudio(&params)
+		if err != nil {
+			return nil, err
+		}
+
+		hash := sha256.Sum256(audio)
+		key, _ = requestKey("transcription", transcriptionKey{
+			Model:    string(params.Model),
+			Language: params.Language.Value,
+			Prompt:   params.Prompt.Value,
+			Audio:    hex.EncodeToString(hash[:]),
+		})
+
+		var cached openai.Transcription
+		if c.cache.get(ctx, key, &cached) {
+			span.SetAttributes(cacheHit)
+			return &cached, nil
+		}
+	}
+
+	if err := c.budget.Allow(); err != nil {
+		return nil, err
+	}
+
+	transcription, err := c.client.Audio.Transcriptions.New(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var seconds float64
+	if field, ok := transcription.JSON.ExtraFields["duration"]; ok {
+		seconds, _ = strconv.ParseFloat(field.Raw(), 64)
+	}
+	c.record(ctx, usage.Entry{Model: string(params.Model), AudioSeconds: seconds})
+
+	c.cache.set(ctx, key, transcription.RawJSON())
+	return transcription, nil
+}
+
+// transcriptionKey identifies a transcription by its settings and the hash of
+// the audio, since the multipart request itself cannot be hashed
+type transcriptionKey struct {
+	Model    string `json:"model"`
+	Language string `json:"language"`
+	Prompt   string `json:"prompt"`
+	Audio    string `json:"audio"`
+}
+
+// bufferAudio reads the audio of a transcription request into memory so it can
+// be hashed, replacing the reader with a copy that keeps the original filename
+func bufferAudio(params *openai.AudioTranscriptionNewParams) ([]byte, error) {
+	audio, err := io.ReadAll(params.File)
+	if err != nil {
+		return nil, errors.NewProcessingError("audio", "transcription", "failed to read audio", err)
+	}
+
+	filename := "audio"
+	switch named := params.File.(type) {
+	case interface{ Filename() string }:
+		filename = named.Filename()
+	case interface{ Name() string }:
+		filename = named.Name()
+	}
+
+	contentType := "application/octet-stream"
+	if typed, ok := params.File.(interface{ ContentType() string }); ok && typed.ContentType() != "" {
+		contentType = typed.ContentType()
+	}
+
+	params.File = openai.File(bytes.NewReader(audio), filepath.Base(filename), contentType)
+	return audio, nil
+}
+
+// generateImages generates images and records how many were created. Image
+// responses are not cached because the returned URLs expire.
+func (c *Client) generateImages(ctx context.Context, params openai.ImageGenerateParams) (_ *openai.ImagesResponse, err error) {
+	ctx, span := startSpan(ctx, "image_generation", string(params.Model))
+	defer func() { tracing.End(span, err) }()
+
+	if err := c.budget.Allow(); err != nil {
+		return nil, err
+	}
+
+	images, err := c.client.Images.Generate(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	c.record(ctx, usage.Entry{
+		Model:            string(params.Model),
+		PromptTokens:     images.Usage.InputTokens,
+		CompletionTokens: images.Usage.OutputTokens,
+		Images:           len(images.Data),
+	})
+
+	return images, nil
+}
+
+// record adds the usage of a call to the run report and its span, and charges
+// it to the budget
+func (c *Client) record(ctx context.Context, e usage.Entry) {
+	c.usage.Record(ctx, e)
+	c.budget.Charge(e)
+
+	trace.SpanFromContext(ctx).SetAttributes(
+		attribute.String("gen_ai.response.model", e.Model),
+		attribute.Int64("gen_ai.usage.input_tokens", e.PromptTokens),
+		attribute.Int64("gen_ai.usage.output_tokens", e.CompletionTokens))
+}
+
+// cacheHit marks the span of a call answered from the response cache
+var cacheHit = attribute.Bool("llm.cache_hit", true)
+
+// startSpan starts the span of an OpenAI call
+func startSpan(ctx context.Context, operation, model string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "openai "+operation,
+		attribute.String("gen_ai.system", "openai"),
+		attribute.String("gen_ai.operation.name", operation),
+		attribute.String("gen_ai.request.model", model))
+}
+
+// modelName prefers the model reported by the API, which includes the snapshot date
+func modelName(reported, requested string) string {
+	if reported != "" {
+		return reported
+	}
+	return requested
+}
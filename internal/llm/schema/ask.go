@@ -0,0 +1,47 @@
+package schema
+
+import (
+	"fmt"
+	"reflect"
+
+	"ai-devs3/pkg/errors"
+)
+
+// MaxAttempts bounds how often an answer failing validation is asked again
+const MaxAttempts = 3
+
+// Retry is an answer that failed validation and the correction sent back
+type Retry struct {
+	Answer     string
+	Correction string
+}
+
+// Ask derives the schema of out's type and calls ask until its answer
+// validates and decodes into out, at most MaxAttempts times. Every call gets
+// the failed answers so far with their corrections, for providers to send
+// back as conversation turns. Errors of ask are returned as they are.
+func Ask(out any, ask func(s Schema, retries []Retry) (string, error)) error {
+	outType := reflect.TypeOf(out)
+	name := Name(outType)
+
+	s, err := Of(outType)
+	if err != nil {
+		return errors.NewProcessingError("schema", name, "cannot derive JSON Schema", err)
+	}
+
+	var retries []Retry
+	var lastErr error
+	for attempt := 1; attempt <= MaxAttempts; attempt++ {
+		content, err := ask(s, retries)
+		if err != nil {
+			return err
+		}
+
+		if lastErr = Decode(content, s, out); lastErr == nil {
+			return nil
+		}
+		retries = append(retries, Retry{Answer: content, Correction: Correction(lastErr)})
+	}
+
+	return errors.NewProcessingError("schema", name, fmt.Sprintf("no valid answer after %d attempts", MaxAttempts), lastErr)
+}
@@ -0,0 +1,88 @@
+package schema
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type city struct {
+	Name string `json:"name"`
+}
+
+func TestAsk(t *testing.T) {
+	failure := errors.New("provider down")
+
+	tests := []struct {
+		name      string
+		answers   []string
+		askErr    error
+		wantName  string
+		wantCalls int
+		wantErr   string
+	}{
+		{
+			name:      "valid at once",
+			answers:   []string{`{"name":"Kraków"}`},
+			wantName:  "Kraków",
+			wantCalls: 1,
+		},
+		{
+			name:      "corrected on retry",
+			answers:   []string{`{"city":"Kraków"}`, `{"name":"Kraków"}`},
+			wantName:  "Kraków",
+			wantCalls: 2,
+		},
+		{
+			name:      "gives up after MaxAttempts",
+			answers:   []string{`nope`},
+			wantCalls: MaxAttempts,
+			wantErr:   "no valid answer after 3 attempts",
+		},
+		{
+			name:      "provider error is returned as is",
+			askErr:    failure,
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out city
+			calls := 0
+			err := Ask(&out, func(s Schema, retries []Retry) (string, error) {
+				calls++
+				if len(retries) != calls-1 {
+					t.Errorf("call %d got %d retries", calls, len(retries))
+				}
+				for _, retry := range retries {
+					if !strings.Contains(retry.Correction, "did not match the required JSON Schema") {
+						t.Errorf("correction = %q", retry.Correction)
+					}
+				}
+				if tt.askErr != nil {
+					return "", tt.askErr
+				}
+				return tt.answers[min(calls, len(tt.answers))-1], nil
+			})
+
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+			switch {
+			case tt.askErr != nil:
+				if !errors.Is(err, tt.askErr) {
+					t.Errorf("Ask() error = %v, want %v", err, tt.askErr)
+				}
+			case tt.wantErr != "":
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Ask() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+			case err != nil:
+				t.Errorf("Ask() error = %v", err)
+			case out.Name != tt.wantName:
+				t.Errorf("Name = %q, want %q", out.Name, tt.wantName)
+			}
+		})
+	}
+}
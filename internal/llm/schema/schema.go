@@ -0,0 +1,198 @@
+// Package schema derives JSON Schemas from Go result types for structured LLM
+// outputs and validates model answers against them.
+//
+// The generated schemas follow the subset accepted by OpenAI's strict mode:
+// every property is required, objects forbid additional properties and
+// optional values are expressed as nullable pointer fields. Allowed string
+// values can be listed in an `enum:"A,B,C"` struct tag.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Schema is a JSON Schema object
+type Schema map[string]any
+
+// For derives the schema of T
+func For[T any]() (Schema, error) {
+	return Of(reflect.TypeFor[T]())
+}
+
+// Of derives the schema of t, which must be a struct or a pointer to one
+func Of(t reflect.Type) (Schema, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("schema: %s is not a struct", t)
+	}
+	return typeSchema(t, map[reflect.Type]bool{})
+}
+
+// invalidName matches characters not allowed in a response format name
+var invalidName = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// Name returns the response format name of t, e.g. "MapAnalysis"
+func Name(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	name := invalidName.ReplaceAllString(t.Name(), "_")
+	if name == "" {
+		name = "response"
+	}
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+// typeSchema builds the schema of a single Go type; seen guards against
+// recursive types, which strict mode cannot express without definitions
+func typeSchema(t reflect.Type, seen map[reflect.Type]bool) (Schema, error) {
+	switch t.Kind() {
+	case reflect.Pointer:
+		s, err := typeSchema(t.Elem(), seen)
+		if err != nil {
+			return nil, err
+		}
+		return nullable(s), nil
+	case reflect.String:
+		return Schema{"type": "string"}, nil
+	case reflect.Bool:
+		return Schema{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Schema{"type": "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}, nil
+	case reflect.Slice, reflect.Array:
+		items, err := typeSchema(t.Elem(), seen)
+		if err != nil {
+			return nil, err
+		}
+		return Schema{"type": "array", "items": items}, nil
+	case reflect.Struct:
+		if seen[t] {
+			return nil, fmt.Errorf("schema: recursive type %s is not supported", t)
+		}
+		seen[t] = true
+		defer delete(seen, t)
+		return structSchema(t, seen)
+	default:
+		return nil, fmt.Errorf("schema: unsupported type %s", t)
+	}
+}
+
+// structSchema builds an object schema from the JSON-visible fields of t
+func structSchema(t reflect.Type, seen map[reflect.Type]bool) (Schema, error) {
+	properties := Schema{}
+	required := []string{}
+
+	var addFields func(t reflect.Type) error
+	addFields = func(t reflect.Type) error {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, ok := jsonName(field)
+			if !ok {
+				continue
+			}
+
+			// Untagged embedded structs are flattened, as encoding/json does
+			if field.Anonymous && field.Tag.Get("json") == "" && field.Type.Kind() == reflect.Struct {
+				if err := addFields(field.Type); err != nil {
+					return err
+				}
+				continue
+			}
+
+			s, err := typeSchema(field.Type, seen)
+			if err != nil {
+				return fmt.Errorf("%s.%s: %w", t.Name(), field.Name, err)
+			}
+			if enum := field.Tag.Get("enum"); enum != "" {
+				s["enum"] = enumValues(enum, field.Type.Kind() == reflect.Pointer)
+			}
+
+			if _, exists := properties[name]; !exists {
+				required = append(required, name)
+			}
+			properties[name] = s
+		}
+		return nil
+	}
+
+	if err := addFields(t); err != nil {
+		return nil, err
+	}
+
+	return Schema{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}, nil
+}
+
+// jsonName returns the JSON property name of a field and whether it is encoded
+func jsonName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() && !field.Anonymous {
+		return "", false
+	}
+
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+	return name, true
+}
+
+// enumValues splits an enum tag, adding null for nullable fields
+func enumValues(tag string, nullable bool) []any {
+	var values []any
+	for _, value := range strings.Split(tag, ",") {
+		values = append(values, strings.TrimSpace(value))
+	}
+	if nullable {
+		values = append(values, nil)
+	}
+	return values
+}
+
+// nullable allows null in addition to the values s accepts
+func nullable(s Schema) Schema {
+	if typ, ok := s["type"].(string); ok {
+		s["type"] = []any{typ, "null"}
+	}
+	return s
+}
+
+// Decode validates a JSON answer against s and unmarshals it into out. The
+// error names the first offending path so it can be fed back to the model.
+func Decode(content string, s Schema, out any) error {
+	var value any
+	if err := json.Unmarshal([]byte(content), &value); err != nil {
+		return fmt.Errorf("answer is not valid JSON: %w", err)
+	}
+	if err := s.Validate(value); err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(content), out); err != nil {
+		return fmt.Errorf("answer does not fit the result type: %w", err)
+	}
+	return nil
+}
+
+// Correction is the follow-up prompt asking the model to fix an invalid answer
+func Correction(err error) string {
+	return fmt.Sprintf("Your previous answer did not match the required JSON Schema: %v. Reply again with only the corrected JSON object.", err)
+}
@@ -0,0 +1,165 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type verdict struct {
+	Thinking string   `json:"_thinking"`
+	Answer   string   `json:"answer" enum:"yes,no"`
+	Score    int      `json:"score"`
+	Tags     []string `json:"tags"`
+	Note     *string  `json:"note"`
+	Ignored  string   `json:"-"`
+}
+
+type tagged struct {
+	verdict
+	Extra float64 `json:"extra"`
+}
+
+type node struct {
+	Children []node `json:"children"`
+}
+
+func TestOf(t *testing.T) {
+	s, err := For[tagged]()
+	if err != nil {
+		t.Fatalf("For() error = %v", err)
+	}
+
+	want := `{"additionalProperties":false,"properties":{` +
+		`"_thinking":{"type":"string"},` +
+		`"answer":{"enum":["yes","no"],"type":"string"},` +
+		`"extra":{"type":"number"},` +
+		`"note":{"type":["string","null"]},` +
+		`"score":{"type":"integer"},` +
+		`"tags":{"items":{"type":"string"},"type":"array"}},` +
+		`"required":["_thinking","answer","score","tags","note","extra"],"type":"object"}`
+	got, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("For() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestOfRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		typ     reflect.Type
+		wantErr string
+	}{
+		{"not a struct", reflect.TypeFor[[]string](), "is not a struct"},
+		{"recursive type", reflect.TypeFor[node](), "recursive type"},
+		{"map field", reflect.TypeFor[struct {
+			Values map[string]int `json:"values"`
+		}](), "unsupported type"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Of(tt.typ)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Of() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestName(t *testing.T) {
+	tests := []struct {
+		typ  reflect.Type
+		want string
+	}{
+		{reflect.TypeFor[verdict](), "verdict"},
+		{reflect.TypeFor[*verdict](), "verdict"},
+		{reflect.TypeFor[struct{}](), "response"},
+	}
+
+	for _, tt := range tests {
+		if got := Name(tt.typ); got != tt.want {
+			t.Errorf("Name(%s) = %q, want %q", tt.typ, got, tt.want)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	s, err := For[verdict]()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "valid",
+			content: `{"_thinking":"t","answer":"yes","score":3,"tags":["a"],"note":null}`,
+		},
+		{
+			name:    "nullable set",
+			content: `{"_thinking":"t","answer":"no","score":0,"tags":[],"note":"n"}`,
+		},
+		{
+			name:    "not JSON",
+			content: `answer: yes`,
+			wantErr: "answer is not valid JSON",
+		},
+		{
+			name:    "missing property",
+			content: `{"_thinking":"t","answer":"yes","score":3,"tags":[]}`,
+			wantErr: `$: missing required property "note"`,
+		},
+		{
+			name:    "extra property",
+			content: `{"_thinking":"t","answer":"yes","score":3,"tags":[],"note":null,"why":"x"}`,
+			wantErr: `$: unexpected property "why"`,
+		},
+		{
+			name:    "value outside enum",
+			content: `{"_thinking":"t","answer":"maybe","score":3,"tags":[],"note":null}`,
+			wantErr: `$.answer: "maybe" is not one of [yes no]`,
+		},
+		{
+			name:    "fraction for integer",
+			content: `{"_thinking":"t","answer":"yes","score":2.5,"tags":[],"note":null}`,
+			wantErr: "$.score: expected integer, got number 2.5",
+		},
+		{
+			name:    "wrong item type",
+			content: `{"_thinking":"t","answer":"yes","score":1,"tags":["a",2],"note":null}`,
+			wantErr: "$.tags[1]: expected string, got number 2",
+		},
+		{
+			name:    "null for required value",
+			content: `{"_thinking":null,"answer":"yes","score":1,"tags":[],"note":null}`,
+			wantErr: "$._thinking: must not be null",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out verdict
+			err := Decode(tt.content, s, &out)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Decode() error = %v", err)
+				}
+				if out.Thinking != "t" {
+					t.Errorf("Decode() did not fill the result: %+v", out)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Decode() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
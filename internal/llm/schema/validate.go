@@ -0,0 +1,124 @@
+package schema
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// Validate checks a decoded JSON value against the schema subset produced by
+// Of: types, nullability, enums, required and additional properties
+func (s Schema) Validate(value any) error {
+	return validate("$", s, value)
+}
+
+// validate checks value at path against s
+func validate(path string, s Schema, value any) error {
+	if enum, ok := s["enum"].([]any); ok && !slices.Contains(enum, value) {
+		return fmt.Errorf("%s: %v is not one of %v", path, describe(value), enum)
+	}
+
+	types := schemaTypes(s)
+	if value == nil {
+		if slices.Contains(types, "null") {
+			return nil
+		}
+		return fmt.Errorf("%s: must not be null", path)
+	}
+
+	switch v := value.(type) {
+	case string:
+		return expect(path, types, "string", value)
+	case bool:
+		return expect(path, types, "boolean", value)
+	case float64:
+		if slices.Contains(types, "integer") && v == math.Trunc(v) {
+			return nil
+		}
+		return expect(path, types, "number", value)
+	case []any:
+		if err := expect(path, types, "array", value); err != nil {
+			return err
+		}
+		items, _ := s["items"].(Schema)
+		for i, item := range v {
+			if err := validate(fmt.Sprintf("%s[%d]", path, i), items, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case map[string]any:
+		if err := expect(path, types, "object", value); err != nil {
+			return err
+		}
+		return validateObject(path, s, v)
+	default:
+		return fmt.Errorf("%s: unexpected value %v", path, describe(value))
+	}
+}
+
+// validateObject checks the properties of an object value
+func validateObject(path string, s Schema, object map[string]any) error {
+	properties, _ := s["properties"].(Schema)
+
+	required, _ := s["required"].([]string)
+	for _, name := range required {
+		if _, ok := object[name]; !ok {
+			return fmt.Errorf("%s: missing required property %q", path, name)
+		}
+	}
+
+	for name, value := range object {
+		property, ok := properties[name].(Schema)
+		if !ok {
+			if s["additionalProperties"] == false {
+				return fmt.Errorf("%s: unexpected property %q", path, name)
+			}
+			continue
+		}
+		if err := validate(path+"."+name, property, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// schemaTypes returns the JSON types a schema accepts
+func schemaTypes(s Schema) []string {
+	switch typ := s["type"].(type) {
+	case string:
+		return []string{typ}
+	case []any:
+		types := make([]string, 0, len(typ))
+		for _, t := range typ {
+			if name, ok := t.(string); ok {
+				types = append(types, name)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+// expect reports an error unless the schema accepts the JSON type got
+func expect(path string, types []string, got string, value any) error {
+	if len(types) == 0 || slices.Contains(types, got) {
+		return nil
+	}
+	return fmt.Errorf("%s: expected %s, got %s %v", path, types[0], got, describe(value))
+}
+
+// describe renders a value for an error message, truncating long strings
+func describe(value any) string {
+	if s, ok := value.(string); ok {
+		if len(s) > 40 {
+			s = s[:40] + "..."
+		}
+		return fmt.Sprintf("%q", s)
+	}
+	if value == nil {
+		return "null"
+	}
+	return fmt.Sprintf("%v", value)
+}
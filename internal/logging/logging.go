@@ -0,0 +1,76 @@
+// Package logging sets up the structured run logger. Every record carries the
+// run ID and task, secrets such as API keys are redacted before a record is
+// written, and the logger travels to handlers and services in the context.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"ai-devs3/pkg/errors"
+)
+
+// Formats accepted by --log-format
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Options configure a logger
+type Options struct {
+	Level   string   // debug, info, warn or error
+	Format  string   // FormatText or FormatJSON
+	Secrets []string // values replaced by Redacted wherever they appear
+}
+
+// New creates a logger writing to w
+func New(w io.Writer, opts Options) (*slog.Logger, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(opts.Level)); err != nil {
+		return nil, errors.NewConfigError("LOG_LEVEL", fmt.Sprintf("unknown log level %q, want debug, info, warn or error", opts.Level), err)
+	}
+
+	handlerOpts := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	switch strings.ToLower(opts.Format) {
+	case FormatText, "":
+		handler = slog.NewTextHandler(w, handlerOpts)
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, handlerOpts)
+	default:
+		return nil, errors.NewConfigError("LOG_FORMAT", fmt.Sprintf("unknown log format %q, want text or json", opts.Format), nil)
+	}
+
+	return slog.New(newRedactHandler(handler, opts.Secrets)), nil
+}
+
+// NewRunID returns a short random ID tying together the records of one run
+func NewRunID() string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
+
+// loggerKey is the context key of the run logger
+type loggerKey struct{}
+
+// WithLogger returns a context carrying logger
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger carried by ctx, or the default logger
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
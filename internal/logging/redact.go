@@ -0,0 +1,118 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// Redacted replaces secrets in log output
+const Redacted = "[REDACTED]"
+
+// minSecretLength keeps short placeholder values from redacting ordinary text
+const minSecretLength = 8
+
+// sensitiveKeys are attribute names whose values are always redacted
+var sensitiveKeys = map[string]bool{
+	"apikey":   true,
+	"api_key":  true,
+	"password": true,
+	"token":    true,
+}
+
+// redactHandler removes secrets from messages and attributes before passing
+// records on, so keys embedded in URLs or raw responses never reach the log
+type redactHandler struct {
+	next     slog.Handler
+	replacer *strings.Replacer // nil when there is nothing to redact
+}
+
+// newRedactHandler wraps next with redaction of secrets
+func newRedactHandler(next slog.Handler, secrets []string) *redactHandler {
+	return &redactHandler{next: next, replacer: SecretReplacer(secrets)}
+}
+
+// SecretReplacer returns a replacer substituting Redacted for every secret,
+// or nil when none is long enough to redact safely
+func SecretReplacer(secrets []string) *strings.Replacer {
+	var pairs []string
+	for _, secret := range secrets {
+		if len(secret) >= minSecretLength {
+			pairs = append(pairs, secret, Redacted)
+		}
+	}
+
+	if len(pairs) == 0 {
+		return nil
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// Enabled reports whether the wrapped handler handles level
+func (h *redactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle redacts the record and passes it on
+func (h *redactHandler) Handle(ctx context.Context, r slog.Record) error {
+	redacted := slog.NewRecord(r.Time, r.Level, h.redact(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(h.redactAttr(a))
+		return true
+	})
+	return h.next.Handle(ctx, redacted)
+}
+
+// WithAttrs redacts attrs once when they are attached
+func (h *redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = h.redactAttr(a)
+	}
+	return &redactHandler{next: h.next.WithAttrs(redacted), replacer: h.replacer}
+}
+
+// WithGroup starts an attribute group
+func (h *redactHandler) WithGroup(name string) slog.Handler {
+	return &redactHandler{next: h.next.WithGroup(name), replacer: h.replacer}
+}
+
+// redactAttr redacts an attribute value, descending into groups
+func (h *redactHandler) redactAttr(a slog.Attr) slog.Attr {
+	if sensitiveKeys[strings.ToLower(a.Key)] {
+		return slog.String(a.Key, Redacted)
+	}
+
+	value := a.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, h.redact(value.String()))
+	case slog.KindGroup:
+		group := value.Group()
+		redacted := make([]any, len(group))
+		for i, member := range group {
+			redacted[i] = h.redactAttr(member)
+		}
+		return slog.Group(a.Key, redacted...)
+	case slog.KindAny:
+		if h.replacer == nil {
+			return slog.Attr{Key: a.Key, Value: value}
+		}
+		// Errors and other values are redacted in their printed form when
+		// they contain a secret, e.g. a request error quoting the URL
+		text := fmt.Sprint(value.Any())
+		if redacted := h.redact(text); redacted != text {
+			return slog.String(a.Key, redacted)
+		}
+	}
+	return slog.Attr{Key: a.Key, Value: value}
+}
+
+// redact replaces every secret in s
+func (h *redactHandler) redact(s string) string {
+	if h.replacer == nil {
+		return s
+	}
+	return h.replacer.Replace(s)
+}
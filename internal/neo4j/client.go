@@ -3,11 +3,15 @@ package neo4j
 import (
 	"context"
 	"fmt"
-	"log"
+	"strings"
 
 	"ai-devs3/internal/config"
+	"ai-devs3/internal/logging"
+	"ai-devs3/internal/tracing"
 
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Client wraps the Neo4j driver with application-specific methods
@@ -47,34 +51,40 @@ func (c *Client) VerifyConnectivity(ctx context.Context) error {
 }
 
 // ClearDatabase clears all nodes and relationships from the database
-func (c *Client) ClearDatabase(ctx context.Context) error {
+func (c *Client) ClearDatabase(ctx context.Context) (err error) {
+	query := "MATCH (n) DETACH DELETE n"
+	ctx, span := startSpan(ctx, "clear_database", query)
+	defer func() { tracing.End(span, err) }()
+
 	session := c.driver.NewSession(ctx, neo4j.SessionConfig{})
 	defer session.Close(ctx)
 
-	_, err := session.Run(ctx, "MATCH (n) DETACH DELETE n", nil)
+	_, err = session.Run(ctx, query, nil)
 	if err != nil {
 		return fmt.Errorf("failed to clear database: %w", err)
 	}
 
-	log.Println("Neo4j database cleared")
+	logging.FromContext(ctx).Info("Neo4j database cleared")
 	return nil
 }
 
 // CreateUser creates a Person node in Neo4j
-func (c *Client) CreateUser(ctx context.Context, userID int, username string) error {
-	session := c.driver.NewSession(ctx, neo4j.SessionConfig{})
-	defer session.Close(ctx)
-
+func (c *Client) CreateUser(ctx context.Context, userID int, username string) (err error) {
 	query := `
 		CREATE (u:Person {userId: $userId, username: $username})
 	`
+	ctx, span := startSpan(ctx, "create_user", query)
+	defer func() { tracing.End(span, err) }()
+
+	session := c.driver.NewSession(ctx, neo4j.SessionConfig{})
+	defer session.Close(ctx)
 
 	params := map[string]any{
 		"userId":   userID,
 		"username": username,
 	}
 
-	_, err := session.Run(ctx, query, params)
+	_, err = session.Run(ctx, query, params)
 	if err != nil {
 		return fmt.Errorf("failed to create user %s: %w", username, err)
 	}
@@ -83,22 +93,24 @@ func (c *Client) CreateUser(ctx context.Context, userID int, username string) er
 }
 
 // CreateConnection creates a KNOWS relationship between two users
-func (c *Client) CreateConnection(ctx context.Context, user1ID, user2ID int) error {
-	session := c.driver.NewSession(ctx, neo4j.SessionConfig{})
-	defer session.Close(ctx)
-
+func (c *Client) CreateConnection(ctx context.Context, user1ID, user2ID int) (err error) {
 	query := `
 		MATCH (u1:Person {userId: $user1Id})
 		MATCH (u2:Person {userId: $user2Id})
 		CREATE (u1)-[:KNOWS]->(u2)
 	`
+	ctx, span := startSpan(ctx, "create_connection", query)
+	defer func() { tracing.End(span, err) }()
+
+	session := c.driver.NewSession(ctx, neo4j.SessionConfig{})
+	defer session.Close(ctx)
 
 	params := map[string]any{
 		"user1Id": user1ID,
 		"user2Id": user2ID,
 	}
 
-	_, err := session.Run(ctx, query, params)
+	_, err = session.Run(ctx, query, params)
 	if err != nil {
 		return fmt.Errorf("failed to create connection between %d and %d: %w", user1ID, user2ID, err)
 	}
@@ -107,14 +119,16 @@ func (c *Client) CreateConnection(ctx context.Context, user1ID, user2ID int) err
 }
 
 // FindShortestPath finds the shortest path between two users
-func (c *Client) FindShortestPath(ctx context.Context, startUsername, endUsername string) ([]string, error) {
-	session := c.driver.NewSession(ctx, neo4j.SessionConfig{})
-	defer session.Close(ctx)
-
+func (c *Client) FindShortestPath(ctx context.Context, startUsername, endUsername string) (_ []string, err error) {
 	query := `
 		MATCH path = shortestPath((start:Person {username: $startUsername})-[:KNOWS*]-(end:Person {username: $endUsername}))
 		RETURN [node in nodes(path) | node.username] as path
 	`
+	ctx, span := startSpan(ctx, "find_shortest_path", query)
+	defer func() { tracing.End(span, err) }()
+
+	session := c.driver.NewSession(ctx, neo4j.SessionConfig{})
+	defer session.Close(ctx)
 
 	params := map[string]any{
 		"startUsername": startUsername,
@@ -158,11 +172,15 @@ func (c *Client) FindShortestPath(ctx context.Context, startUsername, endUsernam
 }
 
 // GetNodeCount returns the total number of nodes in the database
-func (c *Client) GetNodeCount(ctx context.Context) (int, error) {
+func (c *Client) GetNodeCount(ctx context.Context) (_ int, err error) {
+	query := "MATCH (n) RETURN count(n) as count"
+	ctx, span := startSpan(ctx, "node_count", query)
+	defer func() { tracing.End(span, err) }()
+
 	session := c.driver.NewSession(ctx, neo4j.SessionConfig{})
 	defer session.Close(ctx)
 
-	result, err := session.Run(ctx, "MATCH (n) RETURN count(n) as count", nil)
+	result, err := session.Run(ctx, query, nil)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get node count: %w", err)
 	}
@@ -186,11 +204,15 @@ func (c *Client) GetNodeCount(ctx context.Context) (int, error) {
 }
 
 // GetRelationshipCount returns the total number of relationships in the database
-func (c *Client) GetRelationshipCount(ctx context.Context) (int, error) {
+func (c *Client) GetRelationshipCount(ctx context.Context) (_ int, err error) {
+	query := "MATCH ()-[r]->() RETURN count(r) as count"
+	ctx, span := startSpan(ctx, "relationship_count", query)
+	defer func() { tracing.End(span, err) }()
+
 	session := c.driver.NewSession(ctx, neo4j.SessionConfig{})
 	defer session.Close(ctx)
 
-	result, err := session.Run(ctx, "MATCH ()-[r]->() RETURN count(r) as count", nil)
+	result, err := session.Run(ctx, query, nil)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get relationship count: %w", err)
 	}
@@ -212,3 +234,11 @@ func (c *Client) GetRelationshipCount(ctx context.Context) (int, error) {
 
 	return 0, fmt.Errorf("no result returned")
 }
+
+// startSpan starts the span of a Neo4j query
+func startSpan(ctx context.Context, operation, query string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "neo4j "+operation,
+		attribute.String("db.system", "neo4j"),
+		attribute.String("db.operation.name", operation),
+		attribute.String("db.query.text", strings.Join(strings.Fields(query), " ")))
+}
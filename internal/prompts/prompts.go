@@ -0,0 +1,268 @@
+// Package prompts is the library of LLM prompt templates. Prompts are
+// text/template files embedded in the binary under templates/<area>/<name>.tmpl
+// and addressed as "<area>/<name>", e.g. "ocr/extract_text". A directory with
+// the same layout can override any of them at run time, and every prompt
+// carries a version hash of its source that is logged the first time it is used.
+package prompts
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+
+	"ai-devs3/pkg/errors"
+)
+
+// DirEnv names the environment variable pointing at a prompt override directory
+const DirEnv = "PROMPTS_DIR"
+
+// templateExt is the file extension of prompt templates
+const templateExt = ".tmpl"
+
+//go:embed templates
+var embedded embed.FS
+
+// Prompt is a parsed prompt template
+type Prompt struct {
+	Name    string // e.g. "ocr/extract_text"
+	Version string // short hash of the template source
+	Origin  string // "embedded" or the override file path
+	Source  string
+
+	tmpl *template.Template
+}
+
+// Library holds the prompts of a run. A nil *Library serves the embedded
+// prompts, so callers never need to check for one.
+type Library struct {
+	prompts map[string]*Prompt
+
+	mu     sync.Mutex
+	logged map[string]bool
+}
+
+var (
+	defaultMu  sync.RWMutex
+	defaultLib *Library
+
+	embeddedOnce sync.Once
+	embeddedLib  *Library
+	embeddedErr  error
+)
+
+// Load builds a library from the embedded prompts, replaced by any template
+// found under dir. Override files must match an embedded prompt name.
+func Load(dir string) (*Library, error) {
+	base, err := embeddedLibrary()
+	if err != nil {
+		return nil, err
+	}
+
+	prompts := make(map[string]*Prompt, len(base.prompts))
+	for name, prompt := range base.prompts {
+		prompts[name] = prompt
+	}
+	lib := newLibrary(prompts)
+
+	if dir == "" {
+		return lib, nil
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil || !info.IsDir() {
+		return nil, errors.NewConfigError(DirEnv, "prompt directory not found: "+dir, err)
+	}
+
+	overrides, err := readTemplates(os.DirFS(dir), ".", func(name string) string {
+		return path.Join(dir, name+templateExt)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for name, prompt := range overrides {
+		if _, ok := lib.prompts[name]; !ok {
+			return nil, errors.NewConfigError(DirEnv, fmt.Sprintf("%s does not override a known prompt (%s)", prompt.Origin, name), nil)
+		}
+		lib.prompts[name] = prompt
+	}
+
+	return lib, nil
+}
+
+// SetDefault makes lib the library used by the package level Render
+func SetDefault(lib *Library) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultLib = lib
+}
+
+// Default returns the library set with SetDefault, nil when none was set
+func Default() *Library {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultLib
+}
+
+// Render renders a prompt from the default library
+func Render(name string, data any) (string, error) {
+	return Default().Render(name, data)
+}
+
+// Render executes the named prompt with data and logs its version on first use
+func (l *Library) Render(name string, data any) (string, error) {
+	l = l.orEmbedded()
+	if l == nil {
+		return "", errors.NewProcessingError("prompt", name, "embedded prompts failed to load", nil)
+	}
+
+	prompt, ok := l.prompts[name]
+	if !ok {
+		return "", errors.NewProcessingError("prompt", name, "unknown prompt", nil)
+	}
+
+	var b strings.Builder
+	if err := prompt.tmpl.Execute(&b, data); err != nil {
+		return "", errors.NewProcessingError("prompt", name, "failed to render "+prompt.Origin, err)
+	}
+
+	l.logFirstUse(prompt)
+	return b.String(), nil
+}
+
+// Get returns the named prompt
+func (l *Library) Get(name string) (*Prompt, bool) {
+	l = l.orEmbedded()
+	if l == nil {
+		return nil, false
+	}
+	prompt, ok := l.prompts[name]
+	return prompt, ok
+}
+
+// All returns every prompt ordered by name
+func (l *Library) All() []*Prompt {
+	l = l.orEmbedded()
+	if l == nil {
+		return nil
+	}
+
+	all := make([]*Prompt, 0, len(l.prompts))
+	for _, prompt := range l.prompts {
+		all = append(all, prompt)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	return all
+}
+
+// logFirstUse records which prompt version produced the following answers
+func (l *Library) logFirstUse(prompt *Prompt) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.logged[prompt.Name] {
+		return
+	}
+	l.logged[prompt.Name] = true
+	slog.Info("Using prompt", "prompt", prompt.Name, "version", prompt.Version, "origin", prompt.Origin)
+}
+
+// orEmbedded substitutes the shared embedded library for a nil receiver
+func (l *Library) orEmbedded() *Library {
+	if l != nil {
+		return l
+	}
+	lib, err := embeddedLibrary()
+	if err != nil {
+		slog.Warn("Embedded prompts failed to load", "error", err)
+	}
+	return lib
+}
+
+// embeddedLibrary parses the embedded prompts once
+func embeddedLibrary() (*Library, error) {
+	embeddedOnce.Do(func() {
+		sub, err := fs.Sub(embedded, "templates")
+		if err != nil {
+			embeddedErr = err
+			return
+		}
+
+		prompts, err := readTemplates(sub, ".", func(string) string { return "embedded" })
+		if err != nil {
+			embeddedErr = err
+			return
+		}
+		embeddedLib = newLibrary(prompts)
+	})
+	return embeddedLib, embeddedErr
+}
+
+// newLibrary wraps a set of prompts
+func newLibrary(prompts map[string]*Prompt) *Library {
+	return &Library{
+		prompts: prompts,
+		logged:  make(map[string]bool),
+	}
+}
+
+// readTemplates parses every template file below root, naming each prompt
+// after its path without the extension
+func readTemplates(fsys fs.FS, root string, origin func(name string) string) (map[string]*Prompt, error) {
+	prompts := make(map[string]*Prompt)
+
+	err := fs.WalkDir(fsys, root, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || path.Ext(file) != templateExt {
+			return nil
+		}
+
+		source, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+
+		// The newline ending the file is not part of the prompt
+		name := strings.TrimSuffix(file, templateExt)
+		prompt, err := parse(name, strings.TrimSuffix(string(source), "\n"), origin(name))
+		if err != nil {
+			return err
+		}
+		prompts[name] = prompt
+		return nil
+	})
+	if err != nil {
+		return nil, errors.NewConfigError(DirEnv, "failed to load prompts", err)
+	}
+
+	return prompts, nil
+}
+
+// parse compiles a prompt template. Missing variables are errors rather than
+// "<no value>" silently ending up in a prompt.
+func parse(name, source, origin string) (*Prompt, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", origin, err)
+	}
+
+	hash := sha256.Sum256([]byte(source))
+	return &Prompt{
+		Name:    name,
+		Version: hex.EncodeToString(hash[:])[:8],
+		Origin:  origin,
+		Source:  source,
+		tmpl:    tmpl,
+	}, nil
+}
@@ -3,24 +3,94 @@ package cache
 import (
 	"context"
 	"crypto/sha256"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"ai-devs3/internal/config"
-	"ai-devs3/pkg/errors"
+	"ai-devs3/internal/logging"
 )
 
+// metaSuffix is appended to the file name of an entry for its metadata file
+const metaSuffix = ".meta.json"
+
+// lockDir is the subdirectory holding the lock file of each key
+const lockDir = ".locks"
+
+// lockPollInterval is how often a lock held by another process is retried
+const lockPollInterval = 50 * time.Millisecond
+
+// tempSuffix ends the name of a file being written
+const tempSuffix = ".tmp"
+
 // Cache defines the interface for caching operations
 type Cache interface {
-	Get(ctx context.Context, key string) ([]byte, error)
+	// Get returns the data stored under key and whether there was any; a
+	// missing or expired entry is a miss, not an error
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores data under key with the default TTL of the cache
 	Set(ctx context.Context, key string, data []byte) error
+	// SetWithTTL stores data under key for ttl; 0 keeps it until evicted
+	SetWithTTL(ctx context.Context, key string, data []byte, ttl time.Duration) error
+	// GetOrCompute returns the data stored under key, computing and storing it
+	// on a miss, and whether it came from the cache
+	GetOrCompute(ctx context.Context, key string, compute func(context.Context) ([]byte, error)) ([]byte, bool, error)
+	Delete(ctx context.Context, key string) error
+	// List returns the entries whose key starts with prefix, ordered by key
+	List(ctx context.Context, prefix string) ([]Entry, error)
+	Stats() Stats
+}
+
+// Entry describes a cached value
+type Entry struct {
+	Key       string    `json:"key"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at,omitzero"` // zero when the entry never expires
+	UsedAt    time.Time `json:"-"`                   // last read or write, for LRU eviction
+}
+
+// Expired reports whether the entry is past its TTL at now
+func (e Entry) Expired(now time.Time) bool {
+	return !e.ExpiresAt.IsZero() && now.After(e.ExpiresAt)
+}
+
+// Stats counts the lookups of a cache since it was opened
+type Stats struct {
+	Hits   int64
+	Misses int64
 }
 
-// FileCache implements Cache interface using file system
+// HitRate returns the share of lookups answered from the cache, 0 without lookups
+func (s Stats) HitRate() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// FileCache implements Cache interface using file system. Each entry is a
+// data file next to a metadata file holding its key and expiry; the
+// modification time of the data file records its last use. Writers of a key
+// hold an advisory lock on its lock file, so goroutines and processes sharing
+// the directory do not interleave their writes.
 type FileCache struct {
 	baseDir string
+	ttl     time.Duration
+	maxSize int64
+
+	hits   atomic.Int64
+	misses atomic.Int64
+
+	evictMu sync.Mutex
+	flight  flight
 }
 
 // NewFileCache creates a new file-based cache
@@ -32,61 +102,322 @@ func NewFileCache(cfg config.CacheConfig) (*FileCache, error) {
 
 	return &FileCache{
 		baseDir: cfg.BaseDir,
+		ttl:     cfg.TTL,
+		maxSize: cfg.MaxSize,
 	}, nil
 }
 
 // Get retrieves data from cache
-func (f *FileCache) Get(ctx context.Context, key string) ([]byte, error) {
+func (f *FileCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
 	select {
 	case <-ctx.Done():
-		return nil, ctx.Err()
+		return nil, false, ctx.Err()
 	default:
 	}
 
-	filename := f.keyToFilename(key)
-	filePath := filepath.Join(f.baseDir, filename)
+	filePath := filepath.Join(f.baseDir, f.keyToFilename(key))
+
+	// Entries written before metadata existed have none and never expire
+	if entry, err := readMeta(filePath + metaSuffix); err == nil && entry.Expired(time.Now()) {
+		f.remove(filePath)
+		f.misses.Add(1)
+		return nil, false, nil
+	}
 
 	data, err := os.ReadFile(filePath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, errors.NewProcessingError("cache", key, "cache miss", err)
+			f.misses.Add(1)
+			return nil, false, nil
 		}
-		return nil, fmt.Errorf("failed to read cache file: %w", err)
+		return nil, false, fmt.Errorf("failed to read cache file: %w", err)
 	}
 
-	return data, nil
+	// Mark the entry as recently used; failing to do so only affects eviction order
+	now := time.Now()
+	os.Chtimes(filePath, now, now)
+
+	f.hits.Add(1)
+	return data, true, nil
 }
 
 // Set stores data in cache
 func (f *FileCache) Set(ctx context.Context, key string, data []byte) error {
+	return f.SetWithTTL(ctx, key, data, f.ttl)
+}
+
+// SetWithTTL stores data in cache until ttl has passed
+func (f *FileCache) SetWithTTL(ctx context.Context, key string, data []byte, ttl time.Duration) error {
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
 	default:
 	}
 
-	filename := f.keyToFilename(key)
-	filePath := filepath.Join(f.baseDir, filename)
+	unlock, err := f.lock(ctx, key)
+	if err != nil {
+		return err
+	}
+	err = f.write(key, data, ttl)
+	unlock()
+	if err != nil {
+		return err
+	}
+
+	return f.evict(ctx)
+}
+
+// GetOrCompute returns the data stored under key, or runs compute and stores
+// its result on a miss. Concurrent calls for the same key compute it once: in
+// this process later callers wait for the first one, and other processes wait
+// for the lock of the key and then find the stored result. Waiting of either
+// kind ends with ctx.
+func (f *FileCache) GetOrCompute(ctx context.Context, key string, compute func(context.Context) ([]byte, error)) ([]byte, bool, error) {
+	data, cached, shared, err := f.flight.do(ctx, key, func() ([]byte, bool, error) {
+		unlock, err := f.lock(ctx, key)
+		if err != nil {
+			return nil, false, err
+		}
+		defer unlock()
+
+		if data, ok, err := f.Get(ctx, key); err != nil || ok {
+			return data, ok, err
+		}
+
+		data, err := compute(ctx)
+		if err != nil {
+			return nil, false, err
+		}
+
+		// The result is still good when it cannot be stored
+		if err := f.write(key, data, f.ttl); err != nil {
+			logging.FromContext(ctx).Warn("Failed to cache computed value", "key", key, "error", err)
+		}
+		return data, false, nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	// A caller that waited for another one's computation did not pay for it
+	if shared {
+		f.hits.Add(1)
+		return data, true, nil
+	}
+	if !cached {
+		if err := f.evict(ctx); err != nil {
+			logging.FromContext(ctx).Warn("Failed to evict cache entries", "error", err)
+		}
+	}
+	return data, cached, nil
+}
+
+// write stores the data and metadata files of an entry; the caller holds the
+// lock of key
+func (f *FileCache) write(key string, data []byte, ttl time.Duration) error {
+	filePath := filepath.Join(f.baseDir, f.keyToFilename(key))
 
 	// Ensure parent directory exists
 	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
 		return fmt.Errorf("failed to create cache subdirectory: %w", err)
 	}
 
-	// Write to temporary file first, then rename for atomicity
-	tempFile := filePath + ".tmp"
-	if err := os.WriteFile(tempFile, data, 0644); err != nil {
+	entry := Entry{Key: key, Size: int64(len(data)), CreatedAt: time.Now()}
+	if ttl > 0 {
+		entry.ExpiresAt = entry.CreatedAt.Add(ttl)
+	}
+	meta, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache metadata: %w", err)
+	}
+
+	if err := writeFile(filePath, data); err != nil {
 		return fmt.Errorf("failed to write cache file: %w", err)
 	}
+	if err := writeFile(filePath+metaSuffix, meta); err != nil {
+		return fmt.Errorf("failed to write cache metadata: %w", err)
+	}
 
-	if err := os.Rename(tempFile, filePath); err != nil {
-		os.Remove(tempFile) // Clean up on error
-		return fmt.Errorf("failed to rename cache file: %w", err)
+	return nil
+}
+
+// Delete removes an entry from cache; deleting a missing entry is not an error
+func (f *FileCache) Delete(ctx context.Context, key string) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	unlock, err := f.lock(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return f.remove(filepath.Join(f.baseDir, f.keyToFilename(key)))
+}
+
+// List returns the entries whose key starts with prefix. Only files with
+// metadata are entries: task data and anything else sharing the directory
+// is never listed, so purging and eviction leave it alone.
+func (f *FileCache) List(ctx context.Context, prefix string) ([]Entry, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	dirEntries, err := os.ReadDir(f.baseDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	var entries []Entry
+	for _, dirEntry := range dirEntries {
+		name := dirEntry.Name()
+		if !dirEntry.Type().IsRegular() || strings.HasPrefix(name, ".") ||
+			strings.HasSuffix(name, metaSuffix) || strings.HasSuffix(name, tempSuffix) {
+			continue
+		}
+
+		info, err := dirEntry.Info()
+		if err != nil {
+			continue
+		}
+
+		entry, err := readMeta(filepath.Join(f.baseDir, name+metaSuffix))
+		if err != nil || !strings.HasPrefix(entry.Key, prefix) {
+			continue
+		}
+
+		entry.Size = info.Size()
+		entry.UsedAt = info.ModTime()
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries, nil
+}
+
+// Peek returns the data stored under key like Get, but leaves the entry as
+// it is: its use time is not updated, an expired entry is returned rather
+// than removed, and neither a hit nor a miss is counted
+func (f *FileCache) Peek(ctx context.Context, key string) ([]byte, bool, error) {
+	select {
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
+	default:
+	}
+
+	data, err := os.ReadFile(filepath.Join(f.baseDir, f.keyToFilename(key)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("failed to read cache file: %w", err)
+	}
+	return data, true, nil
+}
+
+// Stats returns the hits and misses of Get since the cache was opened
+func (f *FileCache) Stats() Stats {
+	return Stats{Hits: f.hits.Load(), Misses: f.misses.Load()}
+}
+
+// evict removes expired entries and, when the cache is bounded, the least
+// recently used ones until its entries fit into maxSize. Entries are removed
+// without taking their lock, as the caller may hold the lock of one of them.
+func (f *FileCache) evict(ctx context.Context) error {
+	if f.maxSize <= 0 {
+		return nil
+	}
+
+	f.evictMu.Lock()
+	defer f.evictMu.Unlock()
+
+	entries, err := f.List(ctx, "")
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var live []Entry
+	var size int64
+	for _, entry := range entries {
+		if entry.Expired(now) {
+			f.remove(filepath.Join(f.baseDir, f.keyToFilename(entry.Key)))
+			continue
+		}
+		live = append(live, entry)
+		size += entry.Size
+	}
+
+	sort.Slice(live, func(i, j int) bool { return live[i].UsedAt.Before(live[j].UsedAt) })
+	for _, entry := range live {
+		if size <= f.maxSize {
+			break
+		}
+		if err := f.remove(filepath.Join(f.baseDir, f.keyToFilename(entry.Key))); err != nil {
+			return err
+		}
+		size -= entry.Size
 	}
 
 	return nil
 }
 
+// lock takes the advisory lock of key and returns the func releasing it.
+// While another process holds the lock it polls every lockPollInterval, so
+// waiting ends with ctx.
+func (f *FileCache) lock(ctx context.Context, key string) (func(), error) {
+	dir := filepath.Join(f.baseDir, lockDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache lock directory: %w", err)
+	}
+
+	file, err := os.OpenFile(filepath.Join(dir, f.keyToFilename(key)+".lock"), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cache lock: %w", err)
+	}
+
+	for {
+		locked, err := tryLockFile(file)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to lock cache entry: %w", err)
+		}
+		if locked {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			file.Close()
+			return nil, ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+
+	return func() {
+		unlockFile(file)
+		file.Close()
+	}, nil
+}
+
+// remove deletes the data and metadata files of an entry
+func (f *FileCache) remove(filePath string) error {
+	for _, path := range []string{filePath, filePath + metaSuffix} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove cache file: %w", err)
+		}
+	}
+	return nil
+}
+
 // keyToFilename converts cache key to safe filename
 func (f *FileCache) keyToFilename(key string) string {
 	// Replace path separators and other unsafe characters
@@ -109,6 +440,44 @@ func (f *FileCache) keyToFilename(key string) string {
 	return safe
 }
 
+// readMeta reads the metadata file of an entry
+func readMeta(path string) (Entry, error) {
+	var entry Entry
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return entry, err
+	}
+	err = json.Unmarshal(data, &entry)
+	return entry, err
+}
+
+// writeFile writes to a uniquely named temporary file first, then renames it
+// for atomicity
+func writeFile(path string, data []byte) error {
+	temp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*"+tempSuffix)
+	if err != nil {
+		return err
+	}
+	tempFile := temp.Name()
+
+	_, err = temp.Write(data)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tempFile, 0644)
+	}
+	if err == nil {
+		err = os.Rename(tempFile, path)
+	}
+	if err != nil {
+		os.Remove(tempFile) // Clean up on error
+		return err
+	}
+
+	return nil
+}
+
 // CacheKey generates a consistent cache key from components
 func CacheKey(components ...string) string {
 	return strings.Join(components, "_")
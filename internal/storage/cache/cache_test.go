@@ -0,0 +1,229 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"ai-devs3/internal/config"
+)
+
+// newTestCache opens a cache in a fresh directory
+func newTestCache(t *testing.T, maxSize int64) *FileCache {
+	t.Helper()
+	c, err := NewFileCache(config.CacheConfig{BaseDir: t.TempDir(), MaxSize: maxSize})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestFileCacheTTL(t *testing.T) {
+	tests := []struct {
+		name    string
+		ttl     time.Duration
+		wait    time.Duration
+		wantHit bool
+	}{
+		{"no TTL never expires", 0, 10 * time.Millisecond, true},
+		{"within TTL", time.Hour, 0, true},
+		{"past TTL", time.Millisecond, 10 * time.Millisecond, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			c := newTestCache(t, 0)
+
+			if err := c.SetWithTTL(ctx, "key", []byte("value"), tt.ttl); err != nil {
+				t.Fatal(err)
+			}
+			time.Sleep(tt.wait)
+
+			data, ok, err := c.Get(ctx, "key")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.wantHit {
+				t.Fatalf("Get() hit = %v, want %v", ok, tt.wantHit)
+			}
+			if ok && string(data) != "value" {
+				t.Errorf("Get() = %q, want %q", data, "value")
+			}
+
+			// An expired entry is removed when it is read
+			_, err = os.Stat(filepath.Join(c.baseDir, "key"))
+			if exists := err == nil; exists != tt.wantHit {
+				t.Errorf("data file exists = %v, want %v", exists, tt.wantHit)
+			}
+
+			want := Stats{Hits: 1}
+			if !tt.wantHit {
+				want = Stats{Misses: 1}
+			}
+			if stats := c.Stats(); stats != want {
+				t.Errorf("Stats() = %+v, want %+v", stats, want)
+			}
+		})
+	}
+}
+
+func TestFileCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	c := newTestCache(t, 10)
+
+	for _, key := range []string{"a", "b"} {
+		if err := c.Set(ctx, key, []byte("1234")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a was used before b, but reading it makes it the most recently used
+	// A file without metadata is no entry, however large and old it is
+	notes := filepath.Join(c.baseDir, "notes.txt")
+	if err := os.WriteFile(notes, []byte("not part of the cache"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, age := range map[string]time.Duration{"a": 2 * time.Hour, "b": time.Hour, "notes.txt": 3 * time.Hour} {
+		used := time.Now().Add(-age)
+		os.Chtimes(filepath.Join(c.baseDir, key), used, used)
+	}
+	if _, ok, _ := c.Get(ctx, "a"); !ok {
+		t.Fatal("a missing before eviction")
+	}
+
+	if err := c.Set(ctx, "c", []byte("1234")); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := c.List(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := keys(entries), []string{"a", "c"}; !slices.Equal(got, want) {
+		t.Errorf("keys after eviction = %v, want %v", got, want)
+	}
+	if _, err := os.Stat(notes); err != nil {
+		t.Errorf("eviction removed a file without metadata: %v", err)
+	}
+}
+
+func TestFileCacheEvictsExpiredEntries(t *testing.T) {
+	ctx := context.Background()
+	c := newTestCache(t, 1<<20)
+
+	if err := c.SetWithTTL(ctx, "stale", []byte("old"), time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if err := c.Set(ctx, "fresh", []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := c.List(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := keys(entries), []string{"fresh"}; !slices.Equal(got, want) {
+		t.Errorf("keys after eviction = %v, want %v", got, want)
+	}
+}
+
+func TestFileCacheList(t *testing.T) {
+	ctx := context.Background()
+	c := newTestCache(t, 0)
+
+	for _, key := range []string{"s01e01_answer", "s01e01/page", "s02e01_transcript"} {
+		if err := c.SetWithTTL(ctx, key, []byte("data"), time.Hour); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Files that are no entries: they have no metadata
+	files := map[string]string{
+		"notes.txt":                      "unrelated file in the directory",
+		".hidden":                        "dot file",
+		"s01e01_answer.123" + tempSuffix: "unfinished write",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(c.baseDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(c.baseDir, "s01e01_dir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{"", []string{"s01e01/page", "s01e01_answer", "s02e01_transcript"}},
+		{"s01e01", []string{"s01e01/page", "s01e01_answer"}},
+		{"notes", nil},
+		{"s03", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			entries, err := c.List(ctx, tt.prefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := keys(entries); !slices.Equal(got, tt.want) {
+				t.Errorf("List(%q) = %v, want %v", tt.prefix, got, tt.want)
+			}
+			for _, entry := range entries {
+				if entry.Size != 4 || entry.ExpiresAt.IsZero() {
+					t.Errorf("entry = %+v, want size 4 and an expiry", entry)
+				}
+			}
+		})
+	}
+}
+
+func TestFileCachePeek(t *testing.T) {
+	ctx := context.Background()
+	c := newTestCache(t, 0)
+
+	if err := c.SetWithTTL(ctx, "key", []byte("value"), time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(c.baseDir, "key")
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	os.Chtimes(path, old, old)
+	time.Sleep(10 * time.Millisecond)
+
+	// Peeking at an expired entry neither removes nor touches it
+	data, ok, err := c.Peek(ctx, "key")
+	if err != nil || !ok || string(data) != "value" {
+		t.Fatalf("Peek() = %q, %v, %v, want %q", data, ok, err, "value")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("entry removed by Peek: %v", err)
+	}
+	if !info.ModTime().Equal(old) {
+		t.Errorf("Peek() changed the use time to %v", info.ModTime())
+	}
+
+	if _, ok, err := c.Peek(ctx, "missing"); ok || err != nil {
+		t.Errorf("Peek(missing) = %v, %v, want a miss", ok, err)
+	}
+	if stats := c.Stats(); stats != (Stats{}) {
+		t.Errorf("Stats() = %+v, want no lookups counted", stats)
+	}
+}
+
+// keys returns the keys of entries in order
+func keys(entries []Entry) []string {
+	var keys []string
+	for _, entry := range entries {
+		keys = append(keys, entry.Key)
+	}
+	return keys
+}
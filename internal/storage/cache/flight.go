@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// flight deduplicates concurrent computations of the same key: the first
+// caller runs the computation and later callers wait for its result
+type flight struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+// call is a computation in flight
+type call struct {
+	done   chan struct{}
+	data   []byte
+	cached bool
+	err    error
+}
+
+// do runs fn for key unless a call for key is already running, in which case
+// it waits for that call instead. shared reports whether the result came from
+// another caller. A call that failed because its caller's context ended says
+// nothing about the waiters, so those still waiting run fn again.
+func (g *flight) do(ctx context.Context, key string, fn func() ([]byte, bool, error)) (data []byte, cached, shared bool, err error) {
+	for {
+		g.mu.Lock()
+		if g.calls == nil {
+			g.calls = make(map[string]*call)
+		}
+		c, ok := g.calls[key]
+		if !ok {
+			break
+		}
+		g.mu.Unlock()
+
+		select {
+		case <-c.done:
+			if isContextError(c.err) && ctx.Err() == nil {
+				continue
+			}
+			return c.data, c.cached, true, c.err
+		case <-ctx.Done():
+			return nil, false, false, ctx.Err()
+		}
+	}
+	c := &call{done: make(chan struct{})}
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	c.data, c.cached, c.err = fn()
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+	close(c.done)
+
+	return c.data, c.cached, false, c.err
+}
+
+// isContextError reports whether err comes from a canceled or expired context
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,182 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetOrComputeDeduplicates(t *testing.T) {
+	ctx := context.Background()
+	c := newTestCache(t, 0)
+
+	const callers = 8
+	var computed atomic.Int32
+	release := make(chan struct{})
+	compute := func(context.Context) ([]byte, error) {
+		computed.Add(1)
+		<-release
+		return []byte("value"), nil
+	}
+
+	var wg sync.WaitGroup
+	var fromCache atomic.Int32
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data, cached, err := c.GetOrCompute(ctx, "key", compute)
+			if err != nil || string(data) != "value" {
+				t.Errorf("GetOrCompute() = %q, %v, want %q", data, err, "value")
+			}
+			if cached {
+				fromCache.Add(1)
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := computed.Load(); got != 1 {
+		t.Errorf("computed %d times, want once", got)
+	}
+	if got := fromCache.Load(); got != callers-1 {
+		t.Errorf("%d callers got a cached value, want %d", got, callers-1)
+	}
+
+	// Later calls read the stored value
+	data, cached, err := c.GetOrCompute(ctx, "key", compute)
+	if err != nil || !cached || string(data) != "value" {
+		t.Errorf("GetOrCompute() = %q, %v, %v, want the cached value", data, cached, err)
+	}
+	if got := computed.Load(); got != 1 {
+		t.Errorf("computed %d times, want once", got)
+	}
+}
+
+func TestGetOrComputeDoesNotCacheErrors(t *testing.T) {
+	ctx := context.Background()
+	c := newTestCache(t, 0)
+	failure := errors.New("compute failed")
+
+	_, _, err := c.GetOrCompute(ctx, "key", func(context.Context) ([]byte, error) { return nil, failure })
+	if !errors.Is(err, failure) {
+		t.Fatalf("GetOrCompute() error = %v, want %v", err, failure)
+	}
+
+	data, cached, err := c.GetOrCompute(ctx, "key", func(context.Context) ([]byte, error) { return []byte("value"), nil })
+	if err != nil || cached || string(data) != "value" {
+		t.Errorf("GetOrCompute() = %q, %v, %v, want a fresh computation", data, cached, err)
+	}
+}
+
+func TestFlight(t *testing.T) {
+	leaderFailure := errors.New("leader failed")
+
+	tests := []struct {
+		name       string
+		leaderErr  func(ctx context.Context) error
+		cancelLead bool
+		wantRerun  bool
+		wantErr    error
+	}{
+		{
+			name:      "waiter shares the leader's result",
+			leaderErr: func(context.Context) error { return nil },
+		},
+		{
+			name:      "waiter shares the leader's failure",
+			leaderErr: func(context.Context) error { return leaderFailure },
+			wantErr:   leaderFailure,
+		},
+		{
+			name:       "waiter runs again when the leader's context ended",
+			leaderErr:  func(ctx context.Context) error { <-ctx.Done(); return ctx.Err() },
+			cancelLead: true,
+			wantRerun:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var g flight
+			leaderCtx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			started := make(chan struct{})
+			release := make(chan struct{})
+			leaderDone := make(chan struct{})
+			go func() {
+				defer close(leaderDone)
+				g.do(leaderCtx, "key", func() ([]byte, bool, error) {
+					close(started)
+					<-release
+					if err := tt.leaderErr(leaderCtx); err != nil {
+						return nil, false, err
+					}
+					return []byte("leader"), false, nil
+				})
+			}()
+			<-started
+
+			type result struct {
+				data   []byte
+				shared bool
+				err    error
+			}
+			waiter := make(chan result)
+			go func() {
+				data, _, shared, err := g.do(context.Background(), "key", func() ([]byte, bool, error) {
+					return []byte("waiter"), false, nil
+				})
+				waiter <- result{data, shared, err}
+			}()
+
+			// Let the waiter block on the leader's call before it ends
+			time.Sleep(20 * time.Millisecond)
+			if tt.cancelLead {
+				cancel()
+			}
+			close(release)
+			<-leaderDone
+			got := <-waiter
+
+			if !errors.Is(got.err, tt.wantErr) {
+				t.Fatalf("do() error = %v, want %v", got.err, tt.wantErr)
+			}
+			if got.shared == tt.wantRerun {
+				t.Errorf("do() shared = %v, want %v", got.shared, !tt.wantRerun)
+			}
+			if want := map[bool]string{true: "waiter", false: "leader"}[tt.wantRerun]; tt.wantErr == nil && string(got.data) != want {
+				t.Errorf("do() = %q, want %q", got.data, want)
+			}
+		})
+	}
+}
+
+func TestFlightWaiterGivesUpWithItsContext(t *testing.T) {
+	var g flight
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	go g.do(context.Background(), "key", func() ([]byte, bool, error) {
+		close(started)
+		<-release
+		return nil, false, nil
+	})
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, _, _, err := g.do(ctx, "key", func() ([]byte, bool, error) {
+		t.Error("waiter ran the computation while the leader was still running")
+		return nil, false, nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("do() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
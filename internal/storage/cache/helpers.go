@@ -4,7 +4,8 @@ import (
 	"context"
 )
 
-// TaskCache provides task-specific cache operations
+// TaskCache provides task-specific cache operations. A nil *TaskCache never
+// hits and never stores, so tasks can run without a cache.
 type TaskCache struct {
 	cache   Cache
 	taskID  string
@@ -21,33 +22,95 @@ func NewTaskCache(cache Cache, taskID string) *TaskCache {
 }
 
 // GetAudioTranscript retrieves cached audio transcript
-func (t *TaskCache) GetAudioTranscript(ctx context.Context, audioKey string) (string, error) {
-	key := CacheKey(t.taskID, "audio", audioKey, "transcript")
-	data, err := t.cache.Get(ctx, key)
-	if err != nil {
-		return "", err
-	}
-	return string(data), nil
+func (t *TaskCache) GetAudioTranscript(ctx context.Context, audioKey string) (string, bool, error) {
+	return t.get(ctx, "audio", audioKey, "transcript")
 }
 
 // SetAudioTranscript stores audio transcript in cache
 func (t *TaskCache) SetAudioTranscript(ctx context.Context, audioKey, transcript string) error {
-	key := CacheKey(t.taskID, "audio", audioKey, "transcript")
-	return t.cache.Set(ctx, key, []byte(transcript))
+	return t.set(ctx, transcript, "audio", audioKey, "transcript")
+}
+
+// GetOrComputeAudioTranscript returns the cached audio transcript, or
+// transcribes the audio once however many workers ask for it
+func (t *TaskCache) GetOrComputeAudioTranscript(ctx context.Context, audioKey string, transcribe func(context.Context) (string, error)) (string, bool, error) {
+	return t.getOrCompute(ctx, transcribe, "audio", audioKey, "transcript")
 }
 
 // GetOCRText retrieves cached OCR text
-func (t *TaskCache) GetOCRText(ctx context.Context, imageKey string) (string, error) {
-	key := CacheKey(t.taskID, "image", imageKey, "ocr")
-	data, err := t.cache.Get(ctx, key)
-	if err != nil {
-		return "", err
-	}
-	return string(data), nil
+func (t *TaskCache) GetOCRText(ctx context.Context, imageKey string) (string, bool, error) {
+	return t.get(ctx, "image", imageKey, "ocr")
 }
 
 // SetOCRText stores OCR text in cache
 func (t *TaskCache) SetOCRText(ctx context.Context, imageKey, text string) error {
-	key := CacheKey(t.taskID, "image", imageKey, "ocr")
-	return t.cache.Set(ctx, key, []byte(text))
+	return t.set(ctx, text, "image", imageKey, "ocr")
+}
+
+// GetOrComputeOCRText returns the cached OCR text, or extracts it once
+// however many workers ask for it
+func (t *TaskCache) GetOrComputeOCRText(ctx context.Context, imageKey string, extract func(context.Context) (string, error)) (string, bool, error) {
+	return t.getOrCompute(ctx, extract, "image", imageKey, "ocr")
+}
+
+// GetImageDescription retrieves cached image description
+func (t *TaskCache) GetImageDescription(ctx context.Context, imageKey string) (string, bool, error) {
+	return t.get(ctx, "image", imageKey, "description")
+}
+
+// SetImageDescription stores image description in cache
+func (t *TaskCache) SetImageDescription(ctx context.Context, imageKey, description string) error {
+	return t.set(ctx, description, "image", imageKey, "description")
+}
+
+// Stats returns the hits and misses of the underlying cache
+func (t *TaskCache) Stats() Stats {
+	if t == nil {
+		return Stats{}
+	}
+	return t.cache.Stats()
+}
+
+// get reads the text entry keyed by the task ID and parts
+func (t *TaskCache) get(ctx context.Context, parts ...string) (string, bool, error) {
+	if t == nil {
+		return "", false, nil
+	}
+
+	data, ok, err := t.cache.Get(ctx, t.key(parts))
+	if err != nil || !ok {
+		return "", false, err
+	}
+	return string(data), true, nil
+}
+
+// set stores value as the text entry keyed by the task ID and parts
+func (t *TaskCache) set(ctx context.Context, value string, parts ...string) error {
+	if t == nil {
+		return nil
+	}
+	return t.cache.Set(ctx, t.key(parts), []byte(value))
+}
+
+// getOrCompute reads the text entry keyed by the task ID and parts, running
+// compute on a miss; without a cache compute always runs
+func (t *TaskCache) getOrCompute(ctx context.Context, compute func(context.Context) (string, error), parts ...string) (string, bool, error) {
+	if t == nil {
+		value, err := compute(ctx)
+		return value, false, err
+	}
+
+	data, cached, err := t.cache.GetOrCompute(ctx, t.key(parts), func(ctx context.Context) ([]byte, error) {
+		value, err := compute(ctx)
+		return []byte(value), err
+	})
+	if err != nil {
+		return "", false, err
+	}
+	return string(data), cached, nil
+}
+
+// key prefixes the parts of a key with the task ID
+func (t *TaskCache) key(parts []string) string {
+	return CacheKey(append([]string{t.taskID}, parts...)...)
 }
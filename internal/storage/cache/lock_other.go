@@ -0,0 +1,17 @@
+//go:build !unix
+
+package cache
+
+import "os"
+
+// tryLockFile always succeeds where advisory locks are unavailable;
+// concurrent writers in one process are still serialized by the flight of
+// FileCache
+func tryLockFile(file *os.File) (bool, error) {
+	return true, nil
+}
+
+// unlockFile does nothing where advisory locks are unavailable
+func unlockFile(file *os.File) error {
+	return nil
+}
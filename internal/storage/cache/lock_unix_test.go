@@ -0,0 +1,42 @@
+//go:build unix
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLockWaitEndsWithContext(t *testing.T) {
+	c := newTestCache(t, 0)
+
+	// A second open file stands in for another process holding the lock
+	unlock, err := c.lock(context.Background(), "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*lockPollInterval)
+	defer cancel()
+	if _, err := c.lock(ctx, "key"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("lock() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// Other keys are not blocked, and the key is free once released
+	unlockOther, err := c.lock(context.Background(), "other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlockOther()
+
+	unlock()
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	unlock, err = c.lock(ctx, "key")
+	if err != nil {
+		t.Fatalf("lock() after release error = %v", err)
+	}
+	unlock()
+}
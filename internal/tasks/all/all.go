@@ -0,0 +1,25 @@
+// Package all imports every task package so that each registers itself with
+// the tasks registry. Import it for side effects only.
+package all
+
+import (
+	_ "ai-devs3/internal/tasks/s01/e01"
+	_ "ai-devs3/internal/tasks/s01/e02"
+	_ "ai-devs3/internal/tasks/s01/e03"
+	_ "ai-devs3/internal/tasks/s01/e05"
+	_ "ai-devs3/internal/tasks/s02/e01"
+	_ "ai-devs3/internal/tasks/s02/e02"
+	_ "ai-devs3/internal/tasks/s02/e03"
+	_ "ai-devs3/internal/tasks/s02/e04"
+	_ "ai-devs3/internal/tasks/s02/e05"
+	_ "ai-devs3/internal/tasks/s03/e01"
+	_ "ai-devs3/internal/tasks/s03/e02"
+	_ "ai-devs3/internal/tasks/s03/e03"
+	_ "ai-devs3/internal/tasks/s03/e04"
+	_ "ai-devs3/internal/tasks/s03/e05"
+	_ "ai-devs3/internal/tasks/s04/e01"
+	_ "ai-devs3/internal/tasks/s04/e02"
+	_ "ai-devs3/internal/tasks/utils/mockserver"
+	_ "ai-devs3/internal/tasks/utils/ocr"
+	_ "ai-devs3/internal/tasks/utils/video"
+)
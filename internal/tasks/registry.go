@@ -0,0 +1,207 @@
+// Package tasks is the registry of runnable tasks and utilities. Each task
+// package registers itself from init, and the CLI builds its commands, the
+// task list and pre-run requirement checks from the registry.
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+
+	"ai-devs3/internal/config"
+	"ai-devs3/internal/llm"
+	"ai-devs3/internal/tracing"
+	pkgerrors "ai-devs3/pkg/errors"
+
+	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Requirement is an external service or tool a task needs to run
+type Requirement string
+
+// Requirements checked before a task runs
+const (
+	RequireAIDevs Requirement = "aidevs" // AI_DEVS_API_KEY for Centrala
+	RequireOpenAI Requirement = "openai" // OPENAI_API_KEY
+	RequireLLM    Requirement = "llm"    // the provider selected by LLM_PROVIDER
+	RequireOllama Requirement = "ollama" // a reachable OLLAMA_BASE_URL
+	RequireQdrant Requirement = "qdrant" // QDRANT_API_KEY
+	RequireNeo4j  Requirement = "neo4j"  // NEO4J_PASSWORD
+	RequireFFmpeg Requirement = "ffmpeg" // ffmpeg on PATH
+	RequireYTDLP  Requirement = "yt-dlp" // yt-dlp on PATH
+)
+
+// Task describes a registered task or utility command
+type Task struct {
+	ID         string        // command name, e.g. "s01e01"
+	Title      string        // one-line description for the task list
+	Season     int           // course season; 0 marks a utility
+	Requires   []Requirement // checked before the command runs
+	NewCommand func(cfg *config.Config) *cobra.Command
+}
+
+var (
+	mu       sync.RWMutex
+	registry = make(map[string]Task)
+)
+
+// Register adds a task to the registry. It panics when the ID is empty or
+// already registered, since both are programming errors.
+func Register(task Task) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if task.ID == "" || task.NewCommand == nil {
+		panic("tasks: Register requires an ID and a NewCommand func")
+	}
+	if _, exists := registry[task.ID]; exists {
+		panic("tasks: Register called twice for " + task.ID)
+	}
+	registry[task.ID] = task
+}
+
+// All returns every registered task ordered by season and ID, utilities last
+func All() []Task {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	all := make([]Task, 0, len(registry))
+	for _, task := range registry {
+		all = append(all, task)
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Utility() != all[j].Utility() {
+			return !all[i].Utility()
+		}
+		if all[i].Season != all[j].Season {
+			return all[i].Season < all[j].Season
+		}
+		return all[i].ID < all[j].ID
+	})
+
+	return all
+}
+
+// Lookup returns the task registered under id
+func Lookup(id string) (Task, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	task, ok := registry[id]
+	return task, ok
+}
+
+// Utility reports whether the task is a general utility rather than a course task
+func (t Task) Utility() bool {
+	return t.Season == 0
+}
+
+// Command builds the task's cobra command, documents its requirements in the
+// help text and checks them before the command runs. The run is traced as a
+// single span that the spans of its steps and calls hang from.
+func (t Task) Command(cfg *config.Config) *cobra.Command {
+	cmd := t.NewCommand(cfg)
+
+	if cmd.Annotations == nil {
+		cmd.Annotations = make(map[string]string)
+	}
+	cmd.Annotations["title"] = t.Title
+	cmd.Annotations["season"] = fmt.Sprint(t.Season)
+
+	if runE := cmd.RunE; runE != nil {
+		cmd.RunE = func(cmd *cobra.Command, args []string) error {
+			ctx, span := tracing.Start(cmd.Context(), "task "+t.ID, attribute.String("task", t.ID))
+			cmd.SetContext(ctx)
+
+			err := runE(cmd, args)
+			tracing.End(span, err)
+			return err
+		}
+	}
+
+	if len(t.Requires) == 0 {
+		return cmd
+	}
+
+	cmd.Long = strings.TrimRight(cmd.Long, " \t\n") + "\n\nRequires: " + t.RequirementList()
+
+	preRunE := cmd.PreRunE
+	cmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := Validate(cfg, t.Requires...); err != nil {
+			return fmt.Errorf("%s cannot run (see 'ai-devs3 config check %s'): %w", t.ID, t.ID, err)
+		}
+		if preRunE != nil {
+			return preRunE(cmd, args)
+		}
+		return nil
+	}
+
+	return cmd
+}
+
+// RequirementList returns the task requirements as a comma separated list
+func (t Task) RequirementList() string {
+	names := make([]string, len(t.Requires))
+	for i, req := range t.Requires {
+		names[i] = string(req)
+	}
+	return strings.Join(names, ", ")
+}
+
+// Validate checks that cfg and the environment satisfy every requirement and
+// reports all of the missing ones at once
+func Validate(cfg *config.Config, requires ...Requirement) error {
+	var errs []error
+	for _, req := range requires {
+		if err := Check(cfg, req); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Check verifies a single requirement
+func Check(cfg *config.Config, req Requirement) error {
+	switch req {
+	case RequireAIDevs:
+		return requireValue("AI_DEVS_API_KEY", cfg.AIDevs.APIKey)
+	case RequireOpenAI:
+		return requireValue("OPENAI_API_KEY", cfg.OpenAI.APIKey)
+	case RequireLLM:
+		switch strings.ToLower(cfg.LLM.Provider) {
+		case "", llm.ProviderOpenAI:
+			return requireValue("OPENAI_API_KEY", cfg.OpenAI.APIKey)
+		case llm.ProviderOllama:
+			return requireValue("OLLAMA_BASE_URL", cfg.Ollama.BaseURL)
+		default:
+			return pkgerrors.NewConfigError("LLM_PROVIDER",
+				fmt.Sprintf("unsupported provider %q (expected %q or %q)", cfg.LLM.Provider, llm.ProviderOpenAI, llm.ProviderOllama), nil)
+		}
+	case RequireOllama:
+		return requireValue("OLLAMA_BASE_URL", cfg.Ollama.BaseURL)
+	case RequireQdrant:
+		return requireValue("QDRANT_API_KEY", cfg.Qdrant.APIKey)
+	case RequireNeo4j:
+		return requireValue("NEO4J_PASSWORD", cfg.Neo4j.Password)
+	case RequireFFmpeg, RequireYTDLP:
+		if _, err := exec.LookPath(string(req)); err != nil {
+			return pkgerrors.NewConfigError(string(req), "executable not found in PATH", err)
+		}
+		return nil
+	default:
+		return pkgerrors.NewConfigError(string(req), "unknown requirement", nil)
+	}
+}
+
+// requireValue returns a ConfigError when a required setting is empty
+func requireValue(field, value string) error {
+	if value == "" {
+		return pkgerrors.NewConfigError(field, "must be set in the environment or the config file", nil)
+	}
+	return nil
+}
@@ -5,10 +5,21 @@ import (
 	"time"
 
 	"ai-devs3/internal/config"
+	"ai-devs3/internal/tasks"
 
 	"github.com/spf13/cobra"
 )
 
+func init() {
+	tasks.Register(tasks.Task{
+		ID:         "s01e01",
+		Title:      "Robot Authentication",
+		Season:     1,
+		Requires:   []tasks.Requirement{tasks.RequireLLM},
+		NewCommand: NewCommand,
+	})
+}
+
 // NewCommand creates a new cobra command for S01E01 task
 func NewCommand(cfg *config.Config) *cobra.Command {
 	return &cobra.Command{
@@ -26,11 +37,14 @@ func NewCommand(cfg *config.Config) *cobra.Command {
 		The robot asks questions that need to be answered correctly to gain access.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Create context with timeout
-			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+			ctx, cancel := context.WithTimeout(cmd.Context(), 2*time.Minute)
 			defer cancel()
 
 			// Create and run handler
-			handler := NewHandler(cfg)
+			handler, err := NewHandler(cfg)
+			if err != nil {
+				return err
+			}
 			return handler.Execute(ctx)
 		},
 	}
@@ -4,11 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
 
 	"ai-devs3/internal/config"
 	"ai-devs3/internal/http"
-	"ai-devs3/internal/llm/openai"
+	"ai-devs3/internal/inputs"
+	"ai-devs3/internal/llm"
+	"ai-devs3/internal/logging"
 	pkgerrors "ai-devs3/pkg/errors"
 )
 
@@ -19,10 +20,13 @@ type Handler struct {
 }
 
 // NewHandler creates a new S01E01 handler
-func NewHandler(cfg *config.Config) *Handler {
+func NewHandler(cfg *config.Config) (*Handler, error) {
 	// Initialize dependencies
 	httpClient := http.NewClient(cfg.HTTP)
-	llmClient := openai.NewClient(cfg.OpenAI)
+	llmClient, err := llm.New(cfg)
+	if err != nil {
+		return nil, err
+	}
 
 	// Create service
 	service := NewService(cfg, httpClient, llmClient)
@@ -30,15 +34,16 @@ func NewHandler(cfg *config.Config) *Handler {
 	return &Handler{
 		service: service,
 		config:  cfg,
-	}
+	}, nil
 }
 
 // Execute runs the S01E01 task
 func (h *Handler) Execute(ctx context.Context) error {
-	log.Println("Starting S01E01 robot authentication task")
+	logger := logging.FromContext(ctx)
+	logger.Info("Starting S01E01 robot authentication task")
 
 	// Task configuration
-	loginURL := "https://xyz.ag3nts.org/"
+	loginURL := inputs.NewResolver(h.config, "s01e01").ExternalURL("login", "https://xyz.ag3nts.org/")
 	creds := &Credentials{
 		Username: "tester",
 		Password: "574e112a",
@@ -49,19 +54,19 @@ func (h *Handler) Execute(ctx context.Context) error {
 	if err != nil {
 		var taskErr pkgerrors.TaskError
 		if errors.As(err, &taskErr) {
-			log.Printf("Task failed at step %s: %v", taskErr.Step, taskErr.Err)
+			logger.Error("Task failed", "step", taskErr.Step, "error", taskErr.Err)
 			return fmt.Errorf("S01E01 task failed: %w", err)
 		}
 		return fmt.Errorf("S01E01 task failed: %w", err)
 	}
 
 	// Log results
-	log.Printf("Task completed successfully!")
-	log.Printf("Flag extracted: %s", result.Flag)
+	logger.Info("Task completed",
+		"flag", result.Flag)
 
 	fmt.Println("Secret page content:")
 	fmt.Println(result.Content)
-	fmt.Println("Please submit the flag to https://c3ntrala.ag3nts.org/")
+	fmt.Printf("Please submit the flag to %s/\n", h.config.AIDevs.BaseURL)
 
 	return nil
 }
@@ -8,19 +8,21 @@ import (
 
 	"ai-devs3/internal/config"
 	"ai-devs3/internal/http"
-	"ai-devs3/internal/llm/openai"
+	"ai-devs3/internal/llm"
+	"ai-devs3/internal/prompts"
+	"ai-devs3/internal/tracing"
 	"ai-devs3/pkg/errors"
 )
 
 // Service handles the S01E01 robot authentication task
 type Service struct {
 	httpClient *http.Client
-	llmClient  *openai.Client
+	llmClient  llm.ChatModel
 	config     *config.Config
 }
 
 // NewService creates a new S01E01 service
-func NewService(cfg *config.Config, httpClient *http.Client, llmClient *openai.Client) *Service {
+func NewService(cfg *config.Config, httpClient *http.Client, llmClient llm.ChatModel) *Service {
 	return &Service{
 		httpClient: httpClient,
 		llmClient:  llmClient,
@@ -45,7 +47,12 @@ func (s *Service) GetAnswer(ctx context.Context, question *Question) (*Answer, e
 		return nil, errors.NewProcessingError("llm", "question answering", "question is empty", nil)
 	}
 
-	answer, err := s.llmClient.GetAnswer(ctx, question.Text)
+	systemPrompt, err := prompts.Render("s01e01/short_answer", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	answer, err := s.llmClient.Chat(ctx, systemPrompt, question.Text)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get answer from LLM: %w", err)
 	}
@@ -84,8 +91,13 @@ func (s *Service) SubmitLogin(ctx context.Context, loginURL string, creds *Crede
 
 // ExtractFlag extracts flag from login response content
 func (s *Service) ExtractFlag(ctx context.Context, content string) (string, error) {
+	systemPrompt, err := prompts.Render("s01e01/find_flag", nil)
+	if err != nil {
+		return "", err
+	}
+
 	// Use the LLM to find the flag
-	flag, err := s.llmClient.FindFlag(ctx, content)
+	flag, err := s.llmClient.Chat(ctx, systemPrompt, content)
 	if err != nil {
 		return "", fmt.Errorf("failed to extract flag: %w", err)
 	}
@@ -94,35 +106,38 @@ func (s *Service) ExtractFlag(ctx context.Context, content string) (string, erro
 }
 
 // ExecuteTask executes the complete S01E01 task workflow
-func (s *Service) ExecuteTask(ctx context.Context, loginURL string, creds *Credentials) (*TaskResult, error) {
+func (s *Service) ExecuteTask(ctx context.Context, loginURL string, creds *Credentials) (_ *TaskResult, err error) {
+	steps := tracing.NewSteps(ctx)
+	defer func() { steps.End(err) }()
+
 	// Step 1: Fetch the login page
-	htmlContent, err := s.httpClient.FetchPage(ctx, loginURL)
+	htmlContent, err := s.httpClient.FetchPage(steps.Start("fetch_page"), loginURL)
 	if err != nil {
 		return nil, errors.NewTaskError("s01e01", "fetch_page", err)
 	}
 
 	// Step 2: Extract the question
-	question, err := s.ExtractQuestion(ctx, htmlContent)
+	question, err := s.ExtractQuestion(steps.Start("extract_question"), htmlContent)
 	if err != nil {
 		return nil, errors.NewTaskError("s01e01", "extract_question", err)
 	}
 	fmt.Println(question)
 
 	// Step 3: Get answer from LLM
-	answer, err := s.GetAnswer(ctx, question)
+	answer, err := s.GetAnswer(steps.Start("get_answer"), question)
 	if err != nil {
 		return nil, errors.NewTaskError("s01e01", "get_answer", err)
 	}
 	fmt.Println(answer)
 
 	// Step 4: Submit login form
-	loginResponse, err := s.SubmitLogin(ctx, loginURL, creds, answer)
+	loginResponse, err := s.SubmitLogin(steps.Start("submit_login"), loginURL, creds, answer)
 	if err != nil {
 		return nil, errors.NewTaskError("s01e01", "submit_login", err)
 	}
 
 	// Step 5: Extract flag from response
-	flag, err := s.ExtractFlag(ctx, loginResponse.Content)
+	flag, err := s.ExtractFlag(steps.Start("extract_flag"), loginResponse.Content)
 	if err != nil {
 		return nil, errors.NewTaskError("s01e01", "extract_flag", err)
 	}
@@ -5,10 +5,21 @@ import (
 	"time"
 
 	"ai-devs3/internal/config"
+	"ai-devs3/internal/tasks"
 
 	"github.com/spf13/cobra"
 )
 
+func init() {
+	tasks.Register(tasks.Task{
+		ID:         "s01e02",
+		Title:      "RoboISO Verification",
+		Season:     1,
+		Requires:   []tasks.Requirement{tasks.RequireLLM},
+		NewCommand: NewCommand,
+	})
+}
+
 // NewCommand creates a new cobra command for S01E02 task
 func NewCommand(cfg *config.Config) *cobra.Command {
 	return &cobra.Command{
@@ -27,11 +38,14 @@ func NewCommand(cfg *config.Config) *cobra.Command {
 		The system has deliberate misinformation for security testing purposes.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Create context with timeout
-			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+			ctx, cancel := context.WithTimeout(cmd.Context(), 2*time.Minute)
 			defer cancel()
 
 			// Create and run handler
-			handler := NewHandler(cfg)
+			handler, err := NewHandler(cfg)
+			if err != nil {
+				return err
+			}
 			return handler.Execute(ctx)
 		},
 	}
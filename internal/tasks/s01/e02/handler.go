@@ -4,11 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
 
 	"ai-devs3/internal/config"
 	"ai-devs3/internal/http"
-	"ai-devs3/internal/llm/openai"
+	"ai-devs3/internal/inputs"
+	"ai-devs3/internal/llm"
+	"ai-devs3/internal/logging"
 	pkgerrors "ai-devs3/pkg/errors"
 )
 
@@ -19,10 +20,13 @@ type Handler struct {
 }
 
 // NewHandler creates a new S01E02 handler
-func NewHandler(cfg *config.Config) *Handler {
+func NewHandler(cfg *config.Config) (*Handler, error) {
 	// Initialize dependencies
 	httpClient := http.NewClient(cfg.HTTP)
-	llmClient := openai.NewClient(cfg.OpenAI)
+	llmClient, err := llm.New(cfg)
+	if err != nil {
+		return nil, err
+	}
 
 	// Create service
 	service := NewService(cfg, httpClient, llmClient)
@@ -30,32 +34,32 @@ func NewHandler(cfg *config.Config) *Handler {
 	return &Handler{
 		service: service,
 		config:  cfg,
-	}
+	}, nil
 }
 
 // Execute runs the S01E02 task
 func (h *Handler) Execute(ctx context.Context) error {
-	log.Println("Starting S01E02 RoboISO verification task")
+	logger := logging.FromContext(ctx)
+	logger.Info("Starting S01E02 RoboISO verification task")
 
-	// Task configuration
-	verifyURL := "https://xyz.ag3nts.org/verify"
+	// Task configuration (override with --input verify=<url>)
+	verifyURL := inputs.NewResolver(h.config, "s01e02").ExternalURL("verify", "https://xyz.ag3nts.org/verify")
 
 	// Execute the task
 	result, err := h.service.ExecuteTask(ctx, verifyURL)
 	if err != nil {
 		var taskErr pkgerrors.TaskError
 		if errors.As(err, &taskErr) {
-			log.Printf("Task failed at step %s: %v", taskErr.Step, taskErr.Err)
+			logger.Error("Task failed", "step", taskErr.Step, "error", taskErr.Err)
 			return fmt.Errorf("S01E02 task failed: %w", err)
 		}
 		return fmt.Errorf("S01E02 task failed: %w", err)
 	}
 
 	// Log results
-	log.Printf("Task completed successfully!")
-	log.Printf("Final response: %s", result.FinalResponse)
-	log.Printf("Success: %t", result.Success)
-	log.Printf("Message count: %d", result.MessageCount)
+	logger.Info("Task completed",
+		"final_response", result.FinalResponse, "success", result.Success,
+		"messages", result.MessageCount)
 
 	return nil
 }
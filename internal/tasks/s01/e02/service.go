@@ -7,19 +7,21 @@ import (
 
 	"ai-devs3/internal/config"
 	"ai-devs3/internal/http"
-	"ai-devs3/internal/llm/openai"
+	"ai-devs3/internal/llm"
+	"ai-devs3/internal/prompts"
+	"ai-devs3/internal/tracing"
 	"ai-devs3/pkg/errors"
 )
 
 // Service handles the S01E02 RoboISO verification task
 type Service struct {
 	httpClient *http.Client
-	llmClient  *openai.Client
+	llmClient  llm.ChatModel
 	config     *config.Config
 }
 
 // NewService creates a new S01E02 service
-func NewService(cfg *config.Config, httpClient *http.Client, llmClient *openai.Client) *Service {
+func NewService(cfg *config.Config, httpClient *http.Client, llmClient llm.ChatModel) *Service {
 	return &Service{
 		httpClient: httpClient,
 		llmClient:  llmClient,
@@ -48,8 +50,13 @@ func (s *Service) GetRoboISOAnswer(ctx context.Context, question string) (*RoboI
 		return nil, errors.NewProcessingError("llm", "roboiso_answer", "question is empty", nil)
 	}
 
-	// Use the specialized RoboISO method
-	answer, err := s.llmClient.GetAnswerRoboISO(ctx, question)
+	systemPrompt, err := prompts.Render("s01e02/roboiso", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// The prompt asks for the RoboISO JSON message, which is decoded and validated
+	answer, err := llm.ChatStructured[RoboISOMessage](ctx, s.llmClient, systemPrompt, question)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get RoboISO answer: %w", err)
 	}
@@ -82,24 +89,27 @@ func (s *Service) sendVerifyRequest(ctx context.Context, verifyURL string, messa
 }
 
 // ExecuteTask executes the complete S01E02 RoboISO verification task
-func (s *Service) ExecuteTask(ctx context.Context, verifyURL string) (*TaskResult, error) {
+func (s *Service) ExecuteTask(ctx context.Context, verifyURL string) (_ *TaskResult, err error) {
+	steps := tracing.NewSteps(ctx)
+	defer func() { steps.End(err) }()
+
 	messageCount := 0
 
 	// Step 1: Initialize conversation
-	response, err := s.InitializeConversation(ctx, verifyURL)
+	response, err := s.InitializeConversation(steps.Start("initialize_conversation"), verifyURL)
 	if err != nil {
 		return nil, errors.NewTaskError("s01e02", "initialize_conversation", err)
 	}
 	messageCount++
 
 	// Step 2: Get answer from LLM
-	answer, err := s.GetRoboISOAnswer(ctx, response.Content)
+	answer, err := s.GetRoboISOAnswer(steps.Start("get_roboiso_answer"), response.Content)
 	if err != nil {
 		return nil, errors.NewTaskError("s01e02", "get_roboiso_answer", err)
 	}
 
 	// Step 3: Send answer back to verify endpoint
-	finalResponse, err := s.sendVerifyRequest(ctx, verifyURL, answer)
+	finalResponse, err := s.sendVerifyRequest(steps.Start("send_verify_request"), verifyURL, answer)
 	if err != nil {
 		return nil, errors.NewTaskError("s01e02", "send_verify_request", err)
 	}
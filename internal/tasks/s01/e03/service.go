@@ -2,30 +2,37 @@ package e03
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
 
+	"ai-devs3/internal/centrala"
 	"ai-devs3/internal/config"
 	"ai-devs3/internal/http"
-	"ai-devs3/internal/llm/openai"
+	"ai-devs3/internal/llm"
+	"ai-devs3/internal/logging"
+	"ai-devs3/internal/prompts"
+	"ai-devs3/internal/tracing"
 	"ai-devs3/pkg/errors"
 )
 
 // Service handles the S01E03 JSON data processing task
 type Service struct {
-	httpClient *http.Client
-	llmClient  *openai.Client
-	config     *config.Config
+	httpClient     *http.Client
+	centralaClient *centrala.Client
+	llmClient      llm.ChatModel
+	config         *config.Config
 }
 
 // NewService creates a new S01E03 service
-func NewService(cfg *config.Config, httpClient *http.Client, llmClient *openai.Client) *Service {
+func NewService(cfg *config.Config, httpClient *http.Client, centralaClient *centrala.Client, llmClient llm.ChatModel) *Service {
 	return &Service{
-		httpClient: httpClient,
-		llmClient:  llmClient,
-		config:     cfg,
+		httpClient:     httpClient,
+		centralaClient: centralaClient,
+		llmClient:      llmClient,
+		config:         cfg,
 	}
 }
 
@@ -35,13 +42,16 @@ func (s *Service) FetchTestData(ctx context.Context, apiKey string) (map[string]
 		return nil, errors.NewProcessingError("http", "fetch_test_data", "API key is empty", nil)
 	}
 
-	url := fmt.Sprintf("https://c3ntrala.ag3nts.org/data/%s/json.txt", apiKey)
-
-	data, err := s.httpClient.FetchJSONData(ctx, url)
+	content, err := s.centralaClient.FetchTaskData(ctx, "json.txt")
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch test data: %w", err)
 	}
 
+	var data map[string]any
+	if err := json.Unmarshal([]byte(content), &data); err != nil {
+		return nil, errors.NewProcessingError("json", "fetch_test_data", "failed to parse test data", err)
+	}
+
 	return data, nil
 }
 
@@ -80,7 +90,7 @@ func (s *Service) processTestArray(ctx context.Context, arr []any) ([]any, error
 	var answers []string
 	if len(llmQuestions) > 0 {
 		var err error
-		answers, err = s.llmClient.GetMultipleAnswers(ctx, llmQuestions)
+		answers, err = s.getMultipleAnswers(ctx, llmQuestions)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get LLM answers: %w", err)
 		}
@@ -92,6 +102,33 @@ func (s *Service) processTestArray(ctx context.Context, arr []any) ([]any, error
 	return arr, nil
 }
 
+// getMultipleAnswers asks all questions in one prompt and returns the answers in order
+func (s *Service) getMultipleAnswers(ctx context.Context, questions []string) ([]string, error) {
+	systemPrompt, err := prompts.Render("s01e03/multiple_answers", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	prompt := "Answer the following questions in order. Give only the answer for each, no explanations, no comments, in English. Separate answers with newlines.\n\n"
+	for i, q := range questions {
+		prompt += fmt.Sprintf("%d. %s\n", i+1, q)
+	}
+
+	content, err := s.llmClient.Chat(ctx, systemPrompt, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	var answers []string
+	for _, line := range strings.Split(content, "\n") {
+		if trimmed := strings.TrimSpace(line); trimmed != "" {
+			answers = append(answers, trimmed)
+		}
+	}
+
+	return answers, nil
+}
+
 // collectLLMQuestions gathers all LLM questions and their indexes while solving math problems
 func (s *Service) collectLLMQuestions(arr []any) ([]string, []int) {
 	var llmQuestions []string
@@ -185,32 +222,37 @@ func (s *Service) SubmitAnswer(ctx context.Context, apiKey string, processedData
 		"test-data": processedData["test-data"],
 	}
 
-	response := s.httpClient.BuildAIDevsResponse("JSON", apiKey, answer)
-
-	result, err := s.httpClient.PostReport(ctx, "https://c3ntrala.ag3nts.org", response)
+	response, err := s.centralaClient.Report(ctx, "JSON", answer)
 	if err != nil {
 		return "", fmt.Errorf("failed to submit answer: %w", err)
 	}
 
-	return result, nil
+	if flag := response.Flag(); flag != "" {
+		logging.FromContext(ctx).Info("Received flag", "flag", flag)
+	}
+
+	return response.Message, nil
 }
 
 // ExecuteTask executes the complete S01E03 task workflow
-func (s *Service) ExecuteTask(ctx context.Context, apiKey string) (*TaskResult, error) {
+func (s *Service) ExecuteTask(ctx context.Context, apiKey string) (_ *TaskResult, err error) {
+	steps := tracing.NewSteps(ctx)
+	defer func() { steps.End(err) }()
+
 	// Step 1: Fetch test data
-	data, err := s.FetchTestData(ctx, apiKey)
+	data, err := s.FetchTestData(steps.Start("fetch_test_data"), apiKey)
 	if err != nil {
 		return nil, errors.NewTaskError("s01e03", "fetch_test_data", err)
 	}
 
 	// Step 2: Process test data
-	processedData, err := s.ProcessTestData(ctx, data)
+	processedData, err := s.ProcessTestData(steps.Start("process_test_data"), data)
 	if err != nil {
 		return nil, errors.NewTaskError("s01e03", "process_test_data", err)
 	}
 
 	// Step 3: Submit answer
-	response, err := s.SubmitAnswer(ctx, apiKey, processedData)
+	response, err := s.SubmitAnswer(steps.Start("submit_answer"), apiKey, processedData)
 	if err != nil {
 		return nil, errors.NewTaskError("s01e03", "submit_answer", err)
 	}
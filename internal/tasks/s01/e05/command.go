@@ -5,10 +5,21 @@ import (
 	"time"
 
 	"ai-devs3/internal/config"
+	"ai-devs3/internal/tasks"
 
 	"github.com/spf13/cobra"
 )
 
+func init() {
+	tasks.Register(tasks.Task{
+		ID:         "s01e05",
+		Title:      "Text Censoring",
+		Season:     1,
+		Requires:   []tasks.Requirement{tasks.RequireAIDevs, tasks.RequireLLM},
+		NewCommand: NewCommand,
+	})
+}
+
 // NewCommand creates a new cobra command for S01E05 task
 func NewCommand(cfg *config.Config) *cobra.Command {
 	return &cobra.Command{
@@ -18,15 +29,15 @@ func NewCommand(cfg *config.Config) *cobra.Command {
 
 		This task involves:
 			1. Fetching raw text data from the centrala API using your API key
-			2. Using Ollama local LLM to censor personal information in the text
+			2. Using the LLM_PROVIDER model to censor personal information in the text
 			3. Applying specific censoring rules for names, ages, cities, and addresses
 			4. Submitting the censored text back to the centrala API
 			5. Receiving confirmation of successful censoring
 
 		The task requires:
 			- AI_DEVS_API_KEY environment variable to be set
-			- Ollama server running locally (default: http://localhost:11434)
-			- Ollama model installed (default: llama3.2)
+			- LLM_PROVIDER=ollama to keep the text local
+			  (server at http://localhost:11434, model llama3.2)
 
 		Censoring rules:
 			- Names and surnames are replaced together as one "CENZURA"
@@ -35,11 +46,14 @@ func NewCommand(cfg *config.Config) *cobra.Command {
 			- Street addresses are replaced with "CENZURA" after street indicators`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Create context with timeout
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			ctx, cancel := context.WithTimeout(cmd.Context(), 5*time.Minute)
 			defer cancel()
 
 			// Create and run handler
-			handler := NewHandler(cfg)
+			handler, err := NewHandler(cfg)
+			if err != nil {
+				return err
+			}
 			return handler.Execute(ctx)
 		},
 	}
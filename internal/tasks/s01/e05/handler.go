@@ -4,12 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
-	"os"
 
+	"ai-devs3/internal/centrala"
 	"ai-devs3/internal/config"
 	"ai-devs3/internal/http"
-	"ai-devs3/internal/llm/ollama"
+	"ai-devs3/internal/llm"
+	"ai-devs3/internal/logging"
 	pkgerrors "ai-devs3/pkg/errors"
 )
 
@@ -20,28 +20,34 @@ type Handler struct {
 }
 
 // NewHandler creates a new S01E05 handler
-func NewHandler(cfg *config.Config) *Handler {
+func NewHandler(cfg *config.Config) (*Handler, error) {
 	// Initialize dependencies
 	httpClient := http.NewClient(cfg.HTTP)
-	ollamaClient := ollama.NewClient(cfg.Ollama)
+	llmClient, err := llm.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	// Near-deterministic sampling keeps the model from rephrasing the input
+	llmClient = llm.Tune(llmClient, llm.Tuning{TopP: 0.1, MaxTokens: 200})
 
 	// Create service
-	service := NewService(cfg, httpClient, ollamaClient)
+	service := NewService(cfg, httpClient, centrala.NewClient(httpClient, cfg.AIDevs).WithInputs(cfg.Inputs.Overrides), llmClient)
 
 	return &Handler{
 		service: service,
 		config:  cfg,
-	}
+	}, nil
 }
 
 // Execute runs the S01E05 task
 func (h *Handler) Execute(ctx context.Context) error {
-	log.Println("Starting S01E05 text censoring task")
+	logger := logging.FromContext(ctx)
+	logger.Info("Starting S01E05 text censoring task")
 
-	// Get API key from environment
-	apiKey := os.Getenv("AI_DEVS_API_KEY")
+	// Centrala client reads the API key from config
+	apiKey := h.config.AIDevs.APIKey
 	if apiKey == "" {
-		return fmt.Errorf("AI_DEVS_API_KEY environment variable not set")
+		return fmt.Errorf("AI_DEVS_API_KEY is required")
 	}
 
 	// Execute the task
@@ -49,16 +55,15 @@ func (h *Handler) Execute(ctx context.Context) error {
 	if err != nil {
 		var taskErr pkgerrors.TaskError
 		if errors.As(err, &taskErr) {
-			log.Printf("Task failed at step %s: %v", taskErr.Step, taskErr.Err)
+			logger.Error("Task failed", "step", taskErr.Step, "error", taskErr.Err)
 			return fmt.Errorf("S01E05 task failed: %w", err)
 		}
 		return fmt.Errorf("S01E05 task failed: %w", err)
 	}
 
 	// Log results
-	log.Printf("Task completed successfully!")
-	log.Printf("Original text length: %d", len(result.OriginalText))
-	log.Printf("Censored text length: %d", len(result.CensoredText))
+	logger.Info("Task completed",
+		"original_length", len(result.OriginalText), "censored_length", len(result.CensoredText))
 
 	fmt.Println("=== Original Text ===")
 	fmt.Println(result.OriginalText)
@@ -5,25 +5,31 @@ import (
 	"fmt"
 	"strings"
 
+	"ai-devs3/internal/centrala"
 	"ai-devs3/internal/config"
 	"ai-devs3/internal/http"
-	"ai-devs3/internal/llm/ollama"
+	"ai-devs3/internal/llm"
+	"ai-devs3/internal/logging"
+	"ai-devs3/internal/prompts"
+	"ai-devs3/internal/tracing"
 	"ai-devs3/pkg/errors"
 )
 
 // Service handles the S01E05 text censoring task
 type Service struct {
-	httpClient   *http.Client
-	ollamaClient *ollama.Client
-	config       *config.Config
+	httpClient     *http.Client
+	centralaClient *centrala.Client
+	llmClient      llm.ChatModel
+	config         *config.Config
 }
 
 // NewService creates a new S01E05 service
-func NewService(cfg *config.Config, httpClient *http.Client, ollamaClient *ollama.Client) *Service {
+func NewService(cfg *config.Config, httpClient *http.Client, centralaClient *centrala.Client, llmClient llm.ChatModel) *Service {
 	return &Service{
-		httpClient:   httpClient,
-		ollamaClient: ollamaClient,
-		config:       cfg,
+		httpClient:     httpClient,
+		centralaClient: centralaClient,
+		llmClient:      llmClient,
+		config:         cfg,
 	}
 }
 
@@ -33,32 +39,34 @@ func (s *Service) FetchTextData(ctx context.Context, apiKey string) (*TextData,
 		return nil, errors.NewProcessingError("http", "fetch_text_data", "API key is empty", nil)
 	}
 
-	url := fmt.Sprintf("https://c3ntrala.ag3nts.org/data/%s/cenzura.txt", apiKey)
-
-	content, err := s.httpClient.FetchData(ctx, url)
+	content, err := s.centralaClient.FetchTaskData(ctx, "cenzura.txt")
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch text data: %w", err)
 	}
 
 	return &TextData{
 		Content: content,
-		URL:     url,
+		URL:     s.centralaClient.TaskDataURL("cenzura.txt"),
 	}, nil
 }
 
-// CensorText uses Ollama to censor the text according to the rules
+// CensorText uses the LLM to censor the text according to the rules
 func (s *Service) CensorText(ctx context.Context, text string) (*CensorResponse, error) {
 	if strings.TrimSpace(text) == "" {
-		return nil, errors.NewProcessingError("ollama", "censor_text", "text is empty", nil)
+		return nil, errors.NewProcessingError("llm", "censor_text", "text is empty", nil)
+	}
+
+	systemPrompt, err := prompts.Render("s01e05/censor", nil)
+	if err != nil {
+		return nil, err
 	}
 
-	censoredText, err := s.ollamaClient.CensorText(ctx, text)
+	censoredText, err := s.llmClient.Chat(ctx, systemPrompt, text)
 	if err != nil {
-		return nil, fmt.Errorf("failed to censor text with Ollama: %w", err)
+		return nil, fmt.Errorf("failed to censor text: %w", err)
 	}
 
-	fmt.Println("Original text:", text)
-	fmt.Println("Censored text:", censoredText)
+	logging.FromContext(ctx).Debug("Censored text", "original", text, "censored", censoredText)
 
 	return &CensorResponse{
 		CensoredText: censoredText,
@@ -67,33 +75,38 @@ func (s *Service) CensorText(ctx context.Context, text string) (*CensorResponse,
 }
 
 // SubmitCensoredText submits the censored text to the centrala API
-func (s *Service) SubmitCensoredText(ctx context.Context, apiKey string, censoredText string) (string, error) {
-	response := s.httpClient.BuildAIDevsResponse("CENZURA", apiKey, censoredText)
-
-	result, err := s.httpClient.PostReport(ctx, "https://c3ntrala.ag3nts.org", response)
+func (s *Service) SubmitCensoredText(ctx context.Context, censoredText string) (string, error) {
+	response, err := s.centralaClient.Report(ctx, "CENZURA", censoredText)
 	if err != nil {
 		return "", fmt.Errorf("failed to submit censored text: %w", err)
 	}
 
-	return result, nil
+	if flag := response.Flag(); flag != "" {
+		logging.FromContext(ctx).Info("Received flag", "flag", flag)
+	}
+
+	return response.Message, nil
 }
 
 // ExecuteTask executes the complete S01E05 task workflow
-func (s *Service) ExecuteTask(ctx context.Context, apiKey string) (*TaskResult, error) {
+func (s *Service) ExecuteTask(ctx context.Context, apiKey string) (_ *TaskResult, err error) {
+	steps := tracing.NewSteps(ctx)
+	defer func() { steps.End(err) }()
+
 	// Step 1: Fetch text data
-	textData, err := s.FetchTextData(ctx, apiKey)
+	textData, err := s.FetchTextData(steps.Start("fetch_text_data"), apiKey)
 	if err != nil {
 		return nil, errors.NewTaskError("s01e05", "fetch_text_data", err)
 	}
 
 	// Step 2: Censor the text
-	censorResponse, err := s.CensorText(ctx, textData.Content)
+	censorResponse, err := s.CensorText(steps.Start("censor_text"), textData.Content)
 	if err != nil {
 		return nil, errors.NewTaskError("s01e05", "censor_text", err)
 	}
 
 	// Step 3: Submit censored text
-	response, err := s.SubmitCensoredText(ctx, apiKey, censorResponse.CensoredText)
+	response, err := s.SubmitCensoredText(steps.Start("submit_censored_text"), censorResponse.CensoredText)
 	if err != nil {
 		return nil, errors.NewTaskError("s01e05", "submit_censored_text", err)
 	}
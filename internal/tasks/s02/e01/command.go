@@ -5,10 +5,21 @@ import (
 	"time"
 
 	"ai-devs3/internal/config"
+	"ai-devs3/internal/tasks"
 
 	"github.com/spf13/cobra"
 )
 
+func init() {
+	tasks.Register(tasks.Task{
+		ID:         "s02e01",
+		Title:      "Audio Transcription and Analysis",
+		Season:     2,
+		Requires:   []tasks.Requirement{tasks.RequireAIDevs, tasks.RequireLLM},
+		NewCommand: NewCommand,
+	})
+}
+
 // NewCommand creates a new cobra command for S02E01 task
 func NewCommand(cfg *config.Config) *cobra.Command {
 	return &cobra.Command{
@@ -18,15 +29,15 @@ func NewCommand(cfg *config.Config) *cobra.Command {
 
 		This task involves:
 			1. Listing audio files in the przesluchania directory
-			2. Transcribing each audio file using OpenAI Whisper
+			2. Transcribing each audio file with the LLM_PROVIDER transcription model
 			3. Combining all transcripts into a single text
 			4. Analyzing the transcripts to find Professor Maj's institute location
 			5. Submitting the analysis result to the centrala API
 
 		The task requires:
 			- AI_DEVS_API_KEY environment variable to be set
-			- Audio files in ../lessons-md/przesluchania directory
-			- OpenAI API access for Whisper transcription and GPT analysis
+			- Audio files in <LESSONS_DIR>/przesluchania directory
+			- LLM_PROVIDER=openai, the provider with Whisper transcription
 
 		The system will:
 			- Process multiple audio files in parallel
@@ -35,11 +46,14 @@ func NewCommand(cfg *config.Config) *cobra.Command {
 			- Submit the street name as the final answer`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Create context with timeout for audio processing
-			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+			ctx, cancel := context.WithTimeout(cmd.Context(), 10*time.Minute)
 			defer cancel()
 
 			// Create and run handler
-			handler := NewHandler(cfg)
+			handler, err := NewHandler(cfg)
+			if err != nil {
+				return err
+			}
 			return handler.Execute(ctx)
 		},
 	}
@@ -4,12 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
 	"os"
 
+	"ai-devs3/internal/centrala"
 	"ai-devs3/internal/config"
 	"ai-devs3/internal/http"
-	"ai-devs3/internal/llm/openai"
+	"ai-devs3/internal/inputs"
+	"ai-devs3/internal/llm"
+	"ai-devs3/internal/logging"
 	pkgerrors "ai-devs3/pkg/errors"
 )
 
@@ -20,32 +22,42 @@ type Handler struct {
 }
 
 // NewHandler creates a new S02E01 handler
-func NewHandler(cfg *config.Config) *Handler {
+func NewHandler(cfg *config.Config) (*Handler, error) {
 	// Initialize dependencies
 	httpClient := http.NewClient(cfg.HTTP)
-	llmClient := openai.NewClient(cfg.OpenAI)
+	llmClient, err := llm.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	transcriber, err := llm.As[llm.TranscriptionModel](llmClient)
+	if err != nil {
+		return nil, err
+	}
 
 	// Create service
-	service := NewService(cfg, httpClient, llmClient)
+	service := NewService(cfg, httpClient, centrala.NewClient(httpClient, cfg.AIDevs), transcriber, llmClient)
 
 	return &Handler{
 		service: service,
 		config:  cfg,
-	}
+	}, nil
 }
 
 // Execute runs the S02E01 task
 func (h *Handler) Execute(ctx context.Context) error {
-	log.Println("Starting S02E01 audio transcription and analysis task")
+	logger := logging.FromContext(ctx)
+	logger.Info("Starting S02E01 audio transcription and analysis task")
 
-	// Get API key from environment
-	apiKey := os.Getenv("AI_DEVS_API_KEY")
-	if apiKey == "" {
-		return fmt.Errorf("AI_DEVS_API_KEY environment variable not set")
+	// Centrala client reads the API key from config
+	if h.config.AIDevs.APIKey == "" {
+		return fmt.Errorf("AI_DEVS_API_KEY is required")
 	}
 
-	// Default audio directory path
-	audioDir := "../lessons-md/przesluchania"
+	// Audio recordings from the course materials (override with --input przesluchania=<dir>)
+	audioDir, err := inputs.NewResolver(h.config, "s02e01").LessonsPath("przesluchania", "przesluchania")
+	if err != nil {
+		return err
+	}
 
 	// Check if audio directory exists
 	if _, err := os.Stat(audioDir); os.IsNotExist(err) {
@@ -53,21 +65,20 @@ func (h *Handler) Execute(ctx context.Context) error {
 	}
 
 	// Execute the task
-	result, err := h.service.ExecuteTask(ctx, audioDir, apiKey)
+	result, err := h.service.ExecuteTask(ctx, audioDir)
 	if err != nil {
 		var taskErr pkgerrors.TaskError
 		if errors.As(err, &taskErr) {
-			log.Printf("Task failed at step %s: %v", taskErr.Step, taskErr.Err)
+			logger.Error("Task failed", "step", taskErr.Step, "error", taskErr.Err)
 			return fmt.Errorf("S02E01 task failed: %w", err)
 		}
 		return fmt.Errorf("S02E01 task failed: %w", err)
 	}
 
 	// Log results
-	log.Printf("Task completed successfully!")
-	log.Printf("Success: %t", result.Success)
-	log.Printf("Transcripts processed: %d", result.TranscriptCount)
-	log.Printf("Analysis result: %s", result.Analysis.Answer)
+	logger.Info("Task completed",
+		"success", result.Success, "transcripts", result.TranscriptCount,
+		"answer", result.Analysis.Answer)
 
 	fmt.Println("=== Analysis Thinking ===")
 	fmt.Println(result.Analysis.Thinking)
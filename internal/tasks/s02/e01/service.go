@@ -8,25 +8,36 @@ import (
 	"slices"
 	"strings"
 
+	"ai-devs3/internal/centrala"
 	"ai-devs3/internal/config"
 	"ai-devs3/internal/http"
-	"ai-devs3/internal/llm/openai"
+	"ai-devs3/internal/inputs"
+	"ai-devs3/internal/llm"
+	"ai-devs3/internal/logging"
+	"ai-devs3/internal/prompts"
+	"ai-devs3/internal/tracing"
 	"ai-devs3/pkg/errors"
 )
 
 // Service handles the S02E01 audio transcription and analysis task
 type Service struct {
-	httpClient *http.Client
-	llmClient  *openai.Client
-	config     *config.Config
+	httpClient     *http.Client
+	centralaClient *centrala.Client
+	transcriber    llm.TranscriptionModel
+	llmClient      llm.ChatModel
+	config         *config.Config
+	inputs         *inputs.Resolver
 }
 
 // NewService creates a new S02E01 service
-func NewService(cfg *config.Config, httpClient *http.Client, llmClient *openai.Client) *Service {
+func NewService(cfg *config.Config, httpClient *http.Client, centralaClient *centrala.Client, transcriber llm.TranscriptionModel, llmClient llm.ChatModel) *Service {
 	return &Service{
-		httpClient: httpClient,
-		llmClient:  llmClient,
-		config:     cfg,
+		httpClient:     httpClient,
+		centralaClient: centralaClient,
+		transcriber:    transcriber,
+		llmClient:      llmClient,
+		config:         cfg,
+		inputs:         inputs.NewResolver(cfg, "s02e01"),
 	}
 }
 
@@ -92,8 +103,7 @@ func (s *Service) TranscribeAudioFile(ctx context.Context, audioFile AudioFile)
 	}
 	defer file.Close()
 
-	// Transcribe using OpenAI Whisper
-	transcriptText, err := s.llmClient.TranscribeAudio(ctx, file, audioFile.Name)
+	transcriptText, err := s.transcriber.Transcribe(ctx, file, audioFile.Name)
 	if err != nil {
 		return nil, fmt.Errorf("failed to transcribe audio file %s: %w", audioFile.Name, err)
 	}
@@ -156,34 +166,41 @@ func (s *Service) AnalyzeTranscripts(ctx context.Context, combinedTranscripts st
 		return nil, errors.NewProcessingError("llm", "analyze_transcripts", "combined transcripts are empty", nil)
 	}
 
-	// Use OpenAI to analyze the transcripts
-	analysis, err := s.llmClient.AnalyzeTranscripts(ctx, combinedTranscripts)
+	systemPrompt, err := prompts.Render("s02e01/analyze_transcripts", map[string]any{"Transcripts": combinedTranscripts})
+	if err != nil {
+		return nil, err
+	}
+
+	analysis, err := llm.ChatStructured[TranscriptAnalysis](ctx, s.llmClient, systemPrompt,
+		"What is the name of the street where the University Institute is located where Professor Andrzej Maj lectures?")
 	if err != nil {
 		return nil, fmt.Errorf("failed to analyze transcripts: %w", err)
 	}
 
-	return &TranscriptAnalysis{
-		Thinking: analysis.Thinking,
-		Answer:   analysis.Answer,
-	}, nil
+	return analysis, nil
 }
 
 // SubmitAnswer submits the analysis result to the centrala API
-func (s *Service) SubmitAnswer(ctx context.Context, apiKey string, analysis *TranscriptAnalysis) (string, error) {
-	response := s.httpClient.BuildAIDevsResponse("mp3", apiKey, analysis.Answer)
-
-	result, err := s.httpClient.PostReport(ctx, "https://c3ntrala.ag3nts.org", response)
+func (s *Service) SubmitAnswer(ctx context.Context, analysis *TranscriptAnalysis) (string, error) {
+	response, err := s.centralaClient.Report(ctx, "mp3", analysis.Answer)
 	if err != nil {
 		return "", fmt.Errorf("failed to submit answer: %w", err)
 	}
 
-	return result, nil
+	if flag := response.Flag(); flag != "" {
+		logging.FromContext(ctx).Info("Received flag", "flag", flag)
+	}
+
+	return response.Message, nil
 }
 
 // ExecuteTask executes the complete S02E01 task workflow
-func (s *Service) ExecuteTask(ctx context.Context, audioDir string, apiKey string) (*TaskResult, error) {
+func (s *Service) ExecuteTask(ctx context.Context, audioDir string) (_ *TaskResult, err error) {
+	steps := tracing.NewSteps(ctx)
+	defer func() { steps.End(err) }()
+
 	// Step 1: List audio files
-	audioDirectory, err := s.ListAudioFiles(ctx, audioDir)
+	audioDirectory, err := s.ListAudioFiles(steps.Start("list_audio_files"), audioDir)
 	if err != nil {
 		return nil, errors.NewTaskError("s02e01", "list_audio_files", err)
 	}
@@ -194,7 +211,7 @@ func (s *Service) ExecuteTask(ctx context.Context, audioDir string, apiKey strin
 	}
 
 	// Step 2: Transcribe all audio files
-	transcripts, err := s.TranscribeAllAudioFiles(ctx, audioDirectory)
+	transcripts, err := s.TranscribeAllAudioFiles(steps.Start("transcribe_audio_files"), audioDirectory)
 	if err != nil {
 		return nil, errors.NewTaskError("s02e01", "transcribe_audio_files", err)
 	}
@@ -203,13 +220,13 @@ func (s *Service) ExecuteTask(ctx context.Context, audioDir string, apiKey strin
 	combinedTranscripts := s.CombineTranscripts(transcripts)
 
 	// Step 4: Analyze transcripts
-	analysis, err := s.AnalyzeTranscripts(ctx, combinedTranscripts)
+	analysis, err := s.AnalyzeTranscripts(steps.Start("analyze_transcripts"), combinedTranscripts)
 	if err != nil {
 		return nil, errors.NewTaskError("s02e01", "analyze_transcripts", err)
 	}
 
 	// Step 5: Submit answer
-	response, err := s.SubmitAnswer(ctx, apiKey, analysis)
+	response, err := s.SubmitAnswer(steps.Start("submit_answer"), analysis)
 	if err != nil {
 		return nil, errors.NewTaskError("s02e01", "submit_answer", err)
 	}
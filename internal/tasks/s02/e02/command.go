@@ -5,10 +5,21 @@ import (
 	"time"
 
 	"ai-devs3/internal/config"
+	"ai-devs3/internal/tasks"
 
 	"github.com/spf13/cobra"
 )
 
+func init() {
+	tasks.Register(tasks.Task{
+		ID:         "s02e02",
+		Title:      "Map Analysis",
+		Season:     2,
+		Requires:   []tasks.Requirement{tasks.RequireLLM},
+		NewCommand: NewCommand,
+	})
+}
+
 // NewCommand creates a new cobra command for S02E02 task
 func NewCommand(cfg *config.Config) *cobra.Command {
 	return &cobra.Command{
@@ -19,7 +30,7 @@ func NewCommand(cfg *config.Config) *cobra.Command {
 		This task involves:
 			1. Loading map fragments from the images directory
 			2. Processing each fragment for AI vision analysis
-			3. Using OpenAI Vision to analyze the fragments and identify street names
+			3. Using a vision model to analyze the fragments and identify street names
 			4. Evaluating candidate Polish cities based on extracted features
 			5. Making a final decision about the most likely city
 			6. Submitting the identified city to the centrala API
@@ -27,7 +38,7 @@ func NewCommand(cfg *config.Config) *cobra.Command {
 		The task requires:
 			- AI_DEVS_API_KEY environment variable to be set
 			- Map fragment images in ../../images/s02e02/ directory
-			- OpenAI API access with Vision model capabilities
+			- A vision-capable model from the LLM_PROVIDER provider
 
 		The system will:
 			- Process multiple map fragments in parallel
@@ -37,11 +48,14 @@ func NewCommand(cfg *config.Config) *cobra.Command {
 			- Submit the final city identification`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Create context with timeout for image processing
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			ctx, cancel := context.WithTimeout(cmd.Context(), 5*time.Minute)
 			defer cancel()
 
 			// Create and run handler
-			handler := NewHandler(cfg)
+			handler, err := NewHandler(cfg)
+			if err != nil {
+				return err
+			}
 			return handler.Execute(ctx)
 		},
 	}
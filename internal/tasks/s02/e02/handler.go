@@ -4,13 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
 	"os"
 
 	"ai-devs3/internal/config"
 	"ai-devs3/internal/http"
 	"ai-devs3/internal/image"
-	"ai-devs3/internal/llm/openai"
+	"ai-devs3/internal/inputs"
+	"ai-devs3/internal/llm"
+	"ai-devs3/internal/logging"
 	pkgerrors "ai-devs3/pkg/errors"
 )
 
@@ -21,24 +22,32 @@ type Handler struct {
 }
 
 // NewHandler creates a new S02E02 handler
-func NewHandler(cfg *config.Config) *Handler {
+func NewHandler(cfg *config.Config) (*Handler, error) {
 	// Initialize dependencies
 	httpClient := http.NewClient(cfg.HTTP)
-	llmClient := openai.NewClient(cfg.OpenAI)
-	imageProcessor := image.NewProcessor(*cfg)
+	model, err := llm.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	vision, err := llm.As[llm.VisionModel](model)
+	if err != nil {
+		return nil, err
+	}
+	imageProcessor := image.NewProcessor()
 
 	// Create service
-	service := NewService(cfg, httpClient, llmClient, imageProcessor)
+	service := NewService(cfg, httpClient, vision, imageProcessor)
 
 	return &Handler{
 		service: service,
 		config:  cfg,
-	}
+	}, nil
 }
 
 // Execute runs the S02E02 task
 func (h *Handler) Execute(ctx context.Context) error {
-	log.Println("Starting S02E02 map analysis task")
+	logger := logging.FromContext(ctx)
+	logger.Info("Starting S02E02 map analysis task")
 
 	// Get API key from environment
 	apiKey := os.Getenv("AI_DEVS_API_KEY")
@@ -46,8 +55,8 @@ func (h *Handler) Execute(ctx context.Context) error {
 		return fmt.Errorf("AI_DEVS_API_KEY environment variable not set")
 	}
 
-	// Default fragments directory and count
-	fragmentsDir := "data/s02e02"
+	// Fragments live in the task data directory; count is fixed by the task
+	fragmentsDir := inputs.NewResolver(h.config, "s02e02").DataDir()
 	numFragments := 4
 
 	// Validate fragments directory
@@ -60,7 +69,7 @@ func (h *Handler) Execute(ctx context.Context) error {
 	if err != nil {
 		var taskErr pkgerrors.TaskError
 		if errors.As(err, &taskErr) {
-			log.Printf("Task failed at step %s: %v", taskErr.Step, taskErr.Err)
+			logger.Error("Task failed", "step", taskErr.Step, "error", taskErr.Err)
 			return fmt.Errorf("S02E02 task failed: %w", err)
 		}
 		return fmt.Errorf("S02E02 task failed: %w", err)
@@ -70,10 +79,9 @@ func (h *Handler) Execute(ctx context.Context) error {
 	h.service.PrintAnalysisResult(result.AnalysisResult)
 
 	// Log results
-	log.Printf("Task completed successfully!")
-	log.Printf("Identified City: %s", result.IdentifiedCity)
-	log.Printf("Confidence: %s", result.Confidence)
-	log.Printf("Fragments processed: %d", result.FragmentCount)
+	logger.Info("Task completed",
+		"city", result.IdentifiedCity, "confidence", result.Confidence,
+		"fragments", result.FragmentCount)
 
 	fmt.Println("Map analysis successful!")
 	fmt.Printf("Final Answer: %s\n", result.IdentifiedCity)
@@ -2,12 +2,12 @@ package e02
 
 // MapFragment represents a single map fragment to be analyzed
 type MapFragment struct {
-	ID         string
-	Path       string
-	Base64Data string
-	Width      int
-	Height     int
-	TokenCost  int
+	ID        string
+	Path      string
+	Images    [][]byte // encoded image sent for analysis, or its tiles when it is too large to read whole
+	Width     int
+	Height    int
+	TokenCost int
 }
 
 // FragmentAnalysis represents the analysis of a single map fragment
@@ -21,13 +21,13 @@ type CandidateCity struct {
 	CityName        string `json:"city_name"`
 	EvidenceFor     string `json:"evidence_for"`
 	EvidenceAgainst string `json:"evidence_against"`
-	OverallFit      string `json:"overall_fit"`
+	OverallFit      string `json:"overall_fit" enum:"Strong,Medium,Weak"`
 }
 
 // CityDecision represents the final decision about the identified city
 type CityDecision struct {
 	IdentifiedCity string `json:"identified_city"`
-	Confidence     string `json:"confidence"`
+	Confidence     string `json:"confidence" enum:"High,Medium,Low"`
 	Reasoning      string `json:"reasoning"`
 }
 
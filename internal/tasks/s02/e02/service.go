@@ -5,24 +5,28 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"ai-devs3/internal/config"
 	"ai-devs3/internal/http"
 	"ai-devs3/internal/image"
-	"ai-devs3/internal/llm/openai"
+	"ai-devs3/internal/llm"
+	"ai-devs3/internal/logging"
+	"ai-devs3/internal/prompts"
+	"ai-devs3/internal/tracing"
 	"ai-devs3/pkg/errors"
 )
 
 // Service handles the S02E02 map analysis task
 type Service struct {
 	httpClient     *http.Client
-	llmClient      *openai.Client
+	llmClient      llm.VisionModel
 	imageProcessor *image.Processor
 	config         *config.Config
 }
 
 // NewService creates a new S02E02 service
-func NewService(cfg *config.Config, httpClient *http.Client, llmClient *openai.Client, imageProcessor *image.Processor) *Service {
+func NewService(cfg *config.Config, httpClient *http.Client, llmClient llm.VisionModel, imageProcessor *image.Processor) *Service {
 	return &Service{
 		httpClient:     httpClient,
 		llmClient:      llmClient,
@@ -58,8 +62,10 @@ func (s *Service) LoadMapFragments(ctx context.Context, fragmentsDir string, num
 	return fragments, nil
 }
 
-// ProcessMapFragments processes all map fragments for analysis
-func (s *Service) ProcessMapFragments(ctx context.Context, fragments []MapFragment, maxDimension int) ([]MapFragment, error) {
+// ProcessMapFragments prepares all map fragments for analysis, splitting
+// fragments too large to read whole into overlapping tiles so that street
+// names keep their resolution
+func (s *Service) ProcessMapFragments(ctx context.Context, fragments []MapFragment) ([]MapFragment, error) {
 	var processedFragments []MapFragment
 
 	for i, fragment := range fragments {
@@ -70,25 +76,33 @@ func (s *Service) ProcessMapFragments(ctx context.Context, fragments []MapFragme
 		}
 
 		// Process the image
-		result, err := s.imageProcessor.ProcessImage(fragment.Path, maxDimension)
+		data, err := os.ReadFile(fragment.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fragment %s: %w", fragment.ID, err)
+		}
+		tiles, err := s.imageProcessor.Tile(data, image.TextTileSize, image.TextTileOverlap, image.Options{})
 		if err != nil {
 			return nil, fmt.Errorf("failed to process fragment %s: %w", fragment.ID, err)
 		}
 
+		// Tiles run row by row from the top-left to the bottom-right corner
+		first, last := tiles[0].Bounds, tiles[len(tiles)-1].Bounds
 		processedFragment := MapFragment{
-			ID:         fragment.ID,
-			Path:       fragment.Path,
-			Base64Data: result.Base64Data,
-			Width:      result.Width,
-			Height:     result.Height,
-			TokenCost:  result.TokenCost,
+			ID:     fragment.ID,
+			Path:   fragment.Path,
+			Width:  last.Max.X - first.Min.X,
+			Height: last.Max.Y - first.Min.Y,
+		}
+		for _, tile := range tiles {
+			processedFragment.Images = append(processedFragment.Images, tile.Data)
+			processedFragment.TokenCost += tile.TokenCost
 		}
 
 		processedFragments = append(processedFragments, processedFragment)
 
-		// Log progress
-		fmt.Printf("Processed fragment %d/%d: %s (%dx%d, tokens: %d)\n",
-			i+1, len(fragments), fragment.ID, result.Width, result.Height, result.TokenCost)
+		logging.FromContext(ctx).Info("Processed fragment", "fragment", fragment.ID,
+			"index", i+1, "total", len(fragments), "width", processedFragment.Width, "height", processedFragment.Height,
+			"tiles", len(tiles), "tokens", processedFragment.TokenCost)
 	}
 
 	return processedFragments, nil
@@ -100,50 +114,36 @@ func (s *Service) AnalyzeMapFragments(ctx context.Context, fragments []MapFragme
 		return nil, errors.NewProcessingError("analysis", "analyze_fragments", "no fragments to analyze", nil)
 	}
 
-	// Extract base64 data for analysis
-	var imagesBase64 []string
+	// Collect the image data for analysis, telling the model which images
+	// are tiles of one fragment
+	var images [][]byte
+	var tiled []string
 	for _, fragment := range fragments {
-		if fragment.Base64Data == "" {
+		if len(fragment.Images) == 0 {
 			return nil, errors.NewProcessingError("analysis", "analyze_fragments",
-				fmt.Sprintf("fragment %s has no base64 data", fragment.ID), nil)
+				fmt.Sprintf("fragment %s has no image data", fragment.ID), nil)
+		}
+		if len(fragment.Images) > 1 {
+			tiled = append(tiled, fmt.Sprintf("images %d-%d are overlapping tiles of %s",
+				len(images)+1, len(images)+len(fragment.Images), fragment.ID))
 		}
-		imagesBase64 = append(imagesBase64, fragment.Base64Data)
+		images = append(images, fragment.Images...)
 	}
 
-	// Analyze using OpenAI
-	analysis, err := s.llmClient.AnalyzeMapFragments(ctx, imagesBase64)
+	systemPrompt, err := prompts.Render("s02e02/map_fragments", nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to analyze map fragments: %w", err)
+		return nil, err
 	}
 
-	// Convert to our domain model
-	result := &MapAnalysisResult{
-		Thinking:          analysis.Thinking,
-		FragmentAnalysis:  make([]FragmentAnalysis, len(analysis.FragmentAnalysis)),
-		CandidateAnalysis: make([]CandidateCity, len(analysis.CandidateAnalysis)),
-		FinalDecision: CityDecision{
-			IdentifiedCity: analysis.FinalDecision.IdentifiedCity,
-			Confidence:     analysis.FinalDecision.Confidence,
-			Reasoning:      analysis.FinalDecision.Reasoning,
-		},
+	// Street names need full resolution
+	model := llm.Tune(s.llmClient, llm.Tuning{OpenAIModel: "gpt-4.1", Temperature: 0.1, HighDetail: true})
+	userPrompt := "Analyze these map fragments to identify the most likely Polish city they belong to. Extract only clearly visible street names and provide a structured analysis."
+	if len(tiled) > 0 {
+		userPrompt += " Fragments too large to send whole come as tiles: " + strings.Join(tiled, "; ") + "."
 	}
-
-	// Convert fragment analysis
-	for i, fragment := range analysis.FragmentAnalysis {
-		result.FragmentAnalysis[i] = FragmentAnalysis{
-			FragmentID:  fragment.FragmentID,
-			StreetNames: fragment.StreetNames,
-		}
-	}
-
-	// Convert candidate analysis
-	for i, candidate := range analysis.CandidateAnalysis {
-		result.CandidateAnalysis[i] = CandidateCity{
-			CityName:        candidate.CityName,
-			EvidenceFor:     candidate.EvidenceFor,
-			EvidenceAgainst: candidate.EvidenceAgainst,
-			OverallFit:      candidate.OverallFit,
-		}
+	result, err := llm.VisionStructured[MapAnalysisResult](ctx, model, systemPrompt, userPrompt, images)
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze map fragments: %w", err)
 	}
 
 	return result, nil
@@ -183,21 +183,24 @@ func (s *Service) PrintAnalysisResult(result *MapAnalysisResult) {
 }
 
 // ExecuteTask executes the complete S02E02 task workflow
-func (s *Service) ExecuteTask(ctx context.Context, fragmentsDir string, numFragments int, apiKey string) (*TaskResult, error) {
+func (s *Service) ExecuteTask(ctx context.Context, fragmentsDir string, numFragments int, apiKey string) (_ *TaskResult, err error) {
+	steps := tracing.NewSteps(ctx)
+	defer func() { steps.End(err) }()
+
 	// Step 1: Load map fragments
-	fragments, err := s.LoadMapFragments(ctx, fragmentsDir, numFragments)
+	fragments, err := s.LoadMapFragments(steps.Start("load_fragments"), fragmentsDir, numFragments)
 	if err != nil {
 		return nil, errors.NewTaskError("s02e02", "load_fragments", err)
 	}
 
 	// Step 2: Process map fragments
-	processedFragments, err := s.ProcessMapFragments(ctx, fragments, 2048)
+	processedFragments, err := s.ProcessMapFragments(steps.Start("process_fragments"), fragments)
 	if err != nil {
 		return nil, errors.NewTaskError("s02e02", "process_fragments", err)
 	}
 
 	// Step 3: Analyze map fragments
-	analysisResult, err := s.AnalyzeMapFragments(ctx, processedFragments)
+	analysisResult, err := s.AnalyzeMapFragments(steps.Start("analyze_fragments"), processedFragments)
 	if err != nil {
 		return nil, errors.NewTaskError("s02e02", "analyze_fragments", err)
 	}
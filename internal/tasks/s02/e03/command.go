@@ -5,10 +5,21 @@ import (
 	"time"
 
 	"ai-devs3/internal/config"
+	"ai-devs3/internal/tasks"
 
 	"github.com/spf13/cobra"
 )
 
+func init() {
+	tasks.Register(tasks.Task{
+		ID:         "s02e03",
+		Title:      "Robot Image Generation",
+		Season:     2,
+		Requires:   []tasks.Requirement{tasks.RequireAIDevs, tasks.RequireLLM},
+		NewCommand: NewCommand,
+	})
+}
+
 // NewCommand creates a new cobra command for S02E03 task
 func NewCommand(cfg *config.Config) *cobra.Command {
 	return &cobra.Command{
@@ -25,7 +36,7 @@ func NewCommand(cfg *config.Config) *cobra.Command {
 
 		The task requires:
 			1. AI_DEVS_API_KEY environment variable to be set
-			2. OpenAI API access with DALL-E 3 image generation capabilities
+			2. LLM_PROVIDER=openai, the provider with DALL-E 3 image generation
 			3. Sufficient OpenAI credits for image generation
 
 		The system will:
@@ -36,11 +47,14 @@ func NewCommand(cfg *config.Config) *cobra.Command {
 			5. Submit the image URL as the final answer`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Create context with timeout for image generation
-			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+			ctx, cancel := context.WithTimeout(cmd.Context(), 3*time.Minute)
 			defer cancel()
 
 			// Create and run handler
-			handler := NewHandler(cfg)
+			handler, err := NewHandler(cfg)
+			if err != nil {
+				return err
+			}
 			return handler.Execute(ctx)
 		},
 	}
@@ -4,12 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
-	"os"
 
+	"ai-devs3/internal/centrala"
 	"ai-devs3/internal/config"
 	"ai-devs3/internal/http"
-	"ai-devs3/internal/llm/openai"
+	"ai-devs3/internal/llm"
+	"ai-devs3/internal/logging"
 	pkgerrors "ai-devs3/pkg/errors"
 )
 
@@ -20,28 +20,36 @@ type Handler struct {
 }
 
 // NewHandler creates a new S02E03 handler
-func NewHandler(cfg *config.Config) *Handler {
+func NewHandler(cfg *config.Config) (*Handler, error) {
 	// Initialize dependencies
 	httpClient := http.NewClient(cfg.HTTP)
-	llmClient := openai.NewClient(cfg.OpenAI)
+	llmClient, err := llm.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	imageGenerator, err := llm.As[llm.ImageGenerationModel](llmClient)
+	if err != nil {
+		return nil, err
+	}
 
 	// Create service
-	service := NewService(cfg, httpClient, llmClient)
+	service := NewService(cfg, httpClient, centrala.NewClient(httpClient, cfg.AIDevs).WithInputs(cfg.Inputs.Overrides), llmClient, imageGenerator)
 
 	return &Handler{
 		service: service,
 		config:  cfg,
-	}
+	}, nil
 }
 
 // Execute runs the S02E03 task
 func (h *Handler) Execute(ctx context.Context) error {
-	log.Println("Starting S02E03 robot image generation task")
+	logger := logging.FromContext(ctx)
+	logger.Info("Starting S02E03 robot image generation task")
 
-	// Get API key from environment
-	apiKey := os.Getenv("AI_DEVS_API_KEY")
+	// Centrala client reads the API key from config
+	apiKey := h.config.AIDevs.APIKey
 	if apiKey == "" {
-		return fmt.Errorf("AI_DEVS_API_KEY environment variable not set")
+		return fmt.Errorf("AI_DEVS_API_KEY is required")
 	}
 
 	// Execute the task
@@ -49,7 +57,7 @@ func (h *Handler) Execute(ctx context.Context) error {
 	if err != nil {
 		var taskErr pkgerrors.TaskError
 		if errors.As(err, &taskErr) {
-			log.Printf("Task failed at step %s: %v", taskErr.Step, taskErr.Err)
+			logger.Error("Task failed", "step", taskErr.Step, "error", taskErr.Err)
 			return fmt.Errorf("S02E03 task failed: %w", err)
 		}
 		return fmt.Errorf("S02E03 task failed: %w", err)
@@ -59,7 +67,7 @@ func (h *Handler) Execute(ctx context.Context) error {
 	h.service.PrintGenerationDetails(result)
 
 	// Log results
-	log.Printf("Task completed successfully!")
+	logger.Info("Task completed")
 	fmt.Printf("Response: %s\n", result.Response)
 
 	return nil
@@ -2,28 +2,37 @@ package e03
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 
+	"ai-devs3/internal/centrala"
 	"ai-devs3/internal/config"
 	"ai-devs3/internal/http"
-	"ai-devs3/internal/llm/openai"
+	"ai-devs3/internal/llm"
+	"ai-devs3/internal/logging"
+	"ai-devs3/internal/prompts"
+	"ai-devs3/internal/tracing"
 	"ai-devs3/pkg/errors"
 )
 
 // Service handles the S02E03 robot image generation task
 type Service struct {
-	httpClient *http.Client
-	llmClient  *openai.Client
-	config     *config.Config
+	httpClient     *http.Client
+	centralaClient *centrala.Client
+	llmClient      llm.ChatModel
+	imageGenerator llm.ImageGenerationModel
+	config         *config.Config
 }
 
 // NewService creates a new S02E03 service
-func NewService(cfg *config.Config, httpClient *http.Client, llmClient *openai.Client) *Service {
+func NewService(cfg *config.Config, httpClient *http.Client, centralaClient *centrala.Client, llmClient llm.ChatModel, imageGenerator llm.ImageGenerationModel) *Service {
 	return &Service{
-		httpClient: httpClient,
-		llmClient:  llmClient,
-		config:     cfg,
+		httpClient:     httpClient,
+		centralaClient: centralaClient,
+		llmClient:      llmClient,
+		imageGenerator: imageGenerator,
+		config:         cfg,
 	}
 }
 
@@ -33,13 +42,16 @@ func (s *Service) FetchRobotDescription(ctx context.Context, apiKey string) (*Ro
 		return nil, errors.NewProcessingError("http", "fetch_robot_description", "API key is empty", nil)
 	}
 
-	url := fmt.Sprintf("https://c3ntrala.ag3nts.org/data/%s/robotid.json", apiKey)
-
-	data, err := s.httpClient.FetchJSONData(ctx, url)
+	content, err := s.centralaClient.FetchTaskData(ctx, "robotid.json")
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch robot description: %w", err)
 	}
 
+	var data map[string]any
+	if err := json.Unmarshal([]byte(content), &data); err != nil {
+		return nil, errors.NewProcessingError("json", "fetch_robot_description", "failed to parse robot description", err)
+	}
+
 	// Extract the description from the JSON response
 	description, ok := data["description"].(string)
 	if !ok {
@@ -48,7 +60,7 @@ func (s *Service) FetchRobotDescription(ctx context.Context, apiKey string) (*Ro
 
 	return &RobotDescription{
 		Description: description,
-		Source:      url,
+		Source:      s.centralaClient.TaskDataURL("robotid.json"),
 	}, nil
 }
 
@@ -58,7 +70,15 @@ func (s *Service) OptimizeDescriptionForDALLE(ctx context.Context, description s
 		return nil, errors.NewProcessingError("llm", "optimize_description", "description is empty", nil)
 	}
 
-	optimizedPrompt, err := s.llmClient.ExtractKeywordsForDALLE(ctx, description)
+	systemPrompt, err := prompts.Render("s02e03/dalle_keywords", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Some creativity for the visual description, kept short
+	model := llm.Tune(s.llmClient, llm.Tuning{OpenAIModel: "gpt-4.1-mini", Temperature: 0.7, MaxTokens: 200})
+	optimizedPrompt, err := model.Chat(ctx, systemPrompt,
+		fmt.Sprintf("Create a DALL-E 3 optimized prompt for this robot description:\n\n%s", description))
 	if err != nil {
 		return nil, fmt.Errorf("failed to optimize description for DALL-E: %w", err)
 	}
@@ -77,7 +97,7 @@ func (s *Service) GenerateRobotImage(ctx context.Context, optimizedPrompt string
 		return nil, errors.NewProcessingError("llm", "generate_image", "optimized prompt is empty", nil)
 	}
 
-	imageURL, err := s.llmClient.GenerateImage(ctx, optimizedPrompt)
+	imageURL, err := s.imageGenerator.GenerateImage(ctx, optimizedPrompt)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate image with DALL-E: %w", err)
 	}
@@ -90,39 +110,44 @@ func (s *Service) GenerateRobotImage(ctx context.Context, optimizedPrompt string
 }
 
 // SubmitImageURL submits the generated image URL to the centrala API
-func (s *Service) SubmitImageURL(ctx context.Context, apiKey string, imageURL string) (string, error) {
-	response := s.httpClient.BuildAIDevsResponse("robotid", apiKey, imageURL)
-
-	result, err := s.httpClient.PostReport(ctx, "https://c3ntrala.ag3nts.org", response)
+func (s *Service) SubmitImageURL(ctx context.Context, imageURL string) (string, error) {
+	response, err := s.centralaClient.Report(ctx, "robotid", imageURL)
 	if err != nil {
 		return "", fmt.Errorf("failed to submit image URL: %w", err)
 	}
 
-	return result, nil
+	if flag := response.Flag(); flag != "" {
+		logging.FromContext(ctx).Info("Received flag", "flag", flag)
+	}
+
+	return response.Message, nil
 }
 
 // ExecuteTask executes the complete S02E03 task workflow
-func (s *Service) ExecuteTask(ctx context.Context, apiKey string) (*TaskResult, error) {
+func (s *Service) ExecuteTask(ctx context.Context, apiKey string) (_ *TaskResult, err error) {
+	steps := tracing.NewSteps(ctx)
+	defer func() { steps.End(err) }()
+
 	// Step 1: Fetch robot description
-	robotDesc, err := s.FetchRobotDescription(ctx, apiKey)
+	robotDesc, err := s.FetchRobotDescription(steps.Start("fetch_robot_description"), apiKey)
 	if err != nil {
 		return nil, errors.NewTaskError("s02e03", "fetch_robot_description", err)
 	}
 
 	// Step 2: Optimize description for DALL-E
-	optimization, err := s.OptimizeDescriptionForDALLE(ctx, robotDesc.Description)
+	optimization, err := s.OptimizeDescriptionForDALLE(steps.Start("optimize_description"), robotDesc.Description)
 	if err != nil {
 		return nil, errors.NewTaskError("s02e03", "optimize_description", err)
 	}
 
 	// Step 3: Generate image
-	imageResult, err := s.GenerateRobotImage(ctx, optimization.Optimized)
+	imageResult, err := s.GenerateRobotImage(steps.Start("generate_image"), optimization.Optimized)
 	if err != nil {
 		return nil, errors.NewTaskError("s02e03", "generate_image", err)
 	}
 
 	// Step 4: Submit image URL
-	response, err := s.SubmitImageURL(ctx, apiKey, imageResult.ImageURL)
+	response, err := s.SubmitImageURL(steps.Start("submit_image_url"), imageResult.ImageURL)
 	if err != nil {
 		return nil, errors.NewTaskError("s02e03", "submit_image_url", err)
 	}
@@ -5,10 +5,21 @@ import (
 	"time"
 
 	"ai-devs3/internal/config"
+	"ai-devs3/internal/tasks"
 
 	"github.com/spf13/cobra"
 )
 
+func init() {
+	tasks.Register(tasks.Task{
+		ID:         "s02e04",
+		Title:      "File Categorization",
+		Season:     2,
+		Requires:   []tasks.Requirement{tasks.RequireAIDevs, tasks.RequireLLM},
+		NewCommand: NewCommand,
+	})
+}
+
 // NewCommand creates a new cobra command for S02E04 task
 func NewCommand(cfg *config.Config) *cobra.Command {
 	return &cobra.Command{
@@ -19,15 +30,15 @@ func NewCommand(cfg *config.Config) *cobra.Command {
 This task involves:
 1. Scanning the pliki_z_fabryki directory for processable files
 2. Processing text files, images (OCR), and audio files (transcription)
-3. Using OpenAI to categorize content into people and hardware categories
+3. Using the LLM_PROVIDER model to categorize content into people and hardware categories
 4. Implementing caching for expensive operations (OCR, transcription)
 5. Using concurrent processing with worker pools for efficiency
 6. Submitting the categorized file lists to the centrala API
 
 The task requires:
 - AI_DEVS_API_KEY environment variable to be set
-- Files directory at ../lessons-md/pliki_z_fabryki
-- OpenAI API access for OCR, transcription, and categorization
+- Files directory at <LESSONS_DIR>/pliki_z_fabryki
+- LLM_PROVIDER=openai, the provider with both vision (OCR) and Whisper transcription
 - Sufficient disk space for caching results
 
 The system will:
@@ -39,11 +50,14 @@ The system will:
 - Provide detailed processing statistics`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Create context with timeout for file processing
-			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
+			ctx, cancel := context.WithTimeout(cmd.Context(), 15*time.Minute)
 			defer cancel()
 
 			// Create and run handler
-			handler := NewHandler(cfg)
+			handler, err := NewHandler(cfg)
+			if err != nil {
+				return err
+			}
 			return handler.Execute(ctx)
 		},
 	}
@@ -4,12 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
 	"os"
 
+	"ai-devs3/internal/centrala"
 	"ai-devs3/internal/config"
 	"ai-devs3/internal/http"
-	"ai-devs3/internal/llm/openai"
+	"ai-devs3/internal/inputs"
+	"ai-devs3/internal/llm"
+	"ai-devs3/internal/logging"
 	"ai-devs3/internal/storage/cache"
 	pkgerrors "ai-devs3/pkg/errors"
 )
@@ -21,40 +23,53 @@ type Handler struct {
 }
 
 // NewHandler creates a new S02E04 handler
-func NewHandler(cfg *config.Config) *Handler {
+func NewHandler(cfg *config.Config) (*Handler, error) {
 	// Initialize dependencies
 	httpClient := http.NewClient(cfg.HTTP)
-	llmClient := openai.NewClient(cfg.OpenAI)
+	model, err := llm.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	llmClient, err := llm.As[llm.VisionModel](model)
+	if err != nil {
+		return nil, err
+	}
+	transcriber, err := llm.As[llm.TranscriptionModel](model)
+	if err != nil {
+		return nil, err
+	}
 
 	// Create cache
 	fileCache, err := cache.NewFileCache(cfg.Cache)
 	if err != nil {
-		log.Printf("Failed to create file cache: %v", err)
-		return nil
+		return nil, fmt.Errorf("failed to create file cache: %w", err)
 	}
 	taskCache := cache.NewTaskCache(fileCache, "s02e04")
 
 	// Create service
-	service := NewService(cfg, httpClient, llmClient, taskCache)
+	service := NewService(cfg, httpClient, centrala.NewClient(httpClient, cfg.AIDevs), llmClient, transcriber, taskCache)
 
 	return &Handler{
 		service: service,
 		config:  cfg,
-	}
+	}, nil
 }
 
 // Execute runs the S02E04 task
 func (h *Handler) Execute(ctx context.Context) error {
-	log.Println("Starting S02E04 file categorization task")
+	logger := logging.FromContext(ctx)
+	logger.Info("Starting S02E04 file categorization task")
 
-	// Get API key from environment
-	apiKey := os.Getenv("AI_DEVS_API_KEY")
-	if apiKey == "" {
-		return fmt.Errorf("AI_DEVS_API_KEY environment variable not set")
+	// Centrala client reads the API key from config
+	if h.config.AIDevs.APIKey == "" {
+		return fmt.Errorf("AI_DEVS_API_KEY is required")
 	}
 
-	// Default files directory
-	filesDir := "../lessons-md/pliki_z_fabryki"
+	// Factory files from the course materials (override with --input pliki_z_fabryki=<dir>)
+	filesDir, err := inputs.NewResolver(h.config, "s02e04").LessonsPath("pliki_z_fabryki", "pliki_z_fabryki")
+	if err != nil {
+		return err
+	}
 
 	// Check if files directory exists
 	if _, err := os.Stat(filesDir); os.IsNotExist(err) {
@@ -62,11 +77,11 @@ func (h *Handler) Execute(ctx context.Context) error {
 	}
 
 	// Execute the task
-	result, err := h.service.ExecuteTask(ctx, filesDir, apiKey)
+	result, err := h.service.ExecuteTask(ctx, filesDir)
 	if err != nil {
 		var taskErr pkgerrors.TaskError
 		if errors.As(err, &taskErr) {
-			log.Printf("Task failed at step %s: %v", taskErr.Step, taskErr.Err)
+			logger.Error("Task failed", "step", taskErr.Step, "error", taskErr.Err)
 			return fmt.Errorf("S02E04 task failed: %w", err)
 		}
 		return fmt.Errorf("S02E04 task failed: %w", err)
@@ -74,14 +89,13 @@ func (h *Handler) Execute(ctx context.Context) error {
 
 	// Print processing statistics
 	h.service.PrintProcessingStats(result.ProcessingStats)
+	h.config.OpenAI.Usage.Print(os.Stdout)
 
 	// Log results
-	log.Printf("Task completed successfully!")
-	log.Printf("Success: %t", result.Success)
-	log.Printf("Total files processed: %d", result.TotalFiles)
-	log.Printf("Files categorized: %d", result.CategorizedCount)
-	log.Printf("People files: %d", len(result.CategorizedFiles.People))
-	log.Printf("Hardware files: %d", len(result.CategorizedFiles.Hardware))
+	logger.Info("Task completed",
+		"success", result.Success, "files", result.TotalFiles,
+		"categorized", result.CategorizedCount, "people_files", len(result.CategorizedFiles.People),
+		"hardware_files", len(result.CategorizedFiles.Hardware))
 
 	fmt.Println("=== Categorization Results ===")
 	fmt.Printf("People files (%d):\n", len(result.CategorizedFiles.People))
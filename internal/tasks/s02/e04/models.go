@@ -17,6 +17,7 @@ type ProcessingResult struct {
 	ProcessTime float64
 	Error       error
 	FromCache   bool
+	DuplicateOf string // image whose content this near-duplicate reuses
 }
 
 // CategorizedFiles represents the categorization result
@@ -33,6 +34,13 @@ type CategoryResult struct {
 	Confidence    float64
 }
 
+// Categorization is the LLM's verdict on the content of one file
+type Categorization struct {
+	Thinking      string `json:"_thinking"`
+	Category      string `json:"category" enum:"people,hardware,skip"`
+	Justification string `json:"justification"`
+}
+
 // TaskResult represents the final result of the S02E04 task
 type TaskResult struct {
 	Response         string
@@ -54,6 +62,7 @@ type ProcessingStats struct {
 	AudioFiles     int
 	ProcessingTime float64
 	CacheHitRate   float64
+	DuplicateFiles int
 	PeopleFiles    int
 	HardwareFiles  int
 }
@@ -67,11 +76,12 @@ type FileDirectory struct {
 
 // FileInfo represents basic information about a file
 type FileInfo struct {
-	Name      string
-	Path      string
-	Size      int64
-	Extension string
-	Type      string
+	Name        string
+	Path        string
+	Size        int64
+	Extension   string
+	Type        string
+	DuplicateOf string // earlier image this one is a near-duplicate of, processed in its place
 }
 
 // CacheManager handles caching for expensive operations
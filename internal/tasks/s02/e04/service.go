@@ -10,28 +10,48 @@ import (
 	"sync"
 	"time"
 
+	"ai-devs3/internal/centrala"
 	"ai-devs3/internal/config"
 	"ai-devs3/internal/http"
-	"ai-devs3/internal/llm/openai"
+	"ai-devs3/internal/image"
+	"ai-devs3/internal/inputs"
+	"ai-devs3/internal/llm"
+	"ai-devs3/internal/logging"
+	"ai-devs3/internal/prompts"
 	"ai-devs3/internal/storage/cache"
+	"ai-devs3/internal/tasks/utils/ocr"
+	"ai-devs3/internal/tracing"
 	"ai-devs3/pkg/errors"
 )
 
+// duplicateDistance is the largest perceptual hash distance between images
+// processed only once. Scanned documents sharing a layout hash alike, so only
+// near-identical copies count as duplicates.
+const duplicateDistance = 2
+
 // Service handles the S02E04 file categorization task
 type Service struct {
-	httpClient *http.Client
-	llmClient  *openai.Client
-	cache      *cache.TaskCache
-	config     *config.Config
+	httpClient     *http.Client
+	centralaClient *centrala.Client
+	llmClient      llm.VisionModel
+	transcriber    llm.TranscriptionModel
+	imageProcessor *image.Processor
+	cache          *cache.TaskCache
+	config         *config.Config
+	inputs         *inputs.Resolver
 }
 
 // NewService creates a new S02E04 service
-func NewService(cfg *config.Config, httpClient *http.Client, llmClient *openai.Client, taskCache *cache.TaskCache) *Service {
+func NewService(cfg *config.Config, httpClient *http.Client, centralaClient *centrala.Client, llmClient llm.VisionModel, transcriber llm.TranscriptionModel, taskCache *cache.TaskCache) *Service {
 	return &Service{
-		httpClient: httpClient,
-		llmClient:  llmClient,
-		cache:      taskCache,
-		config:     cfg,
+		httpClient:     httpClient,
+		centralaClient: centralaClient,
+		llmClient:      llmClient,
+		transcriber:    transcriber,
+		imageProcessor: image.NewProcessor(),
+		cache:          taskCache,
+		config:         cfg,
+		inputs:         inputs.NewResolver(cfg, "s02e04"),
 	}
 }
 
@@ -78,20 +98,72 @@ func (s *Service) ScanFilesDirectory(ctx context.Context, filesDir string) (*Fil
 	}, nil
 }
 
-// ProcessFiles processes all files in the directory concurrently
+// FindDuplicateImages marks images that are near-duplicates of an earlier one
+// by perceptual hash and returns how many it marked. Images that cannot be
+// hashed are processed on their own.
+func (s *Service) FindDuplicateImages(ctx context.Context, fileDir *FileDirectory) int {
+	logger := logging.FromContext(ctx)
+
+	var indexes []int
+	var hashes []image.Hash
+	for i, file := range fileDir.Files {
+		if file.Type != "image" {
+			continue
+		}
+
+		data, err := os.ReadFile(file.Path)
+		if err != nil {
+			logger.Warn("Failed to read image for hashing", "file", file.Name, "error", err)
+			continue
+		}
+		imageHashes, err := s.imageProcessor.Hash(data)
+		if err != nil {
+			logger.Warn("Failed to hash image", "file", file.Name, "error", err)
+			continue
+		}
+
+		indexes = append(indexes, i)
+		hashes = append(hashes, imageHashes.Perceptual)
+	}
+
+	duplicates := 0
+	for _, cluster := range image.Cluster(hashes, duplicateDistance) {
+		original := fileDir.Files[indexes[cluster[0]]].Name
+		for _, member := range cluster[1:] {
+			file := &fileDir.Files[indexes[member]]
+			file.DuplicateOf = original
+			duplicates++
+			logger.Info("Skipping duplicate image", "file", file.Name, "duplicate_of", original)
+		}
+	}
+
+	return duplicates
+}
+
+// ProcessFiles processes all files in the directory concurrently;
+// near-duplicate images reuse the result of the image they duplicate
 func (s *Service) ProcessFiles(ctx context.Context, fileDir *FileDirectory, options *ProcessingOptions) ([]ProcessingResult, error) {
 	if len(fileDir.Files) == 0 {
 		return []ProcessingResult{}, nil
 	}
 
+	var files, duplicates []FileInfo
+	for _, file := range fileDir.Files {
+		if file.DuplicateOf != "" {
+			duplicates = append(duplicates, file)
+		} else {
+			files = append(files, file)
+		}
+	}
+
 	// Create channels for worker pool
-	fileChan := make(chan FileInfo, len(fileDir.Files))
-	resultChan := make(chan ProcessingResult, len(fileDir.Files))
+	fileChan := make(chan FileInfo, len(files))
+	resultChan := make(chan ProcessingResult, len(files))
 
 	// Send files to process
 	go func() {
 		defer close(fileChan)
-		for _, file := range fileDir.Files {
+		for _, file := range files {
 			select {
 			case <-ctx.Done():
 				return
@@ -135,8 +207,24 @@ func (s *Service) ProcessFiles(ctx context.Context, fileDir *FileDirectory, opti
 
 	// Collect results
 	var results []ProcessingResult
+	byName := make(map[string]ProcessingResult)
 	for result := range resultChan {
 		results = append(results, result)
+		byName[result.FileData.Filename] = result
+	}
+
+	for _, file := range duplicates {
+		original, ok := byName[file.DuplicateOf]
+		if !ok {
+			continue // cancelled before the original was processed
+		}
+		result := original
+		result.FileData.Filename = file.Name
+		result.FileData.FilePath = file.Path
+		result.FileData.Size = file.Size
+		result.ProcessTime = 0
+		result.DuplicateOf = file.DuplicateOf
+		results = append(results, result)
 	}
 
 	return results, nil
@@ -205,78 +293,76 @@ func (s *Service) processTextFile(file FileInfo) (string, error) {
 
 // processImageFile processes an image file using OCR
 func (s *Service) processImageFile(ctx context.Context, file FileInfo, options *ProcessingOptions) (string, bool, error) {
-	// Check cache first
-	if options.CacheEnabled {
-		if cached, err := s.cache.GetOCRText(ctx, file.Name); err == nil {
-			return cached, true, nil
+	extract := func(ctx context.Context) (string, error) {
+		// Read image file
+		imageData, err := os.ReadFile(file.Path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read image file: %w", err)
 		}
-	}
 
-	// Read image file
-	imageData, err := os.ReadFile(file.Path)
-	if err != nil {
-		return "", false, fmt.Errorf("failed to read image file: %w", err)
-	}
-
-	// Perform OCR
-	ocrText, err := s.llmClient.ExtractTextFromImage(ctx, imageData)
-	if err != nil {
-		return "", false, fmt.Errorf("failed to extract text from image: %w", err)
+		// Perform OCR
+		ocrText, err := ocr.ExtractText(ctx, s.llmClient, imageData)
+		if err != nil {
+			return "", fmt.Errorf("failed to extract text from image: %w", err)
+		}
+		return ocrText, nil
 	}
 
-	// Cache the result
-	if options.CacheEnabled {
-		if err := s.cache.SetOCRText(ctx, file.Name, ocrText); err != nil {
-			// Log error but don't fail the operation
-			fmt.Printf("Failed to cache OCR result for %s: %v\n", file.Name, err)
-		}
+	if !options.CacheEnabled {
+		ocrText, err := extract(ctx)
+		return ocrText, false, err
 	}
 
-	return ocrText, false, nil
+	// Workers asking for the same image share a single OCR call
+	return s.cache.GetOrComputeOCRText(ctx, file.Name, extract)
 }
 
 // processAudioFile processes an audio file using transcription
 func (s *Service) processAudioFile(ctx context.Context, file FileInfo, options *ProcessingOptions) (string, bool, error) {
-	// Check cache first
-	if options.CacheEnabled {
-		if cached, err := s.cache.GetAudioTranscript(ctx, file.Name); err == nil {
-			return cached, true, nil
+	transcribe := func(ctx context.Context) (string, error) {
+		// Open audio file
+		audioFile, err := os.Open(file.Path)
+		if err != nil {
+			return "", fmt.Errorf("failed to open audio file: %w", err)
 		}
-	}
+		defer audioFile.Close()
 
-	// Open audio file
-	audioFile, err := os.Open(file.Path)
-	if err != nil {
-		return "", false, fmt.Errorf("failed to open audio file: %w", err)
-	}
-	defer audioFile.Close()
-
-	// Perform transcription
-	transcript, err := s.llmClient.TranscribeAudio(ctx, audioFile, file.Name)
-	if err != nil {
-		return "", false, fmt.Errorf("failed to transcribe audio: %w", err)
+		// Perform transcription
+		transcript, err := s.transcriber.Transcribe(ctx, audioFile, file.Name)
+		if err != nil {
+			return "", fmt.Errorf("failed to transcribe audio: %w", err)
+		}
+		return transcript, nil
 	}
 
-	// Cache the result
-	if options.CacheEnabled {
-		if err := s.cache.SetAudioTranscript(ctx, file.Name, transcript); err != nil {
-			// Log error but don't fail the operation
-			fmt.Printf("Failed to cache transcription for %s: %v\n", file.Name, err)
-		}
+	if !options.CacheEnabled {
+		transcript, err := transcribe(ctx)
+		return transcript, false, err
 	}
 
-	return transcript, false, nil
+	// Workers asking for the same recording share a single transcription
+	return s.cache.GetOrComputeAudioTranscript(ctx, file.Name, transcribe)
 }
 
-// CategorizeFiles categorizes the processed files into people and hardware
+// CategorizeFiles categorizes the processed files into people and hardware;
+// near-duplicate images take the category of the image they duplicate
 func (s *Service) CategorizeFiles(ctx context.Context, results []ProcessingResult) ([]CategoryResult, error) {
 	var categories []CategoryResult
+	byFilename := make(map[string]CategoryResult)
 
 	for _, result := range results {
 		if result.Error != nil {
 			continue
 		}
 
+		// ProcessFiles lists near-duplicates after their originals
+		if original, ok := byFilename[result.DuplicateOf]; ok {
+			category := original
+			category.Filename = result.FileData.Filename
+			categories = append(categories, category)
+			continue
+		}
+
 		if result.FileData.Content == "" || strings.TrimSpace(result.FileData.Content) == "" {
 			categories = append(categories, CategoryResult{
 				Filename:      result.FileData.Filename,
@@ -288,22 +374,35 @@ func (s *Service) CategorizeFiles(ctx context.Context, results []ProcessingResul
 		}
 
 		// Use LLM to categorize
-		categorization, err := s.llmClient.CategorizeContent(ctx, result.FileData.Content)
+		categorization, err := s.categorizeContent(ctx, result.FileData.Content)
 		if err != nil {
 			return nil, fmt.Errorf("failed to categorize file %s: %w", result.FileData.Filename, err)
 		}
 
-		categories = append(categories, CategoryResult{
+		category := CategoryResult{
 			Filename:      result.FileData.Filename,
 			Category:      categorization.Category,
 			Justification: categorization.Justification,
 			Confidence:    0.85, // Default confidence
-		})
+		}
+		categories = append(categories, category)
+		byFilename[category.Filename] = category
 	}
 
 	return categories, nil
 }
 
+// categorizeContent asks whether content is about people, hardware or neither
+func (s *Service) categorizeContent(ctx context.Context, content string) (*Categorization, error) {
+	systemPrompt, err := prompts.Render("s02e04/categorize_content", map[string]any{"Content": content})
+	if err != nil {
+		return nil, err
+	}
+
+	model := llm.Tune(s.llmClient, llm.Tuning{OpenAIModel: "gpt-4o-mini", Temperature: 0.1})
+	return llm.ChatStructured[Categorization](ctx, model, systemPrompt, "Categorize this content into people, hardware, or skip.")
+}
+
 // BuildCategorizedFiles builds the final categorized files structure
 func (s *Service) BuildCategorizedFiles(categories []CategoryResult) *CategorizedFiles {
 	categorized := &CategorizedFiles{
@@ -324,21 +423,26 @@ func (s *Service) BuildCategorizedFiles(categories []CategoryResult) *Categorize
 }
 
 // SubmitCategorization submits the categorization results to the centrala API
-func (s *Service) SubmitCategorization(ctx context.Context, apiKey string, categorized *CategorizedFiles) (string, error) {
-	response := s.httpClient.BuildAIDevsResponse("kategorie", apiKey, categorized)
-
-	result, err := s.httpClient.PostReport(ctx, "https://c3ntrala.ag3nts.org", response)
+func (s *Service) SubmitCategorization(ctx context.Context, categorized *CategorizedFiles) (string, error) {
+	response, err := s.centralaClient.Report(ctx, "kategorie", categorized)
 	if err != nil {
 		return "", fmt.Errorf("failed to submit categorization: %w", err)
 	}
 
-	return result, nil
+	if flag := response.Flag(); flag != "" {
+		logging.FromContext(ctx).Info("Received flag", "flag", flag)
+	}
+
+	return response.Message, nil
 }
 
 // ExecuteTask executes the complete S02E04 task workflow
-func (s *Service) ExecuteTask(ctx context.Context, filesDir string, apiKey string) (*TaskResult, error) {
+func (s *Service) ExecuteTask(ctx context.Context, filesDir string) (_ *TaskResult, err error) {
+	steps := tracing.NewSteps(ctx)
+	defer func() { steps.End(err) }()
+
 	// Step 1: Scan files directory
-	fileDir, err := s.ScanFilesDirectory(ctx, filesDir)
+	fileDir, err := s.ScanFilesDirectory(steps.Start("scan_files_directory"), filesDir)
 	if err != nil {
 		return nil, errors.NewTaskError("s02e04", "scan_files_directory", err)
 	}
@@ -348,7 +452,10 @@ func (s *Service) ExecuteTask(ctx context.Context, filesDir string, apiKey strin
 			fmt.Errorf("no processable files found in directory: %s", filesDir))
 	}
 
-	// Step 2: Process files
+	// Step 2: Process near-duplicate images only once
+	s.FindDuplicateImages(steps.Start("find_duplicate_images"), fileDir)
+
+	// Step 3: Process files
 	options := &ProcessingOptions{
 		MaxWorkers:     runtime.NumCPU() * 4,
 		CacheEnabled:   true,
@@ -357,22 +464,22 @@ func (s *Service) ExecuteTask(ctx context.Context, filesDir string, apiKey strin
 		ProcessingDir:  filesDir,
 	}
 
-	results, err := s.ProcessFiles(ctx, fileDir, options)
+	results, err := s.ProcessFiles(steps.Start("process_files"), fileDir, options)
 	if err != nil {
 		return nil, errors.NewTaskError("s02e04", "process_files", err)
 	}
 
-	// Step 3: Categorize files
-	categories, err := s.CategorizeFiles(ctx, results)
+	// Step 4: Categorize files
+	categories, err := s.CategorizeFiles(steps.Start("categorize_files"), results)
 	if err != nil {
 		return nil, errors.NewTaskError("s02e04", "categorize_files", err)
 	}
 
-	// Step 4: Build categorized files structure
+	// Step 5: Build categorized files structure
 	categorized := s.BuildCategorizedFiles(categories)
 
-	// Step 5: Submit categorization
-	response, err := s.SubmitCategorization(ctx, apiKey, categorized)
+	// Step 6: Submit categorization
+	response, err := s.SubmitCategorization(steps.Start("submit_categorization"), categorized)
 	if err != nil {
 		return nil, errors.NewTaskError("s02e04", "submit_categorization", err)
 	}
@@ -405,6 +512,9 @@ func (s *Service) BuildProcessingStats(results []ProcessingResult, categories []
 		if result.FromCache {
 			cacheHits++
 		}
+		if result.DuplicateOf != "" {
+			stats.DuplicateFiles++
+		}
 
 		if result.Error != nil {
 			stats.ErrorFiles++
@@ -455,6 +565,7 @@ func (s *Service) PrintProcessingStats(stats *ProcessingStats) {
 	fmt.Printf("  Text files: %d\n", stats.TextFiles)
 	fmt.Printf("  Image files: %d\n", stats.ImageFiles)
 	fmt.Printf("  Audio files: %d\n", stats.AudioFiles)
+	fmt.Printf("  Duplicate images: %d\n", stats.DuplicateFiles)
 	fmt.Println()
 
 	fmt.Printf("Categorization results:\n")
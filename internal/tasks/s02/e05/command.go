@@ -5,10 +5,21 @@ import (
 	"time"
 
 	"ai-devs3/internal/config"
+	"ai-devs3/internal/tasks"
 
 	"github.com/spf13/cobra"
 )
 
+func init() {
+	tasks.Register(tasks.Task{
+		ID:         "s02e05",
+		Title:      "Arxiv Document Analysis",
+		Season:     2,
+		Requires:   []tasks.Requirement{tasks.RequireAIDevs, tasks.RequireLLM},
+		NewCommand: NewCommand,
+	})
+}
+
 // NewCommand creates a new cobra command for S02E05 task
 func NewCommand(cfg *config.Config) *cobra.Command {
 	return &cobra.Command{
@@ -19,15 +30,15 @@ func NewCommand(cfg *config.Config) *cobra.Command {
 		This task involves:
 			1. Fetching Professor Maj's intercepted research article from the centrala system
 			2. Processing HTML content to extract text, images, and audio files
-			3. Analyzing images using OpenAI vision capabilities
-			4. Transcribing audio files using OpenAI Whisper
+			3. Analyzing images with the LLM_PROVIDER vision model
+			4. Transcribing audio files with the LLM_PROVIDER transcription model
 			5. Fetching task-specific questions from the centrala API
 			6. Using consolidated context to answer questions about the research
 			7. Submitting answers to the centrala system
 
 		The task requires:
 			1. AI_DEVS_API_KEY environment variable to be set
-			2. OpenAI API access for vision, audio transcription, and text analysis
+			2. LLM_PROVIDER=openai, the provider with both vision and Whisper transcription
 			3. Internet connectivity to fetch remote content
 			4. Sufficient disk space for caching processed content
 
@@ -41,11 +52,14 @@ func NewCommand(cfg *config.Config) *cobra.Command {
 			7. Provide detailed logging of the analysis process`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Create context with timeout for content processing
-			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+			ctx, cancel := context.WithTimeout(cmd.Context(), 10*time.Minute)
 			defer cancel()
 
 			// Create and run handler
-			handler := NewHandler(cfg)
+			handler, err := NewHandler(cfg)
+			if err != nil {
+				return err
+			}
 			return handler.Execute(ctx)
 		},
 	}
@@ -4,11 +4,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"os"
 
+	"ai-devs3/internal/centrala"
 	"ai-devs3/internal/config"
 	"ai-devs3/internal/http"
-	"ai-devs3/internal/llm/openai"
+	"ai-devs3/internal/inputs"
+	"ai-devs3/internal/llm"
+	"ai-devs3/internal/logging"
+	"ai-devs3/internal/storage/cache"
 	pkgerrors "ai-devs3/pkg/errors"
 )
 
@@ -16,27 +21,48 @@ import (
 type Handler struct {
 	config     *config.Config
 	httpClient *http.Client
-	llmClient  *openai.Client
+	llmClient  llm.VisionModel
 	service    *Service
 }
 
 // NewHandler creates a new handler instance
-func NewHandler(cfg *config.Config) *Handler {
+func NewHandler(cfg *config.Config) (*Handler, error) {
 	httpClient := http.NewClient(cfg.HTTP)
-	llmClient := openai.NewClient(cfg.OpenAI)
-	service := NewService(httpClient, llmClient)
+	model, err := llm.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	llmClient, err := llm.As[llm.VisionModel](model)
+	if err != nil {
+		return nil, err
+	}
+	transcriber, err := llm.As[llm.TranscriptionModel](model)
+	if err != nil {
+		return nil, err
+	}
+
+	// Without a cache every image and recording is analyzed again
+	var taskCache *cache.TaskCache
+	if fileCache, err := cache.NewFileCache(cfg.Cache); err != nil {
+		slog.Warn("Failed to create file cache", "error", err)
+	} else {
+		taskCache = cache.NewTaskCache(fileCache, "s02e05")
+	}
+
+	service := NewService(httpClient, centrala.NewClient(httpClient, cfg.AIDevs).WithInputs(cfg.Inputs.Overrides), llmClient, transcriber, inputs.NewResolver(cfg, "s02e05"), taskCache)
 
 	return &Handler{
 		config:     cfg,
 		httpClient: httpClient,
 		llmClient:  llmClient,
 		service:    service,
-	}
+	}, nil
 }
 
 // Execute runs the S02E05 task
 func (h *Handler) Execute(ctx context.Context) error {
-	log.Println("Starting S02E05 arxiv processing task")
+	logger := logging.FromContext(ctx)
+	logger.Info("Starting S02E05 arxiv processing task")
 
 	// Get API key from environment
 	apiKey := h.config.AIDevs.APIKey
@@ -49,7 +75,7 @@ func (h *Handler) Execute(ctx context.Context) error {
 	if err != nil {
 		var taskErr pkgerrors.TaskError
 		if errors.As(err, &taskErr) {
-			log.Printf("Task failed at step %s: %v", taskErr.Step, taskErr.Err)
+			logger.Error("Task failed", "step", taskErr.Step, "error", taskErr.Err)
 			return fmt.Errorf("S02E05 task failed: %w", err)
 		}
 		return fmt.Errorf("S02E05 task failed: %w", err)
@@ -58,12 +84,13 @@ func (h *Handler) Execute(ctx context.Context) error {
 	// Print processing statistics if available
 	if result.ProcessingStats != nil {
 		h.service.PrintProcessingStats(result.ProcessingStats)
+		h.config.OpenAI.Usage.Print(os.Stdout)
 	}
 
 	// Log results
-	log.Printf("Task completed successfully!")
-	log.Printf("Total questions answered: %d", result.TotalQuestions)
-	fmt.Printf("Response: %s\n", result.Response)
+	logger.Info("Task completed",
+		"questions", result.TotalQuestions)
+	logger.Debug("Centrala response", "response", result.Response)
 
 	return nil
 }
@@ -3,7 +3,7 @@ package e05
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -11,8 +11,14 @@ import (
 	"sync"
 	"time"
 
+	"ai-devs3/internal/centrala"
 	"ai-devs3/internal/http"
-	"ai-devs3/internal/llm/openai"
+	"ai-devs3/internal/inputs"
+	"ai-devs3/internal/llm"
+	"ai-devs3/internal/logging"
+	"ai-devs3/internal/prompts"
+	"ai-devs3/internal/storage/cache"
+	"ai-devs3/internal/tracing"
 	"ai-devs3/pkg/errors"
 
 	"golang.org/x/net/html"
@@ -20,20 +26,28 @@ import (
 
 // Service handles the arxiv document analysis task
 type Service struct {
-	httpClient *http.Client
-	llmClient  *openai.Client
+	httpClient     *http.Client
+	centralaClient *centrala.Client
+	llmClient      llm.VisionModel
+	transcriber    llm.TranscriptionModel
+	inputs         *inputs.Resolver
+	cache          *cache.TaskCache
 }
 
 // NewService creates a new service instance
-func NewService(httpClient *http.Client, llmClient *openai.Client) *Service {
+func NewService(httpClient *http.Client, centralaClient *centrala.Client, llmClient llm.VisionModel, transcriber llm.TranscriptionModel, resolver *inputs.Resolver, taskCache *cache.TaskCache) *Service {
 	return &Service{
-		httpClient: httpClient,
-		llmClient:  llmClient,
+		httpClient:     httpClient,
+		centralaClient: centralaClient,
+		llmClient:      llmClient,
+		transcriber:    transcriber,
+		inputs:         resolver,
+		cache:          taskCache,
 	}
 }
 
 // ExecuteTask executes the complete S02E05 task workflow
-func (s *Service) ExecuteTask(ctx context.Context, apiKey string) (*TaskResult, error) {
+func (s *Service) ExecuteTask(ctx context.Context, apiKey string) (_ *TaskResult, err error) {
 	startTime := time.Now()
 
 	// Execute the arxiv task
@@ -43,7 +57,10 @@ func (s *Service) ExecuteTask(ctx context.Context, apiKey string) (*TaskResult,
 	}
 
 	// Submit response
-	response, err := s.submitArxivResponse(ctx, apiKey, answers)
+	steps := tracing.NewSteps(ctx)
+	defer func() { steps.End(err) }()
+
+	response, err := s.submitArxivResponse(steps.Start("submit_response"), answers)
 	if err != nil {
 		return nil, errors.NewTaskError("s02e05", "submit_response", err)
 	}
@@ -60,13 +77,17 @@ func (s *Service) ExecuteTask(ctx context.Context, apiKey string) (*TaskResult,
 }
 
 // executeArxivTask processes the arxiv document and answers questions
-func (s *Service) executeArxivTask(ctx context.Context, apiKey string) (ArxivAnswer, *ProcessingStats, error) {
+func (s *Service) executeArxivTask(ctx context.Context, apiKey string) (_ ArxivAnswer, _ *ProcessingStats, err error) {
+	logger := logging.FromContext(ctx)
+	steps := tracing.NewSteps(ctx)
+	defer func() { steps.End(err) }()
+
 	stats := &ProcessingStats{}
 	// Step 1: Fetch the HTML article
-	articleURL := "https://c3ntrala.ag3nts.org/dane/arxiv-draft.html"
-	log.Printf("Fetching article from: %s", articleURL)
+	articleURL := s.inputs.RemoteURL("arxiv-draft.html", "dane/arxiv-draft.html")
+	logger.Info("Fetching article", "url", articleURL)
 
-	htmlContent, err := s.httpClient.FetchPage(ctx, articleURL)
+	htmlContent, err := s.httpClient.FetchPage(steps.Start("fetch_article"), articleURL)
 	if err != nil {
 		return nil, stats, errors.NewTaskError("s02e05", "fetch_article", err)
 	}
@@ -76,10 +97,9 @@ func (s *Service) executeArxivTask(ctx context.Context, apiKey string) (ArxivAns
 	}
 
 	// Step 2: Fetch questions
-	questionsURL := fmt.Sprintf("https://c3ntrala.ag3nts.org/data/%s/arxiv.txt", apiKey)
-	log.Printf("Fetching questions from: %s", questionsURL)
+	logger.Info("Fetching questions", "url", s.centralaClient.TaskDataURL("arxiv.txt"))
 
-	questionsText, err := s.httpClient.FetchData(ctx, questionsURL)
+	questionsText, err := s.centralaClient.FetchTaskData(steps.Start("fetch_questions"), "arxiv.txt")
 	if err != nil {
 		return nil, stats, errors.NewTaskError("s02e05", "fetch_questions", err)
 	}
@@ -89,8 +109,8 @@ func (s *Service) executeArxivTask(ctx context.Context, apiKey string) (ArxivAns
 	}
 
 	// Step 3: Process the article content
-	log.Println("Processing article content...")
-	content, err := s.processArxivContent(htmlContent, articleURL)
+	logger.Info("Processing article content")
+	content, err := s.processArxivContent(steps.Start("process_content"), htmlContent, articleURL)
 	if err != nil {
 		return nil, stats, errors.NewTaskError("s02e05", "process_content", err)
 	}
@@ -99,8 +119,10 @@ func (s *Service) executeArxivTask(ctx context.Context, apiKey string) (ArxivAns
 	stats.ContentLength = len(content.Text)
 	stats.ImagesProcessed = len(content.ImageDescriptions)
 	stats.AudioProcessed = len(content.AudioTranscripts)
+	stats.CacheHitRate = s.cache.Stats().HitRate()
 
 	// Step 4: Parse questions
+	steps.Start("parse_questions")
 	questions, err := s.parseQuestions(questionsText)
 	if err != nil {
 		return nil, stats, errors.NewTaskError("s02e05", "parse_questions", err)
@@ -110,30 +132,31 @@ func (s *Service) executeArxivTask(ctx context.Context, apiKey string) (ArxivAns
 		return nil, stats, errors.NewTaskError("s02e05", "parse_questions", fmt.Errorf("no questions found to answer"))
 	}
 
-	log.Printf("Found %d questions to answer", len(questions))
+	logger.Info("Found questions to answer", "questions", len(questions))
 	stats.TotalQuestions = len(questions)
 
 	// Step 5: Generate consolidated context
 	consolidatedContext := s.generateConsolidatedContext(content)
 
 	if len(consolidatedContext) < 100 {
-		log.Printf("Warning: consolidated context is very short (%d characters)", len(consolidatedContext))
+		logger.Warn("Consolidated context is very short", "characters", len(consolidatedContext))
 	}
 
 	// Step 5.1: Save consolidated context to file for debugging
 	if err := s.saveConsolidatedContext(consolidatedContext); err != nil {
-		log.Printf("Warning: failed to save consolidated context: %v", err)
+		logger.Warn("Failed to save consolidated context", "error", err)
 	}
 
 	// Step 6: Answer questions using LLM
+	answerCtx := steps.Start("answer_questions")
 	answers := make(ArxivAnswer)
 	answeredCount := 0
 	for questionID, questionText := range questions {
-		log.Printf("Answering question %s: %s", questionID, questionText)
+		logger.Info("Answering question", "question_id", questionID, "question", questionText)
 
-		answer, err := s.answerArxivQuestion(consolidatedContext, questionText)
+		answer, err := s.answerArxivQuestion(answerCtx, consolidatedContext, questionText)
 		if err != nil {
-			log.Printf("Failed to answer question %s: %v", questionID, err)
+			logger.Warn("Failed to answer question", "question_id", questionID, "error", err)
 			answers[questionID] = "Information not available"
 		} else {
 			answers[questionID] = answer
@@ -146,22 +169,24 @@ func (s *Service) executeArxivTask(ctx context.Context, apiKey string) (ArxivAns
 
 	// Validate we have answers for all questions
 	if len(answers) != len(questions) {
-		log.Printf("Warning: answered %d out of %d questions", len(answers), len(questions))
+		logger.Warn("Not every question was answered", "answered", len(answers), "questions", len(questions))
 	}
 
 	return answers, stats, nil
 }
 
 // submitArxivResponse submits the arxiv analysis results to the centrala API
-func (s *Service) submitArxivResponse(ctx context.Context, apiKey string, answers ArxivAnswer) (string, error) {
-	response := s.httpClient.BuildAIDevsResponse("arxiv", apiKey, answers)
-
-	result, err := s.httpClient.PostReport(ctx, "https://c3ntrala.ag3nts.org", response)
+func (s *Service) submitArxivResponse(ctx context.Context, answers ArxivAnswer) (string, error) {
+	response, err := s.centralaClient.Report(ctx, "arxiv", answers)
 	if err != nil {
 		return "", fmt.Errorf("failed to submit arxiv response: %w", err)
 	}
 
-	return result, nil
+	if flag := response.Flag(); flag != "" {
+		logging.FromContext(ctx).Info("Received flag", "flag", flag)
+	}
+
+	return response.Message, nil
 }
 
 // PrintProcessingStats prints detailed processing statistics
@@ -180,7 +205,9 @@ func (s *Service) PrintProcessingStats(stats *ProcessingStats) {
 }
 
 // processArxivContent processes the HTML content and extracts text, images, and audio
-func (s *Service) processArxivContent(htmlContent, baseURL string) (*ArxivContent, error) {
+func (s *Service) processArxivContent(ctx context.Context, htmlContent, baseURL string) (*ArxivContent, error) {
+	logger := logging.FromContext(ctx)
+
 	content := &ArxivContent{
 		ImageDescriptions: make(map[string]string),
 		AudioTranscripts:  make(map[string]string),
@@ -195,26 +222,26 @@ func (s *Service) processArxivContent(htmlContent, baseURL string) (*ArxivConten
 	// Extract text content and convert to markdown
 	textContent := s.extractTextFromHTML(doc)
 	if textContent == "" {
-		log.Printf("Warning: no text content extracted from HTML")
+		logger.Warn("No text content extracted from HTML")
 	}
 	content.Text = s.convertToMarkdown(textContent)
 
 	// Extract and process images with context
 	imageInfos := s.extractImageInfos(doc, baseURL)
 	if len(imageInfos) > 0 {
-		log.Printf("Found %d images to process", len(imageInfos))
-		content.ImageDescriptions = s.processImagesWithContext(imageInfos)
+		logger.Info("Found images to process", "images", len(imageInfos))
+		content.ImageDescriptions = s.processImagesWithContext(ctx, imageInfos)
 	} else {
-		log.Printf("No images found in the document")
+		logger.Info("No images found in the document")
 	}
 
 	// Extract and process audio
 	audioURLs := s.extractAudioURLs(doc, baseURL)
 	if len(audioURLs) > 0 {
-		log.Printf("Found %d audio files to process", len(audioURLs))
-		content.AudioTranscripts = s.processAudioFiles(audioURLs)
+		logger.Info("Found audio files to process", "audio_files", len(audioURLs))
+		content.AudioTranscripts = s.processAudioFiles(ctx, audioURLs)
 	} else {
-		log.Printf("No audio files found in the document")
+		logger.Info("No audio files found in the document")
 	}
 
 	return content, nil
@@ -263,7 +290,7 @@ func (s *Service) extractTextFromHTML(n *html.Node) string {
 
 // saveConsolidatedContext saves the consolidated context to a markdown file
 func (s *Service) saveConsolidatedContext(context string) error {
-	cacheDir := "data/s02e05"
+	cacheDir := s.inputs.DataDir()
 
 	// Ensure cache directory exists
 	if err := os.MkdirAll(cacheDir, 0755); err != nil {
@@ -277,7 +304,7 @@ func (s *Service) saveConsolidatedContext(context string) error {
 		return fmt.Errorf("failed to write consolidated context to file: %w", err)
 	}
 
-	log.Printf("Saved consolidated context to: %s", outputFile)
+	slog.Info("Saved consolidated context", "path", outputFile)
 	return nil
 }
 
@@ -402,12 +429,10 @@ func (s *Service) resolveURL(href, baseURL string) string {
 }
 
 // processImagesWithContext downloads and analyzes images with their captions using caching
-func (s *Service) processImagesWithContext(imageInfos []ImageInfo) map[string]string {
-	descriptions := make(map[string]string)
-	cacheDir := "data/s02e05"
+func (s *Service) processImagesWithContext(ctx context.Context, imageInfos []ImageInfo) map[string]string {
+	logger := logging.FromContext(ctx)
 
-	// Ensure cache directory exists
-	os.MkdirAll(cacheDir, 0755)
+	descriptions := make(map[string]string)
 
 	var wg sync.WaitGroup
 	var mu sync.Mutex
@@ -418,21 +443,20 @@ func (s *Service) processImagesWithContext(imageInfos []ImageInfo) map[string]st
 			defer wg.Done()
 
 			imageKey := fmt.Sprintf("image_%02d", idx+1)
-			cacheFile := filepath.Join(cacheDir, fmt.Sprintf("%s.description.txt", imageKey))
 
 			// Check cache first
-			if cachedDesc, err := os.ReadFile(cacheFile); err == nil {
+			if cachedDesc, ok, err := s.cache.GetImageDescription(ctx, imageKey); err == nil && ok {
 				mu.Lock()
-				descriptions[imageKey] = string(cachedDesc)
+				descriptions[imageKey] = cachedDesc
 				mu.Unlock()
-				log.Printf("Using cached description for %s", imageKey)
+				logger.Debug("Using cached image description", "key", imageKey)
 				return
 			}
 
 			// Download and analyze image
-			imageData, err := s.httpClient.FetchBinaryData(context.Background(), info.URL)
+			imageData, err := s.httpClient.FetchBinaryData(ctx, info.URL)
 			if err != nil {
-				log.Printf("Failed to fetch image %s: %v", info.URL, err)
+				logger.Warn("Failed to fetch image", "url", info.URL, "error", err)
 				mu.Lock()
 				descriptions[imageKey] = fmt.Sprintf("Failed to fetch image from %s", info.URL)
 				mu.Unlock()
@@ -445,9 +469,9 @@ func (s *Service) processImagesWithContext(imageInfos []ImageInfo) map[string]st
 				caption = info.Alt
 			}
 
-			description, err := s.llmClient.AnalyzeImage(context.Background(), imageData, caption)
+			description, err := s.analyzeImage(ctx, imageData, caption)
 			if err != nil {
-				log.Printf("Failed to analyze image %s: %v", info.URL, err)
+				logger.Warn("Failed to analyze image", "url", info.URL, "error", err)
 				mu.Lock()
 				descriptions[imageKey] = fmt.Sprintf("Failed to analyze image from %s", info.URL)
 				mu.Unlock()
@@ -462,13 +486,15 @@ func (s *Service) processImagesWithContext(imageInfos []ImageInfo) map[string]st
 			enhancedDesc := fmt.Sprintf("Visual content analysis - Image from %s%s: %s", info.URL, contextInfo, description)
 
 			// Cache the result
-			os.WriteFile(cacheFile, []byte(enhancedDesc), 0644)
+			if err := s.cache.SetImageDescription(ctx, imageKey, enhancedDesc); err != nil {
+				logger.Warn("Failed to cache image description", "key", imageKey, "error", err)
+			}
 
 			mu.Lock()
 			descriptions[imageKey] = enhancedDesc
 			mu.Unlock()
 
-			log.Printf("Processed and cached description for %s", imageKey)
+			logger.Info("Processed and cached image description", "key", imageKey)
 		}(i, imageInfo)
 	}
 
@@ -477,9 +503,11 @@ func (s *Service) processImagesWithContext(imageInfos []ImageInfo) map[string]st
 }
 
 // processAudioFiles downloads and transcribes audio files with caching
-func (s *Service) processAudioFiles(audioURLs []string) map[string]string {
+func (s *Service) processAudioFiles(ctx context.Context, audioURLs []string) map[string]string {
+	logger := logging.FromContext(ctx)
+
 	transcripts := make(map[string]string)
-	cacheDir := "data/s02e05"
+	cacheDir := s.inputs.DataDir()
 
 	// Ensure cache directory exists
 	os.MkdirAll(cacheDir, 0755)
@@ -493,21 +521,20 @@ func (s *Service) processAudioFiles(audioURLs []string) map[string]string {
 			defer wg.Done()
 
 			audioKey := fmt.Sprintf("audio_%02d", idx+1)
-			cacheFile := filepath.Join(cacheDir, fmt.Sprintf("%s.transcript.txt", audioKey))
 
 			// Check cache first
-			if cachedTranscript, err := os.ReadFile(cacheFile); err == nil {
+			if cachedTranscript, ok, err := s.cache.GetAudioTranscript(ctx, audioKey); err == nil && ok {
 				mu.Lock()
-				transcripts[audioKey] = string(cachedTranscript)
+				transcripts[audioKey] = cachedTranscript
 				mu.Unlock()
-				log.Printf("Using cached transcript for %s", audioKey)
+				logger.Debug("Using cached transcript", "key", audioKey)
 				return
 			}
 
 			// Download audio file
-			audioData, err := s.httpClient.FetchBinaryData(context.Background(), url)
+			audioData, err := s.httpClient.FetchBinaryData(ctx, url)
 			if err != nil {
-				log.Printf("Failed to fetch audio %s: %v", url, err)
+				logger.Warn("Failed to fetch audio", "url", url, "error", err)
 				mu.Lock()
 				transcripts[audioKey] = fmt.Sprintf("Failed to fetch audio from %s", url)
 				mu.Unlock()
@@ -518,7 +545,7 @@ func (s *Service) processAudioFiles(audioURLs []string) map[string]string {
 			tempFile := filepath.Join(cacheDir, fmt.Sprintf("temp_%s.mp3", audioKey))
 			err = os.WriteFile(tempFile, audioData, 0644)
 			if err != nil {
-				log.Printf("Failed to save temp audio file %s: %v", tempFile, err)
+				logger.Warn("Failed to save temp audio file", "path", tempFile, "error", err)
 				mu.Lock()
 				transcripts[audioKey] = fmt.Sprintf("Failed to save audio file from %s", url)
 				mu.Unlock()
@@ -529,7 +556,7 @@ func (s *Service) processAudioFiles(audioURLs []string) map[string]string {
 			// Open file for transcription
 			file, err := os.Open(tempFile)
 			if err != nil {
-				log.Printf("Failed to open temp audio file %s: %v", tempFile, err)
+				logger.Warn("Failed to open temp audio file", "path", tempFile, "error", err)
 				mu.Lock()
 				transcripts[audioKey] = fmt.Sprintf("Failed to open audio file from %s", url)
 				mu.Unlock()
@@ -538,9 +565,9 @@ func (s *Service) processAudioFiles(audioURLs []string) map[string]string {
 			defer file.Close()
 
 			// Transcribe audio
-			transcript, err := s.llmClient.TranscribeAudio(context.Background(), file, filepath.Base(url))
+			transcript, err := s.transcriber.Transcribe(ctx, file, filepath.Base(url))
 			if err != nil {
-				log.Printf("Failed to transcribe audio %s: %v", url, err)
+				logger.Warn("Failed to transcribe audio", "url", url, "error", err)
 				mu.Lock()
 				transcripts[audioKey] = fmt.Sprintf("Failed to transcribe audio from %s", url)
 				mu.Unlock()
@@ -551,13 +578,15 @@ func (s *Service) processAudioFiles(audioURLs []string) map[string]string {
 			enhancedTranscript := fmt.Sprintf("Audio content analysis - Transcript from %s: %s", url, transcript)
 
 			// Cache the result
-			os.WriteFile(cacheFile, []byte(enhancedTranscript), 0644)
+			if err := s.cache.SetAudioTranscript(ctx, audioKey, enhancedTranscript); err != nil {
+				logger.Warn("Failed to cache transcript", "key", audioKey, "error", err)
+			}
 
 			mu.Lock()
 			transcripts[audioKey] = enhancedTranscript
 			mu.Unlock()
 
-			log.Printf("Processed and cached transcript for %s", audioKey)
+			logger.Info("Processed and cached transcript", "key", audioKey)
 		}(i, audioURL)
 	}
 
@@ -583,13 +612,13 @@ func (s *Service) parseQuestions(questionsText string) (map[string]string, error
 			questionText := strings.TrimSpace(parts[1])
 
 			if questionID == "" || questionText == "" {
-				log.Printf("Warning: invalid question format on line %d: %s", lineNum+1, line)
+				slog.Warn("Invalid question format", "line", lineNum+1, "text", line)
 				continue
 			}
 
 			questions[questionID] = questionText
 		} else {
-			log.Printf("Warning: skipping malformed line %d: %s", lineNum+1, line)
+			slog.Warn("Skipping malformed question line", "line", lineNum+1, "text", line)
 		}
 	}
 
@@ -643,31 +672,15 @@ func (s *Service) generateConsolidatedContext(content *ArxivContent) string {
 }
 
 // answerArxivQuestion uses LLM to answer a specific question based on the consolidated context
-func (s *Service) answerArxivQuestion(contextContent, question string) (string, error) {
-	systemPrompt := fmt.Sprintf(`
-	<prompt_objective>
-	You are an expert research analyst tasked with answering specific questions about Professor Maj's intercepted research publication.
-	Your task is to provide concise, accurate, single-sentence answers based solely on the provided context.
-	</prompt_objective>
-
-	<prompt_rules>
-	- Analyze the complete context including text, image descriptions, and audio transcripts
-	- Provide ONLY a single, concise sentence as your answer (no explanations or preambles)
-	- Base your answer strictly on the information provided in the context
-	- If the information is not available in the context, respond with "Information not available"
-	- Do not make assumptions or infer information not explicitly stated
-	- Focus on factual accuracy over speculation
-	- Keep answers under 30 words when possible
-	- Answer in a direct, factual manner without hedging language
-	</prompt_rules>
-
-	<context>
-	%s
-	</context>`, contextContent)
+func (s *Service) answerArxivQuestion(ctx context.Context, contextContent, question string) (string, error) {
+	systemPrompt, err := prompts.Render("s02e05/answer_question", map[string]any{"Context": contextContent})
+	if err != nil {
+		return "", err
+	}
 
 	userPrompt := fmt.Sprintf("Question: %s\n\nAnswer the question with a single factual sentence based on the context provided.", question)
 
-	answer, err := s.llmClient.GetAnswerWithContext(context.Background(), systemPrompt, userPrompt)
+	answer, err := s.llmClient.Chat(ctx, systemPrompt, userPrompt)
 	if err != nil {
 		return "", fmt.Errorf("failed to get answer from LLM: %w", err)
 	}
@@ -675,6 +688,28 @@ func (s *Service) answerArxivQuestion(contextContent, question string) (string,
 	return answer, nil
 }
 
+// analyzeImage describes an image for the article context, taking its
+// caption into account when there is one
+func (s *Service) analyzeImage(ctx context.Context, imageData []byte, caption string) (string, error) {
+	systemPrompt, err := prompts.Render("s02e05/analyze_image", nil)
+	if err != nil {
+		return "", err
+	}
+
+	userPrompt := "Please provide a detailed analysis of this image."
+	if caption != "" {
+		userPrompt = fmt.Sprintf("Please provide a detailed analysis of this image. The image has this caption or context: %s", caption)
+	}
+
+	model := llm.Tune(s.llmClient, llm.Tuning{OpenAIModel: "gpt-4.1-mini", Temperature: 0.3, MaxTokens: 1024})
+	description, err := model.Vision(ctx, systemPrompt, userPrompt, [][]byte{imageData})
+	if err != nil {
+		return "", fmt.Errorf("failed to analyze image: %w", err)
+	}
+
+	return description, nil
+}
+
 // findImageCaption looks for caption text near an image element
 func (s *Service) findImageCaption(imgNode *html.Node) string {
 	// Look for figcaption in parent figure element
@@ -5,10 +5,21 @@ import (
 	"time"
 
 	"ai-devs3/internal/config"
+	"ai-devs3/internal/tasks"
 
 	"github.com/spf13/cobra"
 )
 
+func init() {
+	tasks.Register(tasks.Task{
+		ID:         "s03e01",
+		Title:      "Security Reports Processing",
+		Season:     3,
+		Requires:   []tasks.Requirement{tasks.RequireAIDevs, tasks.RequireLLM},
+		NewCommand: NewCommand,
+	})
+}
+
 // NewCommand creates a new cobra command for S03E01 task
 func NewCommand(cfg *config.Config) *cobra.Command {
 	return &cobra.Command{
@@ -26,25 +37,28 @@ func NewCommand(cfg *config.Config) *cobra.Command {
 
 		The task requires:
 			1. AI_DEVS_API_KEY environment variable to be set
-			2. Files directory at ../lessons-md/pliki_z_fabryki with report files
-			3. Facts directory at ../lessons-md/pliki_z_fabryki/facts with reference data
+			2. Files directory at <LESSONS_DIR>/pliki_z_fabryki with report files
+			3. Facts directory at <LESSONS_DIR>/pliki_z_fabryki/facts with reference data
 			4. OpenAI API access for natural language processing
 			5. Sufficient disk space for caching processed facts
 
 		The command will:
 			1. Process exactly 10 .txt report files
-			2. Extract and cache structured information from facts files
+			2. Extract structured information from facts files (LLM responses are cached)
 			3. Generate context-aware Polish keywords for each report
 			4. Cross-reference people mentioned in reports with facts database
 			5. Include profession, skills, and technology keywords from facts
 			6. Provide detailed processing statistics and caching information`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Create context with timeout for document processing
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			ctx, cancel := context.WithTimeout(cmd.Context(), 5*time.Minute)
 			defer cancel()
 
 			// Create and run handler
-			handler := NewHandler(cfg)
+			handler, err := NewHandler(cfg)
+			if err != nil {
+				return err
+			}
 			return handler.Execute(ctx)
 		},
 	}
@@ -4,11 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
+	"os"
 
+	"ai-devs3/internal/centrala"
 	"ai-devs3/internal/config"
 	"ai-devs3/internal/http"
-	"ai-devs3/internal/llm/openai"
+	"ai-devs3/internal/inputs"
+	"ai-devs3/internal/llm"
+	"ai-devs3/internal/logging"
 	pkgerrors "ai-devs3/pkg/errors"
 )
 
@@ -16,27 +19,31 @@ import (
 type Handler struct {
 	config     *config.Config
 	httpClient *http.Client
-	llmClient  *openai.Client
+	llmClient  llm.ChatModel
 	service    *Service
 }
 
 // NewHandler creates a new handler instance
-func NewHandler(cfg *config.Config) *Handler {
+func NewHandler(cfg *config.Config) (*Handler, error) {
 	httpClient := http.NewClient(cfg.HTTP)
-	llmClient := openai.NewClient(cfg.OpenAI)
-	service := NewService(httpClient, llmClient)
+	llmClient, err := llm.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	service := NewService(httpClient, centrala.NewClient(httpClient, cfg.AIDevs), llmClient, inputs.NewResolver(cfg, "s03e01"))
 
 	return &Handler{
 		config:     cfg,
 		httpClient: httpClient,
 		llmClient:  llmClient,
 		service:    service,
-	}
+	}, nil
 }
 
 // Execute runs the S03E01 task
 func (h *Handler) Execute(ctx context.Context) error {
-	log.Println("Starting S03E01 documents processing task")
+	logger := logging.FromContext(ctx)
+	logger.Info("Starting S03E01 documents processing task")
 
 	// Get API key from environment
 	apiKey := h.config.AIDevs.APIKey
@@ -49,7 +56,7 @@ func (h *Handler) Execute(ctx context.Context) error {
 	if err != nil {
 		var taskErr pkgerrors.TaskError
 		if errors.As(err, &taskErr) {
-			log.Printf("Task failed at step %s: %v", taskErr.Step, taskErr.Err)
+			logger.Error("Task failed", "step", taskErr.Step, "error", taskErr.Err)
 			return fmt.Errorf("S03E01 task failed: %w", err)
 		}
 		return fmt.Errorf("S03E01 task failed: %w", err)
@@ -58,12 +65,12 @@ func (h *Handler) Execute(ctx context.Context) error {
 	// Print processing statistics if available
 	if result.ProcessingStats != nil {
 		h.service.PrintProcessingStats(result.ProcessingStats)
+		h.config.OpenAI.Usage.Print(os.Stdout)
 	}
 
 	// Log results
-	log.Printf("Task completed successfully!")
-	log.Printf("Total files processed: %d", result.TotalFiles)
-	log.Printf("Keywords generated: %d", result.KeywordsGenerated)
+	logger.Info("Task completed",
+		"files", result.TotalFiles, "keywords", result.KeywordsGenerated)
 
 	fmt.Println("=== Documents Processing Results ===")
 	fmt.Printf("Files processed: %d\n", result.TotalFiles)
@@ -5,10 +5,21 @@ import (
 	"time"
 
 	"ai-devs3/internal/config"
+	"ai-devs3/internal/tasks"
 
 	"github.com/spf13/cobra"
 )
 
+func init() {
+	tasks.Register(tasks.Task{
+		ID:         "s03e02",
+		Title:      "Weapon Reports Vector Search",
+		Season:     3,
+		Requires:   []tasks.Requirement{tasks.RequireAIDevs, tasks.RequireLLM, tasks.RequireQdrant},
+		NewCommand: NewCommand,
+	})
+}
+
 // NewCommand creates a new cobra command for S03E02 task
 func NewCommand(cfg *config.Config) *cobra.Command {
 	return &cobra.Command{
@@ -20,38 +31,41 @@ func NewCommand(cfg *config.Config) *cobra.Command {
 			1. Setting up a Qdrant vector database collection for weapon test reports
 			2. Processing all .txt files from the do-not-share directory
 			3. Extracting dates from filenames (format: YYYY_MM_DD.txt)
-			4. Generating embeddings using OpenAI's text-embedding-3-large model
+			4. Generating embeddings with the LLM_PROVIDER embedding model
 			5. Storing report embeddings in Qdrant with metadata (date, filename, content)
 			6. Searching for reports mentioning weapon prototype theft
 			7. Submitting the date of the report containing theft mention
 
 		The task requires:
 			1. AI_DEVS_API_KEY environment variable to be set
-			2. OPENAI_API_KEY environment variable to be set
+			2. LLM_PROVIDER with an embedding model (OPENAI_EMBEDDING_MODEL or OLLAMA_EMBEDDING_MODEL)
 			3. Qdrant vector database cluster running
-			4. Files directory at ../lessons-md/pliki_z_fabryki/do-not-share with report files
+			4. Files directory at <LESSONS_DIR>/pliki_z_fabryki/do-not-share with report files
 			5. Sufficient memory and processing power for embedding generation
 
 		The command will:
-			1. Create/recreate a Qdrant collection with 3072 dimensions
+			1. Create/recreate a Qdrant collection sized for the embedding model
 			2. Process all weapon test report files from the target directory
-			3. Generate embeddings for each report using text-embedding-3-large
+			3. Generate embeddings for each report
 			4. Store embeddings with proper metadata in the vector database
 			5. Search for the query about weapon prototype theft
 			6. Return the date from the most relevant report
 			7. Submit the answer to the centrala API
 
 		Vector Configuration:
-			- Model: text-embedding-3-large (3072 dimensions)
+			- Model: the LLM_PROVIDER embedding model; its vector size sets the collection's
 			- Distance: Cosine similarity
 			- Collection: weapon_reports`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Create context with timeout for vector processing
-			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+			ctx, cancel := context.WithTimeout(cmd.Context(), 10*time.Minute)
 			defer cancel()
 
 			// Create and run handler
-			handler := NewHandler(cfg)
+			handler, err := NewHandler(cfg)
+			if err != nil {
+				return err
+			}
 			return handler.Execute(ctx)
 		},
 	}
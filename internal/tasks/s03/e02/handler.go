@@ -4,55 +4,72 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"os"
 
+	"ai-devs3/internal/centrala"
 	"ai-devs3/internal/config"
 	"ai-devs3/internal/http"
-	"ai-devs3/internal/llm/openai"
+	"ai-devs3/internal/inputs"
+	"ai-devs3/internal/llm"
+	"ai-devs3/internal/logging"
+	"ai-devs3/internal/tracing"
 
 	pkgerrors "ai-devs3/pkg/errors"
 
 	"github.com/qdrant/go-client/qdrant"
+	"google.golang.org/grpc"
 )
 
 // Handler handles the S03E02 task execution
 type Handler struct {
 	config     *config.Config
 	httpClient *http.Client
-	llmClient  *openai.Client
+	embedder   llm.EmbeddingModel
 	service    *Service
 }
 
 // NewHandler creates a new handler instance
-func NewHandler(cfg *config.Config) *Handler {
+func NewHandler(cfg *config.Config) (*Handler, error) {
 	httpClient := http.NewClient(cfg.HTTP)
-	llmClient := openai.NewClient(cfg.OpenAI)
+	model, err := llm.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	embedder, err := llm.As[llm.EmbeddingModel](model)
+	if err != nil {
+		return nil, err
+	}
 	// Initialize Qdrant client
 	qdrantClient, err := qdrant.NewClient(&qdrant.Config{
 		Host:   cfg.Qdrant.Host,
 		Port:   cfg.Qdrant.Port,
 		APIKey: cfg.Qdrant.APIKey,
 		UseTLS: cfg.Qdrant.UseTLS,
+		GrpcOptions: []grpc.DialOption{
+			grpc.WithChainUnaryInterceptor(tracing.UnaryClientInterceptor("qdrant")),
+		},
 	})
 	if err != nil {
-		log.Printf("Warning: failed to initialize Qdrant client: %v", err)
+		slog.Warn("Failed to initialize Qdrant client", "error", err)
 		// Continue with nil client to allow graceful error handling in Execute
 	}
 
 	// Initialize service with Qdrant connection
-	service, err := NewService(httpClient, llmClient, qdrantClient)
+	service, err := NewService(httpClient, centrala.NewClient(httpClient, cfg.AIDevs), embedder, qdrantClient, inputs.NewResolver(cfg, "s03e02"))
 
 	return &Handler{
 		config:     cfg,
 		httpClient: httpClient,
-		llmClient:  llmClient,
+		embedder:   embedder,
 		service:    service,
-	}
+	}, nil
 }
 
 // Execute runs the S03E02 task
 func (h *Handler) Execute(ctx context.Context) error {
-	log.Println("Starting S03E02 weapon reports vector search task")
+	logger := logging.FromContext(ctx)
+	logger.Info("Starting S03E02 weapon reports vector search task")
 
 	// Check if service was initialized properly
 	if h.service == nil {
@@ -70,7 +87,7 @@ func (h *Handler) Execute(ctx context.Context) error {
 	if err != nil {
 		var taskErr pkgerrors.TaskError
 		if errors.As(err, &taskErr) {
-			log.Printf("Task failed at step %s: %v", taskErr.Step, taskErr.Err)
+			logger.Error("Task failed", "step", taskErr.Step, "error", taskErr.Err)
 			return fmt.Errorf("S03E02 task failed: %w", err)
 		}
 		return fmt.Errorf("S03E02 task failed: %w", err)
@@ -79,12 +96,12 @@ func (h *Handler) Execute(ctx context.Context) error {
 	// Print processing statistics if available
 	if result.ProcessingStats != nil {
 		h.service.PrintProcessingStats(result.ProcessingStats)
+		h.config.OpenAI.Usage.Print(os.Stdout)
 	}
 
 	// Log results
-	log.Printf("Task completed successfully!")
-	log.Printf("Reports processed: %d", result.ReportsProcessed)
-	log.Printf("Answer found: %s", result.Answer)
+	logger.Info("Task completed",
+		"reports", result.ReportsProcessed, "answer", result.Answer)
 
 	fmt.Println("=== Vector Search Results ===")
 	fmt.Printf("Reports processed: %d\n", result.ReportsProcessed)
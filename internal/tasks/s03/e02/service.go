@@ -4,14 +4,18 @@ import (
 	"context"
 	"fmt"
 	"io/fs"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
+	"ai-devs3/internal/centrala"
 	"ai-devs3/internal/http"
-	"ai-devs3/internal/llm/openai"
+	"ai-devs3/internal/inputs"
+	"ai-devs3/internal/llm"
+	"ai-devs3/internal/logging"
+	"ai-devs3/internal/tracing"
 	"ai-devs3/pkg/errors"
 
 	"github.com/google/uuid"
@@ -21,23 +25,30 @@ import (
 // Service handles weapon reports vector processing
 type Service struct {
 	httpClient     *http.Client
-	llmClient      *openai.Client
+	centralaClient *centrala.Client
+	embedder       llm.EmbeddingModel
 	qdrantClient   *qdrant.Client
+	inputs         *inputs.Resolver
 	collectionName string
 }
 
 // NewService creates a new service instance
-func NewService(httpClient *http.Client, llmClient *openai.Client, quadrantClient *qdrant.Client) (*Service, error) {
+func NewService(httpClient *http.Client, centralaClient *centrala.Client, embedder llm.EmbeddingModel, quadrantClient *qdrant.Client, resolver *inputs.Resolver) (*Service, error) {
 	return &Service{
 		httpClient:     httpClient,
-		llmClient:      llmClient,
+		centralaClient: centralaClient,
+		embedder:       embedder,
 		qdrantClient:   quadrantClient,
+		inputs:         resolver,
 		collectionName: "weapon_reports",
 	}, nil
 }
 
 // ExecuteTask executes the complete S03E02 task workflow
-func (s *Service) ExecuteTask(ctx context.Context, apiKey string) (*TaskResult, error) {
+func (s *Service) ExecuteTask(ctx context.Context, apiKey string) (_ *TaskResult, err error) {
+	steps := tracing.NewSteps(ctx)
+	defer func() { steps.End(err) }()
+
 	startTime := time.Now()
 
 	// Execute the weapon reports task
@@ -47,7 +58,7 @@ func (s *Service) ExecuteTask(ctx context.Context, apiKey string) (*TaskResult,
 	}
 
 	// Submit response
-	response, err := s.submitWeaponReportsResponse(ctx, apiKey, answer)
+	response, err := s.submitWeaponReportsResponse(steps.Start("submit_response"), answer)
 	if err != nil {
 		return nil, errors.NewTaskError("s03e02", "submit_response", err)
 	}
@@ -64,26 +75,37 @@ func (s *Service) ExecuteTask(ctx context.Context, apiKey string) (*TaskResult,
 }
 
 // processWeaponReportsTask processes all weapon reports and answers the query
-func (s *Service) processWeaponReportsTask(ctx context.Context, apiKey string) (string, *ProcessingStats, error) {
-	stats := &ProcessingStats{
-		VectorDimensions: 3072, // text-embedding-3-large dimensions
-	}
+func (s *Service) processWeaponReportsTask(ctx context.Context, apiKey string) (_ string, _ *ProcessingStats, err error) {
+	logger := logging.FromContext(ctx)
+	steps := tracing.NewSteps(ctx)
+	defer func() { steps.End(err) }()
+
+	stats := &ProcessingStats{}
+	theftQuery := "W raporcie, z którego dnia znajduje się wzmianka o kradzieży prototypu broni?"
 
-	// Step 1: Setup Qdrant collection
-	log.Println("Setting up Qdrant collection...")
-	if err := s.setupQdrantCollection(ctx); err != nil {
+	// Step 1: Setup Qdrant collection, sized for the configured embedding
+	// model by embedding the query once
+	logger.Info("Setting up Qdrant collection")
+	setupCtx := steps.Start("setup_collection")
+	probe, err := s.generateEmbedding(setupCtx, theftQuery)
+	if err != nil {
+		return "", stats, errors.NewTaskError("s03e02", "setup_collection", err)
+	}
+	stats.VectorDimensions = len(probe)
+	if err := s.setupQdrantCollection(setupCtx, uint64(len(probe))); err != nil {
 		return "", stats, errors.NewTaskError("s03e02", "setup_collection", err)
 	}
 	stats.CollectionSetup = true
 
 	// Step 2: Process weapon reports
-	log.Println("Processing weapon reports...")
+	logger.Info("Processing weapon reports")
+	steps.Start("load_reports")
 	reports, err := s.loadWeaponReports()
 	if err != nil {
 		return "", stats, errors.NewTaskError("s03e02", "load_reports", err)
 	}
 
-	log.Printf("Found %d weapon reports", len(reports))
+	logger.Info("Found weapon reports", "reports", len(reports))
 	stats.ReportsProcessed = len(reports)
 
 	// Calculate total data size
@@ -94,46 +116,61 @@ func (s *Service) processWeaponReportsTask(ctx context.Context, apiKey string) (
 	stats.TotalDataSize = totalSize
 
 	// Step 3: Generate embeddings and store in Qdrant
-	embeddingsCount, err := s.processAndStoreReports(ctx, reports)
+	embeddingsCount, err := s.processAndStoreReports(steps.Start("process_store_reports"), reports)
 	if err != nil {
 		return "", stats, errors.NewTaskError("s03e02", "process_store_reports", err)
 	}
 	stats.EmbeddingsGenerated = embeddingsCount
 
 	// Step 4: Query for theft mention
-	log.Println("Searching for theft mention...")
+	logger.Info("Searching for theft mention")
 	searchStart := time.Now()
-	theftQuery := "W raporcie, z którego dnia znajduje się wzmianka o kradzieży prototypu broni?"
-	date, err := s.searchForTheft(ctx, theftQuery)
+	date, err := s.searchForTheft(steps.Start("search_theft"), theftQuery)
 	if err != nil {
 		return "", stats, errors.NewTaskError("s03e02", "search_theft", err)
 	}
 	stats.SearchTime = time.Since(searchStart).Seconds()
 
-	log.Printf("Found theft mention in report from date: %s", date)
+	logger.Info("Found theft mention", "date", date)
 	return date, stats, nil
 }
 
-// setupQdrantCollection creates or recreates the collection for weapon reports
-func (s *Service) setupQdrantCollection(ctx context.Context) error {
+// setupQdrantCollection creates the collection for weapon reports, or
+// recreates it when it holds vectors of another size, e.g. after switching
+// LLM_PROVIDER
+func (s *Service) setupQdrantCollection(ctx context.Context, vectorSize uint64) error {
+	logger := logging.FromContext(ctx)
+
 	// Check if collection exists
 	exists, err := s.qdrantClient.CollectionExists(ctx, s.collectionName)
 	if err != nil {
 		return fmt.Errorf("failed to check collection existence: %w", err)
 	}
 
-	// Skip collection creation if it already exists
 	if exists {
-		log.Println("Collection already exists, skipping collection creation")
-		return nil
+		info, err := s.qdrantClient.GetCollectionInfo(ctx, s.collectionName)
+		if err != nil {
+			return fmt.Errorf("failed to get collection info: %w", err)
+		}
+
+		// Skip collection creation if it already fits the embedding model
+		size := info.GetConfig().GetParams().GetVectorsConfig().GetParams().GetSize()
+		if size == vectorSize {
+			logger.Info("Collection already exists, skipping collection creation")
+			return nil
+		}
+
+		logger.Info("Recreating collection for a different vector size", "size", size, "vector_size", vectorSize)
+		if err := s.qdrantClient.DeleteCollection(ctx, s.collectionName); err != nil {
+			return fmt.Errorf("failed to delete collection: %w", err)
+		}
 	}
 
-	// Create new collection with proper vector size for text-embedding-3-large
-	log.Println("Creating new collection...")
+	logger.Info("Creating new collection", "vector_size", vectorSize)
 	err = s.qdrantClient.CreateCollection(ctx, &qdrant.CreateCollection{
 		CollectionName: s.collectionName,
 		VectorsConfig: qdrant.NewVectorsConfig(&qdrant.VectorParams{
-			Size:     3072, // text-embedding-3-large dimensions
+			Size:     vectorSize,
 			Distance: qdrant.Distance_Cosine,
 		}),
 	})
@@ -141,16 +178,19 @@ func (s *Service) setupQdrantCollection(ctx context.Context) error {
 		return fmt.Errorf("failed to create collection: %w", err)
 	}
 
-	log.Println("Collection created successfully")
+	logger.Info("Collection created")
 	return nil
 }
 
 // loadWeaponReports loads all weapon test reports from the directory
 func (s *Service) loadWeaponReports() ([]WeaponReport, error) {
-	reportsDir := "../lessons-md/pliki_z_fabryki/do-not-share"
+	reportsDir, err := s.inputs.LessonsPath("do-not-share", "pliki_z_fabryki", "do-not-share")
+	if err != nil {
+		return nil, err
+	}
 	var reports []WeaponReport
 
-	err := filepath.WalkDir(reportsDir, func(path string, d fs.DirEntry, err error) error {
+	err = filepath.WalkDir(reportsDir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
@@ -170,7 +210,7 @@ func (s *Service) loadWeaponReports() ([]WeaponReport, error) {
 		filename := filepath.Base(path)
 		date, err := s.extractDateFromFilename(filename)
 		if err != nil {
-			log.Printf("Warning: failed to extract date from %s: %v", filename, err)
+			slog.Warn("Failed to extract date", "file", filename, "error", err)
 			// Use a default date if extraction fails
 			date = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 		}
@@ -215,15 +255,17 @@ func (s *Service) extractDateFromFilename(filename string) (time.Time, error) {
 }
 
 // submitWeaponReportsResponse submits the weapon reports results to the centrala API
-func (s *Service) submitWeaponReportsResponse(ctx context.Context, apiKey string, answer string) (string, error) {
-	response := s.httpClient.BuildAIDevsResponse("wektory", apiKey, answer)
-
-	result, err := s.httpClient.PostReport(ctx, "https://c3ntrala.ag3nts.org", response)
+func (s *Service) submitWeaponReportsResponse(ctx context.Context, answer string) (string, error) {
+	response, err := s.centralaClient.Report(ctx, "wektory", answer)
 	if err != nil {
 		return "", fmt.Errorf("failed to submit weapon reports response: %w", err)
 	}
 
-	return result, nil
+	if flag := response.Flag(); flag != "" {
+		logging.FromContext(ctx).Info("Received flag", "flag", flag)
+	}
+
+	return response.Message, nil
 }
 
 // PrintProcessingStats prints detailed processing statistics
@@ -241,13 +283,15 @@ func (s *Service) PrintProcessingStats(stats *ProcessingStats) {
 
 // processAndStoreReports generates embeddings and stores reports in Qdrant
 func (s *Service) processAndStoreReports(ctx context.Context, reports []WeaponReport) (int, error) {
+	logger := logging.FromContext(ctx)
+
 	var points []*qdrant.PointStruct
 
 	for i, report := range reports {
-		log.Printf("Processing report %d/%d: %s", i+1, len(reports), report.Filename)
+		logger.Info("Processing report", "report", i+1, "reports", len(reports), "file", report.Filename)
 
 		// Generate embedding for the report content
-		embedding, err := s.generateEmbedding(report.Content)
+		embedding, err := s.generateEmbedding(ctx, report.Content)
 		if err != nil {
 			return 0, fmt.Errorf("failed to generate embedding for %s: %w", report.Filename, err)
 		}
@@ -275,7 +319,7 @@ func (s *Service) processAndStoreReports(ctx context.Context, reports []WeaponRe
 	}
 
 	// Upsert all points to Qdrant
-	log.Println("Storing points in Qdrant...")
+	logger.Info("Storing points in Qdrant")
 	_, err := s.qdrantClient.Upsert(ctx, &qdrant.UpsertPoints{
 		CollectionName: s.collectionName,
 		Points:         points,
@@ -284,33 +328,27 @@ func (s *Service) processAndStoreReports(ctx context.Context, reports []WeaponRe
 		return 0, fmt.Errorf("failed to upsert points to Qdrant: %w", err)
 	}
 
-	log.Printf("Successfully stored %d reports in Qdrant", len(points))
+	logger.Info("Stored reports in Qdrant", "reports", len(points))
 	return len(points), nil
 }
 
-// generateEmbedding generates embedding for text using OpenAI
-func (s *Service) generateEmbedding(text string) ([]float64, error) {
-	prompt := openai.EmbeddingRequest{
-		Input: text,
-		Model: "text-embedding-3-large",
-	}
-
-	response, err := s.llmClient.CreateEmbedding(prompt)
+// generateEmbedding generates embedding for text with the configured
+// embedding model
+func (s *Service) generateEmbedding(ctx context.Context, text string) ([]float64, error) {
+	embedding, err := s.embedder.Embed(ctx, text)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create embedding: %w", err)
 	}
 
-	if len(response.Data) == 0 {
-		return nil, fmt.Errorf("no embedding data received")
-	}
-
-	return response.Data[0].Embedding, nil
+	return embedding, nil
 }
 
 // searchForTheft searches for reports mentioning theft and returns the date
 func (s *Service) searchForTheft(ctx context.Context, query string) (string, error) {
+	logger := logging.FromContext(ctx)
+
 	// Generate embedding for the query
-	queryEmbedding, err := s.generateEmbedding(query)
+	queryEmbedding, err := s.generateEmbedding(ctx, query)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate query embedding: %w", err)
 	}
@@ -350,8 +388,7 @@ func (s *Service) searchForTheft(ctx context.Context, query string) (string, err
 		return "", fmt.Errorf("invalid date in result payload")
 	}
 
-	log.Printf("Found most relevant result with score: %f", result.GetScore())
-	log.Printf("Report filename: %s", payload["filename"].GetStringValue())
+	logger.Info("Found most relevant result", "score", result.GetScore(), "file", payload["filename"].GetStringValue())
 
 	return date, nil
 }
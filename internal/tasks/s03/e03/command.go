@@ -5,10 +5,21 @@ import (
 	"time"
 
 	"ai-devs3/internal/config"
+	"ai-devs3/internal/tasks"
 
 	"github.com/spf13/cobra"
 )
 
+func init() {
+	tasks.Register(tasks.Task{
+		ID:         "s03e03",
+		Title:      "Database Query Task",
+		Season:     3,
+		Requires:   []tasks.Requirement{tasks.RequireAIDevs, tasks.RequireLLM},
+		NewCommand: NewCommand,
+	})
+}
+
 // NewCommand creates a new cobra command for S03E03 task
 func NewCommand(cfg *config.Config) *cobra.Command {
 	return &cobra.Command{
@@ -28,7 +39,7 @@ func NewCommand(cfg *config.Config) *cobra.Command {
 		The task requires:
 			1. AI_DEVS_API_KEY environment variable to be set
 			2. OpenAI API access for SQL query generation
-			3. Access to the database API at https://c3ntrala.ag3nts.org/apidb
+			3. Access to the Centrala /apidb endpoint (AI_DEVS_BASE_URL)
 
 		The command will:
 			1. Execute SHOW TABLES to discover database structure
@@ -39,11 +50,14 @@ func NewCommand(cfg *config.Config) *cobra.Command {
 			6. Provide detailed processing statistics and query information`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Create context with timeout for database processing
-			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+			ctx, cancel := context.WithTimeout(cmd.Context(), 3*time.Minute)
 			defer cancel()
 
 			// Create and run handler
-			handler := NewHandler(cfg)
+			handler, err := NewHandler(cfg)
+			if err != nil {
+				return err
+			}
 			return handler.Execute(ctx)
 		},
 	}
@@ -4,11 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
 
+	"ai-devs3/internal/centrala"
 	"ai-devs3/internal/config"
 	"ai-devs3/internal/http"
-	"ai-devs3/internal/llm/openai"
+	"ai-devs3/internal/llm"
+	"ai-devs3/internal/logging"
 	pkgerrors "ai-devs3/pkg/errors"
 )
 
@@ -16,50 +17,52 @@ import (
 type Handler struct {
 	config     *config.Config
 	httpClient *http.Client
-	llmClient  *openai.Client
+	llmClient  llm.ChatModel
 	service    *Service
 }
 
 // NewHandler creates a new handler instance
-func NewHandler(cfg *config.Config) *Handler {
+func NewHandler(cfg *config.Config) (*Handler, error) {
 	httpClient := http.NewClient(cfg.HTTP)
-	llmClient := openai.NewClient(cfg.OpenAI)
-	service := NewService(httpClient, llmClient)
+	llmClient, err := llm.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	service := NewService(centrala.NewClient(httpClient, cfg.AIDevs), llmClient)
 
 	return &Handler{
 		config:     cfg,
 		httpClient: httpClient,
 		llmClient:  llmClient,
 		service:    service,
-	}
+	}, nil
 }
 
 // Execute runs the S03E03 database task
 func (h *Handler) Execute(ctx context.Context) error {
-	log.Println("Starting S03E03 database query task")
+	logger := logging.FromContext(ctx)
+	logger.Info("Starting S03E03 database query task")
 
-	// Get API key from environment
-	apiKey := h.config.AIDevs.APIKey
-	if apiKey == "" {
+	// Centrala client reads the API key from config
+	if h.config.AIDevs.APIKey == "" {
 		return fmt.Errorf("AI_DEVS_API_KEY is required")
 	}
 
 	// Execute the task
-	result, err := h.service.ExecuteTask(ctx, apiKey)
+	result, err := h.service.ExecuteTask(ctx)
 	if err != nil {
 		var taskErr pkgerrors.TaskError
 		if errors.As(err, &taskErr) {
-			log.Printf("Task failed at step %s: %v", taskErr.Step, taskErr.Err)
+			logger.Error("Task failed", "step", taskErr.Step, "error", taskErr.Err)
 			return fmt.Errorf("S03E03 task failed: %w", err)
 		}
 		return fmt.Errorf("S03E03 task failed: %w", err)
 	}
 
 	// Log results
-	log.Printf("Task completed successfully!")
-	log.Printf("Generated SQL query: %s", result.GeneratedQuery)
-	log.Printf("Found datacenter IDs: %v", result.DatacenterIDs)
-	log.Printf("Processing time: %.2f seconds", result.ProcessingTime)
+	logger.Info("Task completed",
+		"query", result.GeneratedQuery, "datacenter_ids", result.DatacenterIDs,
+		"processing_time_s", result.ProcessingTime)
 
 	fmt.Println("=== Database Query Results ===")
 	fmt.Printf("Generated Query: %s\n", result.GeneratedQuery)
@@ -1,18 +1,5 @@
 package e03
 
-// DatabaseRequest represents the request structure for the database API
-type DatabaseRequest struct {
-	Task   string `json:"task"`
-	APIKey string `json:"apikey"`
-	Query  string `json:"query"`
-}
-
-// DatabaseResponse represents the response from the database API
-type DatabaseResponse struct {
-	Reply []map[string]any `json:"reply"`
-	Error string           `json:"error,omitempty"`
-}
-
 // TableSchema represents a table's structure information
 type TableSchema struct {
 	Name          string
@@ -36,12 +23,6 @@ type TaskResult struct {
 	ProcessingTime float64
 }
 
-// AIDevsAPIResponse represents the standard AI-DEVS API response format
-type AIDevsAPIResponse struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
-}
-
 // ProcessingStats represents statistics about the database processing
 type ProcessingStats struct {
 	TablesDiscovered int
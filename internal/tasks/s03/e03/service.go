@@ -2,62 +2,67 @@ package e03
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"log"
 	"strconv"
 	"strings"
 	"time"
 
-	"ai-devs3/internal/http"
-	"ai-devs3/internal/llm/openai"
+	"ai-devs3/internal/centrala"
+	"ai-devs3/internal/llm"
+	"ai-devs3/internal/logging"
+	"ai-devs3/internal/prompts"
+	"ai-devs3/internal/tracing"
 	"ai-devs3/pkg/errors"
 )
 
 // Service handles the database processing task
 type Service struct {
-	httpClient *http.Client
-	llmClient  *openai.Client
+	centralaClient *centrala.Client
+	llmClient      llm.ChatModel
 }
 
 // NewService creates a new service instance
-func NewService(httpClient *http.Client, llmClient *openai.Client) *Service {
+func NewService(centralaClient *centrala.Client, llmClient llm.ChatModel) *Service {
 	return &Service{
-		httpClient: httpClient,
-		llmClient:  llmClient,
+		centralaClient: centralaClient,
+		llmClient:      llmClient,
 	}
 }
 
 // ExecuteTask executes the complete S03E03 database task workflow
-func (s *Service) ExecuteTask(ctx context.Context, apiKey string) (*TaskResult, error) {
+func (s *Service) ExecuteTask(ctx context.Context) (_ *TaskResult, err error) {
+	logger := logging.FromContext(ctx)
+	steps := tracing.NewSteps(ctx)
+	defer func() { steps.End(err) }()
+
 	startTime := time.Now()
 
-	log.Println("Starting database discovery process...")
+	logger.Info("Starting database discovery")
 
 	// Step 1: Discover database structure
-	dbInfo, err := s.discoverDatabaseStructure(ctx, apiKey)
+	dbInfo, err := s.discoverDatabaseStructure(steps.Start("discover_structure"))
 	if err != nil {
 		return nil, errors.NewTaskError("s03e03", "discover_structure", err)
 	}
 
 	// Step 2: Generate SQL query using LLM
-	sqlQuery, err := s.generateSQLQuery(ctx, dbInfo)
+	sqlQuery, err := s.generateSQLQuery(steps.Start("generate_query"), dbInfo)
 	if err != nil {
 		return nil, errors.NewTaskError("s03e03", "generate_query", err)
 	}
 
-	log.Printf("Generated SQL query: %s", sqlQuery)
+	logger.Info("Generated SQL query", "query", sqlQuery)
 
 	// Step 3: Execute the query
-	datacenterIDs, err := s.executeQueryAndExtractIDs(ctx, apiKey, sqlQuery)
+	datacenterIDs, err := s.executeQueryAndExtractIDs(steps.Start("execute_query"), sqlQuery)
 	if err != nil {
 		return nil, errors.NewTaskError("s03e03", "execute_query", err)
 	}
 
-	log.Printf("Found %d active datacenters with inactive managers", len(datacenterIDs))
+	logger.Info("Found active datacenters with inactive managers", "datacenters", len(datacenterIDs))
 
 	// Step 4: Submit the answer
-	response, err := s.submitDatabaseResponse(ctx, apiKey, datacenterIDs)
+	response, err := s.submitDatabaseResponse(steps.Start("submit_response"), datacenterIDs)
 	if err != nil {
 		return nil, errors.NewTaskError("s03e03", "submit_response", err)
 	}
@@ -72,11 +77,12 @@ func (s *Service) ExecuteTask(ctx context.Context, apiKey string) (*TaskResult,
 }
 
 // discoverDatabaseStructure discovers tables and their schemas
-func (s *Service) discoverDatabaseStructure(ctx context.Context, apiKey string) (*DatabaseInfo, error) {
-	log.Println("Discovering database tables...")
+func (s *Service) discoverDatabaseStructure(ctx context.Context) (*DatabaseInfo, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("Discovering database tables")
 
 	// Get table list
-	tables, err := s.executeQuery(ctx, apiKey, "SHOW TABLES")
+	tables, err := s.centralaClient.DBQuery(ctx, "SHOW TABLES")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get table list: %w", err)
 	}
@@ -87,43 +93,43 @@ func (s *Service) discoverDatabaseStructure(ctx context.Context, apiKey string)
 	}
 
 	// Extract table names
-	for _, row := range tables.Reply {
+	for _, row := range tables {
 		for _, value := range row {
 			if tableName, ok := value.(string); ok {
 				dbInfo.Tables = append(dbInfo.Tables, tableName)
-				log.Printf("Found table: %s", tableName)
+				logger.Debug("Found table", "table", tableName)
 			}
 		}
 	}
 
 	// Get schema for each table
 	for _, tableName := range dbInfo.Tables {
-		log.Printf("Getting schema for table: %s", tableName)
+		logger.Debug("Getting table schema", "table", tableName)
 
 		schemaQuery := fmt.Sprintf("SHOW CREATE TABLE %s", tableName)
-		schemaResult, err := s.executeQuery(ctx, apiKey, schemaQuery)
+		schemaResult, err := s.centralaClient.DBQuery(ctx, schemaQuery)
 		if err != nil {
-			log.Printf("Warning: Failed to get schema for table %s: %v", tableName, err)
+			logger.Warn("Failed to get table schema", "table", tableName, "error", err)
 			continue
 		}
 
 		// Find and print side flag in correct_order table
 		if tableName == "correct_order" {
-			log.Printf("Getting content for table: %s", tableName)
+			logger.Debug("Getting table content", "table", tableName)
 
 			contentQuery := fmt.Sprintf("SELECT * FROM %s order by weight", tableName)
-			contentResult, err := s.executeQuery(ctx, apiKey, contentQuery)
+			contentResult, err := s.centralaClient.DBQuery(ctx, contentQuery)
 			if err != nil {
-				log.Printf("Warning: Failed to get content for table %s: %v", tableName, err)
+				logger.Warn("Failed to get table content", "table", tableName, "error", err)
 				continue
 			}
 
 			var sideFlag string
-			for _, row := range contentResult.Reply {
+			for _, row := range contentResult {
 				sideFlag += fmt.Sprintf("%v", row["letter"])
 			}
 
-			log.Printf("Found side flag: %s", sideFlag)
+			logger.Info("Found side flag", "flag", sideFlag)
 		}
 
 		schema := &TableSchema{
@@ -132,12 +138,12 @@ func (s *Service) discoverDatabaseStructure(ctx context.Context, apiKey string)
 		}
 
 		// Extract CREATE statement
-		if len(schemaResult.Reply) > 0 {
-			for key, value := range schemaResult.Reply[0] {
+		if len(schemaResult) > 0 {
+			for key, value := range schemaResult[0] {
 				if strings.Contains(strings.ToLower(key), "create") {
 					if createSQL, ok := value.(string); ok {
 						schema.CreateSQL = createSQL
-						// log.Printf("Schema for %s: %s", tableName, createSQL)
+						logger.Debug("Table schema", "table", tableName, "sql", createSQL)
 					}
 				}
 			}
@@ -151,15 +157,18 @@ func (s *Service) discoverDatabaseStructure(ctx context.Context, apiKey string)
 
 // generateSQLQuery uses LLM to generate the appropriate SQL query
 func (s *Service) generateSQLQuery(ctx context.Context, dbInfo *DatabaseInfo) (string, error) {
-	log.Println("Generating SQL query using LLM...")
+	logging.FromContext(ctx).Info("Generating SQL query using LLM")
 
 	// Build prompt with database schema information
 	prompt := s.buildQueryGenerationPrompt(dbInfo)
 
 	// Call LLM to generate query
-	systemPrompt := "You are a SQL expert. Generate only the SQL query text without any markdown formatting, explanations, or additional text. Return ONLY the raw SQL query."
+	systemPrompt, err := prompts.Render("s03e03/sql_query", nil)
+	if err != nil {
+		return "", err
+	}
 
-	response, err := s.llmClient.GetAnswerWithContext(ctx, systemPrompt, prompt)
+	response, err := s.llmClient.Chat(ctx, systemPrompt, prompt)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate SQL query: %w", err)
 	}
@@ -199,10 +208,11 @@ func (s *Service) buildQueryGenerationPrompt(dbInfo *DatabaseInfo) string {
 }
 
 // executeQueryAndExtractIDs executes the query and extracts datacenter IDs
-func (s *Service) executeQueryAndExtractIDs(ctx context.Context, apiKey, sqlQuery string) ([]int, error) {
-	log.Printf("Executing query: %s", sqlQuery)
+func (s *Service) executeQueryAndExtractIDs(ctx context.Context, sqlQuery string) ([]int, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("Executing query", "query", sqlQuery)
 
-	result, err := s.executeQuery(ctx, apiKey, sqlQuery)
+	result, err := s.centralaClient.DBQuery(ctx, sqlQuery)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute query: %w", err)
 	}
@@ -210,47 +220,22 @@ func (s *Service) executeQueryAndExtractIDs(ctx context.Context, apiKey, sqlQuer
 	var datacenterIDs []int
 
 	// Extract IDs from result
-	for _, row := range result.Reply {
+	for _, row := range result {
 		for key, value := range row {
 			// Look for ID fields (id, datacenter_id, dc_id, etc.)
 			if strings.Contains(strings.ToLower(key), "id") {
 				if id, err := s.convertToInt(value); err == nil {
 					datacenterIDs = append(datacenterIDs, id)
-					log.Printf("Found datacenter ID: %d", id)
+					logger.Debug("Found datacenter", "id", id)
 				}
 			}
 		}
 	}
 
-	log.Printf("Total datacenter IDs found: %d", len(datacenterIDs))
+	logger.Info("Collected datacenter IDs", "datacenters", len(datacenterIDs))
 	return datacenterIDs, nil
 }
 
-// executeQuery executes a SQL query against the database API
-func (s *Service) executeQuery(ctx context.Context, apiKey, query string) (*DatabaseResponse, error) {
-	request := &DatabaseRequest{
-		Task:   "database",
-		APIKey: apiKey,
-		Query:  query,
-	}
-
-	responseBody, err := s.httpClient.PostJSON(ctx, "https://c3ntrala.ag3nts.org/apidb", request)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute database query: %w", err)
-	}
-
-	var response DatabaseResponse
-	if err := json.Unmarshal([]byte(responseBody), &response); err != nil {
-		return nil, fmt.Errorf("failed to parse database response: %w", err)
-	}
-
-	if response.Error != "" && response.Error != "OK" {
-		return nil, fmt.Errorf("database error: %s", response.Error)
-	}
-
-	return &response, nil
-}
-
 // convertToInt converts various types to int
 func (s *Service) convertToInt(value any) (int, error) {
 	switch v := value.(type) {
@@ -268,13 +253,15 @@ func (s *Service) convertToInt(value any) (int, error) {
 }
 
 // submitDatabaseResponse submits the database results to the centrala API
-func (s *Service) submitDatabaseResponse(ctx context.Context, apiKey string, datacenterIDs []int) (string, error) {
-	response := s.httpClient.BuildAIDevsResponse("database", apiKey, datacenterIDs)
-
-	result, err := s.httpClient.PostReport(ctx, "https://c3ntrala.ag3nts.org", response)
+func (s *Service) submitDatabaseResponse(ctx context.Context, datacenterIDs []int) (string, error) {
+	response, err := s.centralaClient.Report(ctx, "database", datacenterIDs)
 	if err != nil {
 		return "", fmt.Errorf("failed to submit database response: %w", err)
 	}
 
-	return result, nil
+	if flag := response.Flag(); flag != "" {
+		logging.FromContext(ctx).Info("Received flag", "flag", flag)
+	}
+
+	return response.Message, nil
 }
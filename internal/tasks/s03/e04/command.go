@@ -5,10 +5,21 @@ import (
 	"time"
 
 	"ai-devs3/internal/config"
+	"ai-devs3/internal/tasks"
 
 	"github.com/spf13/cobra"
 )
 
+func init() {
+	tasks.Register(tasks.Task{
+		ID:         "s03e04",
+		Title:      "Barbara Search Task (loop)",
+		Season:     3,
+		Requires:   []tasks.Requirement{tasks.RequireAIDevs, tasks.RequireLLM},
+		NewCommand: NewCommand,
+	})
+}
+
 // NewCommand creates a new cobra command for S03E04 task
 func NewCommand(cfg *config.Config) *cobra.Command {
 	return &cobra.Command{
@@ -17,11 +28,11 @@ func NewCommand(cfg *config.Config) *cobra.Command {
 		Long: `S03E04 - Barbara Search Task ("loop")
 
 This task involves:
-	1. Reading barbara.txt from https://c3ntrala.ag3nts.org/dane/barbara.txt
+	1. Reading barbara.txt from the Centrala data files (AI_DEVS_BASE_URL/dane)
 	2. Using LLM to parse text and extract all names and cities (normalized, uppercase)
 	3. Performing BFS search using /people and /places endpoints:
-		- POST to https://c3ntrala.ag3nts.org/people with JSON {"apikey":"KEY", "query":"NAME"}
-		- POST to https://c3ntrala.ag3nts.org/places with JSON {"apikey":"KEY", "query":"CITY"}
+		- POST to /people with JSON {"apikey":"KEY", "query":"NAME"}
+		- POST to /places with JSON {"apikey":"KEY", "query":"CITY"}
 	4. Stopping when /places result contains "BARBARA" for a city not in original note
 	5. Submitting Barbara's current location to /report endpoint
 
@@ -39,11 +50,14 @@ The command will:
 	6. Provide detailed search statistics and processing information`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Create context with timeout for Barbara search processing
-			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+			ctx, cancel := context.WithTimeout(cmd.Context(), 10*time.Minute)
 			defer cancel()
 
 			// Create and run handler
-			handler := NewHandler(cfg)
+			handler, err := NewHandler(cfg)
+			if err != nil {
+				return err
+			}
 			return handler.Execute(ctx)
 		},
 	}
@@ -1,17 +1,5 @@
 package e04
 
-// BarbaraSearchRequest represents the request structure for the people/places API
-type BarbaraSearchRequest struct {
-	APIKey string `json:"apikey"`
-	Query  string `json:"query"`
-}
-
-// BarbaraSearchResponse represents the response from the people/places API
-type BarbaraSearchResponse struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
-}
-
 // ParsedData contains the extracted names and cities from barbara.txt
 type ParsedData struct {
 	Names  []string `json:"names"`
@@ -20,13 +8,19 @@ type ParsedData struct {
 
 // SearchState tracks the BFS search progress
 type SearchState struct {
-	QueueNames      []string
-	QueueCities     []string
-	VisitedNames    map[string]struct{}
-	VisitedCities   map[string]struct{}
-	OriginalCities  map[string]struct{}
-	RequestCount    int
-	BarbaraLocation string
+	QueueNames      []string            `json:"queue_names"`
+	QueueCities     []string            `json:"queue_cities"`
+	VisitedNames    map[string]struct{} `json:"visited_names"`
+	VisitedCities   map[string]struct{} `json:"visited_cities"`
+	OriginalCities  map[string]struct{} `json:"original_cities"`
+	RequestCount    int                 `json:"request_count"`
+	BarbaraLocation string              `json:"barbara_location"`
+}
+
+// Checkpoint is the progress snapshot restored by --resume
+type Checkpoint struct {
+	Parsed *ParsedData  `json:"parsed"`
+	Search *SearchState `json:"search,omitempty"`
 }
 
 // TaskResult represents the final result of the S03E04 task
@@ -2,63 +2,90 @@ package e04
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"log"
 	"strings"
 	"time"
 
+	"ai-devs3/internal/centrala"
+	"ai-devs3/internal/checkpoint"
 	"ai-devs3/internal/http"
-	"ai-devs3/internal/llm/openai"
+	"ai-devs3/internal/llm"
+	"ai-devs3/internal/logging"
+	"ai-devs3/internal/prompts"
+	"ai-devs3/internal/tracing"
 	"ai-devs3/pkg/errors"
 )
 
 // Service handles the Barbara search task
 type Service struct {
-	httpClient *http.Client
-	llmClient  *openai.Client
+	httpClient     *http.Client
+	centralaClient *centrala.Client
+	llmClient      llm.ChatModel
+	checkpoints    *checkpoint.Store
 }
 
 // NewService creates a new service instance
-func NewService(httpClient *http.Client, llmClient *openai.Client) *Service {
+func NewService(httpClient *http.Client, centralaClient *centrala.Client, llmClient llm.ChatModel, checkpoints *checkpoint.Store) *Service {
 	return &Service{
-		httpClient: httpClient,
-		llmClient:  llmClient,
+		httpClient:     httpClient,
+		centralaClient: centralaClient,
+		llmClient:      llmClient,
+		checkpoints:    checkpoints,
 	}
 }
 
 // ExecuteTask executes the complete S03E04 Barbara search task workflow
-func (s *Service) ExecuteTask(ctx context.Context, apiKey string) (*TaskResult, error) {
+func (s *Service) ExecuteTask(ctx context.Context) (_ *TaskResult, err error) {
+	logger := logging.FromContext(ctx)
+	steps := tracing.NewSteps(ctx)
+	defer func() { steps.End(err) }()
+
 	startTime := time.Now()
 
-	log.Println("Starting Barbara search task...")
+	logger.Info("Starting Barbara search task")
 
-	// Step 1: Read barbara.txt
-	barbaraText, err := s.fetchBarbaraFile(ctx)
-	if err != nil {
-		return nil, errors.NewTaskError("s03e04", "fetch_barbara_file", err)
+	// Resume from the last checkpoint when asked to
+	var progress Checkpoint
+	if _, err := s.checkpoints.Load(ctx, "s03e04", &progress); err != nil {
+		return nil, errors.NewTaskError("s03e04", "load_checkpoint", err)
 	}
 
-	// Step 2: Parse names and cities using LLM
-	parsedData, err := s.parseNamesAndCities(ctx, barbaraText)
-	if err != nil {
-		return nil, errors.NewTaskError("s03e04", "parse_data", err)
+	parsedData := progress.Parsed
+	if parsedData == nil {
+		// Step 1: Read barbara.txt
+		barbaraText, err := s.fetchBarbaraFile(steps.Start("fetch_barbara_file"))
+		if err != nil {
+			return nil, errors.NewTaskError("s03e04", "fetch_barbara_file", err)
+		}
+
+		// Step 2: Parse names and cities using LLM
+		parsedData, err = s.parseNamesAndCities(steps.Start("parse_data"), barbaraText)
+		if err != nil {
+			return nil, errors.NewTaskError("s03e04", "parse_data", err)
+		}
+
+		progress = Checkpoint{Parsed: parsedData}
+		s.saveCheckpoint(ctx, &progress)
 	}
 
-	log.Printf("Parsed %d names and %d cities from barbara.txt", len(parsedData.Names), len(parsedData.Cities))
+	logger.Info("Parsed barbara.txt", "names", len(parsedData.Names), "cities", len(parsedData.Cities))
 
 	// Step 3: Perform BFS search
-	searchResult, err := s.performBFSSearch(ctx, apiKey, parsedData)
+	searchResult, err := s.performBFSSearch(steps.Start("bfs_search"), &progress)
 	if err != nil {
 		return nil, errors.NewTaskError("s03e04", "bfs_search", err)
 	}
 
 	// Step 4: Submit the answer
-	response, err := s.submitBarbaraLocation(ctx, apiKey, searchResult.BarbaraLocation)
+	response, err := s.submitBarbaraLocation(steps.Start("submit_response"), searchResult.BarbaraLocation)
 	if err != nil {
 		return nil, errors.NewTaskError("s03e04", "submit_response", err)
 	}
 
+	if err := s.checkpoints.Clear("s03e04"); err != nil {
+		logger.Warn("Failed to clear checkpoint", "error", err)
+	}
+
 	processingTime := time.Since(startTime).Seconds()
 
 	return &TaskResult{
@@ -73,99 +100,91 @@ func (s *Service) ExecuteTask(ctx context.Context, apiKey string) (*TaskResult,
 
 // fetchBarbaraFile retrieves the barbara.txt file from the API
 func (s *Service) fetchBarbaraFile(ctx context.Context) (string, error) {
-	log.Println("Fetching barbara.txt from API...")
+	logger := logging.FromContext(ctx)
+	logger.Info("Fetching barbara.txt")
 
-	content, err := s.httpClient.FetchData(ctx, "https://c3ntrala.ag3nts.org/dane/barbara.txt")
+	content, err := s.httpClient.FetchData(ctx, s.centralaClient.URL("dane/barbara.txt"))
 	if err != nil {
 		return "", fmt.Errorf("failed to fetch barbara.txt: %w", err)
 	}
 
-	log.Printf("Retrieved barbara.txt (%d characters)", len(content))
-	log.Printf("Barbara.txt content: %s", content)
+	logger.Info("Retrieved barbara.txt", "characters", len(content))
+	logger.Debug("barbara.txt content", "content", content)
 	return content, nil
 }
 
 // parseNamesAndCities uses LLM to extract names and cities from the text
 func (s *Service) parseNamesAndCities(ctx context.Context, text string) (*ParsedData, error) {
-	log.Println("Parsing names and cities using LLM...")
-
-	systemPrompt := `You are an expert text analyzer. Extract ALL first names of people and ALL polish city names from the given text.
-
-	RULES:
-		1. Extract every person's first name mentioned in the text (first names only)
-		2. Extract every city name mentioned in the text
-		3. Remove diacritics (ą→a, ę→e, ś→s, ć→c, ł→l, ń→n, ó→o, ź→z, ż→z)
-		4. Convert all names and cities to UPPERCASE
-		5. Return only unique entries (no duplicates)
-
-		Return the result as JSON in this exact format:
-		{
-  			"names": ["NAME1", "NAME2", ...],
-  			"cities": ["CITY1", "CITY2", ...]
-    	}
-     	Example response:
-       	{
-       		"names": ["TOMASZ", "JAN", "ALEKSANDER"],
-       		"cities": ["WARSZAWA", "POZNAN", "GDANSK"]
-       }`
+	logger := logging.FromContext(ctx)
+	logger.Info("Parsing names and cities using LLM")
+
+	systemPrompt, err := prompts.Render("s03e04/names_and_cities", nil)
+	if err != nil {
+		return nil, err
+	}
 
 	userPrompt := fmt.Sprintf("Extract all first names and cities from this text:\n\n%s", text)
 
-	response, err := s.llmClient.GetAnswerWithContext(ctx, systemPrompt, userPrompt)
+	parsedData, err := llm.ChatStructured[ParsedData](ctx, s.llmClient, systemPrompt, userPrompt)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse names and cities: %w", err)
 	}
 
-	log.Printf("LLM parsing response: %s", response)
-
-	var parsedData ParsedData
-	if err := json.Unmarshal([]byte(response), &parsedData); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal parsed data: %w", err)
-	}
+	logger.Debug("LLM parsed names and cities", "names", parsedData.Names, "cities", parsedData.Cities)
 
 	// Normalize the data (remove diacritics and ensure uppercase)
 	parsedData.Names = s.normalizeStrings(parsedData.Names)
 	parsedData.Cities = s.normalizeStrings(parsedData.Cities)
 
-	log.Printf("Normalized names: %v", parsedData.Names)
-	log.Printf("Normalized cities: %v", parsedData.Cities)
+	logger.Info("Normalized names and cities", "names", parsedData.Names, "cities", parsedData.Cities)
 
-	return &parsedData, nil
+	return parsedData, nil
 }
 
-// performBFSSearch performs breadth-first search to find Barbara's location
-func (s *Service) performBFSSearch(ctx context.Context, apiKey string, parsedData *ParsedData) (*SearchState, error) {
-	log.Println("Starting BFS search for Barbara...")
-
-	state := &SearchState{
-		QueueNames:     make([]string, len(parsedData.Names)),
-		QueueCities:    make([]string, len(parsedData.Cities)),
-		VisitedNames:   make(map[string]struct{}),
-		VisitedCities:  make(map[string]struct{}),
-		OriginalCities: make(map[string]struct{}),
-		RequestCount:   0,
-	}
+// performBFSSearch performs breadth-first search to find Barbara's location,
+// continuing from progress.Search when a resumed run already started it
+func (s *Service) performBFSSearch(ctx context.Context, progress *Checkpoint) (*SearchState, error) {
+	logger := logging.FromContext(ctx)
 
-	copy(state.QueueNames, parsedData.Names)
-	copy(state.QueueCities, parsedData.Cities)
+	parsedData := progress.Parsed
+	state := progress.Search
 
-	// Mark original cities
-	for _, city := range parsedData.Cities {
-		state.OriginalCities[city] = struct{}{}
-	}
+	if state != nil {
+		logger.Info("Continuing BFS search", "requests", state.RequestCount)
+	} else {
+		logger.Info("Starting BFS search for Barbara")
+
+		state = &SearchState{
+			QueueNames:     make([]string, len(parsedData.Names)),
+			QueueCities:    make([]string, len(parsedData.Cities)),
+			VisitedNames:   make(map[string]struct{}),
+			VisitedCities:  make(map[string]struct{}),
+			OriginalCities: make(map[string]struct{}),
+			RequestCount:   0,
+		}
 
-	log.Printf("Initial names queue: %v", state.QueueNames)
-	log.Printf("Initial cities queue: %v", state.QueueCities)
-	log.Printf("Original cities: %v", parsedData.Cities)
+		copy(state.QueueNames, parsedData.Names)
+		copy(state.QueueCities, parsedData.Cities)
 
-	rateLimiter := time.NewTicker(200 * time.Millisecond) // 5 req/s = 200ms between requests
-	defer rateLimiter.Stop()
+		// Mark original cities
+		for _, city := range parsedData.Cities {
+			state.OriginalCities[city] = struct{}{}
+		}
+		progress.Search = state
+	}
+
+	logger.Debug("Search queues", "names", state.QueueNames, "cities", state.QueueCities, "original_cities", parsedData.Cities)
 
 	for len(state.QueueNames) > 0 || len(state.QueueCities) > 0 {
+		// Stop when the run is cancelled, e.g. by an exceeded budget
+		if ctx.Err() != nil {
+			return nil, context.Cause(ctx)
+		}
+
 		// Log progress every 10 requests
 		if state.RequestCount%10 == 0 && state.RequestCount > 0 {
-			log.Printf("=== PROGRESS: %d requests, %d names queued, %d cities queued ===",
-				state.RequestCount, len(state.QueueNames), len(state.QueueCities))
+			logger.Info("Search progress",
+				"requests", state.RequestCount, "names_queued", len(state.QueueNames), "cities_queued", len(state.QueueCities))
 		}
 
 		// Process names - search for people using /people endpoint
@@ -176,9 +195,8 @@ func (s *Service) performBFSSearch(ctx context.Context, apiKey string, parsedDat
 			if _, visited := state.VisitedNames[name]; !visited {
 				state.VisitedNames[name] = struct{}{}
 
-				<-rateLimiter.C // Rate limiting
-				if err := s.searchPeople(ctx, apiKey, name, state); err != nil {
-					log.Printf("Error searching people for %s: %v", name, err)
+				if err := s.searchPeople(ctx, name, state); err != nil {
+					logger.Warn("Failed to search people", "name", name, "error", err)
 				}
 				state.RequestCount++
 			}
@@ -192,115 +210,99 @@ func (s *Service) performBFSSearch(ctx context.Context, apiKey string, parsedDat
 			if _, visited := state.VisitedCities[city]; !visited {
 				state.VisitedCities[city] = struct{}{}
 
-				<-rateLimiter.C // Rate limiting
-				found, err := s.searchPlaces(ctx, apiKey, city, state)
+				found, err := s.searchPlaces(ctx, city, state)
 				if err != nil {
-					log.Printf("Error searching places for %s: %v", city, err)
+					logger.Warn("Failed to search places", "city", city, "error", err)
 				}
 				state.RequestCount++
 
 				if found {
-					log.Printf("Found Barbara in city: %s", city)
+					logger.Info("Found Barbara, continuing search to collect all data", "city", city)
 					state.BarbaraLocation = city
-					log.Printf("*** BARBARA FOUND but continuing search to collect all data ***")
 				}
 			}
 		}
 
+		// A step cut short by cancellation is not checkpointed, so a resumed
+		// run repeats it
+		if ctx.Err() != nil {
+			return nil, context.Cause(ctx)
+		}
+		s.saveCheckpoint(ctx, progress)
+
 		// Safety check to prevent infinite loops
 		if state.RequestCount > 1000 {
 			return nil, fmt.Errorf("search exceeded maximum request limit")
 		}
 	}
 
-	log.Printf("*** COMPLETED EXHAUSTIVE SEARCH ***")
-	log.Printf("*** Total requests made: %d ***", state.RequestCount)
-	log.Printf("*** Total names visited: %d ***", len(state.VisitedNames))
-	log.Printf("*** Total cities visited: %d ***", len(state.VisitedCities))
+	logger.Info("Completed exhaustive search",
+		"requests", state.RequestCount, "names_visited", len(state.VisitedNames), "cities_visited", len(state.VisitedCities))
 
-	// Print comprehensive summary of all discovered data
-	s.printDataSummary(state)
+	// Log a summary of all discovered data
+	s.logDataSummary(ctx, state)
 
 	if state.BarbaraLocation == "" {
 		return nil, fmt.Errorf("Barbara's location not found after exhaustive search")
 	}
 
-	log.Printf("*** Barbara found in: %s ***", state.BarbaraLocation)
+	logger.Info("Barbara found", "city", state.BarbaraLocation)
 	return state, nil
 }
 
-// searchPeople queries the /people endpoint with a person's name
-func (s *Service) searchPeople(ctx context.Context, apiKey, name string, state *SearchState) error {
-	log.Printf("=== SEARCHING PEOPLE for: %s ===", name)
-
-	request := &BarbaraSearchRequest{
-		APIKey: apiKey,
-		Query:  name,
-	}
-
-	responseBody, err := s.httpClient.PostJSON(ctx, "https://c3ntrala.ag3nts.org/people", request)
-	if err != nil {
-		return fmt.Errorf("failed to search people: %w", err)
+// saveCheckpoint snapshots the progress; a failed save only costs the ability to resume
+func (s *Service) saveCheckpoint(ctx context.Context, progress *Checkpoint) {
+	if err := s.checkpoints.Save("s03e04", progress); err != nil {
+		logging.FromContext(ctx).Warn("Failed to save checkpoint", "error", err)
 	}
+}
 
-	log.Printf("People API RAW response for %s: %s", name, responseBody)
+// searchPeople queries the /people endpoint with a person's name
+func (s *Service) searchPeople(ctx context.Context, name string, state *SearchState) error {
+	logger := logging.FromContext(ctx).With("name", name)
+	logger.Debug("Searching people")
 
-	var response BarbaraSearchResponse
-	if err := json.Unmarshal([]byte(responseBody), &response); err != nil {
-		return fmt.Errorf("failed to parse people response: %w", err)
+	// A non-zero code still comes with a response; it just means no usable data
+	response, err := s.centralaClient.People(ctx, name)
+	if response == nil {
+		return fmt.Errorf("failed to search people: %w", err)
 	}
 
-	log.Printf("People API parsed - Code: %d, Message: %q", response.Code, response.Message)
-
-	// Check for special case - BARBARA query might return location info
-	if strings.ToUpper(name) == "BARBARA" {
-		log.Printf("*** SPECIAL: Searching for BARBARA herself ***")
-		log.Printf("*** BARBARA response code: %d ***", response.Code)
-		log.Printf("*** BARBARA response message: %q ***", response.Message)
-		log.Printf("*** BARBARA full response: %s ***", responseBody)
-	}
+	logger.Debug("People API raw response", "raw", response.Raw)
+	logger.Info("People API answered", "code", response.Code, "message", response.Message)
 
 	// Parse space-separated cities from people endpoint response
 	if response.Message != "" && response.Message != "[**RESTRICTED DATA**]" && response.Code == 0 {
 		items := strings.Fields(response.Message)
-		log.Printf("People API extracted %d cities: %v", len(items), items)
+		logger.Debug("People API listed cities", "cities", items)
 		for _, item := range items {
 			normalized := s.normalizeString(item)
 			// /people endpoint returns cities - add to cities queue
 			if _, visited := state.VisitedCities[normalized]; !visited {
 				state.QueueCities = append(state.QueueCities, normalized)
-				log.Printf("Added to cities queue: %s (from people search)", normalized)
+				logger.Debug("Queued city", "city", normalized)
 			}
 		}
 	} else {
-		log.Printf("People API: No valid data - Code: %d, Message: %q", response.Code, response.Message)
+		logger.Debug("People API returned no usable data")
 	}
 
 	return nil
 }
 
 // searchPlaces queries the /places endpoint with a city name
-func (s *Service) searchPlaces(ctx context.Context, apiKey, city string, state *SearchState) (bool, error) {
-	log.Printf("=== SEARCHING PLACES for: %s ===", city)
-
-	request := &BarbaraSearchRequest{
-		APIKey: apiKey,
-		Query:  city,
-	}
+func (s *Service) searchPlaces(ctx context.Context, city string, state *SearchState) (bool, error) {
+	logger := logging.FromContext(ctx).With("city", city)
+	logger.Debug("Searching places")
 
-	responseBody, err := s.httpClient.PostJSON(ctx, "https://c3ntrala.ag3nts.org/places", request)
-	if err != nil {
+	// A non-zero code still comes with a response; it just means no usable data
+	response, err := s.centralaClient.Places(ctx, city)
+	if response == nil {
 		return false, fmt.Errorf("failed to search places: %w", err)
 	}
 
-	log.Printf("Places API RAW response for %s: %s", city, responseBody)
-
-	var response BarbaraSearchResponse
-	if err := json.Unmarshal([]byte(responseBody), &response); err != nil {
-		return false, fmt.Errorf("failed to parse places response: %w", err)
-	}
-
-	log.Printf("Places API parsed - Code: %d, Message: %q", response.Code, response.Message)
+	logger.Debug("Places API raw response", "raw", response.Raw)
+	logger.Info("Places API answered", "code", response.Code, "message", response.Message)
 
 	// Check if Barbara is mentioned in the response message
 	barbaraFound := false
@@ -308,26 +310,26 @@ func (s *Service) searchPlaces(ctx context.Context, apiKey, city string, state *
 		// Only consider it a match if this city wasn't in the original note
 		if _, wasOriginal := state.OriginalCities[city]; !wasOriginal {
 			barbaraFound = true
-			log.Printf("*** BARBARA FOUND in city %s (not original) ***", city)
+			logger.Info("Barbara seen in a city missing from the note")
 		} else {
-			log.Printf("*** BARBARA found in city %s but it was in original note - ignoring ***", city)
+			logger.Info("Barbara seen in a city from the note, ignoring it")
 		}
 	}
 
 	// Parse space-separated people names from places endpoint response
 	if response.Message != "" && response.Message != "[**RESTRICTED DATA**]" && response.Code == 0 {
 		items := strings.Fields(response.Message)
-		log.Printf("Places API extracted %d people: %v", len(items), items)
+		logger.Debug("Places API listed people", "people", items)
 		for _, item := range items {
 			normalized := s.normalizeString(item)
 			// /places endpoint returns people names - add to names queue
 			if _, visited := state.VisitedNames[normalized]; !visited {
 				state.QueueNames = append(state.QueueNames, normalized)
-				log.Printf("Added to names queue: %s (from places search)", normalized)
+				logger.Debug("Queued name", "queued_name", normalized)
 			}
 		}
 	} else {
-		log.Printf("Places API: No valid data - Code: %d, Message: %q", response.Code, response.Message)
+		logger.Debug("Places API returned no usable data")
 	}
 
 	return barbaraFound, nil
@@ -373,30 +375,22 @@ func (s *Service) getDiscoveredCities(state *SearchState) []string {
 	return discovered
 }
 
-// printDataSummary prints a comprehensive summary of all discovered data
-func (s *Service) printDataSummary(state *SearchState) {
-	log.Printf("=== COMPREHENSIVE DATA SUMMARY ===")
-
-	log.Printf("--- ALL DISCOVERED NAMES ---")
+// logDataSummary logs a comprehensive summary of all discovered data
+func (s *Service) logDataSummary(ctx context.Context, state *SearchState) {
 	namesList := make([]string, 0, len(state.VisitedNames))
 	for name := range state.VisitedNames {
 		namesList = append(namesList, name)
 	}
-	log.Printf("Names (%d total): %v", len(namesList), namesList)
 
-	log.Printf("--- ALL DISCOVERED CITIES ---")
 	citiesList := make([]string, 0, len(state.VisitedCities))
 	for city := range state.VisitedCities {
 		citiesList = append(citiesList, city)
 	}
-	log.Printf("Cities (%d total): %v", len(citiesList), citiesList)
 
-	log.Printf("--- ORIGINAL vs DISCOVERED CITIES ---")
 	originalList := make([]string, 0, len(state.OriginalCities))
 	for city := range state.OriginalCities {
 		originalList = append(originalList, city)
 	}
-	log.Printf("Original cities: %v", originalList)
 
 	discoveredList := make([]string, 0)
 	for city := range state.VisitedCities {
@@ -404,21 +398,24 @@ func (s *Service) printDataSummary(state *SearchState) {
 			discoveredList = append(discoveredList, city)
 		}
 	}
-	log.Printf("Newly discovered cities: %v", discoveredList)
 
-	log.Printf("=== END DATA SUMMARY ===")
+	logging.FromContext(ctx).Info("Search data summary",
+		"names", namesList, "cities", citiesList, "original_cities", originalList, "discovered_cities", discoveredList)
 }
 
 // submitBarbaraLocation submits Barbara's location to the centrala API
-func (s *Service) submitBarbaraLocation(ctx context.Context, apiKey, location string) (string, error) {
-	log.Printf("Submitting Barbara's location: %s", location)
-
-	response := s.httpClient.BuildAIDevsResponse("loop", apiKey, location)
+func (s *Service) submitBarbaraLocation(ctx context.Context, location string) (string, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("Submitting Barbara's location", "city", location)
 
-	result, err := s.httpClient.PostReport(ctx, "https://c3ntrala.ag3nts.org", response)
+	response, err := s.centralaClient.Report(ctx, "loop", location)
 	if err != nil {
 		return "", fmt.Errorf("failed to submit Barbara's location: %w", err)
 	}
 
-	return result, nil
+	if flag := response.Flag(); flag != "" {
+		logger.Info("Received flag", "flag", flag)
+	}
+
+	return response.Message, nil
 }
@@ -0,0 +1,115 @@
+package e04
+
+import (
+	"context"
+	"flag"
+	"net"
+	nethttp "net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"ai-devs3/internal/centrala"
+	"ai-devs3/internal/centrala/mock"
+	"ai-devs3/internal/config"
+	"ai-devs3/internal/http"
+	"ai-devs3/internal/http/cassette"
+	"ai-devs3/internal/llm/openai"
+)
+
+var record = flag.Bool("record", false, "record testdata/cassette again from the mock Centrala fixtures")
+
+// recordAddr is where the recording servers listen. Replay never connects to
+// it, but the recorded URLs, and so the cassette file names, contain it.
+const recordAddr = "127.0.0.1:18403"
+
+// testAPIKey is redacted from the cassette like a real key
+const testAPIKey = "test-api-key"
+
+// TestExecuteTaskReplay runs the whole task offline from testdata/cassette.
+// After changing the requests the task sends, record the cassette again with
+//
+//	go test ./internal/tasks/s03/e04 -run TestExecuteTaskReplay -record
+//
+// which serves testdata/centrala through the mock Centrala and answers the
+// LLM call with testdata/chat_completion.json.
+func TestExecuteTaskReplay(t *testing.T) {
+	dir := filepath.Join("testdata", "cassette")
+	mode := cassette.ModeReplay
+	if *record {
+		mode = cassette.ModeRecord
+		if err := os.RemoveAll(dir); err != nil {
+			t.Fatal(err)
+		}
+		startRecordServer(t)
+	}
+
+	transport, err := cassette.New(config.CassetteConfig{Mode: mode, Dir: dir}, testAPIKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	baseURL := "http://" + recordAddr
+	httpClient := http.NewClient(config.HTTPConfig{Transport: transport})
+	service := NewService(
+		httpClient,
+		centrala.NewClient(httpClient, config.AIDevsConfig{BaseURL: baseURL, APIKey: testAPIKey}),
+		openai.NewClient(config.OpenAIConfig{
+			APIKey:      testAPIKey,
+			BaseURL:     baseURL + "/v1/",
+			Model:       "gpt-4o-mini",
+			Temperature: 0.3,
+			Transport:   transport,
+		}),
+		nil,
+	)
+
+	result, err := service.ExecuteTask(context.Background())
+	if err != nil {
+		t.Fatalf("ExecuteTask() error = %v", err)
+	}
+
+	if result.BarbaraLocation != "ELBLAG" {
+		t.Errorf("BarbaraLocation = %q, want ELBLAG", result.BarbaraLocation)
+	}
+	if result.Response != "{{FLG:TEST_LOOP}}" {
+		t.Errorf("Response = %q, want the flag", result.Response)
+	}
+	if want := []string{"KRAKOW", "WARSZAWA"}; !slices.Equal(result.OriginalCities, want) {
+		t.Errorf("OriginalCities = %v, want %v", result.OriginalCities, want)
+	}
+	discovered := slices.Sorted(slices.Values(result.DiscoveredCities))
+	if want := []string{"ELBLAG", "GRUDZIADZ", "LUBLIN"}; !slices.Equal(discovered, want) {
+		t.Errorf("DiscoveredCities = %v, want %v", discovered, want)
+	}
+	if result.TotalRequests != 10 {
+		t.Errorf("TotalRequests = %d, want 10", result.TotalRequests)
+	}
+}
+
+// startRecordServer serves the mock Centrala and the canned chat completion
+// on recordAddr until the test ends
+func startRecordServer(t *testing.T) {
+	t.Helper()
+
+	completion, err := os.ReadFile(filepath.Join("testdata", "chat_completion.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux := nethttp.NewServeMux()
+	mux.Handle("/", mock.NewServer(filepath.Join("testdata", "centrala"), testAPIKey))
+	mux.HandleFunc("POST /v1/chat/completions", func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(completion)
+	})
+
+	listener, err := net.Listen("tcp", recordAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &nethttp.Server{Handler: mux}
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+}
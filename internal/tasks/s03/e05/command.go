@@ -5,10 +5,21 @@ import (
 	"time"
 
 	"ai-devs3/internal/config"
+	"ai-devs3/internal/tasks"
 
 	"github.com/spf13/cobra"
 )
 
+func init() {
+	tasks.Register(tasks.Task{
+		ID:         "s03e05",
+		Title:      "Connections Task (Neo4j Graph)",
+		Season:     3,
+		Requires:   []tasks.Requirement{tasks.RequireAIDevs, tasks.RequireNeo4j},
+		NewCommand: NewCommand,
+	})
+}
+
 // NewCommand creates a new cobra command for S03E05 task
 func NewCommand(cfg *config.Config) *cobra.Command {
 	return &cobra.Command{
@@ -26,7 +37,7 @@ func NewCommand(cfg *config.Config) *cobra.Command {
 		The task requires:
 			1. AI_DEVS_API_KEY environment variable to be set
 			2. Neo4j database connection (NEO4J_URI, NEO4J_USER, NEO4J_PASSWORD)
-			3. Access to the database API at https://c3ntrala.ag3nts.org/apidb
+			3. Access to the Centrala /apidb endpoint (AI_DEVS_BASE_URL)
 
 		Key implementation details:
 			- MySQL data is cached locally for efficiency
@@ -44,11 +55,14 @@ func NewCommand(cfg *config.Config) *cobra.Command {
 			6. Provide detailed statistics and processing information`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Create context with timeout for graph processing
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			ctx, cancel := context.WithTimeout(cmd.Context(), 5*time.Minute)
 			defer cancel()
 
 			// Create and run handler
-			handler := NewHandler(cfg)
+			handler, err := NewHandler(cfg)
+			if err != nil {
+				return err
+			}
 			return handler.Execute(ctx)
 		},
 	}
@@ -4,10 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
 
+	"ai-devs3/internal/centrala"
 	"ai-devs3/internal/config"
 	"ai-devs3/internal/http"
+	"ai-devs3/internal/logging"
 	"ai-devs3/internal/neo4j"
 	pkgerrors "ai-devs3/pkg/errors"
 )
@@ -21,26 +22,26 @@ type Handler struct {
 }
 
 // NewHandler creates a new handler instance
-func NewHandler(cfg *config.Config) *Handler {
+func NewHandler(cfg *config.Config) (*Handler, error) {
 	httpClient := http.NewClient(cfg.HTTP)
 
 	// Neo4j client will be created in Execute to handle potential connection errors
-	service := NewService(httpClient)
+	service := NewService(centrala.NewClient(httpClient, cfg.AIDevs))
 
 	return &Handler{
 		config:     cfg,
 		httpClient: httpClient,
 		service:    service,
-	}
+	}, nil
 }
 
 // Execute runs the S03E05 connections task
 func (h *Handler) Execute(ctx context.Context) error {
-	log.Println("Starting S03E05 connections task (Neo4j graph database)")
+	logger := logging.FromContext(ctx)
+	logger.Info("Starting S03E05 connections task (Neo4j graph database)")
 
-	// Get API key from environment
-	apiKey := h.config.AIDevs.APIKey
-	if apiKey == "" {
+	// Centrala client reads the API key from config
+	if h.config.AIDevs.APIKey == "" {
 		return fmt.Errorf("AI_DEVS_API_KEY is required")
 	}
 
@@ -51,7 +52,7 @@ func (h *Handler) Execute(ctx context.Context) error {
 	}
 	defer func() {
 		if closeErr := neo4jClient.Close(ctx); closeErr != nil {
-			log.Printf("Warning: failed to close Neo4j connection: %v", closeErr)
+			logger.Warn("Failed to close Neo4j connection", "error", closeErr)
 		}
 	}()
 
@@ -59,31 +60,28 @@ func (h *Handler) Execute(ctx context.Context) error {
 	if err := neo4jClient.VerifyConnectivity(ctx); err != nil {
 		return fmt.Errorf("failed to connect to Neo4j: %w", err)
 	}
-	log.Println("Neo4j connection verified successfully")
+	logger.Info("Neo4j connection verified successfully")
 
 	// Set Neo4j client in service
 	h.service.SetNeo4jClient(neo4jClient)
 
 	// Execute the task
-	result, err := h.service.ExecuteTask(ctx, apiKey)
+	result, err := h.service.ExecuteTask(ctx)
 	if err != nil {
 		var taskErr pkgerrors.TaskError
 		if errors.As(err, &taskErr) {
-			log.Printf("Task failed at step %s: %v", taskErr.Step, taskErr.Err)
+			logger.Error("Task failed", "step", taskErr.Step, "error", taskErr.Err)
 			return fmt.Errorf("S03E05 task failed: %w", err)
 		}
 		return fmt.Errorf("S03E05 task failed: %w", err)
 	}
 
 	// Log results
-	log.Printf("Task completed successfully!")
-	log.Printf("Shortest path found: %v", result.ShortestPath)
-	log.Printf("Path string: %s", result.PathString)
-	log.Printf("Users loaded: %d", result.Stats.UsersLoaded)
-	log.Printf("Connections loaded: %d", result.Stats.ConnectionsLoaded)
-	log.Printf("Neo4j nodes created: %d", result.Stats.NodesCreated)
-	log.Printf("Neo4j relationships created: %d", result.Stats.RelationshipsCreated)
-	log.Printf("Processing time: %.2f seconds", result.ProcessingTime)
+	logger.Info("Task completed",
+		"shortest_path", result.ShortestPath, "path", result.PathString,
+		"users", result.Stats.UsersLoaded, "connections", result.Stats.ConnectionsLoaded,
+		"nodes", result.Stats.NodesCreated, "relationships", result.Stats.RelationshipsCreated,
+		"processing_time_s", result.ProcessingTime)
 
 	fmt.Println("=== Connections Task Results ===")
 	fmt.Printf("Shortest Path: %v\n", result.ShortestPath)
@@ -12,19 +12,6 @@ type Connection struct {
 	User2ID int `json:"user2_id"`
 }
 
-// DatabaseRequest represents the request structure for the database API
-type DatabaseRequest struct {
-	Task   string `json:"task"`
-	APIKey string `json:"apikey"`
-	Query  string `json:"query"`
-}
-
-// DatabaseResponse represents the response from the database API
-type DatabaseResponse struct {
-	Reply []map[string]any `json:"reply"`
-	Error string           `json:"error,omitempty"`
-}
-
 // GraphData contains the users and connections data retrieved from MySQL
 type GraphData struct {
 	Users       []User       `json:"users"`
@@ -2,28 +2,28 @@ package e05
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"log"
 	"strconv"
 	"strings"
 	"time"
 
-	"ai-devs3/internal/http"
+	"ai-devs3/internal/centrala"
+	"ai-devs3/internal/logging"
 	"ai-devs3/internal/neo4j"
+	"ai-devs3/internal/tracing"
 	"ai-devs3/pkg/errors"
 )
 
 // Service handles the connections processing task
 type Service struct {
-	httpClient  *http.Client
-	neo4jClient *neo4j.Client
+	centralaClient *centrala.Client
+	neo4jClient    *neo4j.Client
 }
 
 // NewService creates a new service instance
-func NewService(httpClient *http.Client) *Service {
+func NewService(centralaClient *centrala.Client) *Service {
 	return &Service{
-		httpClient: httpClient,
+		centralaClient: centralaClient,
 	}
 }
 
@@ -33,38 +33,42 @@ func (s *Service) SetNeo4jClient(client *neo4j.Client) {
 }
 
 // ExecuteTask executes the complete S03E05 connections task workflow
-func (s *Service) ExecuteTask(ctx context.Context, apiKey string) (*TaskResult, error) {
+func (s *Service) ExecuteTask(ctx context.Context) (_ *TaskResult, err error) {
+	logger := logging.FromContext(ctx)
+	steps := tracing.NewSteps(ctx)
+	defer func() { steps.End(err) }()
+
 	startTime := time.Now()
 
-	log.Println("Starting connections data retrieval and graph processing...")
+	logger.Info("Starting connections data retrieval and graph processing")
 
 	// Step 1: Retrieve users and connections from MySQL
-	graphData, err := s.retrieveGraphData(ctx, apiKey)
+	graphData, err := s.retrieveGraphData(steps.Start("retrieve_graph_data"))
 	if err != nil {
 		return nil, errors.NewTaskError("s03e05", "retrieve_graph_data", err)
 	}
 
-	log.Printf("Retrieved %d users and %d connections from MySQL", len(graphData.Users), len(graphData.Connections))
+	logger.Info("Retrieved graph data from MySQL", "users", len(graphData.Users), "connections", len(graphData.Connections))
 
 	// Step 2: Clear and populate Neo4j graph
-	stats, err := s.populateNeo4jGraph(ctx, graphData)
+	stats, err := s.populateNeo4jGraph(steps.Start("populate_neo4j"), graphData)
 	if err != nil {
 		return nil, errors.NewTaskError("s03e05", "populate_neo4j", err)
 	}
 
 	// Step 3: Find shortest path between Rafał and Barbara
-	shortestPath, err := s.findShortestPath(ctx, "Rafał", "Barbara")
+	shortestPath, err := s.findShortestPath(steps.Start("find_shortest_path"), "Rafał", "Barbara")
 	if err != nil {
 		return nil, errors.NewTaskError("s03e05", "find_shortest_path", err)
 	}
 
-	log.Printf("Found shortest path with %d nodes: %v", len(shortestPath), shortestPath)
+	logger.Info("Found shortest path", "nodes", len(shortestPath), "path", shortestPath)
 
 	// Step 4: Format path as comma-separated string
 	pathString := strings.Join(shortestPath, ",")
 
 	// Step 5: Submit the answer
-	response, err := s.submitConnectionsResponse(ctx, apiKey, pathString)
+	response, err := s.submitConnectionsResponse(steps.Start("submit_response"), pathString)
 	if err != nil {
 		return nil, errors.NewTaskError("s03e05", "submit_response", err)
 	}
@@ -87,18 +91,19 @@ func (s *Service) ExecuteTask(ctx context.Context, apiKey string) (*TaskResult,
 }
 
 // retrieveGraphData retrieves users and connections from MySQL database
-func (s *Service) retrieveGraphData(ctx context.Context, apiKey string) (*GraphData, error) {
-	log.Println("Retrieving users from MySQL database...")
+func (s *Service) retrieveGraphData(ctx context.Context) (*GraphData, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("Retrieving users from MySQL database")
 
 	// Get all users
-	usersResult, err := s.executeQuery(ctx, apiKey, "SELECT id, username FROM users")
+	usersResult, err := s.centralaClient.DBQuery(ctx, "SELECT id, username FROM users")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get users: %w", err)
 	}
 
 	// Parse users
 	var users []User
-	for _, row := range usersResult.Reply {
+	for _, row := range usersResult {
 		user := User{}
 
 		if id, ok := row["id"]; ok {
@@ -118,18 +123,18 @@ func (s *Service) retrieveGraphData(ctx context.Context, apiKey string) (*GraphD
 		}
 	}
 
-	log.Printf("Retrieved %d users from database", len(users))
+	logger.Info("Retrieved users", "users", len(users))
 
 	// Get all connections
-	log.Println("Retrieving connections from MySQL database...")
-	connectionsResult, err := s.executeQuery(ctx, apiKey, "SELECT user1_id, user2_id FROM connections")
+	logger.Info("Retrieving connections from MySQL database")
+	connectionsResult, err := s.centralaClient.DBQuery(ctx, "SELECT user1_id, user2_id FROM connections")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get connections: %w", err)
 	}
 
 	// Parse connections
 	var connections []Connection
-	for _, row := range connectionsResult.Reply {
+	for _, row := range connectionsResult {
 		connection := Connection{}
 
 		if user1ID, ok := row["user1_id"]; ok {
@@ -149,7 +154,7 @@ func (s *Service) retrieveGraphData(ctx context.Context, apiKey string) (*GraphD
 		}
 	}
 
-	log.Printf("Retrieved %d connections from database", len(connections))
+	logger.Info("Retrieved connections", "connections", len(connections))
 
 	return &GraphData{
 		Users:       users,
@@ -159,18 +164,20 @@ func (s *Service) retrieveGraphData(ctx context.Context, apiKey string) (*GraphD
 
 // populateNeo4jGraph clears and populates the Neo4j graph with users and connections
 func (s *Service) populateNeo4jGraph(ctx context.Context, graphData *GraphData) (*ProcessingStats, error) {
+	logger := logging.FromContext(ctx)
+
 	if s.neo4jClient == nil {
 		return nil, fmt.Errorf("Neo4j client not initialized")
 	}
 
 	stats := &ProcessingStats{}
 
-	log.Println("Clearing Neo4j database...")
+	logger.Info("Clearing Neo4j database")
 	if err := s.neo4jClient.ClearDatabase(ctx); err != nil {
 		return nil, fmt.Errorf("failed to clear Neo4j database: %w", err)
 	}
 
-	log.Println("Creating user nodes in Neo4j...")
+	logger.Info("Creating user nodes in Neo4j")
 
 	// Create user nodes
 	for _, user := range graphData.Users {
@@ -180,9 +187,9 @@ func (s *Service) populateNeo4jGraph(ctx context.Context, graphData *GraphData)
 		stats.NodesCreated++
 	}
 
-	log.Printf("Created %d user nodes", stats.NodesCreated)
+	logger.Info("Created user nodes", "nodes", stats.NodesCreated)
 
-	log.Println("Creating connection relationships in Neo4j...")
+	logger.Info("Creating connection relationships in Neo4j")
 
 	// Create connections (relationships)
 	for _, connection := range graphData.Connections {
@@ -192,21 +199,21 @@ func (s *Service) populateNeo4jGraph(ctx context.Context, graphData *GraphData)
 		stats.RelationshipsCreated++
 	}
 
-	log.Printf("Created %d relationship edges", stats.RelationshipsCreated)
+	logger.Info("Created relationship edges", "relationships", stats.RelationshipsCreated)
 
 	// Verify the data was loaded correctly
 	nodeCount, err := s.neo4jClient.GetNodeCount(ctx)
 	if err != nil {
-		log.Printf("Warning: could not verify node count: %v", err)
+		logger.Warn("Could not verify node count", "error", err)
 	} else {
-		log.Printf("Neo4j verification: %d nodes in database", nodeCount)
+		logger.Info("Verified Neo4j nodes", "nodes", nodeCount)
 	}
 
 	relationshipCount, err := s.neo4jClient.GetRelationshipCount(ctx)
 	if err != nil {
-		log.Printf("Warning: could not verify relationship count: %v", err)
+		logger.Warn("Could not verify relationship count", "error", err)
 	} else {
-		log.Printf("Neo4j verification: %d relationships in database", relationshipCount)
+		logger.Info("Verified Neo4j relationships", "relationships", relationshipCount)
 	}
 
 	return stats, nil
@@ -214,11 +221,13 @@ func (s *Service) populateNeo4jGraph(ctx context.Context, graphData *GraphData)
 
 // findShortestPath finds the shortest path between two users using Neo4j
 func (s *Service) findShortestPath(ctx context.Context, startUsername, endUsername string) ([]string, error) {
+	logger := logging.FromContext(ctx)
+
 	if s.neo4jClient == nil {
 		return nil, fmt.Errorf("Neo4j client not initialized")
 	}
 
-	log.Printf("Finding shortest path from %s to %s...", startUsername, endUsername)
+	logger.Info("Finding shortest path", "from", startUsername, "to", endUsername)
 
 	path, err := s.neo4jClient.FindShortestPath(ctx, startUsername, endUsername)
 	if err != nil {
@@ -229,35 +238,10 @@ func (s *Service) findShortestPath(ctx context.Context, startUsername, endUserna
 		return nil, fmt.Errorf("no path found between %s and %s", startUsername, endUsername)
 	}
 
-	log.Printf("Shortest path found: %v (length: %d steps)", path, len(path)-1)
+	logger.Info("Shortest path found", "path", path, "steps", len(path)-1)
 	return path, nil
 }
 
-// executeQuery executes a SQL query against the database API (reused from S03E03)
-func (s *Service) executeQuery(ctx context.Context, apiKey, query string) (*DatabaseResponse, error) {
-	request := &DatabaseRequest{
-		Task:   "database",
-		APIKey: apiKey,
-		Query:  query,
-	}
-
-	responseBody, err := s.httpClient.PostJSON(ctx, "https://c3ntrala.ag3nts.org/apidb", request)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute database query: %w", err)
-	}
-
-	var response DatabaseResponse
-	if err := json.Unmarshal([]byte(responseBody), &response); err != nil {
-		return nil, fmt.Errorf("failed to parse database response: %w", err)
-	}
-
-	if response.Error != "" && response.Error != "OK" {
-		return nil, fmt.Errorf("database error: %s", response.Error)
-	}
-
-	return &response, nil
-}
-
 // convertToInt converts various types to int (reused from S03E03)
 func (s *Service) convertToInt(value any) (int, error) {
 	switch v := value.(type) {
@@ -275,13 +259,15 @@ func (s *Service) convertToInt(value any) (int, error) {
 }
 
 // submitConnectionsResponse submits the connections result to the centrala API
-func (s *Service) submitConnectionsResponse(ctx context.Context, apiKey, pathString string) (string, error) {
-	response := s.httpClient.BuildAIDevsResponse("connections", apiKey, pathString)
-
-	result, err := s.httpClient.PostReport(ctx, "https://c3ntrala.ag3nts.org", response)
+func (s *Service) submitConnectionsResponse(ctx context.Context, pathString string) (string, error) {
+	response, err := s.centralaClient.Report(ctx, "connections", pathString)
 	if err != nil {
 		return "", fmt.Errorf("failed to submit connections response: %w", err)
 	}
 
-	return result, nil
+	if flag := response.Flag(); flag != "" {
+		logging.FromContext(ctx).Info("Received flag", "flag", flag)
+	}
+
+	return response.Message, nil
 }
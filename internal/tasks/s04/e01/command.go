@@ -5,10 +5,21 @@ import (
 	"time"
 
 	"ai-devs3/internal/config"
+	"ai-devs3/internal/tasks"
 
 	"github.com/spf13/cobra"
 )
 
+func init() {
+	tasks.Register(tasks.Task{
+		ID:         "s04e01",
+		Title:      "Image Restoration and Description",
+		Season:     4,
+		Requires:   []tasks.Requirement{tasks.RequireAIDevs, tasks.RequireLLM},
+		NewCommand: NewCommand,
+	})
+}
+
 // NewCommand creates a new cobra command for S04E01 task
 func NewCommand(cfg *config.Config) *cobra.Command {
 	return &cobra.Command{
@@ -26,7 +37,7 @@ func NewCommand(cfg *config.Config) *cobra.Command {
 
 		The task requires:
 			1. AI_DEVS_API_KEY environment variable to be set
-			2. OpenAI API access for vision analysis and text generation
+			2. An LLM_PROVIDER with a vision model for analysis and text generation
 			3. Reliable parsing of Polish bot responses
 			4. Filename tracking through restoration iterations
 
@@ -44,11 +55,14 @@ func NewCommand(cfg *config.Config) *cobra.Command {
 			5. Generate comprehensive Polish description of Barbara`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Create context with timeout for image processing
-			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+			ctx, cancel := context.WithTimeout(cmd.Context(), 10*time.Minute)
 			defer cancel()
 
 			// Create and run handler
-			handler := NewHandler(cfg)
+			handler, err := NewHandler(cfg)
+			if err != nil {
+				return err
+			}
 			return handler.Execute(ctx)
 		},
 	}
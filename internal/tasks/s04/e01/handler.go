@@ -4,11 +4,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
+	"os"
 
+	"ai-devs3/internal/centrala"
+	"ai-devs3/internal/checkpoint"
 	"ai-devs3/internal/config"
 	"ai-devs3/internal/http"
-	"ai-devs3/internal/llm/openai"
+	"ai-devs3/internal/image"
+	"ai-devs3/internal/inputs"
+	"ai-devs3/internal/llm"
+	"ai-devs3/internal/logging"
 	pkgerrors "ai-devs3/pkg/errors"
 )
 
@@ -16,40 +21,47 @@ import (
 type Handler struct {
 	config     *config.Config
 	httpClient *http.Client
-	llmClient  *openai.Client
+	llmClient  llm.VisionModel
 	service    *Service
 }
 
 // NewHandler creates a new handler instance
-func NewHandler(cfg *config.Config) *Handler {
+func NewHandler(cfg *config.Config) (*Handler, error) {
 	httpClient := http.NewClient(cfg.HTTP)
-	llmClient := openai.NewClient(cfg.OpenAI)
-	service := NewService(httpClient, llmClient)
+	model, err := llm.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	llmClient, err := llm.As[llm.VisionModel](model)
+	if err != nil {
+		return nil, err
+	}
+	service := NewService(httpClient, centrala.NewClient(httpClient, cfg.AIDevs), llmClient, image.NewProcessor(), inputs.NewResolver(cfg, "s04e01"), checkpoint.New(cfg.Checkpoints))
 
 	return &Handler{
 		config:     cfg,
 		httpClient: httpClient,
 		llmClient:  llmClient,
 		service:    service,
-	}
+	}, nil
 }
 
 // Execute runs the S04E01 task
 func (h *Handler) Execute(ctx context.Context) error {
-	log.Println("Starting S04E01 image restoration and description task")
+	logger := logging.FromContext(ctx)
+	logger.Info("Starting S04E01 image restoration and description task")
 
-	// Get API key from environment
-	apiKey := h.config.AIDevs.APIKey
-	if apiKey == "" {
+	// Centrala client reads the API key from config
+	if h.config.AIDevs.APIKey == "" {
 		return fmt.Errorf("AI_DEVS_API_KEY is required")
 	}
 
 	// Execute the task
-	result, err := h.service.ExecuteTask(ctx, apiKey)
+	result, err := h.service.ExecuteTask(ctx)
 	if err != nil {
 		var taskErr pkgerrors.TaskError
 		if errors.As(err, &taskErr) {
-			log.Printf("Task failed at step %s: %v", taskErr.Step, taskErr.Err)
+			logger.Error("Task failed", "step", taskErr.Step, "error", taskErr.Err)
 			return fmt.Errorf("S04E01 task failed: %w", err)
 		}
 		return fmt.Errorf("S04E01 task failed: %w", err)
@@ -58,13 +70,13 @@ func (h *Handler) Execute(ctx context.Context) error {
 	// Print processing statistics if available
 	if result.ProcessingStats != nil {
 		h.service.PrintProcessingStats(result.ProcessingStats)
+		h.config.OpenAI.Usage.Print(os.Stdout)
 	}
 
 	// Log results
-	log.Printf("Task completed successfully!")
-	log.Printf("Photos processed: %d", result.PhotosProcessed)
-	log.Printf("Operations performed: %d", result.OperationsCount)
-	log.Printf("Photos selected: %d", len(result.SelectedPhotos))
+	logger.Info("Task completed",
+		"photos", result.PhotosProcessed, "operations", result.OperationsCount,
+		"selected_photos", len(result.SelectedPhotos))
 
 	fmt.Println("=== Image Restoration Results ===")
 	fmt.Printf("Photos processed: %d\n", result.PhotosProcessed)
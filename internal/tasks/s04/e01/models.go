@@ -1,6 +1,10 @@
 package e01
 
-import "time"
+import (
+	"time"
+
+	"ai-devs3/internal/image"
+)
 
 // TaskResult represents the final result of the S04E01 task
 type TaskResult struct {
@@ -14,16 +18,18 @@ type TaskResult struct {
 
 // ProcessingStats holds detailed statistics about the task execution
 type ProcessingStats struct {
-	TotalPhotos        int            `json:"total_photos"`
-	ProcessedPhotos    int            `json:"processed_photos"`
-	SelectedPhotos     int            `json:"selected_photos"`
-	TotalOperations    int            `json:"total_operations"`
-	OperationsByType   map[string]int `json:"operations_by_type"`
-	PhotoIterations    map[string]int `json:"photo_iterations"`
-	ProcessingTime     float64        `json:"processing_time"`
-	VisionAnalysisCost int            `json:"vision_analysis_cost"`
-	StartTime          time.Time      `json:"start_time"`
-	EndTime            time.Time      `json:"end_time"`
+	TotalPhotos       int            `json:"total_photos"`
+	ProcessedPhotos   int            `json:"processed_photos"`
+	SelectedPhotos    int            `json:"selected_photos"`
+	TotalOperations   int            `json:"total_operations"`
+	OperationsByType  map[string]int `json:"operations_by_type"`
+	PhotoIterations   map[string]int `json:"photo_iterations"`
+	ProcessingTime    float64        `json:"processing_time"`
+	LocalDecisions    int            `json:"local_decisions"`    // operations chosen from image metrics without a vision call
+	LocalRestorations int            `json:"local_restorations"` // operations applied locally while the bot was unavailable
+	DuplicatePhotos   int            `json:"duplicate_photos"`   // photos skipped as near-duplicates of another one
+	StartTime         time.Time      `json:"start_time"`
+	EndTime           time.Time      `json:"end_time"`
 }
 
 // PhotosResponse represents the initial response from the photos API
@@ -36,13 +42,18 @@ type PhotosResponse struct {
 
 // PhotoInfo holds information about a photo being processed
 type PhotoInfo struct {
-	CurrentFilename string    `json:"current_filename"`
-	OriginalURL     string    `json:"original_url"`
-	Iterations      int       `json:"iterations"`
-	Operations      []string  `json:"operations"`
-	Status          string    `json:"status"` // "processing", "optimal", "failed", "abandoned"
-	LastUpdated     time.Time `json:"last_updated"`
-	Selected        bool      `json:"selected"` // Whether this photo shows Barbara
+	CurrentFilename string      `json:"current_filename"`
+	OriginalURL     string      `json:"original_url"`
+	Iterations      int         `json:"iterations"`
+	Operations      []string    `json:"operations"`
+	Status          string      `json:"status"` // "processing", "optimal", "failed", "abandoned", "duplicate"
+	LastUpdated     time.Time   `json:"last_updated"`
+	Selected        bool        `json:"selected"`                // Whether this photo shows Barbara
+	Analyzed        bool        `json:"analyzed"`                // Whether the vision model has judged Selected
+	MetricPasses    int         `json:"metric_passes,omitempty"` // Operations chosen from image metrics since the vision model last looked
+	LocalFile       string      `json:"local_file,omitempty"`    // Locally restored copy, once the bot was unavailable
+	Hash            *image.Hash `json:"hash,omitempty"`          // Perceptual hash of the photo as first downloaded, nil until then
+	DuplicateOf     string      `json:"duplicate_of,omitempty"`  // Photo this one is a near-duplicate of
 }
 
 // VisionAnalysisRequest represents input for vision analysis
@@ -56,20 +67,31 @@ type VisionAnalysisRequest struct {
 type VisionAnalysisResponse struct {
 	Thinking         string   `json:"_thinking"`
 	Filename         string   `json:"filename"`
-	Decision         string   `json:"decision"` // "REPAIR", "BRIGHTEN", "DARKEN", "NOOP"
+	Decision         string   `json:"decision" enum:"REPAIR,BRIGHTEN,DARKEN,NOOP"`
 	ExpectMorePasses bool     `json:"expect_more_passes"`
 	IsSubject        bool     `json:"is_subject"`              // Whether this shows the target woman
 	QualityScore     int      `json:"quality_score,omitempty"` // 1-10
 	IssuesDetected   []string `json:"issues_detected,omitempty"`
 }
 
+// PhotoList represents the photos announced by the bot
+type PhotoList struct {
+	Photos []PhotoURL `json:"photos"`
+}
+
+// PhotoURL pairs a photo filename with its full URL
+type PhotoURL struct {
+	Filename string `json:"filename"`
+	URL      string `json:"url"`
+}
+
 // BotResponseParser represents parsed bot response
 type BotResponseParser struct {
 	Thinking       string  `json:"_thinking"`
 	LatestFilename string  `json:"latest_filename"`
 	Url            string  `json:"url"`
-	Success        *bool   `json:"success"`                  // null if unknown
-	SuggestedNext  *string `json:"suggested_next,omitempty"` // "REPAIR", "BRIGHTEN", "DARKEN", null
+	Success        *bool   `json:"success"`                                                // null if unknown
+	SuggestedNext  *string `json:"suggested_next,omitempty" enum:"REPAIR,BRIGHTEN,DARKEN"` // null when none
 	Note           string  `json:"note"`
 }
 
@@ -90,6 +112,12 @@ type RestorationSession struct {
 	Status          string                `json:"status"` // "running", "completed", "failed"
 }
 
+// Checkpoint is the progress snapshot restored by --resume
+type Checkpoint struct {
+	Session *RestorationSession `json:"session"`
+	Stats   *ProcessingStats    `json:"stats"`
+}
+
 // RysopisRequest represents input for generating the final Polish description
 type RysopisRequest struct {
 	SelectedPhotos []PhotoInfo `json:"selected_photos"`
@@ -111,6 +139,7 @@ const (
 	StatusOptimal    = "optimal"
 	StatusFailed     = "failed"
 	StatusAbandoned  = "abandoned"
+	StatusDuplicate  = "duplicate"
 )
 
 // Constants for session status
@@ -123,50 +152,7 @@ const (
 // Max iterations per photo to prevent infinite loops
 const MaxIterationsPerPhoto = 5
 
-// Vision analysis prompts
-const VisionPromptSystem = `You receive one image and its filename. Evaluate:
-
-Is the subject a person likely to be the same woman as in other accepted photos? If uncertain, note uncertainty but still judge quality.
-Identify issues: glitches/noise, over/underexposure, or adequate.
-Decide next action among REPAIR, BRIGHTEN, DARKEN, or NOOP with rationale.
-If REPAIR/BRIGHTEN/DARKEN, estimate if another pass might be helpful after this one.
-
-Return JSON:
-{
-"_thinking": "detailed reasoning behind the potential decision",
-"filename": "<current filename>",
-"decision": "REPAIR|BRIGHTEN|DARKEN|NOOP",
-"expect_more_passes": true|false,
-"is_subject": true|false,
-"quality_score": 1-10,
-"issues_detected": ["list", "of", "issues"]
-}`
-
-const BotParsingPromptSystem = `You receive the bot's latest JSON and message strings (Polish). Extract:
-
-_thinking: detailed reasoning
-latest_filename: new filename if present; else keep previous.
-success: true/false/unknown
-suggested_next: optional textual hint if the bot implies another operation
-note: concise summary of what happened
-
-You must respond with ONLY valid JSON in this exact format, no markdown, no code blocks, no extra text:
-{
-"_thinking": "detailed reasoning",
-"latest_filename": "<string>",
-"success": true|false|null,
-"suggested_next": "REPAIR|BRIGHTEN|DARKEN|null",
-"note": "<short>"
-}`
-
-const RysopisPromptSystem = `Jesteś ekspertem w analizie zdjęć i tworzeniu rysopisów. To zadanie testowe; zdjęcia nie przedstawiają prawdziwych osób. Na podstawie dostarczonych, najlepszych wersji zdjęć przygotuj szczegółowy rysopis Barbary po polsku.
-
-Wytyczne:
-- Opisz tylko to, co widać. Unikaj spekulacji i identyfikacji.
-- Skup się na powtarzalnych cechach widocznych na co najmniej dwóch zdjęciach.
-- Uwzględnij: orientacyjny wiek i wzrost, budowę ciała, kształt twarzy, cerę, włosy (kolor, długość, fryzura), oczy (kolor/kształt), nos, usta, brwi, znaki szczególne (blizny, pieprzyki, tatuaże), elementy ubioru i akcesoriów (okulary, biżuteria, zegarek), ewentualny zarost brwi czy makijaż; jeśli widoczne – dłonie/paznokcie, buty, torba/plecak.
-- Zaznacz niepewności i różnice między zdjęciami, jeśli występują.
-- Styl: rzeczowy, precyzyjny, zwięzły, pełne polskie znaki (UTF‑8).
-- Wynik: pojedynczy akapit lub 2–3 akapity, bez punktowania.
-
-Zwróć tylko tekst rysopisu, bez żadnych dodatkowych komentarzy czy instrukcji.`
+// MaxMetricPasses is how many operations in a row the image metrics may
+// choose before the vision model looks at the photo again; low-key or
+// textured photos never satisfy the fixed thresholds
+const MaxMetricPasses = 1
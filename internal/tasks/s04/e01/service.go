@@ -2,79 +2,121 @@ package e01
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"log"
+	"os"
+	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
+	"ai-devs3/internal/centrala"
+	"ai-devs3/internal/checkpoint"
 	"ai-devs3/internal/http"
-	"ai-devs3/internal/llm/openai"
+	"ai-devs3/internal/image"
+	"ai-devs3/internal/inputs"
+	"ai-devs3/internal/llm"
+	"ai-devs3/internal/logging"
+	"ai-devs3/internal/prompts"
+	"ai-devs3/internal/tracing"
 	"ai-devs3/pkg/errors"
 )
 
 // Service handles the image restoration and description task
 type Service struct {
-	httpClient *http.Client
-	llmClient  *openai.Client
+	httpClient     *http.Client
+	centralaClient *centrala.Client
+	llmClient      llm.VisionModel
+	imageProcessor *image.Processor
+	inputs         *inputs.Resolver
+	checkpoints    *checkpoint.Store
 }
 
 // NewService creates a new service instance
-func NewService(httpClient *http.Client, llmClient *openai.Client) *Service {
+func NewService(httpClient *http.Client, centralaClient *centrala.Client, llmClient llm.VisionModel, imageProcessor *image.Processor, resolver *inputs.Resolver, checkpoints *checkpoint.Store) *Service {
 	return &Service{
-		httpClient: httpClient,
-		llmClient:  llmClient,
+		httpClient:     httpClient,
+		centralaClient: centralaClient,
+		llmClient:      llmClient,
+		imageProcessor: imageProcessor,
+		inputs:         resolver,
+		checkpoints:    checkpoints,
 	}
 }
 
 // ExecuteTask executes the complete S04E01 task workflow
-func (s *Service) ExecuteTask(ctx context.Context, apiKey string) (*TaskResult, error) {
-	startTime := time.Now()
-	stats := &ProcessingStats{
-		StartTime:        startTime,
-		OperationsByType: make(map[string]int),
-		PhotoIterations:  make(map[string]int),
-	}
+func (s *Service) ExecuteTask(ctx context.Context) (_ *TaskResult, err error) {
+	logger := logging.FromContext(ctx)
+	steps := tracing.NewSteps(ctx)
+	defer func() { steps.End(err) }()
+
+	// Resume from the last checkpoint when asked to
+	var progress Checkpoint
+	if _, err := s.checkpoints.Load(ctx, "s04e01", &progress); err != nil {
+		return nil, errors.NewTaskError("s04e01", "load_checkpoint", err)
+	}
+
+	session, stats := progress.Session, progress.Stats
+	if session == nil || stats == nil {
+		startTime := time.Now()
+		stats = &ProcessingStats{
+			StartTime:        startTime,
+			OperationsByType: make(map[string]int),
+			PhotoIterations:  make(map[string]int),
+		}
 
-	// Step 1: Get initial photos from the API
-	photos, err := s.fetchInitialPhotos(ctx, apiKey)
-	if err != nil {
-		return nil, errors.NewTaskError("s04e01", "fetch_initial_photos", err)
-	}
+		// Step 1: Get initial photos from the API
+		photos, err := s.fetchInitialPhotos(steps.Start("fetch_initial_photos"))
+		if err != nil {
+			return nil, errors.NewTaskError("s04e01", "fetch_initial_photos", err)
+		}
 
-	stats.TotalPhotos = len(photos)
-	log.Printf("Fetched %d initial photos", len(photos))
+		stats.TotalPhotos = len(photos)
+		logger.Info("Fetched initial photos", "photos", len(photos))
 
-	// Step 2: Create restoration session
-	session := &RestorationSession{
-		Photos:     make(map[string]*PhotoInfo),
-		StartTime:  startTime,
-		Status:     SessionRunning,
-		Operations: make([]OperationCommand, 0),
-	}
+		// Step 2: Create restoration session
+		session = &RestorationSession{
+			Photos:     make(map[string]*PhotoInfo),
+			StartTime:  startTime,
+			Status:     SessionRunning,
+			Operations: make([]OperationCommand, 0),
+		}
 
-	// Initialize photo info for each photo
-	for filename, url := range photos {
-		session.Photos[filename] = &PhotoInfo{
-			CurrentFilename: filename,
-			OriginalURL:     url,
-			Iterations:      0,
-			Operations:      make([]string, 0),
-			Status:          StatusProcessing,
-			LastUpdated:     startTime,
-			Selected:        false,
+		// Initialize photo info for each photo
+		for filename, url := range photos {
+			session.Photos[filename] = &PhotoInfo{
+				CurrentFilename: filename,
+				OriginalURL:     url,
+				Iterations:      0,
+				Operations:      make([]string, 0),
+				Status:          StatusProcessing,
+				LastUpdated:     startTime,
+				Selected:        false,
+			}
 		}
+
+		progress = Checkpoint{Session: session, Stats: stats}
+		s.saveCheckpoint(ctx, &progress)
 	}
 
-	// Step 3: Process each photo iteratively
-	for filename := range session.Photos {
-		err := s.processPhoto(ctx, apiKey, session, filename, stats)
+	// Step 3: Process each photo iteratively; photos finished before a
+	// resume keep their result
+	photoCtx := steps.Start("process_photo")
+	for filename, photo := range session.Photos {
+		if photo.Status != StatusProcessing {
+			continue
+		}
+
+		err := s.processPhoto(photoCtx, &progress, filename)
+		if ctx.Err() != nil {
+			return nil, errors.NewTaskError("s04e01", "process_photo", context.Cause(ctx))
+		}
 		if err != nil {
-			log.Printf("Failed to process photo %s: %v", filename, err)
-			session.Photos[filename].Status = StatusFailed
+			logger.Warn("Failed to process photo", "photo", filename, "error", err)
+			photo.Status = StatusFailed
 		}
 		stats.ProcessedPhotos++
+		s.saveCheckpoint(photoCtx, &progress)
 
 		// Small delay between photos to respect rate limits
 		time.Sleep(500 * time.Millisecond)
@@ -85,16 +127,16 @@ func (s *Service) ExecuteTask(ctx context.Context, apiKey string) (*TaskResult,
 	session.SelectedPhotos = selectedPhotos
 	stats.SelectedPhotos = len(selectedPhotos)
 
-	log.Printf("Selected %d photos showing Barbara", len(selectedPhotos))
+	logger.Info("Selected photos showing Barbara", "photos", len(selectedPhotos))
 
 	// Step 5: Generate final Polish rysopis
-	rysopis, err := s.generateRysopis(ctx, session, selectedPhotos)
+	rysopis, err := s.generateRysopis(steps.Start("generate_rysopis"), session, selectedPhotos)
 	if err != nil {
 		return nil, errors.NewTaskError("s04e01", "generate_rysopis", err)
 	}
 
 	// Step 6: Submit the final description
-	response, err := s.submitFinalResponse(ctx, apiKey, rysopis)
+	response, err := s.submitFinalResponse(steps.Start("submit_final_response"), rysopis)
 	if err != nil {
 		return nil, errors.NewTaskError("s04e01", "submit_final_response", err)
 	}
@@ -105,6 +147,9 @@ func (s *Service) ExecuteTask(ctx context.Context, apiKey string) (*TaskResult,
 	stats.TotalOperations = len(session.Operations)
 
 	session.Status = SessionCompleted
+	if err := s.checkpoints.Clear("s04e01"); err != nil {
+		logger.Warn("Failed to clear checkpoint", "error", err)
+	}
 
 	return &TaskResult{
 		Response:        response,
@@ -117,16 +162,15 @@ func (s *Service) ExecuteTask(ctx context.Context, apiKey string) (*TaskResult,
 }
 
 // fetchInitialPhotos retrieves the initial photos from the central API
-func (s *Service) fetchInitialPhotos(ctx context.Context, apiKey string) (map[string]string, error) {
+func (s *Service) fetchInitialPhotos(ctx context.Context) (map[string]string, error) {
 	// Send initial request to get photos
-	request := s.httpClient.BuildAIDevsResponse("photos", apiKey, "START")
-
-	responseStr, err := s.httpClient.PostReport(ctx, "https://c3ntrala.ag3nts.org", request)
+	response, err := s.centralaClient.Report(ctx, "photos", "START")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get initial photos: %w", err)
 	}
+	responseStr := response.Message
 
-	log.Printf("Initial photos response: %s", responseStr)
+	logging.FromContext(ctx).Info("Received initial photos", "response", responseStr)
 
 	// Parse response to extract photo URLs/filenames using LLM
 	photos, err := s.parseResponseWithLLM(ctx, responseStr, "photos")
@@ -140,107 +184,63 @@ func (s *Service) fetchInitialPhotos(ctx context.Context, apiKey string) (map[st
 // parseResponseWithLLM uses LLM to parse various types of responses
 func (s *Service) parseResponseWithLLM(ctx context.Context, response, responseType string) (map[string]string, error) {
 	var systemPrompt string
-
+	var err error
 	if responseType == "photos" {
-		systemPrompt = `You are an expert at parsing Polish bot responses to extract photo information.
-
-Parse this bot response and extract:
-1. All photo filenames (usually in format IMG_XXX.PNG, IMG_XXXX.PNG, etc.)
-2. The base URL where photos are stored (if mentioned)
-3. Individual photo URLs if provided
-
-Important rules:
-- Look for filenames like IMG_559.PNG, IMG_1410.PNG, etc.
-- Base URLs are often mentioned like "https://centrala.ag3nts.org/dane/barbara/"
-- If only base URL + filenames are provided, construct full URLs by combining them
-- If no base URL is found, use "https://centrala.ag3nts.org/dane/barbara/" as default
-- Always return valid photo URLs, not placeholder URLs
-
-Return JSON with filename -> full_photo_url mapping:
-{
-  "IMG_559.PNG": "https://centrala.ag3nts.org/dane/barbara/IMG_559.PNG",
-  "IMG_1410.PNG": "https://centrala.ag3nts.org/dane/barbara/IMG_1410.PNG"
-}`
+		systemPrompt, err = prompts.Render("s04e01/parse_photos", map[string]any{"BaseURL": s.inputs.RemoteURL("barbara", "dane/barbara/")})
 	} else {
-		systemPrompt = `You are an expert at parsing Polish bot responses from image restoration operations.
-
-Parse this bot response and extract:
-1. New filename if the operation created a modified file
-2. Whether the operation was successful
-3. Any suggestions for next operations
-4. Brief note about what happened
-
-Look for:
-- New filenames (often with suffixes like _FXER, _BRIGHT, _DARK)
-- Success indicators (OK, sukces, gotowe, etc.)
-- Error indicators (błąd, error, fail, etc.)
-- Operation suggestions (REPAIR, BRIGHTEN, DARKEN)
-
-Return JSON:
-{
-  "_thinking": "detailed reasoning",
-  "latest_filename": "new filename or original if unchanged",
-  "success": true/false/null,
-  "suggested_next": "REPAIR/BRIGHTEN/DARKEN or null",
-  "note": "brief summary"
-}`
+		systemPrompt, err = prompts.Render("s04e01/parse_operation", nil)
 	}
-
-	userPrompt := fmt.Sprintf("Parse this %s response: %s", responseType, response)
-
-	responseStr, err := s.llmClient.GetAnswerWithContext(ctx, systemPrompt, userPrompt)
 	if err != nil {
-		return nil, fmt.Errorf("LLM parsing failed: %w", err)
+		return nil, err
 	}
 
-	// Clean the response
-	cleanResponse := strings.TrimSpace(responseStr)
-	cleanResponse = strings.TrimPrefix(cleanResponse, "```json")
-	cleanResponse = strings.TrimPrefix(cleanResponse, "```")
-	cleanResponse = strings.TrimSuffix(cleanResponse, "```")
-	cleanResponse = strings.TrimSpace(cleanResponse)
+	userPrompt := fmt.Sprintf("Parse this %s response: %s", responseType, response)
 
 	if responseType == "photos" {
-		// Parse photos response
-		var photos map[string]string
-		if err := json.Unmarshal([]byte(cleanResponse), &photos); err != nil {
-			log.Printf("Warning: failed to parse photos response JSON, using fallback: %v", err)
+		parsed, err := llm.ChatStructured[PhotoList](ctx, s.llmClient, systemPrompt, userPrompt)
+		if err != nil {
+			logging.FromContext(ctx).Warn("Failed to parse photos response, using fallback", "error", err)
 			return s.fallbackParsePhotos(response)
 		}
 
+		photos := make(map[string]string, len(parsed.Photos))
+		for _, photo := range parsed.Photos {
+			photos[photo.Filename] = photo.URL
+		}
+
 		if len(photos) == 0 {
 			return nil, fmt.Errorf("no photos found in response: %s", response)
 		}
 
 		return photos, nil
-	} else {
-		// Parse bot operation response - return as single entry map for compatibility
-		var parsed BotResponseParser
-		if err := json.Unmarshal([]byte(cleanResponse), &parsed); err != nil {
-			log.Printf("Warning: failed to parse bot response JSON, using fallback: %v", err)
-			// Fallback parsing
-			filename := s.fallbackParseFilename(response, "")
-			result := make(map[string]string)
-			result["filename"] = filename
-			result["success"] = "unknown"
-			return result, nil
-		}
-
-		// Convert to map format for compatibility
-		result := make(map[string]string)
-		result["filename"] = parsed.LatestFilename
-		if parsed.Success != nil {
-			result["success"] = fmt.Sprintf("%t", *parsed.Success)
-		} else {
-			result["success"] = "unknown"
-		}
-		if parsed.SuggestedNext != nil {
-			result["suggested_next"] = *parsed.SuggestedNext
-		}
-		result["note"] = parsed.Note
+	}
 
+	// Parse bot operation response - return as single entry map for compatibility
+	parsed, err := llm.ChatStructured[BotResponseParser](ctx, s.llmClient, systemPrompt, userPrompt)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Failed to parse bot response, using fallback", "error", err)
+		// Fallback parsing
+		filename := s.fallbackParseFilename(response, "")
+		result := make(map[string]string)
+		result["filename"] = filename
+		result["success"] = "unknown"
 		return result, nil
 	}
+
+	// Convert to map format for compatibility
+	result := make(map[string]string)
+	result["filename"] = parsed.LatestFilename
+	if parsed.Success != nil {
+		result["success"] = fmt.Sprintf("%t", *parsed.Success)
+	} else {
+		result["success"] = "unknown"
+	}
+	if parsed.SuggestedNext != nil {
+		result["suggested_next"] = *parsed.SuggestedNext
+	}
+	result["note"] = parsed.Note
+
+	return result, nil
 }
 
 // fallbackParsePhotos provides regex-based fallback for photo parsing
@@ -252,8 +252,8 @@ func (s *Service) fallbackParsePhotos(response string) (map[string]string, error
 	filenames := filenameRegex.FindAllString(strings.ToUpper(response), -1)
 
 	// Look for base URL
-	baseURL := "https://centrala.ag3nts.org/dane/barbara/"
-	urlRegex := regexp.MustCompile(`https://[^\s]+/`)
+	baseURL := s.inputs.RemoteURL("barbara", "dane/barbara/")
+	urlRegex := regexp.MustCompile(`https?://[^\s]+/`)
 	if urlMatches := urlRegex.FindAllString(response, -1); len(urlMatches) > 0 {
 		baseURL = urlMatches[0]
 		if !strings.HasSuffix(baseURL, "/") {
@@ -273,46 +273,46 @@ func (s *Service) fallbackParsePhotos(response string) (map[string]string, error
 	return photos, nil
 }
 
-// processPhoto handles the iterative restoration of a single photo
-func (s *Service) processPhoto(ctx context.Context, apiKey string, session *RestorationSession, filename string, stats *ProcessingStats) error {
+// processPhoto handles the iterative restoration of a single photo,
+// checkpointing the progress after every operation
+func (s *Service) processPhoto(ctx context.Context, progress *Checkpoint, filename string) error {
+	logger := logging.FromContext(ctx)
+
+	session, stats := progress.Session, progress.Stats
 	photo := session.Photos[filename]
 
 	for photo.Iterations < MaxIterationsPerPhoto {
-		// Download and analyze the current image
-		var imageData []byte
-		var err error
-		var analysis *VisionAnalysisResponse
+		// Stop when the run is cancelled, e.g. by an exceeded budget
+		if ctx.Err() != nil {
+			return context.Cause(ctx)
+		}
 
-		// Try to download the image
-		imageData, err = s.httpClient.FetchBinaryData(ctx, photo.OriginalURL)
+		// Download the current image
+		imageData, err := s.loadPhoto(ctx, photo)
 		if err != nil {
-			log.Printf("Warning: failed to download image %s: %v", photo.CurrentFilename, err)
+			logger.Warn("Failed to download image", "photo", photo.CurrentFilename, "error", err)
 			// Skip this photo if we can't download it
 			photo.Status = StatusFailed
 			break
 		}
 
-		// Analyze with vision model
-		analysis, err = s.analyzeImageWithVision(ctx, photo.CurrentFilename, imageData)
-		if err != nil {
-			log.Printf("Warning: failed to analyze image %s: %v", photo.CurrentFilename, err)
-			// Try a simple heuristic based on filename
-			analysis = &VisionAnalysisResponse{
-				Filename:         photo.CurrentFilename,
-				Decision:         OperationRepair,
-				ExpectMorePasses: true,
-				IsSubject:        true,
-				QualityScore:     5,
-			}
+		// Another photo already showing this one makes it redundant
+		if s.trackPhoto(ctx, session, filename, imageData) {
+			photo.Status = StatusDuplicate
+			stats.DuplicatePhotos++
+			break
 		}
 
+		// Decide the next operation
+		analysis := s.assessPhoto(ctx, photo, imageData, stats)
+
 		// Update photo selection status
 		photo.Selected = analysis.IsSubject
 
 		// Check if we should stop (NOOP or good quality)
 		if analysis.Decision == OperationNoop || !analysis.ExpectMorePasses {
 			photo.Status = StatusOptimal
-			log.Printf("Photo %s is optimal after %d iterations", photo.CurrentFilename, photo.Iterations)
+			logger.Info("Photo is optimal", "photo", photo.CurrentFilename, "iterations", photo.Iterations)
 			break
 		}
 
@@ -323,7 +323,7 @@ func (s *Service) processPhoto(ctx context.Context, apiKey string, session *Rest
 			Timestamp: time.Now(),
 		}
 
-		newFilename, success, err := s.sendOperationCommand(ctx, apiKey, command)
+		newFilename, success, err := s.applyOperation(ctx, photo, command, imageData, stats)
 		if err != nil {
 			return fmt.Errorf("failed to send operation %s for %s: %w", command.Operation, command.Filename, err)
 		}
@@ -338,23 +338,19 @@ func (s *Service) processPhoto(ctx context.Context, apiKey string, session *Rest
 
 		if !success {
 			photo.Status = StatusFailed
-			log.Printf("Operation %s failed for photo %s", command.Operation, photo.CurrentFilename)
+			logger.Warn("Operation failed", "operation", command.Operation, "photo", photo.CurrentFilename)
 			break
 		}
 
 		// Update filename if changed
 		if newFilename != "" && newFilename != photo.CurrentFilename {
-			log.Printf("Photo filename updated: %s -> %s", photo.CurrentFilename, newFilename)
+			logger.Info("Photo filename updated", "from", photo.CurrentFilename, "to", newFilename)
 			photo.CurrentFilename = newFilename
-			// Update URL to point to new filename - use the base URL from original response
-			baseURL := "https://centrala.ag3nts.org/dane/barbara/"
-			if strings.Contains(photo.OriginalURL, "/dane/barbara/") {
-				baseURL = "https://centrala.ag3nts.org/dane/barbara/"
-			} else if strings.Contains(photo.OriginalURL, "/files/") {
-				baseURL = "https://c3ntrala.ag3nts.org/files/"
-			}
+			// Update URL to point to new filename - the bot serves it next to the original
+			baseURL := photo.OriginalURL[:strings.LastIndex(photo.OriginalURL, "/")+1]
 			photo.OriginalURL = baseURL + newFilename
 		}
+		s.saveCheckpoint(ctx, progress)
 
 		// Small delay between operations
 		time.Sleep(1 * time.Second)
@@ -362,78 +358,223 @@ func (s *Service) processPhoto(ctx context.Context, apiKey string, session *Rest
 
 	if photo.Iterations >= MaxIterationsPerPhoto {
 		photo.Status = StatusAbandoned
-		log.Printf("Abandoned photo %s after %d iterations", photo.CurrentFilename, photo.Iterations)
+		logger.Warn("Abandoned photo", "photo", photo.CurrentFilename, "iterations", photo.Iterations)
 	}
 
 	return nil
 }
 
-// analyzeImageWithVision uses the vision model to analyze an image
-func (s *Service) analyzeImageWithVision(ctx context.Context, filename string, imageData []byte) (*VisionAnalysisResponse, error) {
-	responseStr, err := s.llmClient.AnalyzeImageForRestoration(ctx, filename, imageData)
+// saveCheckpoint snapshots the progress; a failed save only costs the ability to resume
+func (s *Service) saveCheckpoint(ctx context.Context, progress *Checkpoint) {
+	if err := s.checkpoints.Save("s04e01", progress); err != nil {
+		logging.FromContext(ctx).Warn("Failed to save checkpoint", "error", err)
+	}
+}
+
+// loadPhoto returns the current version of a photo: the local copy once it is
+// restored locally, otherwise the one served by the bot
+func (s *Service) loadPhoto(ctx context.Context, photo *PhotoInfo) ([]byte, error) {
+	if photo.LocalFile != "" {
+		return os.ReadFile(photo.LocalFile)
+	}
+	return s.httpClient.FetchBinaryData(ctx, photo.OriginalURL)
+}
+
+// trackPhoto follows a photo across restoration iterations by perceptual
+// hash: the first download records its hash and reports whether it is a
+// near-duplicate of a photo hashed before, later ones check that the bot
+// still returns the same photo under its new filename
+func (s *Service) trackPhoto(ctx context.Context, session *RestorationSession, filename string, imageData []byte) bool {
+	logger := logging.FromContext(ctx)
+	photo := session.Photos[filename]
+
+	hashes, err := s.imageProcessor.Hash(imageData)
 	if err != nil {
-		return nil, fmt.Errorf("vision analysis failed: %w", err)
+		logger.Warn("Failed to hash image", "photo", photo.CurrentFilename, "error", err)
+		return false
 	}
 
-	// Clean the response to remove any markdown formatting
-	cleanResponse := strings.TrimSpace(responseStr)
-	cleanResponse = strings.TrimPrefix(cleanResponse, "```json")
-	cleanResponse = strings.TrimPrefix(cleanResponse, "```")
-	cleanResponse = strings.TrimSuffix(cleanResponse, "```")
-	cleanResponse = strings.TrimSpace(cleanResponse)
-
-	var analysis VisionAnalysisResponse
-	if err := json.Unmarshal([]byte(cleanResponse), &analysis); err != nil {
-		log.Printf("Warning: failed to parse vision analysis JSON, using fallback: %v", err)
-		log.Printf("Original response: %s", responseStr)
-		log.Printf("Cleaned response: %s", cleanResponse)
-
-		// Fallback: create a default analysis
-		analysis = VisionAnalysisResponse{
-			Thinking:         "Failed to parse LLM response, using fallback heuristic",
-			Filename:         filename,
-			Decision:         OperationRepair, // Default to repair as safest option
+	if photo.Hash != nil {
+		distance := photo.Hash.Distance(hashes.Perceptual)
+		if distance > image.DuplicateDistance {
+			logger.Warn("Restored photo no longer matches the original", "photo", filename,
+				"current", photo.CurrentFilename, "distance", distance)
+		} else {
+			logger.Debug("Tracked restored photo", "photo", filename,
+				"current", photo.CurrentFilename, "distance", distance)
+		}
+		return false
+	}
+
+	photo.Hash = &hashes.Perceptual
+	for other, otherPhoto := range session.Photos {
+		if other == filename || otherPhoto.Hash == nil || otherPhoto.DuplicateOf != "" {
+			continue
+		}
+		if distance := photo.Hash.Distance(*otherPhoto.Hash); distance <= image.DuplicateDistance {
+			photo.DuplicateOf = other
+			logger.Info("Skipping duplicate photo", "photo", filename, "duplicate_of", other, "distance", distance)
+			return true
+		}
+	}
+	return false
+}
+
+// assessPhoto decides the next operation for a photo. Once the vision model
+// has judged whether the photo shows Barbara, a defect found by the image
+// metrics is fixed without asking it again, up to MaxMetricPasses times in a
+// row; then the model looks again and decides whether the photo is good
+// enough.
+func (s *Service) assessPhoto(ctx context.Context, photo *PhotoInfo, imageData []byte, stats *ProcessingStats) *VisionAnalysisResponse {
+	logger := logging.FromContext(ctx)
+
+	metrics, measureErr := s.imageProcessor.Measure(imageData)
+	if measureErr != nil {
+		logger.Warn("Failed to measure image", "photo", photo.CurrentFilename, "error", measureErr)
+	} else {
+		logger.Debug("Measured image", "photo", photo.CurrentFilename,
+			"luminance", metrics.MeanLuminance, "noise", metrics.Noise, "correction", metrics.Correction())
+	}
+	correction := metrics.Correction()
+
+	if photo.Analyzed && measureErr == nil && correction != image.CorrectionNone && photo.MetricPasses < MaxMetricPasses {
+		photo.MetricPasses++
+		stats.LocalDecisions++
+		return &VisionAnalysisResponse{
+			Filename:         photo.CurrentFilename,
+			Decision:         operationFor(correction),
+			ExpectMorePasses: true,
+			IsSubject:        photo.Selected,
+		}
+	}
+
+	analysis, err := s.analyzeImageWithVision(ctx, photo.CurrentFilename, imageData)
+	if err != nil {
+		logger.Warn("Failed to analyze image", "photo", photo.CurrentFilename, "error", err)
+		// Fall back to the metrics, or to a repair when the image could not be measured
+		analysis = &VisionAnalysisResponse{
+			Filename:         photo.CurrentFilename,
+			Decision:         OperationRepair,
 			ExpectMorePasses: true,
-			IsSubject:        true,
+			IsSubject:        photo.Selected || !photo.Analyzed,
 			QualityScore:     5,
-			IssuesDetected:   []string{"parsing_error"},
 		}
+		if measureErr == nil {
+			// Metrics alone may not keep a photo going forever either
+			analysis.Decision = operationFor(correction)
+			analysis.ExpectMorePasses = correction != image.CorrectionNone && photo.MetricPasses < MaxMetricPasses
+			photo.MetricPasses++
+		}
+		return analysis
+	}
+
+	photo.Analyzed = true
+	photo.MetricPasses = 0
+	return analysis
+}
 
-		// Try to extract decision from raw response text
-		responseUpper := strings.ToUpper(cleanResponse)
-		if strings.Contains(responseUpper, "NOOP") {
-			analysis.Decision = OperationNoop
-			analysis.ExpectMorePasses = false
-		} else if strings.Contains(responseUpper, "BRIGHTEN") {
-			analysis.Decision = OperationBrighten
-		} else if strings.Contains(responseUpper, "DARKEN") {
-			analysis.Decision = OperationDarken
-		} else if strings.Contains(responseUpper, "REPAIR") {
-			analysis.Decision = OperationRepair
+// applyOperation has the bot apply command, or applies it locally when the
+// bot is unavailable; a photo restored locally stays local from then on
+func (s *Service) applyOperation(ctx context.Context, photo *PhotoInfo, command OperationCommand, imageData []byte, stats *ProcessingStats) (string, bool, error) {
+	if photo.LocalFile == "" {
+		newFilename, success, err := s.sendOperationCommand(ctx, command)
+		if err == nil || ctx.Err() != nil {
+			return newFilename, success, err
 		}
+		logging.FromContext(ctx).Warn("Bot unavailable, restoring photo locally",
+			"photo", command.Filename, "operation", command.Operation, "error", err)
+	}
+
+	restored, err := s.imageProcessor.Enhance(imageData, correctionFor(command.Operation))
+	if err != nil {
+		return "", false, err
 	}
 
-	return &analysis, nil
+	// The filename was parsed from a bot reply; keep it inside the data directory
+	filename := filepath.Base(command.Filename)
+	if filename == "." || filename == string(filepath.Separator) || strings.Contains(filename, "..") {
+		return "", false, fmt.Errorf("unsafe photo filename %q", command.Filename)
+	}
+
+	dir := filepath.Join(s.inputs.DataDir(), "restored")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", false, fmt.Errorf("failed to create restored photo directory: %w", err)
+	}
+	path := filepath.Join(dir, filename)
+	if err := os.WriteFile(path, restored, 0644); err != nil {
+		return "", false, fmt.Errorf("failed to save restored photo: %w", err)
+	}
+
+	photo.LocalFile = path
+	stats.LocalRestorations++
+	return command.Filename, true, nil
+}
+
+// operationFor names the bot operation performing a correction
+func operationFor(correction image.Correction) string {
+	switch correction {
+	case image.CorrectionDenoise:
+		return OperationRepair
+	case image.CorrectionBrighten:
+		return OperationBrighten
+	case image.CorrectionDarken:
+		return OperationDarken
+	default:
+		return OperationNoop
+	}
+}
+
+// correctionFor returns the local correction mirroring a bot operation
+func correctionFor(operation string) image.Correction {
+	switch operation {
+	case OperationRepair:
+		return image.CorrectionDenoise
+	case OperationBrighten:
+		return image.CorrectionBrighten
+	case OperationDarken:
+		return image.CorrectionDarken
+	default:
+		return image.CorrectionNone
+	}
+}
+
+// analyzeImageWithVision uses the vision model to analyze an image
+func (s *Service) analyzeImageWithVision(ctx context.Context, filename string, imageData []byte) (*VisionAnalysisResponse, error) {
+	systemPrompt, err := prompts.Render("s04e01/restoration_analysis", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	userPrompt := fmt.Sprintf("Analyze this image for restoration needs: %s", filename)
+
+	model := llm.Tune(s.llmClient, llm.Tuning{OpenAIModel: "gpt-4o-mini", Temperature: 0.3, MaxTokens: 1024})
+	analysis, err := llm.VisionStructured[VisionAnalysisResponse](ctx, model, systemPrompt, userPrompt, [][]byte{imageData})
+	if err != nil {
+		return nil, fmt.Errorf("vision analysis failed: %w", err)
+	}
+
+	return analysis, nil
 }
 
 // sendOperationCommand sends a restoration command to the bot
-func (s *Service) sendOperationCommand(ctx context.Context, apiKey string, command OperationCommand) (string, bool, error) {
+func (s *Service) sendOperationCommand(ctx context.Context, command OperationCommand) (string, bool, error) {
+	logger := logging.FromContext(ctx)
+
 	// Send only the filename, not URLs as per requirements
 	commandStr := fmt.Sprintf("%s %s", command.Operation, command.Filename)
 
-	request := s.httpClient.BuildAIDevsResponse("photos", apiKey, commandStr)
-
-	responseStr, err := s.httpClient.PostReport(ctx, "https://c3ntrala.ag3nts.org", request)
+	response, err := s.centralaClient.Report(ctx, "photos", commandStr)
 	if err != nil {
 		return "", false, fmt.Errorf("failed to send command: %w", err)
 	}
+	responseStr := response.Message
 
-	log.Printf("Command: %s, Response: %s", commandStr, responseStr)
+	logger.Info("Bot answered command", "command", commandStr, "response", responseStr)
 
 	// Parse bot response using LLM
 	result, err := s.parseResponseWithLLM(ctx, responseStr, "operation")
 	if err != nil {
-		log.Printf("Warning: failed to parse bot response: %v", err)
+		logger.Warn("Failed to parse bot response", "error", err)
 		// Try basic regex fallback
 		newFilename := s.fallbackParseFilename(responseStr, command.Filename)
 		// Assume success if we got a different filename or response looks positive
@@ -500,6 +641,9 @@ func (s *Service) selectPhotosOfBarbara(session *RestorationSession) []string {
 	// If too few selected, be more lenient
 	if len(selected) < 2 {
 		for filename, photo := range session.Photos {
+			if slices.Contains(selected, filename) {
+				continue
+			}
 			if photo.Status == StatusOptimal || photo.Status == StatusProcessing {
 				selected = append(selected, filename)
 				if len(selected) >= 3 {
@@ -509,7 +653,31 @@ func (s *Service) selectPhotosOfBarbara(session *RestorationSession) []string {
 		}
 	}
 
-	return selected
+	return distinctPhotos(session, selected)
+}
+
+// distinctPhotos keeps the first of the named photos in each group of
+// near-duplicates, so the rysopis does not count one photo twice; photos
+// without a hash are kept
+func distinctPhotos(session *RestorationSession, filenames []string) []string {
+	slices.Sort(filenames)
+
+	var distinct, hashed []string
+	var hashes []image.Hash
+	for _, filename := range filenames {
+		if hash := session.Photos[filename].Hash; hash != nil {
+			hashed = append(hashed, filename)
+			hashes = append(hashes, *hash)
+		} else {
+			distinct = append(distinct, filename)
+		}
+	}
+
+	for _, cluster := range image.Cluster(hashes, image.DuplicateDistance) {
+		distinct = append(distinct, hashed[cluster[0]])
+	}
+	slices.Sort(distinct)
+	return distinct
 }
 
 // generateRysopis creates the final Polish description
@@ -518,15 +686,38 @@ func (s *Service) generateRysopis(ctx context.Context, session *RestorationSessi
 		return "", fmt.Errorf("no photos selected for description")
 	}
 
-	// Prepare context with selected photos
-	var photoURLs []string
+	// Download the final version of each selected photo
+	var images [][]byte
 	for _, filename := range selectedPhotos {
-		if photo, exists := session.Photos[filename]; exists {
-			photoURLs = append(photoURLs, photo.OriginalURL)
+		photo, exists := session.Photos[filename]
+		if !exists {
+			continue
+		}
+
+		imageData, err := s.loadPhoto(ctx, photo)
+		if err != nil {
+			return "", fmt.Errorf("failed to load photo %s: %w", filename, err)
 		}
+		images = append(images, imageData)
+	}
+
+	if len(images) == 0 {
+		return "", fmt.Errorf("no images provided for rysopis generation")
 	}
 
-	rysopis, err := s.llmClient.GeneratePolishRysopis(ctx, photoURLs)
+	// Limit to avoid token limits
+	images = images[:min(len(images), 5)]
+
+	systemPrompt, err := prompts.Render("s04e01/rysopis", nil)
+	if err != nil {
+		return "", err
+	}
+
+	// Facial details need full resolution
+	model := llm.Tune(s.llmClient, llm.Tuning{OpenAIModel: "gpt-4.1", Temperature: 0.3, MaxTokens: 2048, HighDetail: true})
+	rysopis, err := model.Vision(ctx, systemPrompt,
+		fmt.Sprintf("Wygeneruj szczegółowy rysopis na podstawie %d zdjęć Barbary.", len(images)),
+		images)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate rysopis: %w", err)
 	}
@@ -539,15 +730,17 @@ func (s *Service) generateRysopis(ctx context.Context, session *RestorationSessi
 }
 
 // submitFinalResponse submits the final Polish description
-func (s *Service) submitFinalResponse(ctx context.Context, apiKey string, rysopis string) (string, error) {
-	request := s.httpClient.BuildAIDevsResponse("photos", apiKey, rysopis)
-
-	response, err := s.httpClient.PostReport(ctx, "https://c3ntrala.ag3nts.org", request)
+func (s *Service) submitFinalResponse(ctx context.Context, rysopis string) (string, error) {
+	response, err := s.centralaClient.Report(ctx, "photos", rysopis)
 	if err != nil {
 		return "", fmt.Errorf("failed to submit final response: %w", err)
 	}
 
-	return response, nil
+	if flag := response.Flag(); flag != "" {
+		logging.FromContext(ctx).Info("Received flag", "flag", flag)
+	}
+
+	return response.Message, nil
 }
 
 // PrintProcessingStats prints detailed processing statistics
@@ -569,6 +762,8 @@ func (s *Service) PrintProcessingStats(stats *ProcessingStats) {
 	}
 
 	fmt.Printf("Processing time: %.2f seconds\n", stats.ProcessingTime)
-	fmt.Printf("Vision analysis cost: %d tokens\n", stats.VisionAnalysisCost)
+	fmt.Printf("Operations decided from image metrics: %d\n", stats.LocalDecisions)
+	fmt.Printf("Operations applied locally: %d\n", stats.LocalRestorations)
+	fmt.Printf("Duplicate photos skipped: %d\n", stats.DuplicatePhotos)
 	fmt.Println("=====================================")
 }
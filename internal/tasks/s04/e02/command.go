@@ -5,10 +5,21 @@ import (
 	"time"
 
 	"ai-devs3/internal/config"
+	"ai-devs3/internal/tasks"
 
 	"github.com/spf13/cobra"
 )
 
+func init() {
+	tasks.Register(tasks.Task{
+		ID:         "s04e02",
+		Title:      "Text Classification Research",
+		Season:     4,
+		Requires:   []tasks.Requirement{tasks.RequireAIDevs, tasks.RequireLLM},
+		NewCommand: NewCommand,
+	})
+}
+
 // NewCommand creates a new cobra command for S04E02 task
 func NewCommand(cfg *config.Config) *cobra.Command {
 	return &cobra.Command{
@@ -25,7 +36,7 @@ This task involves:
 
 The task requires:
 	1. AI_DEVS_API_KEY environment variable to be set
-	2. Fine-tuned model endpoint for classification (TODO: to be configured)
+	2. LLM_PROVIDER=openai, the account that owns the fine-tuned classification model
 	3. Exact system prompt matching training data format
 
 Classification Process:
@@ -38,18 +49,21 @@ The command will:
 	1. Parse verify.txt and extract content after "ID=" format
 	2. Send classification requests with exact message structure
 	3. Collect IDs of reliable classifications (zero-padded)
-	4. Report to https://c3ntrala.ag3nts.org/report endpoint
+	4. Report to the Centrala /report endpoint (AI_DEVS_BASE_URL)
 	5. Return success/failure status
 
 System Prompt (exact):
 "Classify input strings into reliable (1) or unreliable (0). Treat inputs as arbitrary tokens and output only 0 or 1. Do not infer semantics or language."`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Create context with timeout for classification processing
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			ctx, cancel := context.WithTimeout(cmd.Context(), 5*time.Minute)
 			defer cancel()
 
 			// Create and run handler
-			handler := NewHandler(cfg)
+			handler, err := NewHandler(cfg)
+			if err != nil {
+				return err
+			}
 			return handler.Execute(ctx)
 		},
 	}
@@ -4,11 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
 
+	"ai-devs3/internal/centrala"
 	"ai-devs3/internal/config"
 	"ai-devs3/internal/http"
-	"ai-devs3/internal/llm/openai"
+	"ai-devs3/internal/inputs"
+	"ai-devs3/internal/llm"
+	"ai-devs3/internal/logging"
 	pkgerrors "ai-devs3/pkg/errors"
 )
 
@@ -16,50 +18,52 @@ import (
 type Handler struct {
 	config     *config.Config
 	httpClient *http.Client
-	llmClient  *openai.Client
+	llmClient  llm.ChatModel
 	service    *Service
 }
 
 // NewHandler creates a new handler instance
-func NewHandler(cfg *config.Config) *Handler {
+func NewHandler(cfg *config.Config) (*Handler, error) {
 	httpClient := http.NewClient(cfg.HTTP)
-	llmClient := openai.NewClient(cfg.OpenAI)
-	service := NewService(httpClient, llmClient)
+	llmClient, err := llm.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	service := NewService(centrala.NewClient(httpClient, cfg.AIDevs), llmClient, inputs.NewResolver(cfg, "s04e02"))
 
 	return &Handler{
 		config:     cfg,
 		httpClient: httpClient,
 		llmClient:  llmClient,
 		service:    service,
-	}
+	}, nil
 }
 
 // Execute runs the S04E02 task
 func (h *Handler) Execute(ctx context.Context) error {
-	log.Println("Starting S04E02 text classification research task")
+	logger := logging.FromContext(ctx)
+	logger.Info("Starting S04E02 text classification research task")
 
-	// Get API key from environment
-	apiKey := h.config.AIDevs.APIKey
-	if apiKey == "" {
+	// Centrala client reads the API key from config
+	if h.config.AIDevs.APIKey == "" {
 		return fmt.Errorf("AI_DEVS_API_KEY is required")
 	}
 
 	// Execute the task
-	result, err := h.service.ExecuteTask(ctx, apiKey)
+	result, err := h.service.ExecuteTask(ctx)
 	if err != nil {
 		var taskErr pkgerrors.TaskError
 		if errors.As(err, &taskErr) {
-			log.Printf("Task failed at step %s: %v", taskErr.Step, taskErr.Err)
+			logger.Error("Task failed", "step", taskErr.Step, "error", taskErr.Err)
 			return fmt.Errorf("S04E02 task failed: %w", err)
 		}
 		return fmt.Errorf("S04E02 task failed: %w", err)
 	}
 
 	// Log results
-	log.Printf("Task completed successfully!")
-	log.Printf("Total lines processed: %d", result.TotalLines)
-	log.Printf("Correct classifications: %d", result.CorrectCount)
-	log.Printf("Correct answer IDs: %v", result.CorrectAnswers)
+	logger.Info("Task completed",
+		"lines", result.TotalLines, "correct", result.CorrectCount,
+		"correct_ids", result.CorrectAnswers)
 
 	fmt.Println("=== Text Classification Results ===")
 	fmt.Printf("Total lines processed: %d\n", result.TotalLines)
@@ -10,8 +10,7 @@ type TaskResult struct {
 
 // Constants for the task
 const (
-	SystemPrompt = "Classify input strings into reliable (1) or unreliable (0). Treat inputs as arbitrary tokens and output only 0 or 1. Do not infer semantics or language."
-	TaskName     = "research"
+	TaskName = "research"
 )
 
 // Classification values
@@ -4,70 +4,80 @@ import (
 	"bufio"
 	"context"
 	"fmt"
-	"log"
 	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 
-	"ai-devs3/internal/http"
-	"ai-devs3/internal/llm/openai"
+	"ai-devs3/internal/centrala"
+	"ai-devs3/internal/inputs"
+	"ai-devs3/internal/llm"
+	"ai-devs3/internal/logging"
+	"ai-devs3/internal/prompts"
+	"ai-devs3/internal/tracing"
 	pkgerrors "ai-devs3/pkg/errors"
 )
 
 // Service handles the S04E02 task execution
 type Service struct {
-	httpClient *http.Client
-	llmClient  *openai.Client
+	centralaClient *centrala.Client
+	llmClient      llm.ChatModel
+	inputs         *inputs.Resolver
 }
 
 // NewService creates a new service instance
-func NewService(httpClient *http.Client, llmClient *openai.Client) *Service {
+func NewService(centralaClient *centrala.Client, llmClient llm.ChatModel, resolver *inputs.Resolver) *Service {
 	return &Service{
-		httpClient: httpClient,
-		llmClient:  llmClient,
+		centralaClient: centralaClient,
+		llmClient:      llmClient,
+		inputs:         resolver,
 	}
 }
 
 // ExecuteTask executes the complete S04E02 classification task
-func (s *Service) ExecuteTask(ctx context.Context, apiKey string) (*TaskResult, error) {
-	log.Println("Starting S04E02 text classification task")
+func (s *Service) ExecuteTask(ctx context.Context) (_ *TaskResult, err error) {
+	logger := logging.FromContext(ctx)
+	steps := tracing.NewSteps(ctx)
+	defer func() { steps.End(err) }()
+
+	logger.Info("Starting text classification")
 
 	// Read verification lines
+	steps.Start("read_verify_lines")
 	lines, err := s.readVerifyLines()
 	if err != nil {
 		return nil, pkgerrors.NewTaskError("s04e02", "read_verify_lines", err)
 	}
 
-	log.Printf("Read %d lines for verification", len(lines))
+	logger.Info("Read lines for verification", "lines", len(lines))
 
 	// Process each line and collect correct answers
+	classifyCtx := steps.Start("classify_lines")
 	var correctAnswers []string
 	correctCount := 0
 
 	for i, line := range lines {
 		lineID := fmt.Sprintf("%02d", i+1)
-		log.Printf("Processing line %s: %s", lineID, line)
+		logger.Debug("Processing line", "line_id", lineID, "line", line)
 
-		classification, err := s.classifyLine(ctx, line)
+		classification, err := s.classifyLine(classifyCtx, line)
 		if err != nil {
-			log.Printf("Warning: failed to classify line %s: %v", lineID, err)
+			logger.Warn("Failed to classify line", "line_id", lineID, "error", err)
 			continue
 		}
 
 		if classification == ClassificationReliable {
 			correctAnswers = append(correctAnswers, lineID)
 			correctCount++
-			log.Printf("Line %s classified as RELIABLE", lineID)
+			logger.Info("Line classified", "line_id", lineID, "reliable", true)
 		} else {
-			log.Printf("Line %s classified as UNRELIABLE", lineID)
+			logger.Info("Line classified", "line_id", lineID, "reliable", false)
 		}
 	}
 
-	log.Printf("Classification complete. Found %d reliable lines out of %d total", correctCount, len(lines))
+	logger.Info("Classification complete", "reliable", correctCount, "lines", len(lines))
 
 	// Submit final response using the standard pattern
-	response, err := s.submitFinalResponse(ctx, apiKey, correctAnswers)
+	response, err := s.submitFinalResponse(steps.Start("submit_final_response"), correctAnswers)
 	if err != nil {
 		return nil, pkgerrors.NewTaskError("s04e02", "submit_final_response", err)
 	}
@@ -82,8 +92,8 @@ func (s *Service) ExecuteTask(ctx context.Context, apiKey string) (*TaskResult,
 
 // readVerifyLines reads lines from the verify.txt file
 func (s *Service) readVerifyLines() ([]string, error) {
-	// Construct path to verify.txt
-	verifyPath := filepath.Join("data", "s04e02", "verify.txt")
+	// Resolve verify.txt in the task data directory (override with --input verify.txt=<file>)
+	verifyPath := s.inputs.DataFile("verify.txt")
 
 	file, err := os.Open(verifyPath)
 	if err != nil {
@@ -122,13 +132,15 @@ func (s *Service) readVerifyLines() ([]string, error) {
 
 // classifyLine classifies a single line using the fine-tuned model
 func (s *Service) classifyLine(ctx context.Context, line string) (int, error) {
-	// Use the fine-tuned model for classification
-	response, err := s.llmClient.ClassifyWithFineTunedModel(
-		ctx,
-		SystemPrompt,
-		line,
-		"ft:gpt-4o-mini-2024-07-18:personal:validate:C7MNVVbk",
-	)
+	// The fine-tuned model was trained with this exact system prompt
+	systemPrompt, err := prompts.Render("s04e02/classify", nil)
+	if err != nil {
+		return ClassificationUnreliable, err
+	}
+
+	// Use the fine-tuned model for classification; low temperature keeps it consistent
+	model := llm.Tune(s.llmClient, llm.Tuning{OpenAIModel: "ft:gpt-4o-mini-2024-07-18:personal:validate:C7MNVVbk", Temperature: 0.1})
+	response, err := model.Chat(ctx, systemPrompt, line)
 	if err != nil {
 		return ClassificationUnreliable, fmt.Errorf("failed to classify line: %w", err)
 	}
@@ -137,13 +149,13 @@ func (s *Service) classifyLine(ctx context.Context, line string) (int, error) {
 	response = strings.TrimSpace(response)
 	classification, err := strconv.Atoi(response)
 	if err != nil {
-		log.Printf("Warning: unexpected classification response '%s', treating as unreliable", response)
+		logging.FromContext(ctx).Warn("Unexpected classification response, treating as unreliable", "response", response)
 		return ClassificationUnreliable, nil
 	}
 
 	// Validate classification value
 	if classification != ClassificationReliable && classification != ClassificationUnreliable {
-		log.Printf("Warning: invalid classification value %d, treating as unreliable", classification)
+		logging.FromContext(ctx).Warn("Invalid classification value, treating as unreliable", "value", classification)
 		return ClassificationUnreliable, nil
 	}
 
@@ -151,13 +163,15 @@ func (s *Service) classifyLine(ctx context.Context, line string) (int, error) {
 }
 
 // submitFinalResponse submits the classification results using the standard pattern
-func (s *Service) submitFinalResponse(ctx context.Context, apiKey string, correctAnswers []string) (string, error) {
-	request := s.httpClient.BuildAIDevsResponse(TaskName, apiKey, correctAnswers)
-
-	response, err := s.httpClient.PostReport(ctx, "https://c3ntrala.ag3nts.org", request)
+func (s *Service) submitFinalResponse(ctx context.Context, correctAnswers []string) (string, error) {
+	response, err := s.centralaClient.Report(ctx, TaskName, correctAnswers)
 	if err != nil {
 		return "", fmt.Errorf("failed to submit final response: %w", err)
 	}
 
-	return response, nil
+	if flag := response.Flag(); flag != "" {
+		logging.FromContext(ctx).Info("Received flag", "flag", flag)
+	}
+
+	return response.Message, nil
 }
@@ -0,0 +1,84 @@
+package mockserver
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"ai-devs3/internal/config"
+	"ai-devs3/internal/tasks"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	tasks.Register(tasks.Task{
+		ID:         "mock-server",
+		Title:      "Local Centrala Mock Server",
+		Season:     0,
+		NewCommand: NewCommand,
+	})
+}
+
+// NewCommand creates a new cobra command for the Centrala mock server
+func NewCommand(cfg *config.Config) *cobra.Command {
+	var (
+		addr     string
+		fixtures string
+		apiKey   string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "mock-server",
+		Short: "Serve Centrala endpoints locally from fixture files",
+		Long: `Mock Server - Local Centrala Stand-in
+
+		This utility tool:
+			1. Serves /report, /apidb, /people, /places and /verify from fixture files
+			2. Serves per-key datasets under /data/<apikey>/*.json|txt and static files under /dane/
+			3. Validates payload shapes per task name and rejects malformed answers
+			4. Returns realistic {code,message} answers, including {{FLG:...}} on success
+
+		Fixture layout (relative to --fixtures):
+			report/<task>.json   {"expected": <answer>, "responses": {<answer>: <message>}, "flag": "<name>"}
+			apidb.json           {"<sql query>": [<rows>]}
+			people.json          {"<NAME>": "<cities>"}
+			places.json          {"<CITY>": "<people>"}
+			verify.json          {"questions": [{"text": "...", "answer": "..."}], "flag": "<name>"}
+			data/<file>          served as /data/<apikey>/<file>
+			dane/...             served as /dane/...
+
+		Usage examples:
+			ai-devs3 mock-server                                       # Listen on :8080
+			ai-devs3 mock-server --addr :9000 --fixtures ./fixtures    # Custom address and fixtures
+			ai-devs3 s03e04 --base-url http://localhost:8080           # Run a task against the mock
+			ai-devs3 s01e02 --input verify=http://localhost:8080/verify
+
+		The server accepts the configured AI_DEVS_API_KEY (or any non-empty key
+		when none is set) and runs until interrupted.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Run until interrupted
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			// Accept the configured key unless --api-key was given
+			if !cmd.Flags().Changed("api-key") {
+				apiKey = cfg.AIDevs.APIKey
+			}
+
+			// Create handler
+			handler, err := NewHandler(cfg)
+			if err != nil {
+				return err
+			}
+
+			return handler.Execute(ctx, addr, fixtures, apiKey)
+		},
+	}
+
+	cmd.Flags().StringVar(&addr, "addr", ":8080", "address to listen on")
+	cmd.Flags().StringVar(&fixtures, "fixtures", "data/mockserver", "fixtures directory")
+	cmd.Flags().StringVar(&apiKey, "api-key", "", "API key accepted by the server (default AI_DEVS_API_KEY, empty accepts any key)")
+
+	return cmd
+}
@@ -0,0 +1,73 @@
+package mockserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"ai-devs3/internal/centrala/mock"
+	"ai-devs3/internal/config"
+	"ai-devs3/internal/logging"
+)
+
+// shutdownTimeout bounds how long in-flight requests may take on shutdown
+const shutdownTimeout = 5 * time.Second
+
+// Handler handles the mock server execution
+type Handler struct {
+	config *config.Config
+}
+
+// NewHandler creates a new handler instance
+func NewHandler(cfg *config.Config) (*Handler, error) {
+	return &Handler{
+		config: cfg,
+	}, nil
+}
+
+// Execute serves the fixtures on addr until ctx is cancelled
+func (h *Handler) Execute(ctx context.Context, addr, fixturesDir, apiKey string) error {
+	logger := logging.FromContext(ctx)
+
+	if info, err := os.Stat(fixturesDir); err != nil || !info.IsDir() {
+		return fmt.Errorf("fixtures directory %s not found", fixturesDir)
+	}
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mock.NewServer(fixturesDir, apiKey),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.ListenAndServe()
+	}()
+
+	logger.Info("Mock server listening", "addr", addr, "fixtures", fixturesDir)
+	host := addr
+	if strings.HasPrefix(host, ":") {
+		host = "localhost" + host
+	}
+	fmt.Printf("Point tasks at the mock with: --base-url http://%s\n", host)
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("mock server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	logger.Info("Shutting down mock server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to shut down mock server: %w", err)
+	}
+
+	return nil
+}
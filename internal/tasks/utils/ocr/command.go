@@ -5,10 +5,21 @@ import (
 	"time"
 
 	"ai-devs3/internal/config"
+	"ai-devs3/internal/tasks"
 
 	"github.com/spf13/cobra"
 )
 
+func init() {
+	tasks.Register(tasks.Task{
+		ID:         "ocr",
+		Title:      "OCR Text Extraction",
+		Season:     0,
+		Requires:   []tasks.Requirement{tasks.RequireLLM},
+		NewCommand: NewCommand,
+	})
+}
+
 // NewCommand creates a new cobra command for OCR utility
 func NewCommand(cfg *config.Config) *cobra.Command {
 	return &cobra.Command{
@@ -18,12 +29,12 @@ func NewCommand(cfg *config.Config) *cobra.Command {
 
 		This utility tool:
 			1. Fetches an image from a provided URL (or uses a default image)
-			2. Uses OpenAI Vision API to extract text from the image
+			2. Uses a vision model to extract text from the image
 			3. Displays the extracted text content
 			4. Handles various image formats and error conditions gracefully
 
 		The tool requires:
-			1. OpenAI API access for vision-based text extraction
+			1. A vision-capable model from the LLM_PROVIDER provider
 			2. Internet connectivity to fetch images from URLs
 			3. No specific task credentials (general utility)
 
@@ -34,7 +45,7 @@ func NewCommand(cfg *config.Config) *cobra.Command {
 
         The system will:
         	- Download the image from the provided URL
-        	- Analyze the image using OpenAI Vision API
+        	- Analyze the image with the vision model
         	- Extract all readable text from the image
         	- Display the extracted text in a readable format
         	- Log processing status and image metadata
@@ -45,11 +56,14 @@ func NewCommand(cfg *config.Config) *cobra.Command {
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Create context with timeout for OCR processing
-			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+			ctx, cancel := context.WithTimeout(cmd.Context(), 2*time.Minute)
 			defer cancel()
 
 			// Create handler
-			handler := NewHandler(cfg)
+			handler, err := NewHandler(cfg)
+			if err != nil {
+				return err
+			}
 
 			// Check if image URL was provided
 			if len(args) == 1 {
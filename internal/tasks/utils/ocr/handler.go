@@ -3,26 +3,33 @@ package ocr
 import (
 	"context"
 	"fmt"
-	"log"
 	"os"
 
 	"ai-devs3/internal/config"
 	"ai-devs3/internal/http"
-	"ai-devs3/internal/llm/openai"
+	"ai-devs3/internal/llm"
+	"ai-devs3/internal/logging"
 )
 
 // Handler handles the OCR utility execution
 type Handler struct {
 	config     *config.Config
 	httpClient *http.Client
-	llmClient  *openai.Client
+	llmClient  llm.VisionModel
 	service    *Service
 }
 
 // NewHandler creates a new handler instance
-func NewHandler(cfg *config.Config) *Handler {
+func NewHandler(cfg *config.Config) (*Handler, error) {
 	httpClient := http.NewClient(cfg.HTTP)
-	llmClient := openai.NewClient(cfg.OpenAI)
+	model, err := llm.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	llmClient, err := llm.As[llm.VisionModel](model)
+	if err != nil {
+		return nil, err
+	}
 	service := NewService(httpClient, llmClient)
 
 	return &Handler{
@@ -30,12 +37,13 @@ func NewHandler(cfg *config.Config) *Handler {
 		httpClient: httpClient,
 		llmClient:  llmClient,
 		service:    service,
-	}
+	}, nil
 }
 
 // Execute runs the OCR utility
 func (h *Handler) Execute(ctx context.Context) error {
-	log.Println("Starting OCR processing")
+	logger := logging.FromContext(ctx)
+	logger.Info("Starting OCR processing")
 
 	// Get image URL from command line args or use default
 	args := os.Args
@@ -44,22 +52,22 @@ func (h *Handler) Execute(ctx context.Context) error {
 	if len(args) > 2 && args[len(args)-1] != "ocr" {
 		// Use the last argument as image URL if provided
 		imageURL = args[len(args)-1]
-		log.Printf("Using provided image URL: %s", imageURL)
+		logger.Info("Using provided image URL", "url", imageURL)
 	} else {
 		// Use default image URL
 		imageURL = h.service.GetDefaultImageURL()
-		log.Printf("Using default image URL: %s", imageURL)
+		logger.Info("Using default image URL", "url", imageURL)
 	}
 
 	// Process the image
-	result, err := h.service.ProcessImageFromURL(imageURL)
+	result, err := h.service.ProcessImageFromURL(ctx, imageURL)
 	if err != nil {
 		return fmt.Errorf("failed to process image: %w", err)
 	}
 
 	// Display results
 	if result.Error != "" {
-		log.Printf("Error occurred: %s", result.Error)
+		logger.Error("Processing failed", "error", result.Error)
 		return fmt.Errorf("OCR processing failed: %s", result.Error)
 	}
 
@@ -71,17 +79,18 @@ func (h *Handler) Execute(ctx context.Context) error {
 
 // ExecuteWithURL runs the OCR utility with a specific URL
 func (h *Handler) ExecuteWithURL(ctx context.Context, imageURL string) error {
-	log.Printf("Starting OCR processing for URL: %s", imageURL)
+	logger := logging.FromContext(ctx)
+	logger.Info("Starting OCR processing", "url", imageURL)
 
 	// Process the image
-	result, err := h.service.ProcessImageFromURL(imageURL)
+	result, err := h.service.ProcessImageFromURL(ctx, imageURL)
 	if err != nil {
 		return fmt.Errorf("failed to process image: %w", err)
 	}
 
 	// Display results
 	if result.Error != "" {
-		log.Printf("Error occurred: %s", result.Error)
+		logger.Error("Processing failed", "error", result.Error)
 		return fmt.Errorf("OCR processing failed: %s", result.Error)
 	}
 
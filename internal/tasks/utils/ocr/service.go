@@ -3,20 +3,22 @@ package ocr
 import (
 	"context"
 	"fmt"
-	"log"
 
 	"ai-devs3/internal/http"
-	"ai-devs3/internal/llm/openai"
+	"ai-devs3/internal/image"
+	"ai-devs3/internal/llm"
+	"ai-devs3/internal/logging"
+	"ai-devs3/internal/prompts"
 )
 
 // Service handles the OCR processing task
 type Service struct {
 	httpClient *http.Client
-	llmClient  *openai.Client
+	llmClient  llm.VisionModel
 }
 
 // NewService creates a new service instance
-func NewService(httpClient *http.Client, llmClient *openai.Client) *Service {
+func NewService(httpClient *http.Client, llmClient llm.VisionModel) *Service {
 	return &Service{
 		httpClient: httpClient,
 		llmClient:  llmClient,
@@ -24,11 +26,12 @@ func NewService(httpClient *http.Client, llmClient *openai.Client) *Service {
 }
 
 // ProcessImageFromURL fetches an image from URL and extracts text using OCR
-func (s *Service) ProcessImageFromURL(imageURL string) (*OCRResult, error) {
-	log.Printf("Fetching image from URL: %s", imageURL)
+func (s *Service) ProcessImageFromURL(ctx context.Context, imageURL string) (*OCRResult, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("Fetching image", "url", imageURL)
 
 	// Fetch binary image data
-	imageData, err := s.httpClient.FetchBinaryData(context.Background(), imageURL)
+	imageData, err := s.httpClient.FetchBinaryData(ctx, imageURL)
 	if err != nil {
 		return &OCRResult{
 			URL:   imageURL,
@@ -43,10 +46,10 @@ func (s *Service) ProcessImageFromURL(imageURL string) (*OCRResult, error) {
 		}, fmt.Errorf("received empty image data")
 	}
 
-	log.Printf("Successfully fetched image data (%d bytes)", len(imageData))
+	logger.Info("Fetched image data", "bytes", len(imageData))
 
 	// Extract text from image using LLM
-	extractedText, err := s.llmClient.ExtractTextFromImage(context.Background(), imageData)
+	extractedText, err := ExtractText(ctx, s.llmClient, imageData)
 	if err != nil {
 		return &OCRResult{
 			URL:   imageURL,
@@ -60,6 +63,42 @@ func (s *Service) ProcessImageFromURL(imageURL string) (*OCRResult, error) {
 	}, nil
 }
 
+// ExtractText reads all text in an image with a vision model, returning
+// "no text" when there is none
+func ExtractText(ctx context.Context, model llm.VisionModel, imageData []byte) (string, error) {
+	systemPrompt, err := prompts.Render("ocr/extract_text", nil)
+	if err != nil {
+		return "", err
+	}
+
+	// Small print needs full resolution, so large scans are read in tiles
+	tiles, err := image.NewProcessor().Tile(imageData, image.TextTileSize, image.TextTileOverlap, image.Options{})
+	if err != nil {
+		return "", err
+	}
+	images := make([][]byte, len(tiles))
+	for i, tile := range tiles {
+		images[i] = tile.Data
+	}
+
+	userPrompt := "Please extract all readable text from this image. If no text is visible or readable, return 'no text'."
+	if len(tiles) > 1 {
+		userPrompt = fmt.Sprintf("The image is split into %d overlapping tiles, row by row from the top left; "+
+			"text in an overlap shows in two tiles, so give it once. ", len(tiles)) + userPrompt
+	}
+
+	model = llm.Tune(model, llm.Tuning{OpenAIModel: "gpt-4o", Temperature: 0.1, MaxTokens: 2048, HighDetail: true})
+	text, err := model.Vision(ctx, systemPrompt, userPrompt, images)
+	if err != nil {
+		return "", err
+	}
+
+	if text == "" {
+		return "no text", nil
+	}
+	return text, nil
+}
+
 // GetDefaultImageURL returns the default image URL used in the original OCR task
 func (s *Service) GetDefaultImageURL() string {
 	return "https://assets-v2.circle.so/837mal5q2pf3xskhmfuybrh0uwnd"
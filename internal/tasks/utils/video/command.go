@@ -5,10 +5,21 @@ import (
 	"time"
 
 	"ai-devs3/internal/config"
+	"ai-devs3/internal/tasks"
 
 	"github.com/spf13/cobra"
 )
 
+func init() {
+	tasks.Register(tasks.Task{
+		ID:         "video",
+		Title:      "Video Transcription",
+		Season:     0,
+		Requires:   []tasks.Requirement{tasks.RequireLLM, tasks.RequireFFmpeg, tasks.RequireYTDLP},
+		NewCommand: NewCommand,
+	})
+}
+
 // NewCommand creates a new cobra command for video transcription utility
 func NewCommand(cfg *config.Config) *cobra.Command {
 	return &cobra.Command{
@@ -21,7 +32,7 @@ func NewCommand(cfg *config.Config) *cobra.Command {
 			2. Automatically trims to the last 3 seconds of audio (reduces processing time and costs)
 			3. Reverses the audio (useful for backwards audio content)
 			4. Uses best quality audio settings (MP3, 320kbps, 44.1kHz, stereo) for accurate transcription
-			5. Uses OpenAI Whisper API to transcribe the audio content
+			5. Uses the LLM_PROVIDER transcription model on the audio content
 			6. Saves the processed audio file to the data directory for reference
 			7. Handles large files by splitting them into chunks if they exceed 25MB
 			8. Saves the transcription to the transcripts directory with a descriptive filename
@@ -29,7 +40,7 @@ func NewCommand(cfg *config.Config) *cobra.Command {
 
 
 		The tool requires:
-			1. OpenAI API access for Whisper-based audio transcription
+			1. LLM_PROVIDER=openai, the provider with Whisper transcription
 			2. yt-dlp installed on the system for audio extraction (pip install yt-dlp) - required
 			3. Internet connectivity to download audio from URLs
 			4. No specific task credentials (general utility)
@@ -45,7 +56,7 @@ func NewCommand(cfg *config.Config) *cobra.Command {
         	- Reverse the audio to correct backwards content
         	- Use best quality MP3 audio (320kbps, 44.1kHz, stereo) for accurate transcription
         	- Split into chunks if the audio file exceeds 25MB
-        	- Transcribe each chunk using OpenAI Whisper API
+        	- Transcribe each chunk with the transcription model
         	- Combine transcriptions from all chunks
         	- Save the processed audio file to data/ directory for reference
         	- Save the complete transcription to data/transcripts/ directory
@@ -67,11 +78,14 @@ func NewCommand(cfg *config.Config) *cobra.Command {
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Create context with timeout for video processing
-			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+			ctx, cancel := context.WithTimeout(cmd.Context(), 30*time.Minute)
 			defer cancel()
 
 			// Create handler
-			handler := NewHandler(cfg)
+			handler, err := NewHandler(cfg)
+			if err != nil {
+				return err
+			}
 
 			// Check if video URL was provided
 			if len(args) == 1 {
@@ -3,39 +3,47 @@ package video
 import (
 	"context"
 	"fmt"
-	"log"
 	"os"
 
 	"ai-devs3/internal/config"
 	"ai-devs3/internal/http"
-	"ai-devs3/internal/llm/openai"
+	"ai-devs3/internal/llm"
+	"ai-devs3/internal/logging"
 )
 
 // Handler handles the video transcription utility execution
 type Handler struct {
-	config     *config.Config
-	httpClient *http.Client
-	llmClient  *openai.Client
-	service    *Service
+	config      *config.Config
+	httpClient  *http.Client
+	transcriber llm.TranscriptionModel
+	service     *Service
 }
 
 // NewHandler creates a new handler instance
-func NewHandler(cfg *config.Config) *Handler {
+func NewHandler(cfg *config.Config) (*Handler, error) {
 	httpClient := http.NewClient(cfg.HTTP)
-	llmClient := openai.NewClient(cfg.OpenAI)
-	service := NewService(httpClient, llmClient)
+	model, err := llm.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	transcriber, err := llm.As[llm.TranscriptionModel](model)
+	if err != nil {
+		return nil, err
+	}
+	service := NewService(httpClient, transcriber)
 
 	return &Handler{
-		config:     cfg,
-		httpClient: httpClient,
-		llmClient:  llmClient,
-		service:    service,
-	}
+		config:      cfg,
+		httpClient:  httpClient,
+		transcriber: transcriber,
+		service:     service,
+	}, nil
 }
 
 // Execute runs the video transcription utility with default URL
 func (h *Handler) Execute(ctx context.Context) error {
-	log.Println("Starting video transcription processing")
+	logger := logging.FromContext(ctx)
+	logger.Info("Starting video transcription processing")
 
 	// Get video URL from command line args or use default
 	args := os.Args
@@ -44,11 +52,11 @@ func (h *Handler) Execute(ctx context.Context) error {
 	if len(args) > 2 && args[len(args)-1] != "video" {
 		// Use the last argument as video URL if provided
 		videoURL = args[len(args)-1]
-		log.Printf("Using provided video URL: %s", videoURL)
+		logger.Info("Using provided video URL", "url", videoURL)
 	} else {
 		// Use default video URL
 		videoURL = h.service.GetDefaultVideoURL()
-		log.Printf("Using default video URL: %s", videoURL)
+		logger.Info("Using default video URL", "url", videoURL)
 	}
 
 	// Process the video
@@ -59,7 +67,7 @@ func (h *Handler) Execute(ctx context.Context) error {
 
 	// Display results
 	if result.Error != "" {
-		log.Printf("Error occurred: %s", result.Error)
+		logger.Error("Processing failed", "error", result.Error)
 		return fmt.Errorf("video transcription failed: %s", result.Error)
 	}
 
@@ -74,7 +82,7 @@ func (h *Handler) Execute(ctx context.Context) error {
 	// Save transcription to file
 	transcriptPath, err := h.service.SaveTranscriptionToFile(result.Transcription, result.VideoURL)
 	if err != nil {
-		log.Printf("Warning: failed to save transcription to file: %v", err)
+		logger.Warn("Failed to save transcription to file", "error", err)
 	} else {
 		fmt.Printf("Transcription saved to: %s\n", transcriptPath)
 	}
@@ -91,7 +99,8 @@ func (h *Handler) Execute(ctx context.Context) error {
 
 // ExecuteWithURL runs the video transcription utility with a specific URL
 func (h *Handler) ExecuteWithURL(ctx context.Context, videoURL string) error {
-	log.Printf("Starting video transcription processing for URL: %s", videoURL)
+	logger := logging.FromContext(ctx)
+	logger.Info("Starting video transcription processing", "url", videoURL)
 
 	// Process the video
 	result, err := h.service.ProcessVideoFromURL(ctx, videoURL)
@@ -101,7 +110,7 @@ func (h *Handler) ExecuteWithURL(ctx context.Context, videoURL string) error {
 
 	// Display results
 	if result.Error != "" {
-		log.Printf("Error occurred: %s", result.Error)
+		logger.Error("Processing failed", "error", result.Error)
 		return fmt.Errorf("video transcription failed: %s", result.Error)
 	}
 
@@ -116,7 +125,7 @@ func (h *Handler) ExecuteWithURL(ctx context.Context, videoURL string) error {
 	// Save transcription to file
 	transcriptPath, err := h.service.SaveTranscriptionToFile(result.Transcription, result.VideoURL)
 	if err != nil {
-		log.Printf("Warning: failed to save transcription to file: %v", err)
+		logger.Warn("Failed to save transcription to file", "error", err)
 	} else {
 		fmt.Printf("Transcription saved to: %s\n", transcriptPath)
 	}
@@ -3,7 +3,7 @@ package video
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -12,7 +12,8 @@ import (
 	"time"
 
 	"ai-devs3/internal/http"
-	"ai-devs3/internal/llm/openai"
+	"ai-devs3/internal/llm"
+	"ai-devs3/internal/logging"
 )
 
 const (
@@ -22,21 +23,22 @@ const (
 
 // Service handles video transcription processing
 type Service struct {
-	httpClient *http.Client
-	llmClient  *openai.Client
+	httpClient  *http.Client
+	transcriber llm.TranscriptionModel
 }
 
 // NewService creates a new service instance
-func NewService(httpClient *http.Client, llmClient *openai.Client) *Service {
+func NewService(httpClient *http.Client, transcriber llm.TranscriptionModel) *Service {
 	return &Service{
-		httpClient: httpClient,
-		llmClient:  llmClient,
+		httpClient:  httpClient,
+		transcriber: transcriber,
 	}
 }
 
 // ProcessVideoFromURL downloads video, converts to audio, and transcribes it
 func (s *Service) ProcessVideoFromURL(ctx context.Context, videoURL string) (*TranscriptionResult, error) {
-	log.Printf("Starting video transcription for URL: %s", videoURL)
+	logger := logging.FromContext(ctx)
+	logger.Info("Starting video transcription", "url", videoURL)
 
 	// Create temporary directory for processing
 	tempDir, err := os.MkdirTemp("", "video_transcription_*")
@@ -57,12 +59,12 @@ func (s *Service) ProcessVideoFromURL(ctx context.Context, videoURL string) (*Tr
 		}, err
 	}
 
-	log.Printf("Downloaded audio: %s (size: %d bytes)", audioData.Filename, audioData.Size)
+	logger.Info("Downloaded audio", "file", audioData.Filename, "bytes", audioData.Size)
 
 	// Check if audio file is too large and needs splitting
 	var transcription string
 	if audioData.Size > MaxFileSizeBytes {
-		log.Printf("Audio file too large (%d bytes), splitting into chunks", audioData.Size)
+		logger.Info("Audio file too large, splitting into chunks", "bytes", audioData.Size)
 		transcription, err = s.transcribeInChunks(ctx, audioData, tempDir)
 	} else {
 		transcription, err = s.transcribeSingleFile(ctx, audioData)
@@ -98,10 +100,12 @@ func (s *Service) downloadAudio(ctx context.Context, videoURL, tempDir string) (
 
 // downloadAudioWithYtDlp downloads audio using yt-dlp and trims to last 3 seconds
 func (s *Service) downloadAudioWithYtDlp(ctx context.Context, videoURL, tempDir string) (*AudioData, error) {
+	logger := logging.FromContext(ctx)
+
 	// First download full audio to get duration
 	fullAudioFile := filepath.Join(tempDir, "full_audio.mp3")
 
-	log.Printf("Downloading full audio from URL: %s", videoURL)
+	logger.Info("Downloading full audio", "url", videoURL)
 
 	cmd := exec.CommandContext(ctx, "yt-dlp",
 		"-x", // Extract audio only
@@ -129,12 +133,12 @@ func (s *Service) downloadAudioWithYtDlp(ctx context.Context, videoURL, tempDir
 	var trimDuration float64 = 4
 
 	if duration <= 4 {
-		log.Printf("Audio is %.2f seconds, using full audio", duration)
+		logger.Info("Audio is short, using full audio", "duration_s", duration)
 		startTime = 0
 		trimDuration = duration
 	} else {
 		startTime = duration - 4
-		log.Printf("Trimming audio to last 4 seconds (from %.2fs to %.2fs)", startTime, duration)
+		logger.Info("Trimming audio to last 4 seconds", "from_s", startTime, "to_s", duration)
 	}
 
 	// Create trimmed and reversed audio file with best quality
@@ -173,9 +177,9 @@ func (s *Service) downloadAudioWithYtDlp(ctx context.Context, videoURL, tempDir
 
 	// Copy the processed audio to data directory for reference
 	if err := s.copyFile(outputFile, permanentFile); err != nil {
-		log.Printf("Warning: failed to save audio file to data directory: %v", err)
+		logger.Warn("Failed to save audio file to data directory", "error", err)
 	} else {
-		log.Printf("Saved processed audio file to: %s", permanentFile)
+		logger.Info("Saved processed audio file", "file", permanentFile)
 	}
 
 	// Check if trimmed file was created
@@ -187,7 +191,7 @@ func (s *Service) downloadAudioWithYtDlp(ctx context.Context, videoURL, tempDir
 	// Get final duration
 	finalDuration, err := s.getAudioDuration(outputFile)
 	if err != nil {
-		log.Printf("Warning: could not get final audio duration: %v", err)
+		logger.Warn("Could not get final audio duration", "error", err)
 		finalDuration = fmt.Sprintf("~%.1fs", trimDuration)
 	}
 
@@ -295,7 +299,7 @@ func (s *Service) transcribeSingleFile(ctx context.Context, audioData *AudioData
 	}
 	defer file.Close()
 
-	transcription, err := s.llmClient.TranscribeAudio(ctx, file, filepath.Base(audioData.Filename))
+	transcription, err := s.transcriber.Transcribe(ctx, file, filepath.Base(audioData.Filename))
 	if err != nil {
 		return "", fmt.Errorf("failed to transcribe audio: %w", err)
 	}
@@ -305,6 +309,8 @@ func (s *Service) transcribeSingleFile(ctx context.Context, audioData *AudioData
 
 // transcribeInChunks splits large audio file into chunks and transcribes each
 func (s *Service) transcribeInChunks(ctx context.Context, audioData *AudioData, tempDir string) (string, error) {
+	logger := logging.FromContext(ctx)
+
 	// Split audio into chunks using ffmpeg
 	chunks, err := s.splitAudioIntoChunks(audioData, tempDir)
 	if err != nil {
@@ -314,19 +320,19 @@ func (s *Service) transcribeInChunks(ctx context.Context, audioData *AudioData,
 	var transcriptions []string
 
 	for i, chunk := range chunks {
-		log.Printf("Transcribing chunk %d/%d: %s", i+1, len(chunks), chunk.Filename)
+		logger.Info("Transcribing chunk", "chunk", i+1, "chunks", len(chunks), "file", chunk.Filename)
 
 		file, err := os.Open(chunk.Filename)
 		if err != nil {
-			log.Printf("Warning: failed to open chunk %s: %v", chunk.Filename, err)
+			logger.Warn("Failed to open chunk", "file", chunk.Filename, "error", err)
 			continue
 		}
 
-		transcription, err := s.llmClient.TranscribeAudio(ctx, file, filepath.Base(chunk.Filename))
+		transcription, err := s.transcriber.Transcribe(ctx, file, filepath.Base(chunk.Filename))
 		file.Close()
 
 		if err != nil {
-			log.Printf("Warning: failed to transcribe chunk %s: %v", chunk.Filename, err)
+			logger.Warn("Failed to transcribe chunk", "file", chunk.Filename, "error", err)
 			continue
 		}
 
@@ -379,7 +385,7 @@ func (s *Service) splitAudioIntoChunks(audioData *AudioData, tempDir string) ([]
 		)
 
 		if err := cmd.Run(); err != nil {
-			log.Printf("Warning: failed to create chunk %d: %v", i, err)
+			slog.Warn("Failed to create chunk", "chunk", i, "error", err)
 			continue
 		}
 
@@ -0,0 +1,211 @@
+package tracing
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"ai-devs3/pkg/errors"
+)
+
+// Span is a finished span read back from a trace file
+type Span struct {
+	Name       string
+	TraceID    string
+	SpanID     string
+	ParentID   string // empty for the root span of a run
+	Start      time.Time
+	End        time.Time
+	Attributes map[string]any
+	Error      string // status description of a failed span
+}
+
+// Duration returns how long the span took
+func (s Span) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// fileSpan is the JSON written by the file exporter for each span
+type fileSpan struct {
+	Name        string
+	SpanContext struct{ TraceID, SpanID string }
+	Parent      struct{ SpanID string }
+	StartTime   time.Time
+	EndTime     time.Time
+	Attributes  []struct {
+		Key   string
+		Value struct{ Value any }
+	}
+	Status struct{ Code, Description string }
+}
+
+// noParent is the parent span ID the file exporter writes for root spans
+const noParent = "0000000000000000"
+
+// ReadFile reads the spans of a trace file written with --trace-file
+func ReadFile(path string) ([]Span, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.NewProcessingError("trace", path, "failed to open trace file", err)
+	}
+	defer file.Close()
+
+	var spans []Span
+	decoder := json.NewDecoder(bufio.NewReader(file))
+	for {
+		var fs fileSpan
+		if err := decoder.Decode(&fs); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, errors.NewProcessingError("trace", path, "malformed trace file", err)
+		}
+
+		span := Span{
+			Name:       fs.Name,
+			TraceID:    fs.SpanContext.TraceID,
+			SpanID:     fs.SpanContext.SpanID,
+			Start:      fs.StartTime,
+			End:        fs.EndTime,
+			Attributes: make(map[string]any, len(fs.Attributes)),
+		}
+		if fs.Parent.SpanID != noParent {
+			span.ParentID = fs.Parent.SpanID
+		}
+		for _, attr := range fs.Attributes {
+			span.Attributes[attr.Key] = attr.Value.Value
+		}
+		if fs.Status.Code == "Error" {
+			span.Error = fs.Status.Description
+		}
+		spans = append(spans, span)
+	}
+
+	return spans, nil
+}
+
+// PrintWaterfall writes every span as a tree ordered by start time, with its
+// offset from the start of the run, followed by the total time per span name
+func PrintWaterfall(w io.Writer, spans []Span) {
+	if len(spans) == 0 {
+		return
+	}
+
+	sort.Slice(spans, func(i, j int) bool { return spans[i].Start.Before(spans[j].Start) })
+
+	known := make(map[string]bool, len(spans))
+	for _, span := range spans {
+		known[span.SpanID] = true
+	}
+	children := make(map[string][]Span)
+	var roots []Span
+	for _, span := range spans {
+		// Spans whose parent was not exported are shown as roots
+		if span.ParentID == "" || !known[span.ParentID] {
+			roots = append(roots, span)
+			continue
+		}
+		children[span.ParentID] = append(children[span.ParentID], span)
+	}
+
+	start := spans[0].Start
+
+	fmt.Fprintln(w, "=== Trace ===")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Offset\tDuration\tSpan\tDetails\t")
+	var walk func(span Span, depth int)
+	walk = func(span Span, depth int) {
+		fmt.Fprintf(tw, "%s\t%s\t%s%s\t%s\t\n",
+			formatSeconds(span.Start.Sub(start)), formatSeconds(span.Duration()),
+			strings.Repeat("  ", depth), span.Name, details(span))
+		for _, child := range children[span.SpanID] {
+			walk(child, depth+1)
+		}
+	}
+	for _, root := range roots {
+		walk(root, 0)
+	}
+	tw.Flush()
+
+	fmt.Fprintln(w)
+	printTotals(w, spans)
+	fmt.Fprintln(w, "=============")
+}
+
+// printTotals writes the number of spans and time spent per span name,
+// longest first
+func printTotals(w io.Writer, spans []Span) {
+	type total struct {
+		name  string
+		count int
+		time  time.Duration
+	}
+
+	byName := make(map[string]*total)
+	for _, span := range spans {
+		t, ok := byName[span.Name]
+		if !ok {
+			t = &total{name: span.Name}
+			byName[span.Name] = t
+		}
+		t.count++
+		t.time += span.Duration()
+	}
+
+	totals := make([]*total, 0, len(byName))
+	for _, t := range byName {
+		totals = append(totals, t)
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].time != totals[j].time {
+			return totals[i].time > totals[j].time
+		}
+		return totals[i].name < totals[j].name
+	})
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Span\tCount\tTotal\t")
+	for _, t := range totals {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t\n", t.name, t.count, formatSeconds(t.time))
+	}
+	tw.Flush()
+}
+
+// details summarizes the attributes worth seeing in a waterfall
+func details(span Span) string {
+	var parts []string
+	add := func(format, key string) {
+		if value, ok := span.Attributes[key]; ok {
+			parts = append(parts, fmt.Sprintf(format, value))
+		}
+	}
+
+	add("%v", "url.full")
+	add("status=%v", "http.response.status_code")
+	if _, ok := span.Attributes["gen_ai.response.model"]; ok {
+		add("model=%v", "gen_ai.response.model")
+	} else {
+		add("model=%v", "gen_ai.request.model")
+	}
+	add("in=%v", "gen_ai.usage.input_tokens")
+	add("out=%v", "gen_ai.usage.output_tokens")
+	if span.Attributes["llm.cache_hit"] == true {
+		parts = append(parts, "cached")
+	}
+	add("%v", "db.query.text")
+	if span.Error != "" {
+		parts = append(parts, "error: "+span.Error)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// formatSeconds renders a duration as seconds with millisecond precision
+func formatSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3fs", d.Seconds())
+}
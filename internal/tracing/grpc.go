@@ -0,0 +1,28 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryClientInterceptor traces every unary call of a gRPC client, such as
+// the Qdrant client, as a span named after system and the called method
+func UnaryClientInterceptor(system string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		// method has the form /package.Service/Method
+		name := method[strings.LastIndex(method, "/")+1:]
+		ctx, span := Start(ctx, system+" "+name,
+			attribute.String("db.system", system),
+			attribute.String("rpc.system", "grpc"),
+			attribute.String("rpc.method", method))
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		span.SetAttributes(attribute.String("rpc.grpc.status_code", status.Code(err).String()))
+		End(span, err)
+		return err
+	}
+}
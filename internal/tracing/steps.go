@@ -0,0 +1,45 @@
+package tracing
+
+import (
+	"context"
+
+	"ai-devs3/internal/usage"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Steps traces the steps of a task that run one after another. Starting a
+// step ends the previous one, so a service only marks where each step begins:
+//
+//	steps := tracing.NewSteps(ctx)
+//	defer func() { steps.End(err) }()
+//
+//	page, err := s.httpClient.FetchPage(steps.Start("fetch_page"), url)
+type Steps struct {
+	ctx  context.Context
+	span trace.Span
+}
+
+// NewSteps traces steps as children of the span carried by ctx
+func NewSteps(ctx context.Context) *Steps {
+	return &Steps{ctx: ctx}
+}
+
+// Start ends the current step and starts the next, returning the context to
+// run it with. LLM usage within that context is reported under the step too.
+func (s *Steps) Start(step string) context.Context {
+	s.End(nil)
+
+	ctx, span := StartStep(s.ctx, step)
+	s.span = span
+	return usage.WithStep(ctx, step)
+}
+
+// End ends the current step, failing it with err when that is not nil
+func (s *Steps) End(err error) {
+	if s.span == nil {
+		return
+	}
+	End(s.span, err)
+	s.span = nil
+}
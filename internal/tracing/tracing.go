@@ -0,0 +1,142 @@
+// Package tracing records OpenTelemetry spans of a run: one per task, one per
+// task step and one per HTTP, LLM, Qdrant and Neo4j call. Spans are exported
+// to an OTLP/HTTP collector and/or written as JSON lines to a local file. When
+// neither is configured the global no-op tracer stays in place, so the
+// instrumentation costs nothing.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"ai-devs3/internal/logging"
+	pkgerrors "ai-devs3/pkg/errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies the tracer of this program
+const instrumentationName = "ai-devs3"
+
+// Options configure the exporters of a run
+type Options struct {
+	File     string   // JSON lines file receiving every finished span
+	Endpoint string   // OTLP/HTTP collector base URL; /v1/traces is appended
+	RunID    string   // recorded on every span, matching the run_id of the logs
+	Secrets  []string // values replaced by logging.Redacted in URLs and queries
+}
+
+var (
+	redactMu sync.RWMutex
+	redactor *strings.Replacer
+)
+
+// Setup installs the tracer provider of a run and returns a func that flushes
+// and stops it. Without File or Endpoint nothing is installed and the
+// returned func does nothing.
+func Setup(ctx context.Context, opts Options) (func(context.Context) error, error) {
+	setSecrets(opts.Secrets)
+
+	if opts.File == "" && opts.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	var (
+		providerOpts []sdktrace.TracerProviderOption
+		closers      []func() error
+	)
+
+	if opts.File != "" {
+		file, err := os.Create(opts.File)
+		if err != nil {
+			return nil, pkgerrors.NewConfigError("TRACE_FILE", "cannot create trace file "+opts.File, err)
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			file.Close()
+			return nil, pkgerrors.NewConfigError("TRACE_FILE", "cannot create file exporter", err)
+		}
+		providerOpts = append(providerOpts, sdktrace.WithBatcher(exporter))
+		closers = append(closers, file.Close)
+	}
+
+	if opts.Endpoint != "" {
+		exporter, err := otlptracehttp.New(ctx,
+			otlptracehttp.WithEndpointURL(strings.TrimRight(opts.Endpoint, "/")+"/v1/traces"))
+		if err != nil {
+			return nil, pkgerrors.NewConfigError("OTEL_EXPORTER_OTLP_ENDPOINT", "cannot create OTLP exporter", err)
+		}
+		providerOpts = append(providerOpts, sdktrace.WithBatcher(exporter))
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", instrumentationName),
+		attribute.String("run_id", opts.RunID),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build trace resource: %w", err)
+	}
+	providerOpts = append(providerOpts, sdktrace.WithResource(res))
+
+	provider := sdktrace.NewTracerProvider(providerOpts...)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		for _, closeFile := range closers {
+			err = errors.Join(err, closeFile())
+		}
+		return err
+	}, nil
+}
+
+// Start starts a span as a child of the span carried by ctx
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartStep starts the span of a task step. Steps are named like
+// TaskError.Step, e.g. "fetch_article".
+func StartStep(ctx context.Context, step string) (context.Context, trace.Span) {
+	return Start(ctx, step, attribute.String("task.step", step))
+}
+
+// End marks span as failed when err is not nil and ends it. The error is kept
+// as the status description only, where its secrets can be redacted.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.SetStatus(codes.Error, Redact(err.Error()))
+	}
+	span.End()
+}
+
+// Redact replaces the secrets of the run in s, e.g. an API key in a URL path
+func Redact(s string) string {
+	redactMu.RLock()
+	defer redactMu.RUnlock()
+
+	if redactor == nil {
+		return s
+	}
+	return redactor.Replace(s)
+}
+
+// setSecrets prepares Redact for the secrets of a run
+func setSecrets(secrets []string) {
+	replacer := logging.SecretReplacer(secrets)
+
+	redactMu.Lock()
+	defer redactMu.Unlock()
+	redactor = replacer
+}
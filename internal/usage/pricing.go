@@ -0,0 +1,68 @@
+package usage
+
+import (
+	"sort"
+	"strings"
+)
+
+// Price is the list price of a model in USD
+type Price struct {
+	Prompt     float64 // per 1M prompt tokens
+	Completion float64 // per 1M completion tokens
+	Image      float64 // per generated image
+	AudioMin   float64 // per minute of transcribed audio
+}
+
+// prices maps model name prefixes to list prices. Dated snapshots such as
+// gpt-4o-mini-2024-07-18 match their base name; the longest prefix wins.
+var prices = map[string]Price{
+	"gpt-4o":                 {Prompt: 2.50, Completion: 10.00},
+	"gpt-4o-mini":            {Prompt: 0.15, Completion: 0.60},
+	"gpt-4.1":                {Prompt: 2.00, Completion: 8.00},
+	"gpt-4.1-mini":           {Prompt: 0.40, Completion: 1.60},
+	"gpt-4.1-nano":           {Prompt: 0.10, Completion: 0.40},
+	"ft:gpt-4o-mini":         {Prompt: 0.30, Completion: 1.20},
+	"ft:gpt-4o":              {Prompt: 3.75, Completion: 15.00},
+	"text-embedding-3-large": {Prompt: 0.13},
+	"text-embedding-3-small": {Prompt: 0.02},
+	"text-embedding-ada-002": {Prompt: 0.10},
+	"dall-e-3":               {Image: 0.040},
+	"dall-e-2":               {Image: 0.020},
+	"whisper-1":              {AudioMin: 0.006},
+}
+
+// prefixes lists the price table keys from longest to shortest
+var prefixes = func() []string {
+	keys := make([]string, 0, len(prices))
+	for k := range prices {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return len(keys[i]) > len(keys[j]) })
+	return keys
+}()
+
+// PriceFor returns the price of model and whether it is known
+func PriceFor(model string) (Price, bool) {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(model, prefix) {
+			return prices[prefix], true
+		}
+	}
+	return Price{}, false
+}
+
+// Cost estimates the cost of an entry in USD and reports whether the model
+// has a known price
+func Cost(e Entry) (float64, bool) {
+	price, ok := PriceFor(e.Model)
+	if !ok {
+		return 0, false
+	}
+
+	cost := float64(e.PromptTokens)*price.Prompt/1e6 +
+		float64(e.CompletionTokens)*price.Completion/1e6 +
+		float64(e.Images)*price.Image +
+		e.AudioSeconds/60*price.AudioMin
+
+	return cost, true
+}
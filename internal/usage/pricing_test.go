@@ -0,0 +1,38 @@
+package usage
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCost(t *testing.T) {
+	tests := []struct {
+		name       string
+		entry      Entry
+		wantCost   float64
+		wantPriced bool
+	}{
+		{"prompt and completion", Entry{Model: "gpt-4o", PromptTokens: 1_000_000, CompletionTokens: 500_000}, 2.50 + 5.00, true},
+		// gpt-4o-mini must not be priced as its shorter prefix gpt-4o
+		{"longest prefix wins", Entry{Model: "gpt-4o-mini", PromptTokens: 1_000_000, CompletionTokens: 1_000_000}, 0.15 + 0.60, true},
+		{"dated snapshot", Entry{Model: "gpt-4.1-mini-2025-04-14", PromptTokens: 2_000_000}, 0.80, true},
+		{"fine-tune", Entry{Model: "ft:gpt-4o-mini-2024-07-18:personal:validate:C7MNVVbk", PromptTokens: 1_000_000, CompletionTokens: 1_000_000}, 0.30 + 1.20, true},
+		{"embedding", Entry{Model: "text-embedding-3-large", PromptTokens: 1_000_000}, 0.13, true},
+		{"images", Entry{Model: "dall-e-3", Images: 2}, 0.08, true},
+		{"audio", Entry{Model: "whisper-1", AudioSeconds: 90}, 0.009, true},
+		{"unknown model", Entry{Model: "llama3.2", PromptTokens: 1_000_000}, 0, false},
+		{"no usage", Entry{Model: "gpt-4o"}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cost, priced := Cost(tt.entry)
+			if priced != tt.wantPriced {
+				t.Errorf("Cost() priced = %v, want %v", priced, tt.wantPriced)
+			}
+			if math.Abs(cost-tt.wantCost) > 1e-9 {
+				t.Errorf("Cost() = %v, want %v", cost, tt.wantCost)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+// Package usage records token usage and estimated cost of LLM calls,
+// aggregated per task step and model.
+package usage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"text/tabwriter"
+)
+
+// unattributedStep names usage recorded outside any WithStep context
+const unattributedStep = "-"
+
+// Entry is the usage reported for a single API call
+type Entry struct {
+	Model            string
+	PromptTokens     int64
+	CompletionTokens int64
+	Images           int
+	AudioSeconds     float64
+}
+
+// Totals aggregates the usage of one or more calls
+type Totals struct {
+	Calls            int
+	PromptTokens     int64
+	CompletionTokens int64
+	Images           int
+	AudioSeconds     float64
+	Cost             float64
+	Unpriced         bool // at least one call used a model without a known price
+}
+
+// Tokens returns the prompt and completion tokens combined
+func (t Totals) Tokens() int64 {
+	return t.PromptTokens + t.CompletionTokens
+}
+
+// add folds an entry and its cost into the totals
+func (t *Totals) add(e Entry, cost float64, priced bool) {
+	t.Calls++
+	t.PromptTokens += e.PromptTokens
+	t.CompletionTokens += e.CompletionTokens
+	t.Images += e.Images
+	t.AudioSeconds += e.AudioSeconds
+	t.Cost += cost
+	t.Unpriced = t.Unpriced || !priced
+}
+
+// Line is the aggregated usage of one model within one step
+type Line struct {
+	Step  string
+	Model string
+	Totals
+}
+
+// key identifies a report line
+type key struct {
+	step  string
+	model string
+}
+
+// Recorder collects usage across a run. A nil *Recorder ignores every call,
+// so clients can record unconditionally.
+type Recorder struct {
+	mu      sync.Mutex
+	lines   map[key]*Totals
+	order   []key
+	total   Totals
+	printed bool
+}
+
+// NewRecorder creates an empty recorder
+func NewRecorder() *Recorder {
+	return &Recorder{
+		lines: make(map[key]*Totals),
+	}
+}
+
+// Record adds the usage of one call, attributed to the step carried by ctx
+func (r *Recorder) Record(ctx context.Context, e Entry) {
+	if r == nil {
+		return
+	}
+
+	cost, priced := Cost(e)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k := key{step: Step(ctx), model: e.Model}
+	line, ok := r.lines[k]
+	if !ok {
+		line = &Totals{}
+		r.lines[k] = line
+		r.order = append(r.order, k)
+	}
+	line.add(e, cost, priced)
+	r.total.add(e, cost, priced)
+}
+
+// Total returns the usage of the whole run
+func (r *Recorder) Total() Totals {
+	if r == nil {
+		return Totals{}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.total
+}
+
+// Lines returns usage per step and model, ordered by step in first-use order
+func (r *Recorder) Lines() []Line {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	firstUse := make(map[string]int)
+	lines := make([]Line, 0, len(r.order))
+	for i, k := range r.order {
+		if _, ok := firstUse[k.step]; !ok {
+			firstUse[k.step] = i
+		}
+		lines = append(lines, Line{Step: k.step, Model: k.model, Totals: *r.lines[k]})
+	}
+
+	sort.SliceStable(lines, func(i, j int) bool {
+		return firstUse[lines[i].Step] < firstUse[lines[j].Step]
+	})
+
+	return lines
+}
+
+// Print writes the usage report to w and marks the recorder as printed
+func (r *Recorder) Print(w io.Writer) {
+	if r == nil {
+		return
+	}
+
+	lines := r.Lines()
+	total := r.Total()
+
+	r.mu.Lock()
+	r.printed = true
+	r.mu.Unlock()
+
+	if total.Calls == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "=== LLM Usage ===")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Step\tModel\tCalls\tPrompt\tCompletion\tImages\tAudio (s)\tCost\t")
+	for _, line := range lines {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t\n", line.Step, line.Model, formatTotals(line.Totals))
+	}
+	fmt.Fprintf(tw, "Total\t\t%s\t\n", formatTotals(total))
+	tw.Flush()
+
+	if total.Unpriced {
+		fmt.Fprintln(w, "* cost excludes models without a known price")
+	}
+	fmt.Fprintln(w, "=================")
+}
+
+// Printed reports whether the report has already been printed
+func (r *Recorder) Printed() bool {
+	if r == nil {
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.printed
+}
+
+// formatTotals renders the numeric report columns
+func formatTotals(t Totals) string {
+	cost := fmt.Sprintf("$%.4f", t.Cost)
+	if t.Unpriced {
+		cost += "*"
+	}
+	return fmt.Sprintf("%d\t%d\t%d\t%d\t%.1f\t%s", t.Calls, t.PromptTokens, t.CompletionTokens, t.Images, t.AudioSeconds, cost)
+}
+
+// stepKey is the context key carrying the current task step
+type stepKey struct{}
+
+// WithStep returns a context whose LLM usage is attributed to step. Step names
+// follow the TaskError.Step naming, e.g. "classify_lines".
+func WithStep(ctx context.Context, step string) context.Context {
+	return context.WithValue(ctx, stepKey{}, step)
+}
+
+// Step returns the step carried by ctx
+func Step(ctx context.Context) string {
+	if step, ok := ctx.Value(stepKey{}).(string); ok && step != "" {
+		return step
+	}
+	return unattributedStep
+}
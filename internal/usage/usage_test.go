@@ -0,0 +1,93 @@
+package usage
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestRecorderAggregatesPerStep(t *testing.T) {
+	ctx := context.Background()
+	analyze := WithStep(ctx, "analyze_photos")
+	describe := WithStep(ctx, "generate_rysopis")
+
+	r := NewRecorder()
+	r.Record(describe, Entry{Model: "gpt-4.1", PromptTokens: 1000, CompletionTokens: 100, Images: 3})
+	r.Record(analyze, Entry{Model: "gpt-4o-mini", PromptTokens: 500, CompletionTokens: 50, Images: 1})
+	r.Record(ctx, Entry{Model: "llama3.2", PromptTokens: 10})
+	r.Record(analyze, Entry{Model: "gpt-4o-mini", PromptTokens: 500, CompletionTokens: 50, Images: 1})
+	r.Record(describe, Entry{Model: "gpt-4o-mini", PromptTokens: 200})
+
+	want := []struct {
+		step, model string
+		calls       int
+		tokens      int64
+		images      int
+		unpriced    bool
+	}{
+		// Steps keep their first-use order, models within a step theirs
+		{"generate_rysopis", "gpt-4.1", 1, 1100, 3, false},
+		{"generate_rysopis", "gpt-4o-mini", 1, 200, 0, false},
+		{"analyze_photos", "gpt-4o-mini", 2, 1100, 2, false},
+		{unattributedStep, "llama3.2", 1, 10, 0, true},
+	}
+
+	lines := r.Lines()
+	if len(lines) != len(want) {
+		t.Fatalf("Lines() returned %d lines, want %d: %+v", len(lines), len(want), lines)
+	}
+	var cost float64
+	for i, w := range want {
+		line := lines[i]
+		if line.Step != w.step || line.Model != w.model {
+			t.Errorf("line %d = %s/%s, want %s/%s", i, line.Step, line.Model, w.step, w.model)
+		}
+		if line.Calls != w.calls || line.Tokens() != w.tokens || line.Images != w.images || line.Unpriced != w.unpriced {
+			t.Errorf("line %d totals = %+v, want %d calls, %d tokens, %d images, unpriced %v",
+				i, line.Totals, w.calls, w.tokens, w.images, w.unpriced)
+		}
+		cost += line.Cost
+	}
+
+	total := r.Total()
+	if total.Calls != 5 || total.Tokens() != 2410 || total.Images != 5 || !total.Unpriced {
+		t.Errorf("Total() = %+v, want 5 calls, 2410 tokens, 5 images, unpriced", total)
+	}
+	if math.Abs(total.Cost-cost) > 1e-12 {
+		t.Errorf("Total().Cost = %v, want the sum of the lines %v", total.Cost, cost)
+	}
+}
+
+func TestRecorderPrint(t *testing.T) {
+	r := NewRecorder()
+
+	var out bytes.Buffer
+	r.Print(&out)
+	if out.Len() != 0 || !r.Printed() {
+		t.Errorf("empty recorder printed %q, Printed() = %v; want no output, true", out.String(), r.Printed())
+	}
+
+	r.Record(WithStep(context.Background(), "classify_lines"), Entry{Model: "unknown-model", PromptTokens: 1})
+	out.Reset()
+	r.Print(&out)
+	for _, want := range []string{"classify_lines", "unknown-model", "$0.0000*", "cost excludes models without a known price"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("report lacks %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestNilRecorder(t *testing.T) {
+	var r *Recorder
+	r.Record(context.Background(), Entry{Model: "gpt-4o", PromptTokens: 1})
+	r.Print(&bytes.Buffer{})
+
+	if got := r.Total(); got != (Totals{}) {
+		t.Errorf("Total() = %+v, want zero", got)
+	}
+	if r.Lines() != nil || r.Printed() {
+		t.Error("nil recorder reported lines or a printed report")
+	}
+}